@@ -0,0 +1,111 @@
+package sentry
+
+import "sync"
+
+// breadcrumbRing is a fixed-size ring buffer holding the most recent
+// breadcrumbs of a scope. Its own lock lets AddBreadcrumb hold the scope lock
+// for reading only, so concurrent callers don't queue up on the scope.
+//
+// The methods of breadcrumbRing are safe to call on a nil ring, which holds no
+// breadcrumbs, except for add.
+type breadcrumbRing struct {
+	mu sync.Mutex
+	// items holds the breadcrumbs, its length is the capacity of the ring.
+	items []*Breadcrumb
+	// next is the index in items the next breadcrumb is stored at.
+	next int
+	// size is the number of breadcrumbs stored in items.
+	size int
+}
+
+// newBreadcrumbRing returns a ring holding breadcrumbs, in order.
+func newBreadcrumbRing(breadcrumbs []*Breadcrumb) *breadcrumbRing {
+	r := &breadcrumbRing{}
+	r.reset(breadcrumbs, len(breadcrumbs))
+	return r
+}
+
+// add stores breadcrumb, dropping the oldest breadcrumbs if there are more
+// than limit.
+func (r *breadcrumbRing) add(breadcrumb *Breadcrumb, limit int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if limit != len(r.items) {
+		r.reset(r.appendLocked(nil), limit)
+	}
+	if limit <= 0 {
+		return
+	}
+	r.items[r.next] = breadcrumb
+	r.next = (r.next + 1) % len(r.items)
+	if r.size < len(r.items) {
+		r.size++
+	}
+}
+
+// reset replaces the contents of the ring with the last capacity breadcrumbs
+// and changes its capacity. It must be called with mu held.
+func (r *breadcrumbRing) reset(breadcrumbs []*Breadcrumb, capacity int) {
+	if capacity <= 0 {
+		r.items, r.next, r.size = nil, 0, 0
+		return
+	}
+	if len(breadcrumbs) > capacity {
+		breadcrumbs = breadcrumbs[len(breadcrumbs)-capacity:]
+	}
+	r.items = make([]*Breadcrumb, capacity)
+	r.size = copy(r.items, breadcrumbs)
+	r.next = r.size % capacity
+}
+
+// appendTo appends the breadcrumbs of the ring to dst, oldest first.
+func (r *breadcrumbRing) appendTo(dst []*Breadcrumb) []*Breadcrumb {
+	if r == nil {
+		return dst
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.appendLocked(dst)
+}
+
+func (r *breadcrumbRing) appendLocked(dst []*Breadcrumb) []*Breadcrumb {
+	if r.size == 0 {
+		return dst
+	}
+	start := (r.next - r.size + len(r.items)) % len(r.items)
+	if start+r.size <= len(r.items) {
+		return append(dst, r.items[start:start+r.size]...)
+	}
+	dst = append(dst, r.items[start:]...)
+	return append(dst, r.items[:r.next]...)
+}
+
+// list returns the breadcrumbs of the ring, oldest first. It returns an empty
+// slice rather than nil if there are none.
+func (r *breadcrumbRing) list() []*Breadcrumb {
+	return r.appendTo(make([]*Breadcrumb, 0, r.len()))
+}
+
+func (r *breadcrumbRing) len() int {
+	if r == nil {
+		return 0
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.size
+}
+
+// clone returns a copy of the ring with the same capacity.
+func (r *breadcrumbRing) clone() *breadcrumbRing {
+	if r == nil {
+		return &breadcrumbRing{}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return &breadcrumbRing{
+		items: append([]*Breadcrumb(nil), r.items...),
+		next:  r.next,
+		size:  r.size,
+	}
+}
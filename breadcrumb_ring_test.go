@@ -0,0 +1,69 @@
+package sentry
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func breadcrumbMessages(breadcrumbs []*Breadcrumb) []string {
+	messages := make([]string, 0, len(breadcrumbs))
+	for _, b := range breadcrumbs {
+		messages = append(messages, b.Message)
+	}
+	return messages
+}
+
+func TestBreadcrumbRing(t *testing.T) {
+	r := &breadcrumbRing{}
+	for i := 0; i < 5; i++ {
+		r.add(&Breadcrumb{Message: strconv.Itoa(i)}, 3)
+	}
+	assertEqual(t, breadcrumbMessages(r.list()), []string{"2", "3", "4"})
+
+	// Raising the limit keeps the stored breadcrumbs.
+	r.add(&Breadcrumb{Message: "5"}, 5)
+	assertEqual(t, breadcrumbMessages(r.list()), []string{"2", "3", "4", "5"})
+
+	// Lowering it keeps the most recent ones.
+	r.add(&Breadcrumb{Message: "6"}, 2)
+	assertEqual(t, breadcrumbMessages(r.list()), []string{"5", "6"})
+
+	clone := r.clone()
+	clone.add(&Breadcrumb{Message: "7"}, 2)
+	assertEqual(t, breadcrumbMessages(r.list()), []string{"5", "6"})
+	assertEqual(t, breadcrumbMessages(clone.list()), []string{"6", "7"})
+
+	r.add(&Breadcrumb{Message: "ignored"}, 0)
+	assertEqual(t, r.len(), 0)
+
+	var empty *breadcrumbRing
+	assertEqual(t, empty.list(), []*Breadcrumb{})
+}
+
+func TestAddBreadcrumbConcurrentlyWithClone(_ *testing.T) {
+	scope := NewScope()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				scope.AddBreadcrumb(&Breadcrumb{Message: "concurrent"}, maxBreadcrumbs)
+				scope.Clone().AddBreadcrumb(&Breadcrumb{Message: "clone"}, maxBreadcrumbs)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkAddBreadcrumb(b *testing.B) {
+	scope := NewScope()
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		breadcrumb := &Breadcrumb{Message: "benchmark"}
+		for pb.Next() {
+			scope.AddBreadcrumb(breadcrumb, maxBreadcrumbs)
+		}
+	})
+}
@@ -0,0 +1,88 @@
+package sentry
+
+import (
+	"context"
+	"strings"
+)
+
+// Operations of cache spans, as recognized by the Caches insights of Sentry.
+// See https://develop.sentry.dev/sdk/telemetry/traces/modules/caches/.
+const (
+	CacheGet    = "cache.get"
+	CachePut    = "cache.put"
+	CacheRemove = "cache.remove"
+	CacheFlush  = "cache.flush"
+)
+
+// cacheKeyPrefixSeparator separates the namespace of a cache key from the
+// rest, as in "user:42".
+const cacheKeyPrefixSeparator = ":"
+
+// A CacheSpan is a span describing an operation on a cache. Set the outcome
+// of the operation with its methods before finishing it.
+type CacheSpan struct {
+	*Span
+}
+
+// StartCacheSpan starts a span for the cache operation, one of CacheGet,
+// CachePut, CacheRemove and CacheFlush, on the given keys. The span is a child
+// of the span stored in ctx. Without a span in ctx, StartCacheSpan returns nil
+// rather than starting a transaction for a single cache operation; the
+// methods of a nil CacheSpan do nothing.
+//
+//	span := sentry.StartCacheSpan(ctx, sentry.CacheGet, "user:42")
+//	value, ok := cache.Get("user:42")
+//	span.SetHit(ok)
+//	span.SetItemSize(len(value))
+//	span.Finish()
+//
+// The prefix of the first key up to and including the first ":" is recorded
+// as the key prefix, so that keys such as "user:42" group under "user:".
+func StartCacheSpan(ctx context.Context, operation string, keys ...string) *CacheSpan {
+	if SpanFromContext(ctx) == nil {
+		return nil
+	}
+	span := StartSpan(ctx, operation, WithDescription(strings.Join(keys, ", ")))
+	if len(keys) > 0 {
+		span.SetData("cache.key", keys)
+		if i := strings.Index(keys[0], cacheKeyPrefixSeparator); i > 0 {
+			span.SetData("cache.key_prefix", keys[0][:i+len(cacheKeyPrefixSeparator)])
+		}
+	}
+	return &CacheSpan{Span: span}
+}
+
+// SetHit records whether a CacheGet operation found the item.
+func (s *CacheSpan) SetHit(hit bool) {
+	if s == nil {
+		return
+	}
+	s.SetData("cache.hit", hit)
+}
+
+// SetItemSize records the size in bytes of the item read or written.
+func (s *CacheSpan) SetItemSize(size int) {
+	if s == nil {
+		return
+	}
+	s.SetData("cache.item_size", size)
+}
+
+// SetServer records the address and port of the cache server.
+func (s *CacheSpan) SetServer(address string, port int) {
+	if s == nil {
+		return
+	}
+	s.SetData("network.peer.address", address)
+	if port > 0 {
+		s.SetData("network.peer.port", port)
+	}
+}
+
+// Finish finishes the span, see Span.Finish.
+func (s *CacheSpan) Finish() {
+	if s == nil {
+		return
+	}
+	s.Span.Finish()
+}
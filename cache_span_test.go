@@ -0,0 +1,47 @@
+package sentry
+
+import (
+	"testing"
+)
+
+func TestStartCacheSpan(t *testing.T) {
+	ctx := NewTestContext(ClientOptions{EnableTracing: true, TracesSampleRate: 1.0})
+	transaction := StartTransaction(ctx, "name")
+
+	span := StartCacheSpan(transaction.Context(), CacheGet, "user:42", "user:43")
+	span.SetHit(true)
+	span.SetItemSize(128)
+	span.SetServer("cache.internal", 6379)
+	span.Finish()
+
+	assertEqual(t, span.ParentSpanID, transaction.SpanID)
+	assertEqual(t, span.Op, "cache.get")
+	assertEqual(t, span.Description, "user:42, user:43")
+	assertEqual(t, span.Data, map[string]interface{}{
+		"cache.key":            []string{"user:42", "user:43"},
+		"cache.key_prefix":     "user:",
+		"cache.hit":            true,
+		"cache.item_size":      128,
+		"network.peer.address": "cache.internal",
+		"network.peer.port":    6379,
+	})
+
+	span = StartCacheSpan(transaction.Context(), CacheFlush)
+	assertEqual(t, span.Description, "")
+	assertEqual(t, len(span.Data), 0)
+}
+
+func TestStartCacheSpanWithoutTransaction(t *testing.T) {
+	transport := &TransportMock{}
+	ctx := NewTestContext(ClientOptions{EnableTracing: true, TracesSampleRate: 1.0, Transport: transport})
+
+	span := StartCacheSpan(ctx, CacheGet, "user:42")
+	if span != nil {
+		t.Fatalf("started a span without a parent: %v", span)
+	}
+	span.SetHit(false)
+	span.SetItemSize(0)
+	span.SetServer("cache.internal", 6379)
+	span.Finish()
+	assertEqual(t, len(transport.Events()), 0)
+}
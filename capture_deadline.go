@@ -0,0 +1,117 @@
+package sentry
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// captureTimeoutTag is the tag of the minimal events sent in place of events
+// that could not be processed within ClientOptions.CaptureTimeout.
+const captureTimeoutTag = "sentry.capture_timeout"
+
+// States of a captureDeadline.
+const (
+	capturePending int32 = iota
+	captureSending
+	captureExpired
+)
+
+// captureDeadline decides between sending the processed event of a capture
+// with ClientOptions.CaptureTimeout and the minimal event replacing it,
+// whichever is ready first.
+type captureDeadline struct {
+	state int32
+}
+
+// claim reports whether the processed event may be sent. It is always true
+// for a nil deadline.
+func (d *captureDeadline) claim() bool {
+	return d == nil || atomic.CompareAndSwapInt32(&d.state, capturePending, captureSending)
+}
+
+// expire reports whether the minimal event is to be sent instead of the
+// processed one, which is then discarded.
+func (d *captureDeadline) expire() bool {
+	return atomic.CompareAndSwapInt32(&d.state, capturePending, captureExpired)
+}
+
+// captureWithDeadline processes event in a new goroutine and waits for at
+// most timeout. If the event is not handed to the transport by then, a
+// minimal event with the same ID is sent instead. The sampling decision is
+// made up front, so that it applies to either event.
+func (client *Client) captureWithDeadline(event *Event, hint *EventHint, scope EventModifier, timeout time.Duration) *EventID {
+	if !client.sampleEvent(event, scope) {
+		dropEvent(client.onEventDropped, event, DropReasonSampleRate)
+		return nil
+	}
+	if event.EventID == "" {
+		event.EventID = EventID(uuid())
+	}
+	minimal := client.minimalEvent(event, hint)
+	// The processing goroutine completes hint, the minimal event gets a
+	// copy of what the caller passed.
+	minimalHint := &EventHint{}
+	if hint != nil {
+		*minimalHint = *hint
+	}
+	// The caller may change its scope as soon as the capture returns.
+	if s, ok := scope.(*Scope); ok && s != nil {
+		scope = s.Clone()
+	}
+	deadline := &captureDeadline{}
+	event.sdkMetaData.deadline = deadline
+
+	done := make(chan *EventID, 1)
+	go func() {
+		done <- client.processEvent(event, hint, scope)
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case eventID := <-done:
+		return eventID
+	case <-timer.C:
+	}
+
+	if !deadline.expire() {
+		// The processed event is on its way, sending it may be what is slow.
+		return &minimal.EventID
+	}
+	debugLog(LevelWarning, "Capture timed out, sending a minimal event", "event_id", minimal.EventID, "timeout", timeout)
+	// The event processors and BeforeSend still filter and scrub the minimal
+	// event, without blocking the caller any longer.
+	go client.finishEvent(minimal, minimalHint, nil)
+	return &minimal.EventID
+}
+
+// minimalEvent returns the event sent in place of event if processing it
+// takes longer than ClientOptions.CaptureTimeout. It only holds the message,
+// the types and values of the exceptions, the level and the options of the
+// client, since the scope is not applied.
+func (client *Client) minimalEvent(event *Event, hint *EventHint) *Event {
+	minimal := NewEvent()
+	minimal.EventID = event.EventID
+	minimal.Level = event.Level
+	if minimal.Level == "" {
+		minimal.Level = LevelError
+	}
+	minimal.Message = event.Message
+	for _, exception := range event.Exception {
+		minimal.Exception = append(minimal.Exception, Exception{Type: exception.Type, Value: exception.Value})
+	}
+	minimal.Timestamp = client.now()
+	minimal.Release = client.options.Release
+	minimal.Dist = client.options.Dist
+	minimal.Environment = client.runtimeOptions().Environment
+	minimal.ServerName = client.options.ServerName
+	if minimal.ServerName == "" {
+		minimal.ServerName = hostname
+	}
+	minimal.Platform = "go"
+	minimal.Sdk = SdkInfo{Name: client.GetSDKIdentifier(), Version: SDKVersion}
+	minimal.Tags[captureTimeoutTag] = "true"
+	if hint != nil {
+		minimal.sdkMetaData.delivery = hint.Delivery
+	}
+	return minimal
+}
@@ -0,0 +1,68 @@
+package sentry
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCaptureTimeout(t *testing.T) {
+	release := make(chan struct{})
+	processed := make(chan struct{}, 1)
+	transport := &TransportMock{}
+	client, err := NewClient(ClientOptions{
+		Dsn:            testDsn,
+		Transport:      transport,
+		Release:        "1.0",
+		CaptureTimeout: 100 * time.Millisecond,
+		BeforeSend: func(event *Event, hint *EventHint) *Event {
+			defer func() { processed <- struct{}{} }()
+			if event.Tags[captureTimeoutTag] == "true" {
+				event.Exception[0].Value = "[scrubbed]"
+				return event
+			}
+			if event.Message != "fast" {
+				<-release
+			}
+			return event
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	scope := NewScope()
+	scope.SetTag("scope", "tag")
+
+	start := time.Now()
+	eventID := client.CaptureException(errors.New("slow"), nil, scope)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("capture blocked for %s", elapsed)
+	}
+	if eventID == nil {
+		t.Fatal("no event ID")
+	}
+	// BeforeSend applies to the minimal event too, in the background.
+	<-processed
+	events := transport.Events()
+	for wait := time.Now().Add(time.Second); len(events) == 0 && time.Now().Before(wait); events = transport.Events() {
+		time.Sleep(time.Millisecond)
+	}
+	assertEqual(t, len(events), 1)
+	minimal := events[0]
+	assertEqual(t, minimal.EventID, *eventID)
+	assertEqual(t, minimal.Tags, map[string]string{captureTimeoutTag: "true"})
+	assertEqual(t, minimal.Release, "1.0")
+	assertEqual(t, minimal.Exception, []Exception{{Type: "*errors.errorString", Value: "[scrubbed]"}})
+
+	// The processed event is discarded once it is ready.
+	close(release)
+	<-processed
+	assertEqual(t, len(transport.Events()), 1)
+
+	eventID = client.CaptureMessage("fast", nil, scope)
+	<-processed
+	events = transport.Events()
+	assertEqual(t, len(events), 2)
+	assertEqual(t, events[1].EventID, *eventID)
+	assertEqual(t, events[1].Tags["scope"], "tag")
+}
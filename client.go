@@ -34,6 +34,10 @@ const maxErrorDepth = 10
 // would be rejected by Sentry.
 const defaultMaxSpans = 1000
 
+// defaultMaxGoroutines limits the default number of goroutines reported in
+// the threads of an event, see ClientOptions.AttachGoroutines.
+const defaultMaxGoroutines = 100
+
 // hostname is the host name reported by the kernel. It is precomputed once to
 // avoid syscalls when capturing events.
 //
@@ -93,7 +97,7 @@ type EventModifier interface {
 	ApplyToEvent(event *Event, hint *EventHint, client *Client) *Event
 }
 
-var globalEventProcessors []EventProcessor
+var globalEventProcessors eventProcessors
 
 // AddGlobalEventProcessor adds processor to the global list of event
 // processors. Global event processors apply to all events.
@@ -101,8 +105,18 @@ var globalEventProcessors []EventProcessor
 // AddGlobalEventProcessor is deprecated. Most users will prefer to initialize
 // the SDK with Init and provide a ClientOptions.BeforeSend function or use
 // Scope.AddEventProcessor instead.
-func AddGlobalEventProcessor(processor EventProcessor) {
-	globalEventProcessors = append(globalEventProcessors, processor)
+func AddGlobalEventProcessor(processor EventProcessor, opts ...EventProcessorOption) {
+	globalEventProcessors = globalEventProcessors.add(processor, opts)
+}
+
+// RemoveGlobalEventProcessor removes the global event processor added with
+// the given name, see WithEventProcessorName. It reports whether there was
+// such a processor. Like AddGlobalEventProcessor, it must not be called from
+// concurrent goroutines.
+func RemoveGlobalEventProcessor(name string) bool {
+	var removed bool
+	globalEventProcessors, removed = globalEventProcessors.remove(name)
+	return removed
 }
 
 // Integration allows for registering a functions that modify or discard captured events.
@@ -116,36 +130,239 @@ type ClientOptions struct {
 	// The DSN to use. If the DSN is not set, the client is effectively
 	// disabled.
 	Dsn string
+	// OrgID is the ID of the Sentry organization the DSN belongs to. It is
+	// propagated as the org_id entry of the dynamic sampling context. If
+	// empty, it is taken from the DSN host where it is encoded, as with
+	// sentry.io; set it for DSNs that don't carry it.
+	OrgID string
 	// In debug mode, the debug information is printed to stdout to help you
 	// understand what sentry is doing.
 	Debug bool
 	// Configures whether SDK should generate and attach stacktraces to pure
 	// capture message calls.
 	AttachStacktrace bool
+	// AttachGoroutines configures whether error events include the stack traces
+	// of all goroutines as threads, with the goroutine capturing the event
+	// marked as current, and as crashed for exceptions and panics. Dumping the
+	// goroutines briefly stops the program, so this is best used in programs
+	// with few errors.
+	AttachGoroutines bool
+	// MaxGoroutines is the maximum number of goroutines reported with
+	// AttachGoroutines. Defaults to 100, which also replaces values that are
+	// not positive.
+	MaxGoroutines int
 	// The sample rate for event submission in the range [0.0, 1.0]. By default,
 	// all events are sent. Thus, as a historical special case, the sample rate
 	// 0.0 is treated as if it was 1.0. To drop all events, set the DSN to the
 	// empty string.
 	SampleRate float64
+	// EventBudgets caps the number of events sent per EventBudgetPeriod for
+	// each rate limit category, such as "error" or "transaction", protecting
+	// the quota from runaway error loops. Events beyond the budget of their
+	// category are dropped and counted; the counts are sent as a single
+	// warning event when the next period starts or the client is flushed.
+	// Categories without a budget are not capped. Events dropped by sampling,
+	// event processors or BeforeSend don't count towards the budget.
+	EventBudgets map[string]int
+	// EventBudgetPeriod is the period of EventBudgets. Defaults to one hour.
+	EventBudgetPeriod time.Duration
+	// ErrorStormThreshold, if positive, is the number of error and message
+	// events of the same issue per minute above which the issue is in an
+	// error storm. The events of an issue in a storm are not sent, but
+	// counted, and an aggregate event with their count, the times the first
+	// and last were seen and the stack trace of the first is sent every
+	// ErrorStormInterval instead. Events of the issue are sent again after a
+	// minute with no more than ErrorStormThreshold of them. Issues are told
+	// apart by the fingerprint of the events, or else by the type of their
+	// exception and the frame it was raised in, or else by their message.
+	ErrorStormThreshold int
+	// ErrorStormInterval is the interval of the aggregate events of an error
+	// storm, see ErrorStormThreshold. Defaults to one minute. The aggregates
+	// are sent on time also when no more events arrive, and pending ones
+	// when the client is flushed.
+	ErrorStormInterval time.Duration
+	// LoadShedding, if set, lets the client reduce the overhead of the SDK
+	// when the process is under pressure, as measured by its CPU usage and
+	// the fill ratio of the queue of the transport. The features are
+	// disabled progressively, in the order of the LoadSheddingLevel
+	// constants, and enabled again as the pressure subsides. The current
+	// level is part of Client.Stats.
+	LoadShedding *LoadSheddingOptions
+	// GroupingStrategy, if set, makes the client compute the fingerprints of
+	// error events, which decide the issues they are grouped into, instead of
+	// leaving it to Sentry. It is meant for programs whose issues are split
+	// by changes to stack traces between releases, e.g. by inlining.
+	// Fingerprints set on events or scopes take precedence.
+	GroupingStrategy GroupingStrategy
+	// GroupingFrames is the number of frames of GroupingTypeAndTopFrames.
+	// Defaults to 3.
+	GroupingFrames int
+	// CaptureTimeout, if positive, bounds the time capturing an error or
+	// message event blocks the caller. The event is then processed in a new
+	// goroutine, with a copy of the scope. If it is not handed to the
+	// transport within CaptureTimeout, because of slow event processors or
+	// BeforeSend for example, a minimal event with the same ID is sent
+	// instead, tagged sentry.capture_timeout. The minimal event only holds
+	// the message, the exception types and values and the level, since the
+	// scope is not applied to it. The event processors of the client and
+	// BeforeSend still are, in the background.
+	CaptureTimeout time.Duration
+	// AsyncEventProcessing moves running the event processors of the client
+	// and the integrations, BeforeSend and BeforeSendTransaction off the
+	// goroutine capturing an event to a goroutine of the client. The scope
+	// is still applied when the event is captured. Events exceeding the
+	// queue of AsyncEventProcessingQueueSize events are dropped with
+	// DropReasonQueueOverflow. Flush waits for the queued events.
+	AsyncEventProcessing bool
+	// AsyncEventProcessingQueueSize is the size of the queue of
+	// AsyncEventProcessing. Defaults to 100.
+	AsyncEventProcessingQueueSize int
+	// AggregateTransactionsShorterThan, if positive, merges the transactions
+	// shorter than it that share a name into one summary transaction per
+	// TransactionAggregationInterval, for consumers emitting vast numbers of
+	// tiny transactions. The summary keeps the data of the first merged
+	// transaction, but not its spans, and reports the count and a histogram
+	// of the durations as measurements. Transactions with a profile are
+	// never merged. The delivery of a merged transaction reports
+	// DeliverySent once it is merged.
+	AggregateTransactionsShorterThan time.Duration
+	// TransactionAggregationInterval is the interval of
+	// AggregateTransactionsShorterThan. Defaults to 10 seconds.
+	TransactionAggregationInterval time.Duration
 	// Enable performance tracing.
 	EnableTracing bool
 	// The sample rate for sampling traces in the range [0.0, 1.0].
 	TracesSampleRate float64
 	// Used to customize the sampling of traces, overrides TracesSampleRate.
 	TracesSampler TracesSampler
+	// PreferParentSamplingContext makes a transaction continuing a trace
+	// take the entries of the dynamic sampling context of its parent over its
+	// own when its dynamic sampling context is created, rather than falling
+	// back to them. See DynamicSamplingContextFromTransaction.
+	PreferParentSamplingContext bool
+	// TransactionNamer, if set, is consulted for the name and source of
+	// every transaction when it is started, after the integration or the
+	// caller of StartTransaction has named it, so that naming conventions,
+	// such as stripping version prefixes or merging locales, live in one
+	// place. Returning an empty name or source keeps the respective value.
+	// The server integrations pass the request in the info.
+	TransactionNamer func(info TransactionNameInfo) (name string, source TransactionSource)
+	// DynamicSamplingContextTags maps the names of scope tags to the names
+	// of the dynamic sampling context entries they are mirrored into, for
+	// example {"plan": "plan_tier"}. The entries are propagated in the
+	// baggage header, so that dynamic sampling rules in Sentry can bias
+	// retention toward important customers. A transaction takes the tags of
+	// the scope it is started with and its own tags, its own taking
+	// precedence. Tags never replace the entries set by the SDK.
+	DynamicSamplingContextTags map[string]string
+	// TransactionNameQuality, if set, reports whether the name of a
+	// transaction is of good enough quality to be part of its dynamic
+	// sampling context, replacing HasGoodTransactionName. Dynamic sampling
+	// rules in Sentry match transaction names, so names with IDs in them
+	// would make the rules miss. Set it, for example, to include the URLs
+	// the program parameterized itself, or to exclude some operations.
+	TransactionNameQuality func(transaction *Span) bool
+	// SpanMetrics are the rules of the metric summaries attached to spans
+	// when their transaction is finished. Relay uses the summaries as the
+	// custom dimensions of span metrics.
+	SpanMetrics []SpanMetricRule
+	// LenientBaggageParsing makes continuing a trace keep the valid sentry-*
+	// members of an incoming baggage header that has malformed members,
+	// reporting those to the debug logger. By default, such a header is
+	// rejected: Hub.ContinueTrace returns an error and ContinueFromHeaders
+	// ignores it.
+	LenientBaggageParsing bool
+	// DiscardUnsampledSpanData makes spans of unsampled transactions ignore
+	// tags, data and contexts set on them, and keeps them out of the list of
+	// spans of their transaction. Unsampled transactions are never sent, so
+	// this only saves work, but code reading back what it set on a span sees
+	// nothing. Events captured during an unsampled transaction still carry
+	// its trace context.
+	DiscardUnsampledSpanData bool
 	// The sample rate for profiling traces in the range [0.0, 1.0].
 	// This is relative to TracesSampleRate - it is a ratio of profiled traces out of all sampled traces.
 	ProfilesSampleRate float64
+	// ContentionProfileThreshold enables block and mutex profiling for slow
+	// transactions: when a sampled transaction lasts longer, the SDK records
+	// the blocking and lock contention of the program for
+	// ContentionProfileWindow and attaches the profiles to the transaction,
+	// which is sent once the window ends. Zero disables contention profiling.
+	//
+	// The rates of the block and mutex profiles are only raised during the
+	// window, and block profiling is turned off afterwards, so this should
+	// not be combined with enabling block profiling elsewhere.
+	ContentionProfileThreshold time.Duration
+	// ContentionProfileWindow is the time contention is recorded for after a
+	// slow transaction. Defaults to 1s.
+	ContentionProfileWindow time.Duration
+	// ExecutionTraceThreshold enables execution trace flight recording: the
+	// SDK keeps the most recent part of the runtime execution trace in
+	// memory and attaches a snapshot of it, as trace.out, to sampled
+	// transactions that last longer than the threshold. At most one snapshot
+	// is attached per minute. Zero disables flight recording.
+	//
+	// The trace shows scheduling, garbage collection and blocking of all
+	// goroutines, and can be inspected with "go tool trace". Flight recording
+	// requires Go 1.25 or later, and there is only one flight recorder per
+	// program, configured by the first client that enables it.
+	ExecutionTraceThreshold time.Duration
+	// ExecutionTraceMaxBytes bounds the size of the recorded trace. Defaults
+	// to 4 MiB.
+	ExecutionTraceMaxBytes uint64
 	// List of regexp strings that will be used to match against event's message
 	// and if applicable, caught errors type and value.
 	// If the match is found, then a whole event will be dropped.
 	IgnoreErrors []string
+	// List of matchers that will be called with the original error of an
+	// event, if available. If any of them reports a match, the event is
+	// dropped. Use IgnoreErrorIs and IgnoreErrorType to match against error
+	// values and types anywhere in the error chain, or provide a custom
+	// function.
+	IgnoreErrorMatchers []ErrorMatcher
 	// List of regexp strings that will be used to match against a transaction's
 	// name.  If a match is found, then the transaction  will be dropped.
 	IgnoreTransactions []string
+	// List of matchers that will be called with the root span of a finished
+	// transaction. If any of them reports a match, the transaction is dropped
+	// before it is converted into an event. Like IgnoreTransactions, the
+	// matchers are evaluated by the IgnoreTransactions integration, and are
+	// silently skipped if that integration is removed with Integrations.
+	IgnoreTransactionMatchers []TransactionMatcher
 	// If this flag is enabled, certain personally identifiable information (PII) is added by active integrations.
-	// By default, no such data is sent.
+	// By default, no such data is sent. Use PII to select the kinds of data instead.
 	SendDefaultPII bool
+	// PII selects the kinds of personally identifiable information added by
+	// the SDK and active integrations. If set, it takes precedence over
+	// SendDefaultPII, see Client.PII.
+	PII *PIIOptions
+	// HeaderDenylist are the names of the HTTP headers whose values are
+	// replaced with "[Filtered]" in the requests attached to events and in
+	// breadcrumbs, regardless of SendDefaultPII. Defaults to
+	// DefaultHeaderDenylist.
+	HeaderDenylist []string
+	// HeaderAllowlist, if not empty, restricts the HTTP headers attached to
+	// events and breadcrumbs to the listed ones and Host.
+	HeaderAllowlist []string
+	// QueryParamDenylist are the names of query parameters, such as "token"
+	// or "session", whose values are replaced with "[Filtered]" in the
+	// requests attached to events, in the "url" and "http.query" data of
+	// breadcrumbs and in the descriptions of HTTP spans.
+	QueryParamDenylist []string
+	// CookieDenylist are the names of cookies whose values are replaced
+	// with "[Filtered]" in the requests attached to events.
+	CookieDenylist []string
+	// GeoResolver, if set, returns the location of the user of an event from
+	// the IP address of the user or, if not set, of the client of the request
+	// of the event, e.g. by looking it up in a local database. Returning nil
+	// leaves the location out. It is called by HTTPTransport on its worker
+	// goroutine, and by HTTPSyncTransport before sending, and lets events
+	// carry the location without the address unless PII allows it.
+	GeoResolver func(ip string) *Geo
+	// MaxRequestBodySize controls how large the bodies of HTTP requests
+	// attached to events by Hub.SetRequest, and the integrations using it, may
+	// be. Bodies are only buffered as the handler reads them, up to that
+	// size. The default is RequestBodySizeMedium.
+	MaxRequestBodySize RequestBodySize
 	// BeforeSend is called before error events are sent to Sentry.
 	// Use it to mutate the event or return nil to discard the event.
 	BeforeSend func(event *Event, hint *EventHint) *Event
@@ -154,11 +371,77 @@ type ClientOptions struct {
 	BeforeSendTransaction func(event *Event, hint *EventHint) *Event
 	// Before breadcrumb add callback.
 	BeforeBreadcrumb func(breadcrumb *Breadcrumb, hint *BreadcrumbHint) *Breadcrumb
+	// OnEventDropped is called whenever an event is discarded, with the reason
+	// and the rate limit category of the event, for example "error" or
+	// "transaction". For transactions that were not sampled, event is nil.
+	// The callback may be called from the goroutines of the transport, and
+	// must not block.
+	OnEventDropped func(event *Event, reason DropReason, category string)
 	// Integrations to be installed on the current Client, receives default
 	// integrations.
 	Integrations func([]Integration) []Integration
+	// DisabledIntegrations are the names of integrations that are not
+	// installed, whether they are default integrations or returned by
+	// Integrations. It is a simpler alternative to filtering the defaults in
+	// Integrations. The names of the default integrations are the
+	// Integration constants, for example IntegrationModules to leave out the
+	// module list that makes up a large part of every event.
+	DisabledIntegrations []string
+	// ProtocolCompatibility restricts the envelopes sent to Sentry to the
+	// features supported by older self-hosted Sentry versions, which reject
+	// envelopes with newer items. The default is ProtocolLatest.
+	ProtocolCompatibility ProtocolCompatibility
 	// io.Writer implementation that should be used with the Debug mode.
 	DebugWriter io.Writer
+	// SelfMonitoringDsn enables reporting failures of the SDK itself, such
+	// as events that cannot be serialized, a transport that keeps failing to
+	// send events or transactions exceeding MaxSpans, as events to the
+	// project of this DSN. Each kind of failure is reported at most once per
+	// minute.
+	SelfMonitoringDsn string
+	// CrashDir enables crash persistence: events of recovered panics are
+	// written to this directory before they are sent, and removed once the
+	// transport reports their delivery. Events left over by a process that
+	// terminated before they were sent are sent by the next client created
+	// with the same CrashDir. The directory is created if needed.
+	//
+	// Custom transports that do not report delivery outcomes leave the events
+	// in the directory, so they are sent a second time at the next start.
+	CrashDir string
+	// CrashSignals are signals that terminate the program after a fatal event
+	// naming the signal is persisted to CrashDir, with the stacks of all
+	// goroutines. Once the event is written, the signal is raised again with
+	// its default behavior. Do not list signals the program handles itself,
+	// such as SIGTERM for a graceful shutdown. Requires CrashDir.
+	CrashSignals []os.Signal
+	// CaptureFatalCrashes records the crash output of the Go runtime in
+	// CrashDir, and reports it at the next start. This captures what no
+	// recover can, such as fatal errors, unrecovered panics and signals
+	// raised in cgo code like SIGSEGV, SIGBUS or SIGABRT. For signals in
+	// native code, the program counter is reported as a native frame,
+	// symbolized with the symbol table of the executable if it is present
+	// and the executable was not replaced in the meantime.
+	//
+	// Each process records its crash output in a file of its own, but the
+	// files of processes that are still running are removed by others, so
+	// use a CrashDir per process. Requires CrashDir and Go 1.23 or later.
+	CaptureFatalCrashes bool
+	// DebugLogger receives the debug messages of the SDK in Debug mode,
+	// with a level and details as key-value pairs, instead of DebugWriter.
+	// Use it to route the messages into the logging system of the program.
+	DebugLogger DebugLogger
+	// EventEncoder replaces encoding/json for encoding events in the
+	// transports of the SDK, for example with a faster JSON library. If it
+	// fails, the event is encoded with encoding/json. Events that exceed
+	// the size limit are truncated and encoded again with encoding/json.
+	EventEncoder EventEncoder
+	// EnvelopeHeader, if set, is called by the transports of the SDK with
+	// the header of the envelope of every event before it is sent. It may
+	// change the header, for example overriding sent_at or adding fields
+	// read by a custom Relay. The trace header holds the dynamic sampling
+	// context of the event, if any. The function is called from the
+	// goroutines of the transport, and must not block.
+	EnvelopeHeader func(header EnvelopeHeader, event *Event)
 	// The transport to use. Defaults to HTTPTransport.
 	Transport Transport
 	// The server name to be reported.
@@ -170,8 +453,10 @@ type ClientOptions struct {
 	// https://docs.sentry.io/product/releases/.
 	//
 	// If Release is not set, the SDK will try to derive a default value
-	// from environment variables or the Git repository in the working
-	// directory.
+	// from environment variables, the VCS information embedded in the binary
+	// by the Go toolchain (as "module/path@revision", with a "-dirty" suffix
+	// for builds with uncommitted changes), or the Git repository in the
+	// working directory.
 	//
 	// If you distribute a compiled binary, it is recommended to set the
 	// Release value explicitly at build time. As an example, you can use:
@@ -187,6 +472,10 @@ type ClientOptions struct {
 	// See https://golang.org/cmd/go/ and https://golang.org/cmd/link/ for
 	// the official documentation of -ldflags and -X, respectively.
 	Release string
+	// DisableReleaseFromBuild disables deriving a default Release from the
+	// build information and the Git repository. Environment variables are
+	// still used.
+	DisableReleaseFromBuild bool
 	// The dist to be sent with events.
 	Dist string
 	// The environment to be sent with events.
@@ -199,6 +488,37 @@ type ClientOptions struct {
 	// See https://develop.sentry.dev/sdk/envelopes/#size-limits for size limits
 	// applied during event ingestion. Events that exceed these limits might get dropped.
 	MaxSpans int
+	// SeverityMapper, if set, maps the native severities of the SDK and the
+	// integrations, such as HTTP status codes, gRPC codes, log levels,
+	// panics and errors, to the level of their events and breadcrumbs, in
+	// place of the mapping of each integration. Returning an empty level
+	// keeps the default of the integration. See Client.MapSeverity.
+	SeverityMapper func(severity Severity) Level
+	// AttachPprofLabels adds the pprof labels of the context of events
+	// captured with one, see RequestContextExtractor, as tags. Labels set
+	// with pprof.Do, such as a job ID or shard, then annotate the errors
+	// captured within. Go provides no way to read the labels of a goroutine
+	// other than through the context passed to pprof.Do. Labels don't
+	// replace the tags of the event and its scope.
+	AttachPprofLabels bool
+	// RequestContextExtractor, if set, is called with the context of every
+	// event captured with one, that is with EventHint.Context or
+	// EventHint.Request set, as the HTTP integrations and the *WithContext
+	// functions do. The tags, user and contexts it returns are added to the
+	// event, so that values stored in the context, such as a request ID or
+	// the authenticated user, are attached without setting them on the
+	// scope. They don't replace the values of the event and its scope.
+	RequestContextExtractor func(ctx context.Context) (tags map[string]string, user User, contexts map[string]Context)
+	// Clock is the source of the current time of the SDK: the timestamps of
+	// events, breadcrumbs and spans, the windows of EventBudgets and
+	// transaction aggregation, and the expiry of rate limits in the HTTP
+	// transports. Defaults to the system clock. The sent_at header of
+	// envelopes always follows the system clock, since Sentry corrects the
+	// timestamps of events for clock drift with it.
+	Clock Clock
+	// IDGenerator generates the trace and span IDs of spans. Defaults to
+	// random IDs.
+	IDGenerator IDGenerator
 	// An optional pointer to http.Client that will be used with a default
 	// HTTPTransport. Using your own client will make HTTPTransport, HTTPProxy,
 	// HTTPSProxy and CaCerts options ignored.
@@ -224,20 +544,61 @@ type ClientOptions struct {
 	// is not optimized for long chains either. The top-level error together with a
 	// stack trace is often the most useful information.
 	MaxErrorDepth int
-	// Default event tags. These are overridden by tags set on a scope.
+	// StrictOptions makes NewClient return the error of Validate if the
+	// options have any problem, instead of ignoring or correcting them.
+	StrictOptions bool
+	// Default event tags. These are overridden by tags set on a scope or on
+	// the event itself.
+	//
+	// Tags are also read from environment variables prefixed with
+	// SENTRY_TAGS_, such that SENTRY_TAGS_region=eu-west-1 sets the tag
+	// "region". Tags in this option override tags from the environment.
 	Tags map[string]string
 }
 
 // Client is the underlying processor that is used by the main API and Hub
 // instances. It must be created with NewClient.
 type Client struct {
-	mu              sync.RWMutex
-	options         ClientOptions
+	mu      sync.RWMutex
+	options ClientOptions
+	// runtime holds the options changed with UpdateOptions, protected by mu.
+	// If set, it takes precedence over the same fields in options.
+	runtime         *RuntimeOptions
 	dsn             *Dsn
-	eventProcessors []EventProcessor
+	eventProcessors eventProcessors
 	integrations    []Integration
-	sdkIdentifier   string
-	sdkVersion      string
+	// selfMonitor reports SDK failures if SelfMonitoringDsn is set.
+	selfMonitor *selfMonitor
+	// crashes persists the events of crashes if CrashDir is set.
+	crashes *crashStore
+	// throttle holds the messages of Hub.CaptureMessageThrottled.
+	throttle messageThrottle
+	// pipeline runs the event processors and BeforeSend if
+	// ClientOptions.AsyncEventProcessing is set.
+	pipeline *eventPipeline
+	// budget enforces EventBudgets, if set.
+	budget *eventBudget
+	// storms aggregates error storms, see ErrorStormThreshold.
+	storms *errorStorms
+	// shedder holds the level of LoadShedding, if set.
+	shedder *loadShedder
+	// aggregator merges short transactions, if
+	// AggregateTransactionsShorterThan is set.
+	aggregator *transactionAggregator
+	// contentionCaptures are the transactions waiting for the end of their
+	// contention profile window, which Flush waits for.
+	contentionCaptures backgroundTasks
+	// counters collects the statistics of the client for Stats.
+	counters clientCounters
+	// onEventDropped is called for dropped events, it combines the
+	// OnEventDropped option with the statistics and self-monitoring of the
+	// client.
+	onEventDropped func(event *Event, reason DropReason, category string)
+	// transactionFilter is the installed IgnoreTransactions integration, if
+	// any. It is consulted when transactions finish.
+	transactionFilter *ignoreTransactionsIntegration
+	sdkIdentifier     string
+	sdkVersion        string
 	// Transport is read-only. Replacing the transport of an existing client is
 	// not supported, create a new client instead.
 	Transport Transport
@@ -251,6 +612,12 @@ type Client struct {
 // single goroutine) or hub methods (for concurrent programs, for example web
 // servers).
 func NewClient(options ClientOptions) (*Client, error) {
+	if options.StrictOptions {
+		if err := options.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
 	// The default error event sample rate for all SDKs is 1.0 (send all).
 	//
 	// In Go, the zero value (default) for float64 is 0.0, which means that
@@ -274,11 +641,7 @@ func NewClient(options ClientOptions) (*Client, error) {
 	}
 
 	if options.Debug {
-		debugWriter := options.DebugWriter
-		if debugWriter == nil {
-			debugWriter = os.Stderr
-		}
-		Logger.SetOutput(debugWriter)
+		setDebugOutput(true, options.DebugWriter, options.DebugLogger)
 	}
 
 	if options.Dsn == "" {
@@ -286,7 +649,7 @@ func NewClient(options ClientOptions) (*Client, error) {
 	}
 
 	if options.Release == "" {
-		options.Release = defaultRelease()
+		options.Release = defaultRelease(!options.DisableReleaseFromBuild)
 	}
 
 	if options.Environment == "" {
@@ -301,6 +664,18 @@ func NewClient(options ClientOptions) (*Client, error) {
 		options.MaxSpans = defaultMaxSpans
 	}
 
+	if options.ExecutionTraceMaxBytes == 0 {
+		options.ExecutionTraceMaxBytes = defaultExecutionTraceMaxBytes
+	}
+
+	if options.ContentionProfileWindow == 0 {
+		options.ContentionProfileWindow = defaultContentionProfileWindow
+	}
+
+	if options.MaxGoroutines <= 0 {
+		options.MaxGoroutines = defaultMaxGoroutines
+	}
+
 	// SENTRYGODEBUG is a comma-separated list of key=value pairs (similar
 	// to GODEBUG). It is not a supported feature: recognized debug options
 	// may change any time.
@@ -340,9 +715,54 @@ func NewClient(options ClientOptions) (*Client, error) {
 		sdkVersion:    SDKVersion,
 	}
 
+	if options.SelfMonitoringDsn != "" {
+		monitor, err := newSelfMonitor(options)
+		if err != nil {
+			return nil, err
+		}
+		client.selfMonitor = monitor
+		client.onEventDropped = monitor.wrapOnEventDropped(options.OnEventDropped)
+	} else {
+		client.onEventDropped = options.OnEventDropped
+	}
+	client.onEventDropped = client.counters.wrapOnEventDropped(client.onEventDropped)
+
+	if options.LoadShedding != nil {
+		client.shedder = newLoadShedder(*options.LoadShedding)
+	}
+	if options.ErrorStormThreshold > 0 {
+		client.storms = newErrorStorms(options.ErrorStormThreshold, options.ErrorStormInterval)
+		client.storms.now, client.storms.send = client.now, client.sendStormAggregates
+	}
+	if len(options.EventBudgets) > 0 {
+		client.budget = newEventBudget(options.EventBudgets, options.EventBudgetPeriod)
+	}
+	if options.AggregateTransactionsShorterThan > 0 {
+		client.aggregator = newTransactionAggregator(options.AggregateTransactionsShorterThan, options.TransactionAggregationInterval)
+	}
+	if options.AsyncEventProcessing {
+		client.pipeline = newEventPipeline(&client, options.AsyncEventProcessingQueueSize)
+	}
+
 	client.setupTransport()
 	client.setupIntegrations()
 
+	if options.CrashDir != "" && client.dsn != nil {
+		client.crashes = newCrashStore(options.CrashDir)
+		client.crashes.sendPersisted(client.Transport)
+		if options.CaptureFatalCrashes {
+			client.crashes.sendCrashOutputs(&client)
+			startCrashOutputOnce.Do(client.crashes.startCrashOutput)
+		}
+		client.handleCrashSignals(options.CrashSignals)
+	}
+
+	if options.ExecutionTraceThreshold > 0 {
+		startExecutionTraceOnce.Do(func() {
+			startExecutionTrace(options.ExecutionTraceMaxBytes)
+		})
+	}
+
 	return &client, nil
 }
 
@@ -366,6 +786,8 @@ func (client *Client) setupTransport() {
 		}
 	}
 
+	opts.OnEventDropped = client.onEventDropped
+
 	transport.Configure(opts)
 	client.Transport = transport
 }
@@ -374,17 +796,28 @@ func (client *Client) setupIntegrations() {
 	integrations := []Integration{
 		new(contextifyFramesIntegration),
 		new(environmentIntegration),
+		new(cloudEnvironmentIntegration),
 		new(modulesIntegration),
 		new(ignoreErrorsIntegration),
 		new(ignoreTransactionsIntegration),
 		new(globalTagsIntegration),
+		new(contextCancellationIntegration),
 	}
 
 	if client.options.Integrations != nil {
 		integrations = client.options.Integrations(integrations)
 	}
 
+	disabled := make(map[string]bool, len(client.options.DisabledIntegrations))
+	for _, name := range client.options.DisabledIntegrations {
+		disabled[name] = true
+	}
+
 	for _, integration := range integrations {
+		if disabled[integration.Name()] {
+			Logger.Printf("Integration %s is disabled\n", integration.Name())
+			continue
+		}
 		if client.integrationAlreadyInstalled(integration.Name()) {
 			Logger.Printf("Integration %s is already installed\n", integration.Name())
 			continue
@@ -406,14 +839,84 @@ func (client *Client) setupIntegrations() {
 // Note that typical programs have only a single client created by Init and the
 // client is shared among multiple hubs, one per goroutine, such that adding an
 // event processor to the client affects all hubs that share the client.
-func (client *Client) AddEventProcessor(processor EventProcessor) {
-	client.eventProcessors = append(client.eventProcessors, processor)
+//
+// See EventProcessorOption for naming and ordering processors.
+func (client *Client) AddEventProcessor(processor EventProcessor, opts ...EventProcessorOption) {
+	client.eventProcessors = client.eventProcessors.add(processor, opts)
+}
+
+// RemoveEventProcessor removes the event processor added with the given name,
+// see WithEventProcessorName. It reports whether there was such a processor.
+// Like AddEventProcessor, it must not be called from concurrent goroutines.
+func (client *Client) RemoveEventProcessor(name string) bool {
+	var removed bool
+	client.eventProcessors, removed = client.eventProcessors.remove(name)
+	return removed
 }
 
 // Options return ClientOptions for the current Client.
 func (client *Client) Options() ClientOptions {
 	// Note: internally, consider using `client.options` instead of `client.Options()` to avoid copying the object each time.
-	return client.options
+	options := client.options
+	runtime := client.runtimeOptions()
+	options.SampleRate = runtime.SampleRate
+	options.TracesSampleRate = runtime.TracesSampleRate
+	options.Environment = runtime.Environment
+	options.Debug = runtime.Debug
+	return options
+}
+
+// RuntimeOptions are the client options that can be changed with
+// Client.UpdateOptions after the client was created. See ClientOptions for
+// the meaning of each option.
+type RuntimeOptions struct {
+	SampleRate       float64
+	TracesSampleRate float64
+	Environment      string
+	Debug            bool
+}
+
+// UpdateOptions changes options of the client while it is in use, for
+// example when a service reloads its configuration. update is called with
+// the current options and the changes it makes apply to all events captured
+// and transactions started afterwards. It is safe to call UpdateOptions
+// concurrently with capturing events.
+//
+// Unlike in NewClient, a SampleRate of 0 set by update is not replaced by the
+// default of 1.
+func (client *Client) UpdateOptions(update func(options *RuntimeOptions)) {
+	client.mu.Lock()
+	previous := client.runtimeOptionsLocked()
+	runtime := previous
+	update(&runtime)
+	client.runtime = &runtime
+	client.mu.Unlock()
+
+	debugChanged := runtime.Debug != previous.Debug
+
+	if debugChanged {
+		setDebugOutput(runtime.Debug, client.options.DebugWriter, client.options.DebugLogger)
+	}
+}
+
+// runtimeOptions returns the current options set with UpdateOptions.
+func (client *Client) runtimeOptions() RuntimeOptions {
+	client.mu.RLock()
+	defer client.mu.RUnlock()
+
+	return client.runtimeOptionsLocked()
+}
+
+func (client *Client) runtimeOptionsLocked() RuntimeOptions {
+	if client.runtime != nil {
+		return *client.runtime
+	}
+	return RuntimeOptions{
+		SampleRate:       client.options.SampleRate,
+		TracesSampleRate: client.options.TracesSampleRate,
+		Environment:      client.options.Environment,
+		Debug:            client.options.Debug,
+	}
 }
 
 type EventOptions struct {
@@ -422,13 +925,22 @@ type EventOptions struct {
 
 // CaptureMessage captures an arbitrary message.
 func (client *Client) CaptureMessage(message string, hint *EventHint, scope EventModifier, opts ...EventOptions) *EventID {
-	event := client.EventFromMessage(message, LevelInfo, opts...)
+	event := client.eventFromMessage(message, LevelInfo, opts...)
 	return client.CaptureEvent(event, hint, scope)
 }
 
 // CaptureException captures an error.
 func (client *Client) CaptureException(exception error, hint *EventHint, scope EventModifier) *EventID {
-	event := client.EventFromException(exception, LevelError)
+	// Copy the hint rather than modifying the one owned by the caller.
+	h := EventHint{}
+	if hint != nil {
+		h = *hint
+	}
+	if h.OriginalException == nil {
+		h.OriginalException = exception
+	}
+	hint = &h
+	event := client.eventFromException(exception, client.MapSeverity(SeverityError, exception, LevelError))
 	return client.CaptureEvent(event, hint, scope)
 }
 
@@ -448,7 +960,17 @@ func (client *Client) CaptureCheckIn(checkIn *CheckIn, monitorConfig *MonitorCon
 // the utility methods like CaptureException. The return value is the
 // event ID. In case Sentry is disabled or event was dropped, the return value will be nil.
 func (client *Client) CaptureEvent(event *Event, hint *EventHint, scope EventModifier) *EventID {
-	return client.processEvent(event, hint, scope)
+	client.counters.recordCaptured()
+	var eventID *EventID
+	if timeout := client.options.CaptureTimeout; timeout > 0 && event != nil && event.Type == "" {
+		eventID = client.captureWithDeadline(event, hint, scope, timeout)
+	} else {
+		eventID = client.processEvent(event, hint, scope)
+	}
+	if eventID == nil && hint != nil {
+		hint.Delivery.resolve(DeliveryDropped)
+	}
+	return eventID
 }
 
 // Recover captures a panic.
@@ -482,6 +1004,18 @@ func (client *Client) RecoverWithContext(
 		return nil
 	}
 
+	event, hint := client.eventFromRecovered(ctx, err, hint, opts...)
+	return client.CaptureEvent(event, hint, scope)
+}
+
+// eventFromRecovered creates an event from a recovered panic value err, and
+// returns it together with the hint to capture it with.
+func (client *Client) eventFromRecovered(
+	ctx context.Context,
+	err interface{},
+	hint *EventHint,
+	opts ...EventOptions,
+) (*Event, *EventHint) {
 	if ctx != nil {
 		if hint == nil {
 			hint = &EventHint{}
@@ -491,16 +1025,52 @@ func (client *Client) RecoverWithContext(
 		}
 	}
 
+	// The stack trace leading to the panic is more useful than the one
+	// leading to the recover call, which typically only shows deferred
+	// functions and panic handlers.
+	panicSite := panicStacktrace()
+
+	level := client.MapSeverity(SeverityPanic, err, LevelFatal)
 	var event *Event
 	switch err := err.(type) {
 	case error:
-		event = client.EventFromException(err, LevelFatal)
+		hasStacktrace := len(extractStacktracePCs(err)) > 0
+		event = client.eventFromException(err, level)
+		if !hasStacktrace && panicSite != nil {
+			// The outermost error is the last one in the list.
+			event.Exception[len(event.Exception)-1].Stacktrace = panicSite
+		}
 	case string:
-		event = client.EventFromMessage(err, LevelFatal, opts...)
+		event = client.eventFromMessage(err, level, opts...)
+	case fmt.Formatter, fmt.Stringer:
+		event = client.eventFromMessage(fmt.Sprintf("%v", err), level, opts...)
 	default:
-		event = client.EventFromMessage(fmt.Sprintf("%#v", err), LevelFatal, opts...)
+		event = client.eventFromMessage(fmt.Sprintf("%#v", err), level, opts...)
+	}
+	if len(event.Threads) > 0 && panicSite != nil {
+		event.Threads[0].Stacktrace = panicSite
+		event.Threads[0].Crashed = true
+	}
+	event.sdkMetaData.crash = true
+	return event, hint
+}
+
+// Close stops the goroutines of the client, after the events waiting for
+// ClientOptions.AsyncEventProcessing are processed, and stops sending the
+// aggregates of error storms on a timer. Events captured afterwards are
+// processed on the calling goroutine. Close does not flush the transport,
+// call Flush first. It is meant for clients that are discarded while the
+// program runs, such as the clients of tenants.
+func (client *Client) Close() {
+	if client.selfMonitor != nil {
+		client.selfMonitor.client.Close()
+	}
+	if client.pipeline != nil {
+		client.pipeline.close()
+	}
+	if client.storms != nil {
+		client.storms.stop()
 	}
-	return client.CaptureEvent(event, hint, scope)
 }
 
 // Flush waits until the underlying Transport sends any buffered events to the
@@ -515,14 +1085,52 @@ func (client *Client) RecoverWithContext(
 // the network synchronously, configure it to use the HTTPSyncTransport in the
 // call to Init.
 func (client *Client) Flush(timeout time.Duration) bool {
-	return client.Transport.Flush(timeout)
+	start := time.Now()
+	// All the waits below share one deadline, so that Flush blocks for at
+	// most timeout in total.
+	deadline := start.Add(timeout)
+	if client.selfMonitor != nil {
+		defer func() {
+			client.selfMonitor.client.Flush(time.Until(deadline))
+		}()
+	}
+	defer func() {
+		client.counters.recordFlush(time.Since(start))
+	}()
+	client.throttle.flush()
+	if client.storms != nil {
+		client.sendStormAggregates(client.storms.drain())
+	}
+	if client.budget != nil {
+		client.sendBudgetSummary(client.budget.drain(client.now()))
+	}
+	if !client.contentionCaptures.wait(time.Until(deadline)) {
+		return false
+	}
+	if client.pipeline != nil && !client.pipeline.wait(time.Until(deadline)) {
+		return false
+	}
+	if client.aggregator != nil {
+		for _, summary := range client.aggregator.drain() {
+			client.Transport.SendEvent(summary)
+		}
+	}
+	return client.Transport.Flush(time.Until(deadline))
 }
 
 // EventFromMessage creates an event from the given message string.
 func (client *Client) EventFromMessage(message string, level Level, opts ...EventOptions) *Event {
+	event := client.eventFromMessage(message, level, opts...)
+	event.resolveStacktraces()
+	return event
+}
+
+// eventFromMessage is like EventFromMessage, but leaves building the frames
+// of the stack traces to processEvent, after sampling.
+func (client *Client) eventFromMessage(message string, level Level, opts ...EventOptions) *Event {
 	if message == "" {
 		err := usageError{fmt.Errorf("%s called with empty message", callerFunctionName())}
-		return client.EventFromException(err, level)
+		return client.eventFromException(err, level)
 	}
 	event := NewEvent()
 	event.Level = level
@@ -530,7 +1138,7 @@ func (client *Client) EventFromMessage(message string, level Level, opts ...Even
 
 	if client.options.AttachStacktrace {
 		event.Threads = []Thread{{
-			Stacktrace: NewStacktrace(opts...),
+			Stacktrace: event.pendingStacktrace(callers(), skipFrames(opts)),
 			Crashed:    false,
 			Current:    true,
 		}}
@@ -541,6 +1149,14 @@ func (client *Client) EventFromMessage(message string, level Level, opts ...Even
 
 // EventFromException creates a new Sentry event from the given `error` instance.
 func (client *Client) EventFromException(exception error, level Level) *Event {
+	event := client.eventFromException(exception, level)
+	event.resolveStacktraces()
+	return event
+}
+
+// eventFromException is like EventFromException, but leaves building the
+// frames of the stack traces to processEvent, after sampling.
+func (client *Client) eventFromException(exception error, level Level) *Event {
 	event := NewEvent()
 	event.Level = level
 
@@ -549,7 +1165,7 @@ func (client *Client) EventFromException(exception error, level Level) *Event {
 		err = usageError{fmt.Errorf("%s called with nil error", callerFunctionName())}
 	}
 
-	event.SetException(err, client.options.MaxErrorDepth)
+	event.setException(err, client.options.MaxErrorDepth)
 
 	return event
 }
@@ -608,42 +1224,161 @@ func (client *Client) processEvent(event *Event, hint *EventHint, scope EventMod
 		err := usageError{fmt.Errorf("%s called with nil event", callerFunctionName())}
 		return client.CaptureException(err, hint, scope)
 	}
+	client.checkLoad()
+
+	deadline := event.sdkMetaData.deadline
+	if hint == nil {
+		hint = &EventHint{}
+	}
+	hint = completeHint(hint, scope)
+	event.sdkMetaData.delivery = hint.Delivery
 
-	// Transactions are sampled by options.TracesSampleRate or
-	// options.TracesSampler when they are started. Other events
-	// (errors, messages) are sampled here. Does not apply to check-ins.
-	if event.Type != transactionType && event.Type != checkInType && !sample(client.options.SampleRate) {
-		Logger.Println("Event dropped due to SampleRate hit.")
+	// Events captured with a deadline were sampled by captureWithDeadline.
+	if deadline == nil && !client.sampleEvent(event, scope) {
+		dropEvent(client.onEventDropped, event, DropReasonSampleRate)
 		return nil
 	}
 
+	event.resolveStacktraces()
+	original := event
 	if event = client.prepareEvent(event, hint, scope); event == nil {
+		dropEvent(client.onEventDropped, original, DropReasonEventProcessor)
 		return nil
 	}
 
-	// Apply beforeSend* processors
-	if hint == nil {
-		hint = &EventHint{}
+	// Error storms are detected by the fingerprints of the events, which
+	// the scope and GroupingStrategy set, and else by their stack traces.
+	if client.storms != nil && event.Type == "" && !event.sdkMetaData.stormAggregate && !event.sdkMetaData.budgetSummary {
+		ok, aggregates := client.storms.take(event, client.now())
+		client.sendStormAggregates(aggregates)
+		if !ok {
+			dropEvent(client.onEventDropped, event, DropReasonErrorStorm)
+			return nil
+		}
+	}
+
+	if client.pipeline != nil {
+		ok, closed := client.pipeline.enqueue(event, hint, deadline)
+		if ok {
+			return &event.EventID
+		}
+		if !closed {
+			dropEvent(client.onEventDropped, event, DropReasonQueueOverflow)
+			return nil
+		}
+		// The client was closed, the event is processed here.
+	}
+	return client.finishEvent(event, hint, deadline)
+}
+
+// sampleEvent reports whether event is kept by ClientOptions.SampleRate, or
+// the sample rate of scope. Transactions are kept, they are sampled by
+// TracesSampleRate or TracesSampler when they are started, and so are
+// check-ins.
+func (client *Client) sampleEvent(event *Event, scope EventModifier) bool {
+	if event.Type == transactionType || event.Type == checkInType {
+		return true
+	}
+	sampleRate := client.runtimeOptions().SampleRate
+	if scope, ok := scope.(*Scope); ok && scope != nil {
+		if rate, _ := scope.sampleRates(); rate != nil {
+			sampleRate = *rate
+		}
+	}
+	return sample(sampleRate)
+}
+
+// finishEvent runs the client and global event processors and BeforeSend on
+// an event prepared with the scope, and hands it to the transport.
+func (client *Client) finishEvent(event *Event, hint *EventHint, deadline *captureDeadline) *EventID {
+	original := event
+	if event = client.eventProcessors.apply(event, hint, "Client"); event == nil {
+		dropEvent(client.onEventDropped, original, DropReasonEventProcessor)
+		return nil
 	}
+	if event = globalEventProcessors.apply(event, hint, "Global"); event == nil {
+		dropEvent(client.onEventDropped, original, DropReasonEventProcessor)
+		return nil
+	}
+
+	if event.sdkMetaData.transactionProfile != nil {
+		event.sdkMetaData.transactionProfile.UpdateFromEvent(event)
+	}
+
+	// Apply beforeSend* processors
+	original = event
 	if event.Type == transactionType && client.options.BeforeSendTransaction != nil {
 		// Transaction events
 		if event = client.options.BeforeSendTransaction(event, hint); event == nil {
-			Logger.Println("Transaction dropped due to BeforeSendTransaction callback.")
+			dropEvent(client.onEventDropped, original, DropReasonBeforeSend)
 			return nil
 		}
 	} else if event.Type != transactionType && event.Type != checkInType && client.options.BeforeSend != nil {
 		// All other events
 		if event = client.options.BeforeSend(event, hint); event == nil {
-			Logger.Println("Event dropped due to BeforeSend callback.")
+			dropEvent(client.onEventDropped, original, DropReasonBeforeSend)
 			return nil
 		}
 	}
 
+	normalizeEventData(event)
+	event.sdkMetaData.delivery = hint.Delivery
+	if !client.downgradeEvent(event) {
+		dropEvent(client.onEventDropped, event, DropReasonProtocolCompatibility)
+		return nil
+	}
+	if !deadline.claim() {
+		// A minimal event was sent in place of this one.
+		return &event.EventID
+	}
+	// Only the events that would be sent count towards the budget.
+	if client.budget != nil && !event.sdkMetaData.budgetSummary {
+		ok, summary := client.budget.take(string(categoryFor(event.Type)), client.now())
+		client.sendBudgetSummary(summary)
+		if !ok {
+			dropEvent(client.onEventDropped, event, DropReasonEventBudget)
+			return nil
+		}
+	}
+	if event.sdkMetaData.crash && client.crashes != nil {
+		client.crashes.persist(event)
+	}
+	if event.Type == transactionType && client.aggregator != nil {
+		// The summaries are copies of processed transactions, they are sent
+		// as is.
+		merged, summaries := client.aggregator.add(event, client.now())
+		for _, summary := range summaries {
+			client.Transport.SendEvent(summary)
+		}
+		if merged {
+			event.sdkMetaData.delivery.resolve(DeliverySent)
+			return &event.EventID
+		}
+	}
 	client.Transport.SendEvent(event)
 
 	return &event.EventID
 }
 
+// now returns the current time of ClientOptions.Clock.
+func (client *Client) now() time.Time {
+	return clockOrDefault(client.options.Clock).Now()
+}
+
+// sendBudgetSummary sends the event of summary, if not nil.
+func (client *Client) sendBudgetSummary(summary *budgetSummary) {
+	if summary != nil {
+		client.processEvent(summary.event(client.options.EventBudgets), nil, nil)
+	}
+}
+
+// sendStormAggregates sends the aggregates of error storms.
+func (client *Client) sendStormAggregates(aggregates []*Event) {
+	for _, aggregate := range aggregates {
+		client.processEvent(aggregate, nil, nil)
+	}
+}
+
 func (client *Client) prepareEvent(event *Event, hint *EventHint, scope EventModifier) *Event {
 	if event.EventID == "" {
 		// TODO set EventID when the event is created, same as in other SDKs. It's necessary for profileTransaction.ID.
@@ -651,7 +1386,7 @@ func (client *Client) prepareEvent(event *Event, hint *EventHint, scope EventMod
 	}
 
 	if event.Timestamp.IsZero() {
-		event.Timestamp = time.Now()
+		event.Timestamp = client.now()
 	}
 
 	if event.Level == "" {
@@ -675,7 +1410,7 @@ func (client *Client) prepareEvent(event *Event, hint *EventHint, scope EventMod
 	}
 
 	if event.Environment == "" {
-		event.Environment = client.options.Environment
+		event.Environment = client.runtimeOptions().Environment
 	}
 
 	event.Platform = "go"
@@ -689,33 +1424,22 @@ func (client *Client) prepareEvent(event *Event, hint *EventHint, scope EventMod
 		}},
 	}
 
-	if scope != nil {
-		event = scope.ApplyToEvent(event, hint, client)
-		if event == nil {
-			return nil
-		}
-	}
-
-	for _, processor := range client.eventProcessors {
-		id := event.EventID
-		event = processor(event, hint)
-		if event == nil {
-			Logger.Printf("Event dropped by one of the Client EventProcessors: %s\n", id)
-			return nil
-		}
+	if client.options.AttachGoroutines && event.Type == "" {
+		event.Threads = goroutineThreads(event, client.options.MaxGoroutines)
 	}
 
-	for _, processor := range globalEventProcessors {
-		id := event.EventID
-		event = processor(event, hint)
+	if scope = withGlobalScope(scope); scope != nil {
+		event = scope.ApplyToEvent(event, hint, client)
 		if event == nil {
-			Logger.Printf("Event dropped by one of the Global EventProcessors: %s\n", id)
 			return nil
 		}
 	}
 
-	if event.sdkMetaData.transactionProfile != nil {
-		event.sdkMetaData.transactionProfile.UpdateFromEvent(event)
+	client.applyGrouping(event)
+	applyContextTags(event, hint)
+	client.applyRequestContext(event, hint)
+	if client.options.AttachPprofLabels {
+		applyPprofLabels(event, hint)
 	}
 
 	return event
@@ -729,6 +1453,12 @@ func (client *Client) listIntegrations() []string {
 	return integrations
 }
 
+// ignoresTransaction reports whether the transaction rooted at span is to be
+// dropped by the IgnoreTransactions integration.
+func (client *Client) ignoresTransaction(span *Span) bool {
+	return client.transactionFilter != nil && client.transactionFilter.ignoreTransaction(span)
+}
+
 func (client *Client) integrationAlreadyInstalled(name string) bool {
 	for _, integration := range client.integrations {
 		if integration.Name() == name {
@@ -0,0 +1,204 @@
+package sentry_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+)
+
+func panicWithError() {
+	panic(errors.New("panic error"))
+}
+
+func panicWithString() {
+	panic("panic string")
+}
+
+func TestRecoverReportsPanicSite(t *testing.T) {
+	var got *sentry.Event
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		AttachStacktrace: true,
+		BeforeSend: func(event *sentry.Event, _ *sentry.EventHint) *sentry.Event {
+			got = event
+			return event
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lastFrame := func(st *sentry.Stacktrace) string {
+		t.Helper()
+		if st == nil || len(st.Frames) == 0 {
+			t.Fatal("missing stack trace")
+		}
+		return st.Frames[len(st.Frames)-1].Function
+	}
+
+	func() {
+		defer client.Recover(nil, nil, sentry.NewScope())
+		panicWithError()
+	}()
+	if f := lastFrame(got.Exception[0].Stacktrace); f != "panicWithError" {
+		t.Errorf("got last frame %q, want panicWithError", f)
+	}
+
+	func() {
+		defer client.Recover(nil, nil, sentry.NewScope())
+		panicWithString()
+	}()
+	if f := lastFrame(got.Threads[0].Stacktrace); f != "panicWithString" {
+		t.Errorf("got last frame %q, want panicWithString", f)
+	}
+	if !got.Threads[0].Crashed {
+		t.Error("panicking thread should be marked as crashed")
+	}
+}
+
+type formattedPanic struct{}
+
+func (formattedPanic) Format(f fmt.State, _ rune) {
+	fmt.Fprint(f, "formatted panic")
+}
+
+func TestRecoverFormatsPanicValues(t *testing.T) {
+	var got *sentry.Event
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		BeforeSend: func(event *sentry.Event, _ *sentry.EventHint) *sentry.Event {
+			got = event
+			return event
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	func() {
+		defer client.Recover(nil, nil, sentry.NewScope())
+		panic(formattedPanic{})
+	}()
+	if got.Message != "formatted panic" {
+		t.Errorf("got message %q, want %q", got.Message, "formatted panic")
+	}
+}
+
+func blockUntilClosed(started, c chan struct{}) {
+	close(started)
+	<-c
+}
+
+func TestAttachGoroutines(t *testing.T) {
+	var got *sentry.Event
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		AttachGoroutines: true,
+		BeforeSend: func(event *sentry.Event, _ *sentry.EventHint) *sentry.Event {
+			got = event
+			return event
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	started, c := make(chan struct{}), make(chan struct{})
+	defer close(c)
+	go blockUntilClosed(started, c)
+	<-started
+
+	client.CaptureException(errors.New("boom"), nil, sentry.NewScope())
+	if len(got.Threads) < 2 {
+		t.Fatalf("got %d threads, want at least 2", len(got.Threads))
+	}
+	if th := got.Threads[0]; !th.Current || !th.Crashed || th.ID == "" {
+		t.Errorf("first thread = %+v, want the current, crashed goroutine", th)
+	}
+	var blocked bool
+	for _, th := range got.Threads[1:] {
+		if th.Current || th.Crashed {
+			t.Errorf("thread %s should be neither current nor crashed", th.ID)
+		}
+		if th.Stacktrace != nil {
+			frames := th.Stacktrace.Frames
+			blocked = blocked || frames[len(frames)-1].Function == "blockUntilClosed"
+		}
+	}
+	if !blocked {
+		t.Error("missing thread of the blocked goroutine")
+	}
+
+	client, err = sentry.NewClient(sentry.ClientOptions{
+		AttachGoroutines: true,
+		MaxGoroutines:    1,
+		BeforeSend: func(event *sentry.Event, _ *sentry.EventHint) *sentry.Event {
+			got = event
+			return event
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.CaptureMessage("message", nil, sentry.NewScope())
+	if len(got.Threads) != 1 || got.Threads[0].Crashed {
+		t.Errorf("got threads %+v, want a single goroutine that did not crash", got.Threads)
+	}
+
+	// A negative limit falls back to the default.
+	client, err = sentry.NewClient(sentry.ClientOptions{
+		AttachGoroutines: true,
+		MaxGoroutines:    -1,
+		BeforeSend: func(event *sentry.Event, _ *sentry.EventHint) *sentry.Event {
+			got = event
+			return event
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.CaptureMessage("message", nil, sentry.NewScope())
+	if len(got.Threads) < 2 {
+		t.Errorf("got %d threads, want all goroutines", len(got.Threads))
+	}
+}
+
+func TestStacktraceFramesBeforeSend(t *testing.T) {
+	var got *sentry.Event
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		AttachStacktrace: true,
+		BeforeSend: func(event *sentry.Event, _ *sentry.EventHint) *sentry.Event {
+			got = event
+			return event
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lastFunction := func(st *sentry.Stacktrace) string {
+		if st == nil || len(st.Frames) == 0 {
+			return ""
+		}
+		return st.Frames[len(st.Frames)-1].Function
+	}
+	const want = "TestStacktraceFramesBeforeSend"
+
+	client.CaptureException(errors.New("boom"), nil, sentry.NewScope())
+	if f := lastFunction(got.Exception[0].Stacktrace); f != want {
+		t.Errorf("innermost exception frame in BeforeSend = %q, want %q", f, want)
+	}
+	client.CaptureMessage("message", nil, sentry.NewScope())
+	if f := lastFunction(got.Threads[0].Stacktrace); f != want {
+		t.Errorf("innermost thread frame in BeforeSend = %q, want %q", f, want)
+	}
+
+	// Events created outside of capture calls have their frames right away.
+	event := client.EventFromException(errors.New("boom"), sentry.LevelError)
+	if f := lastFunction(event.Exception[0].Stacktrace); f != want {
+		t.Errorf("innermost frame of EventFromException = %q, want %q", f, want)
+	}
+	event = client.EventFromMessage("message", sentry.LevelInfo)
+	if f := lastFunction(event.Threads[0].Stacktrace); f != want {
+		t.Errorf("innermost frame of EventFromMessage = %q, want %q", f, want)
+	}
+}
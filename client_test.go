@@ -1,10 +1,13 @@
 package sentry
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -480,6 +483,83 @@ func TestSampleRateCanDropEvent(t *testing.T) {
 	}
 }
 
+func TestScopeSampleRate(t *testing.T) {
+	client, _, transport := setupClientTest()
+	scope := NewScope()
+
+	scope.SetSampleRate(0)
+	client.CaptureMessage("dropped", nil, scope)
+	if transport.lastEvent != nil {
+		t.Error("expected event to be dropped")
+	}
+
+	// The override is inherited by clones and can be removed.
+	clone := scope.Clone()
+	clone.SetSampleRate(-1)
+	client.CaptureMessage("sent", nil, clone)
+	assertEqual(t, transport.lastEvent.Message, "sent")
+	transport.lastEvent = nil
+	client.CaptureMessage("dropped", nil, scope.Clone())
+	if transport.lastEvent != nil {
+		t.Error("expected event to be dropped")
+	}
+}
+
+func TestScopeTracesSampleRate(t *testing.T) {
+	ctx := NewTestContext(ClientOptions{EnableTracing: true, TracesSampleRate: 1.0})
+	hub := GetHubFromContext(ctx)
+
+	hub.Scope().SetTracesSampleRate(0)
+	transaction := StartTransaction(ctx, "noisy")
+	assertEqual(t, transaction.Sampled, SampledFalse)
+	// Explicit decisions take precedence.
+	transaction = StartTransaction(ctx, "explicit", WithSpanSampled(SampledTrue))
+	assertEqual(t, transaction.Sampled, SampledTrue)
+
+	hub.Scope().SetTracesSampleRate(1)
+	client := hub.Client()
+	client.options.TracesSampler = TracesSampler(func(SamplingContext) float64 { return 0 })
+	transaction = StartTransaction(ctx, "sampled")
+	assertEqual(t, transaction.Sampled, SampledTrue)
+	assertEqual(t, transaction.frozenDynamicSamplingContext().Entries["sample_rate"], "1")
+}
+
+func TestUpdateOptions(t *testing.T) {
+	client, scope, transport := setupClientTest()
+	client.options.Environment = "staging"
+	var debug bytes.Buffer
+	client.options.DebugWriter = &debug
+	defer Logger.SetOutput(io.Discard)
+
+	client.UpdateOptions(func(options *RuntimeOptions) {
+		assertEqual(t, options.Environment, "staging")
+		options.Environment = "production"
+		options.TracesSampleRate = 0.5
+		options.Debug = true
+	})
+	client.CaptureMessage("Foo", nil, scope)
+	assertEqual(t, transport.lastEvent.Environment, "production")
+	assertEqual(t, client.Options().TracesSampleRate, 0.5)
+	Logger.Print("debug enabled")
+	if !strings.Contains(debug.String(), "debug enabled") {
+		t.Errorf("debug output not written to DebugWriter: %q", debug.String())
+	}
+
+	client.UpdateOptions(func(options *RuntimeOptions) {
+		options.SampleRate = 0.000000000000001
+		options.Debug = false
+	})
+	transport.lastEvent = nil
+	client.CaptureMessage("Bar", nil, scope)
+	if transport.lastEvent != nil {
+		t.Error("expected event to be dropped")
+	}
+	Logger.Print("debug disabled")
+	if strings.Contains(debug.String(), "debug disabled") {
+		t.Error("debug output written after disabling Debug")
+	}
+}
+
 func TestApplyToScopeCanDropEvent(t *testing.T) {
 	client, scope, transport := setupClientTest()
 	scope.shouldDropEvent = true
@@ -0,0 +1,28 @@
+package sentry
+
+import "time"
+
+// Clock is the source of the current time of the SDK, see ClientOptions.Clock.
+// Replacing it lets tests control timestamps, durations and the expiry of rate
+// limits, and lets simulators replay historical traffic with its original
+// timestamps.
+//
+// The durations of spans are the difference of two readings of the clock. If
+// both readings carry a monotonic clock reading, as those of time.Now do, the
+// duration is unaffected by changes of the wall clock, for example by NTP.
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock is the default Clock, returning time.Now.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// clockOrDefault returns clock, or the system clock if clock is nil.
+func clockOrDefault(clock Clock) Clock {
+	if clock == nil {
+		return systemClock{}
+	}
+	return clock
+}
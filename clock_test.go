@@ -0,0 +1,73 @@
+package sentry
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClientClock(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	transport := &TransportMock{}
+	client, err := NewClient(ClientOptions{Transport: transport, Clock: &testClock{now: now}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := NewHub(client, NewScope())
+
+	hub.AddBreadcrumb(&Breadcrumb{Message: "crumb"}, nil)
+	hub.CaptureMessage("message")
+
+	event := transport.lastEvent
+	assertEqual(t, event.Timestamp, now)
+	assertEqual(t, event.Breadcrumbs[0].Timestamp, now)
+}
+
+func TestTransportClock(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Add("X-Sentry-Rate-Limits", "60:error")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	clock := &testClock{now: time.Now()}
+	tr := NewHTTPSyncTransport()
+	tr.Configure(ClientOptions{Dsn: strings.Replace(srv.URL, "//", "//pubkey@", 1) + "/1", Clock: clock})
+
+	tr.SendEvent(NewEvent())
+	tr.SendEvent(NewEvent())
+	assertEqual(t, atomic.LoadInt32(&requests), int32(1))
+
+	// The rate limit expires by the clock of the transport.
+	clock.now = clock.now.Add(time.Minute)
+	tr.SendEvent(NewEvent())
+	assertEqual(t, atomic.LoadInt32(&requests), int32(2))
+}
+
+func TestTransportSentAtIgnoresClock(t *testing.T) {
+	sentAt := make(chan time.Time, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var header struct {
+			SentAt time.Time `json:"sent_at"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&header)
+		sentAt <- header.SentAt
+	}))
+	defer srv.Close()
+
+	tr := NewHTTPSyncTransport()
+	tr.Configure(ClientOptions{
+		Dsn:   strings.Replace(srv.URL, "//", "//pubkey@", 1) + "/1",
+		Clock: &testClock{now: time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)},
+	})
+	tr.SendEvent(NewEvent())
+	if got := <-sentAt; time.Since(got) > time.Minute {
+		t.Errorf("sent_at = %v, want the current time", got)
+	}
+}
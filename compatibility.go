@@ -0,0 +1,36 @@
+package sentry
+
+// ProtocolCompatibility selects the features of the Sentry protocol the SDK
+// uses, see ClientOptions.ProtocolCompatibility.
+type ProtocolCompatibility int
+
+const (
+	// ProtocolLatest uses every feature of the protocol the SDK supports. It
+	// is the default.
+	ProtocolLatest ProtocolCompatibility = iota
+	// ProtocolLegacy restricts envelopes to what older self-hosted Sentry
+	// and Relay versions accept: events and transactions with their
+	// attachments. Check-ins and metrics are dropped with
+	// DropReasonProtocolCompatibility, profiles and the _metrics_summary of
+	// ClientOptions.SpanMetrics are left out of transactions, and the trace
+	// header carrying the dynamic sampling context is omitted.
+	ProtocolLegacy
+)
+
+// downgradeEvent prepares event for the protocol selected by the
+// ProtocolCompatibility option. It reports whether the event can be sent at
+// all.
+func (client *Client) downgradeEvent(event *Event) bool {
+	if client.options.ProtocolCompatibility != ProtocolLegacy {
+		return true
+	}
+	switch event.Type {
+	case checkInType, metricType:
+		return false
+	}
+	event.sdkMetaData.transactionProfile = nil
+	event.sdkMetaData.dsc = DynamicSamplingContext{}
+	event.sdkMetaData.metricsSummary = nil
+	event.sdkMetaData.legacyProtocol = true
+	return true
+}
@@ -0,0 +1,57 @@
+package sentry
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestProtocolLegacy(t *testing.T) {
+	var dropped []DropReason
+	transport := &TransportMock{}
+	client, err := NewClient(ClientOptions{
+		Dsn:                   testDsn,
+		Transport:             transport,
+		Release:               "1.0",
+		ProtocolCompatibility: ProtocolLegacy,
+		OnEventDropped: func(_ *Event, reason DropReason, _ string) {
+			dropped = append(dropped, reason)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	scope := NewScope()
+
+	client.CaptureCheckIn(&CheckIn{MonitorSlug: "cron", Status: CheckInStatusOK}, nil, scope)
+	assertEqual(t, dropped, []DropReason{DropReasonProtocolCompatibility})
+	assertEqual(t, len(transport.Events()), 0)
+
+	event := NewEvent()
+	event.Type = transactionType
+	event.Transaction = "GET /"
+	event.sdkMetaData.transactionProfile = &profileInfo{}
+	event.sdkMetaData.dsc = DynamicSamplingContext{Entries: map[string]string{"release": "1.0"}, Frozen: true}
+	summary := spanMetricsSummary{}
+	summary.add("d:custom/duration@millisecond", 1, nil)
+	event.sdkMetaData.metricsSummary = summary
+	event.Spans = []*Span{{Op: "db", metricsSummary: summary}}
+	client.CaptureEvent(event, nil, scope)
+
+	events := transport.Events()
+	assertEqual(t, len(events), 1)
+	if events[0].sdkMetaData.transactionProfile != nil {
+		t.Error("the profile was not removed")
+	}
+	assertEqual(t, len(events[0].sdkMetaData.dsc.Entries), 0)
+	body, err := json.Marshal(events[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(body, []byte("_metrics_summary")) {
+		t.Errorf("the metrics summary was sent: %s", body)
+	}
+	if !bytes.Contains(body, []byte(`"op":"db"`)) {
+		t.Errorf("the spans were not sent: %s", body)
+	}
+}
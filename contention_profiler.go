@@ -0,0 +1,95 @@
+package sentry
+
+import (
+	"bytes"
+	"runtime"
+	"runtime/pprof"
+	"sync/atomic"
+	"time"
+)
+
+// defaultContentionProfileWindow is the default of
+// ClientOptions.ContentionProfileWindow.
+const defaultContentionProfileWindow = time.Second
+
+const (
+	// contentionBlockProfileRate samples blocking events of 10µs on average.
+	contentionBlockProfileRate = 10000
+	// contentionMutexProfileFraction samples one in 10 contention events.
+	contentionMutexProfileFraction = 10
+)
+
+// contentionProfiling is 1 while a contention profile window is open. Windows
+// of concurrent slow transactions do not overlap, only the first one gets the
+// profiles.
+var contentionProfiling int32
+
+// captureWithContentionProfiles captures the transaction event with hub after
+// recording block and mutex profiles for window, in a new goroutine. Flushing
+// the client of hub waits for the capture.
+func captureWithContentionProfiles(hub *Hub, event *Event, hint *EventHint, window time.Duration) {
+	client := hub.Client()
+	if client == nil {
+		return
+	}
+	client.contentionCaptures.add()
+	go func() {
+		defer client.contentionCaptures.done()
+		event.Attachments = append(event.Attachments, recordContentionProfiles(window)...)
+		hub.CaptureEventWithHint(event, hint)
+	}()
+}
+
+// recordContentionProfiles returns the block and mutex profiles at the start
+// and end of a window of the given duration, with the profile rates raised
+// during the window. Comparing the profiles shows the contention within the
+// window, for example with:
+//
+//	go tool pprof -base block-base.pprof block.pprof
+//
+// It returns nil if another window is open.
+func recordContentionProfiles(window time.Duration) []*Attachment {
+	if !atomic.CompareAndSwapInt32(&contentionProfiling, 0, 1) {
+		return nil
+	}
+	defer atomic.StoreInt32(&contentionProfiling, 0)
+
+	blockBase, mutexBase := writeProfile("block"), writeProfile("mutex")
+	runtime.SetBlockProfileRate(contentionBlockProfileRate)
+	mutexFraction := runtime.SetMutexProfileFraction(contentionMutexProfileFraction)
+	time.Sleep(window)
+	runtime.SetBlockProfileRate(0)
+	runtime.SetMutexProfileFraction(mutexFraction)
+
+	var attachments []*Attachment
+	for _, p := range []struct {
+		filename string
+		payload  []byte
+	}{
+		{"block-base.pprof", blockBase},
+		{"block.pprof", writeProfile("block")},
+		{"mutex-base.pprof", mutexBase},
+		{"mutex.pprof", writeProfile("mutex")},
+	} {
+		if p.payload == nil {
+			continue
+		}
+		attachments = append(attachments, &Attachment{
+			Filename:    p.filename,
+			ContentType: "application/octet-stream",
+			Payload:     p.payload,
+		})
+	}
+	return attachments
+}
+
+// writeProfile returns the named runtime profile in the pprof format, or nil
+// if it could not be written.
+func writeProfile(name string) []byte {
+	var b bytes.Buffer
+	if err := pprof.Lookup(name).WriteTo(&b, 0); err != nil {
+		Logger.Printf("Could not write the %s profile: %v", name, err)
+		return nil
+	}
+	return b.Bytes()
+}
@@ -0,0 +1,56 @@
+package sentry
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestContentionProfiles(t *testing.T) {
+	transport := &TransportMock{}
+	client, _ := NewClient(ClientOptions{
+		Dsn:                        testDsn,
+		Transport:                  transport,
+		EnableTracing:              true,
+		TracesSampleRate:           1,
+		ContentionProfileThreshold: time.Millisecond,
+		ContentionProfileWindow:    10 * time.Millisecond,
+	})
+	ctx := SetHubOnContext(context.Background(), NewHub(client, NewScope()))
+
+	fast := StartTransaction(ctx, "fast")
+	fast.EndTime = fast.StartTime
+	fast.Finish()
+	if len(transport.Events()) != 1 || len(transport.Events()[0].Attachments) != 0 {
+		t.Fatal("fast transaction should be sent immediately without profiles")
+	}
+
+	slow := StartTransaction(ctx, "slow")
+	slow.EndTime = slow.StartTime.Add(time.Second)
+	slow.Finish()
+
+	// Flushing waits for the end of the window.
+	if !client.Flush(5 * time.Second) {
+		t.Fatal("Flush timed out")
+	}
+	if len(transport.Events()) != 2 {
+		t.Fatal("slow transaction was not sent")
+	}
+	event := transport.Events()[1]
+	assertEqual(t, event.Transaction, "slow")
+	var filenames []string
+	for _, a := range event.Attachments {
+		filenames = append(filenames, a.Filename)
+	}
+	assertEqual(t, filenames, []string{"block-base.pprof", "block.pprof", "mutex-base.pprof", "mutex.pprof"})
+}
+
+func TestRecordContentionProfilesDoesNotOverlap(t *testing.T) {
+	atomic.StoreInt32(&contentionProfiling, 1)
+	defer atomic.StoreInt32(&contentionProfiling, 0)
+
+	if attachments := recordContentionProfiles(time.Hour); attachments != nil {
+		t.Errorf("got %d attachments while another window is open", len(attachments))
+	}
+}
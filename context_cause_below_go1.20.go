@@ -0,0 +1,11 @@
+//go:build !go1.20
+
+package sentry
+
+import "context"
+
+// contextCause returns the cause of the cancellation of ctx. Cancellation
+// causes were introduced in Go 1.20, older versions only report ctx.Err().
+func contextCause(ctx context.Context) error {
+	return ctx.Err()
+}
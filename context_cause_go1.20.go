@@ -0,0 +1,11 @@
+//go:build go1.20
+
+package sentry
+
+import "context"
+
+// contextCause returns the cause of the cancellation of ctx, as reported by
+// context.Cause.
+func contextCause(ctx context.Context) error {
+	return context.Cause(ctx)
+}
@@ -0,0 +1,69 @@
+package sentry
+
+import (
+	"fmt"
+	"sync"
+)
+
+// contextTags are the context values registered with RegisterContextTag.
+var contextTags struct {
+	mu   sync.RWMutex
+	tags []contextTag
+}
+
+type contextTag struct {
+	key  interface{}
+	name string
+}
+
+// RegisterContextTag registers a context key whose value is set as the tag
+// tagName on every event captured with a context, that is with
+// EventHint.Context or EventHint.Request set. It saves setting the tag on the
+// scope wherever the value is stored in a context:
+//
+//	type requestIDKey struct{}
+//
+//	sentry.RegisterContextTag(requestIDKey{}, "request_id")
+//
+// Values are formatted with fmt.Sprint, nil values are skipped. The tags don't
+// replace those of the event and its scope. Registering a key again changes
+// its tag name.
+func RegisterContextTag(key interface{}, tagName string) {
+	contextTags.mu.Lock()
+	defer contextTags.mu.Unlock()
+
+	for i, tag := range contextTags.tags {
+		if tag.key == key {
+			contextTags.tags[i].name = tagName
+			return
+		}
+	}
+	contextTags.tags = append(contextTags.tags, contextTag{key: key, name: tagName})
+}
+
+// applyContextTags sets the tags registered with RegisterContextTag from the
+// context of hint on event.
+func applyContextTags(event *Event, hint *EventHint) {
+	contextTags.mu.RLock()
+	registered := contextTags.tags
+	contextTags.mu.RUnlock()
+	if len(registered) == 0 {
+		return
+	}
+	ctx := hintContext(hint)
+	if ctx == nil {
+		return
+	}
+
+	tags := make(map[string]string, len(registered))
+	for _, tag := range registered {
+		switch v := ctx.Value(tag.key).(type) {
+		case nil:
+		case string:
+			tags[tag.name] = v
+		default:
+			tags[tag.name] = fmt.Sprint(v)
+		}
+	}
+	addMissingTags(event, tags)
+}
@@ -0,0 +1,31 @@
+package sentry
+
+import (
+	"context"
+	"testing"
+)
+
+type (
+	testJobIDKey struct{}
+	testShardKey struct{}
+)
+
+func TestRegisterContextTag(t *testing.T) {
+	defer func() { contextTags.tags = nil }()
+	RegisterContextTag(testJobIDKey{}, "job")
+	RegisterContextTag(testJobIDKey{}, "job_id")
+	RegisterContextTag(testShardKey{}, "shard")
+	RegisterContextTag("unset", "unset")
+
+	client, scope, transport := setupClientTest()
+	ctx := context.WithValue(context.Background(), testJobIDKey{}, "42")
+	ctx = context.WithValue(ctx, testShardKey{}, 7)
+
+	client.CaptureMessage("message", &EventHint{Context: ctx}, scope)
+	tags := transport.lastEvent.Tags
+	assertEqual(t, tags["job_id"], "42")
+	assertEqual(t, tags["shard"], "7")
+	_, hasOld := tags["job"]
+	_, hasUnset := tags["unset"]
+	assertEqual(t, hasOld || hasUnset, false)
+}
@@ -0,0 +1,347 @@
+package sentry
+
+import (
+	"context"
+	"time"
+)
+
+// Keys of the well-known contexts in Event.Contexts. See
+// https://develop.sentry.dev/sdk/event-payloads/contexts/.
+const (
+	deviceContextKey  = "device"
+	osContextKey      = "os"
+	runtimeContextKey = "runtime"
+	appContextKey     = "app"
+	cultureContextKey = "culture"
+
+	cloudResourceContextKey = "cloud_resource"
+	kubernetesContextKey    = "kubernetes"
+	flagsContextKey         = "flags"
+)
+
+// maxFeatureFlags is the number of most recently evaluated feature flags kept
+// in the flags context.
+const maxFeatureFlags = 100
+
+// DeviceContext describes the device that caused the event.
+type DeviceContext struct {
+	Name       string
+	Family     string
+	Model      string
+	ModelID    string
+	Arch       string
+	NumCPU     int
+	MemorySize int64
+	Timezone   string
+}
+
+// Map returns the context as it is stored in Event.Contexts. Empty fields are
+// omitted.
+func (c DeviceContext) Map() Context {
+	m := Context{}
+	if c.Name != "" {
+		m["name"] = c.Name
+	}
+	if c.Family != "" {
+		m["family"] = c.Family
+	}
+	if c.Model != "" {
+		m["model"] = c.Model
+	}
+	if c.ModelID != "" {
+		m["model_id"] = c.ModelID
+	}
+	if c.Arch != "" {
+		m["arch"] = c.Arch
+	}
+	if c.NumCPU != 0 {
+		m["num_cpu"] = c.NumCPU
+	}
+	if c.MemorySize != 0 {
+		m["memory_size"] = c.MemorySize
+	}
+	if c.Timezone != "" {
+		m["timezone"] = c.Timezone
+	}
+	return m
+}
+
+// OSContext describes the operating system on which the event was created.
+type OSContext struct {
+	Name           string
+	Version        string
+	Build          string
+	KernelVersion  string
+	RawDescription string
+}
+
+// Map returns the context as it is stored in Event.Contexts. Empty fields are
+// omitted.
+func (c OSContext) Map() Context {
+	m := Context{}
+	if c.Name != "" {
+		m["name"] = c.Name
+	}
+	if c.Version != "" {
+		m["version"] = c.Version
+	}
+	if c.Build != "" {
+		m["build"] = c.Build
+	}
+	if c.KernelVersion != "" {
+		m["kernel_version"] = c.KernelVersion
+	}
+	if c.RawDescription != "" {
+		m["raw_description"] = c.RawDescription
+	}
+	return m
+}
+
+// RuntimeContext describes the runtime in more detail. For Go programs, the
+// SDK populates it automatically.
+type RuntimeContext struct {
+	Name           string
+	Version        string
+	RawDescription string
+}
+
+// Map returns the context as it is stored in Event.Contexts. Empty fields are
+// omitted.
+func (c RuntimeContext) Map() Context {
+	m := Context{}
+	if c.Name != "" {
+		m["name"] = c.Name
+	}
+	if c.Version != "" {
+		m["version"] = c.Version
+	}
+	if c.RawDescription != "" {
+		m["raw_description"] = c.RawDescription
+	}
+	return m
+}
+
+// AppContext describes the application that caused the event.
+type AppContext struct {
+	StartTime  time.Time
+	Identifier string
+	Name       string
+	Version    string
+	Build      string
+	BuildType  string
+}
+
+// Map returns the context as it is stored in Event.Contexts. Empty fields are
+// omitted.
+func (c AppContext) Map() Context {
+	m := Context{}
+	if !c.StartTime.IsZero() {
+		m["app_start_time"] = c.StartTime.UTC().Format(time.RFC3339)
+	}
+	if c.Identifier != "" {
+		m["app_identifier"] = c.Identifier
+	}
+	if c.Name != "" {
+		m["app_name"] = c.Name
+	}
+	if c.Version != "" {
+		m["app_version"] = c.Version
+	}
+	if c.Build != "" {
+		m["app_build"] = c.Build
+	}
+	if c.BuildType != "" {
+		m["build_type"] = c.BuildType
+	}
+	return m
+}
+
+// CultureContext describes certain properties of the culture in which the
+// software is used.
+type CultureContext struct {
+	Calendar    string
+	DisplayName string
+	Locale      string
+	// Is24HourFormat is nil when unknown.
+	Is24HourFormat *bool
+	Timezone       string
+}
+
+// Map returns the context as it is stored in Event.Contexts. Empty fields are
+// omitted.
+func (c CultureContext) Map() Context {
+	m := Context{}
+	if c.Calendar != "" {
+		m["calendar"] = c.Calendar
+	}
+	if c.DisplayName != "" {
+		m["display_name"] = c.DisplayName
+	}
+	if c.Locale != "" {
+		m["locale"] = c.Locale
+	}
+	if c.Is24HourFormat != nil {
+		m["is_24_hour_format"] = *c.Is24HourFormat
+	}
+	if c.Timezone != "" {
+		m["timezone"] = c.Timezone
+	}
+	return m
+}
+
+// CloudResourceContext describes the cloud resource the program runs on, for
+// example an EC2 instance or a Cloud Run service. The SDK populates it
+// automatically for the environments it detects.
+type CloudResourceContext struct {
+	// Provider is the cloud provider, for example "aws" or "gcp".
+	Provider string
+	// Platform is the service of the provider, for example "aws_ecs" or
+	// "gcp_cloud_run".
+	Platform         string
+	AccountID        string
+	Region           string
+	AvailabilityZone string
+	HostID           string
+	HostType         string
+}
+
+// Map returns the context as it is stored in Event.Contexts. Empty fields are
+// omitted.
+func (c CloudResourceContext) Map() Context {
+	m := Context{}
+	if c.Provider != "" {
+		m["cloud.provider"] = c.Provider
+	}
+	if c.Platform != "" {
+		m["cloud.platform"] = c.Platform
+	}
+	if c.AccountID != "" {
+		m["cloud.account.id"] = c.AccountID
+	}
+	if c.Region != "" {
+		m["cloud.region"] = c.Region
+	}
+	if c.AvailabilityZone != "" {
+		m["cloud.availability_zone"] = c.AvailabilityZone
+	}
+	if c.HostID != "" {
+		m["host.id"] = c.HostID
+	}
+	if c.HostType != "" {
+		m["host.type"] = c.HostType
+	}
+	return m
+}
+
+// KubernetesContext describes the Kubernetes pod the program runs in. The SDK
+// populates it automatically from the environment variables commonly set with
+// the downward API.
+type KubernetesContext struct {
+	Namespace string
+	PodName   string
+	NodeName  string
+}
+
+// Map returns the context as it is stored in Event.Contexts. Empty fields are
+// omitted.
+func (c KubernetesContext) Map() Context {
+	m := Context{}
+	if c.Namespace != "" {
+		m["namespace"] = c.Namespace
+	}
+	if c.PodName != "" {
+		m["pod_name"] = c.PodName
+	}
+	if c.NodeName != "" {
+		m["node_name"] = c.NodeName
+	}
+	return m
+}
+
+// FeatureFlag is the result of evaluating a feature flag, as stored in the
+// flags context by Scope.AddFeatureFlag.
+type FeatureFlag struct {
+	Flag   string `json:"flag"`
+	Result bool   `json:"result"`
+}
+
+// SetDeviceContext sets the device context for the current scope.
+func (scope *Scope) SetDeviceContext(device DeviceContext) {
+	scope.SetContext(deviceContextKey, device.Map())
+}
+
+// SetOSContext sets the os context for the current scope.
+func (scope *Scope) SetOSContext(osContext OSContext) {
+	scope.SetContext(osContextKey, osContext.Map())
+}
+
+// SetRuntimeContext sets the runtime context for the current scope.
+func (scope *Scope) SetRuntimeContext(runtimeContext RuntimeContext) {
+	scope.SetContext(runtimeContextKey, runtimeContext.Map())
+}
+
+// SetAppContext sets the app context for the current scope.
+func (scope *Scope) SetAppContext(app AppContext) {
+	scope.SetContext(appContextKey, app.Map())
+}
+
+// SetCultureContext sets the culture context for the current scope.
+func (scope *Scope) SetCultureContext(culture CultureContext) {
+	scope.SetContext(cultureContextKey, culture.Map())
+}
+
+// SetCloudResourceContext sets the cloud resource context for the current
+// scope.
+func (scope *Scope) SetCloudResourceContext(cloudResource CloudResourceContext) {
+	scope.SetContext(cloudResourceContextKey, cloudResource.Map())
+}
+
+// SetKubernetesContext sets the kubernetes context for the current scope.
+func (scope *Scope) SetKubernetesContext(kubernetes KubernetesContext) {
+	scope.SetContext(kubernetesContextKey, kubernetes.Map())
+}
+
+// AddFeatureFlag records the result of evaluating a feature flag in the flags
+// context of the current scope, such that events carry the flags that were
+// in effect when they happened. Only the 100 most recently evaluated flags
+// are kept, evaluating a flag again moves it to the end.
+func (scope *Scope) AddFeatureFlag(flag string, result bool) {
+	scope.mu.Lock()
+	defer scope.mu.Unlock()
+
+	var flags []FeatureFlag
+	if flagsContext, ok := scope.contexts[flagsContextKey]; ok {
+		flags, _ = flagsContext["values"].([]FeatureFlag)
+	}
+
+	// The context may be shared with clones of the scope, never modify it.
+	values := make([]FeatureFlag, 0, len(flags)+1)
+	for _, f := range flags {
+		if f.Flag != flag {
+			values = append(values, f)
+		}
+	}
+	values = append(values, FeatureFlag{Flag: flag, Result: result})
+	if len(values) > maxFeatureFlags {
+		values = values[len(values)-maxFeatureFlags:]
+	}
+
+	scope.ownContexts()
+	scope.contexts[flagsContextKey] = Context{"values": values}
+}
+
+// RecordFeatureFlag records the result of evaluating a feature flag on the
+// current scope of the hub of ctx, see Scope.AddFeatureFlag. It is meant to
+// be called from the hooks of feature flag libraries, for example from the
+// After method of an OpenFeature hook:
+//
+//	func (sentryHook) After(ctx context.Context, hc openfeature.HookContext,
+//		details openfeature.InterfaceEvaluationDetails, _ openfeature.HookHints) error {
+//		if result, ok := details.Value.(bool); ok {
+//			sentry.RecordFeatureFlag(ctx, details.FlagKey, result)
+//		}
+//		return nil
+//	}
+func RecordFeatureFlag(ctx context.Context, flag string, result bool) {
+	hubFromContext(ctx).Scope().AddFeatureFlag(flag, result)
+}
@@ -0,0 +1,119 @@
+package sentry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestTypedContextsMap(t *testing.T) {
+	tests := []struct {
+		name string
+		got  Context
+		want Context
+	}{
+		{
+			name: "EmptyDevice",
+			got:  DeviceContext{}.Map(),
+			want: Context{},
+		},
+		{
+			name: "Device",
+			got:  DeviceContext{Name: "host", Arch: "arm64", NumCPU: 4, MemorySize: 1024}.Map(),
+			want: Context{"name": "host", "arch": "arm64", "num_cpu": 4, "memory_size": int64(1024)},
+		},
+		{
+			name: "OS",
+			got:  OSContext{Name: "linux", KernelVersion: "6.1.0"}.Map(),
+			want: Context{"name": "linux", "kernel_version": "6.1.0"},
+		},
+		{
+			name: "Runtime",
+			got:  RuntimeContext{Name: "go", Version: "go1.22.0"}.Map(),
+			want: Context{"name": "go", "version": "go1.22.0"},
+		},
+		{
+			name: "App",
+			got: AppContext{
+				StartTime: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+				Name:      "api",
+				Version:   "1.2.3",
+			}.Map(),
+			want: Context{"app_start_time": "2024-01-02T03:04:05Z", "app_name": "api", "app_version": "1.2.3"},
+		},
+		{
+			name: "Culture",
+			got:  CultureContext{Locale: "de-DE", Is24HourFormat: Pointer(true)}.Map(),
+			want: Context{"locale": "de-DE", "is_24_hour_format": true},
+		},
+		{
+			name: "CloudResource",
+			got:  CloudResourceContext{Provider: "aws", Platform: "aws_ecs", Region: "eu-central-1"}.Map(),
+			want: Context{"cloud.provider": "aws", "cloud.platform": "aws_ecs", "cloud.region": "eu-central-1"},
+		},
+		{
+			name: "Kubernetes",
+			got:  KubernetesContext{Namespace: "default", PodName: "api-0"}.Map(),
+			want: Context{"namespace": "default", "pod_name": "api-0"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertEqual(t, tt.got, tt.want)
+		})
+	}
+}
+
+func TestScopeSetTypedContexts(t *testing.T) {
+	scope := NewScope()
+	scope.SetAppContext(AppContext{Name: "api"})
+	scope.SetCultureContext(CultureContext{Timezone: "Europe/Berlin"})
+
+	event := scope.ApplyToEvent(NewEvent(), nil, nil)
+
+	assertEqual(t, event.Contexts["app"], Context{"app_name": "api"})
+	assertEqual(t, event.Contexts["culture"], Context{"timezone": "Europe/Berlin"})
+}
+
+func TestScopeAddFeatureFlag(t *testing.T) {
+	scope := NewScope()
+	scope.AddFeatureFlag("new-checkout", true)
+	scope.AddFeatureFlag("dark-mode", false)
+	clone := scope.Clone()
+	scope.AddFeatureFlag("new-checkout", false)
+
+	event := scope.ApplyToEvent(NewEvent(), nil, nil)
+	b, err := json.Marshal(event.Contexts["flags"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, string(b),
+		`{"values":[{"flag":"dark-mode","result":false},{"flag":"new-checkout","result":false}]}`)
+
+	assertEqual(t, clone.contexts["flags"]["values"], []FeatureFlag{
+		{Flag: "new-checkout", Result: true},
+		{Flag: "dark-mode", Result: false},
+	}, "clone should not be modified")
+}
+
+func TestScopeAddFeatureFlagLimit(t *testing.T) {
+	scope := NewScope()
+	for i := 0; i < maxFeatureFlags+10; i++ {
+		scope.AddFeatureFlag(fmt.Sprintf("flag-%d", i), true)
+	}
+
+	values := scope.contexts["flags"]["values"].([]FeatureFlag)
+	assertEqual(t, len(values), maxFeatureFlags)
+	assertEqual(t, values[0].Flag, "flag-10")
+}
+
+func TestRecordFeatureFlag(t *testing.T) {
+	hub, _, scope := setupHubTest()
+	ctx := SetHubOnContext(context.Background(), hub)
+
+	RecordFeatureFlag(ctx, "beta", true)
+
+	assertEqual(t, scope.contexts["flags"], Context{"values": []FeatureFlag{{Flag: "beta", Result: true}}})
+}
@@ -0,0 +1,146 @@
+package sentry
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// crashSignalTimeout is the time given to the transport to send the event of
+// a crash signal before the signal is raised again.
+const crashSignalTimeout = 2 * time.Second
+
+// crashStore keeps the events of crashes in a directory until they are
+// delivered, see ClientOptions.CrashDir.
+type crashStore struct {
+	dir string
+}
+
+func newCrashStore(dir string) *crashStore {
+	return &crashStore{dir: dir}
+}
+
+// persist writes event to the store and arranges for it to be removed once
+// it is delivered. It must be called before the event is passed to the
+// transport.
+func (s *crashStore) persist(event *Event) {
+	path, err := s.write(event)
+	if err != nil {
+		Logger.Printf("Crash event %s could not be persisted: %v", event.EventID, err)
+		return
+	}
+
+	delivery := event.sdkMetaData.delivery
+	if delivery == nil {
+		delivery = NewDelivery()
+		event.sdkMetaData.delivery = delivery
+	}
+	delivery.onResolve = func(outcome DeliveryOutcome) {
+		// Keep events that failed to be sent for the next start.
+		if outcome != DeliveryFailed {
+			_ = os.Remove(path)
+		}
+	}
+}
+
+// write stores event as a JSON file and syncs it to disk, such that it
+// survives the process terminating right after.
+func (s *crashStore) write(event *Event) (string, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(s.dir, string(event.EventID)+".json")
+	tmp, err := os.CreateTemp(s.dir, ".crash-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	return path, os.Rename(tmp.Name(), path)
+}
+
+// sendPersisted passes the events left in the store by earlier processes to
+// transport, removing them from the store.
+func (s *crashStore) sendPersisted(transport Transport) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			Logger.Printf("Persisted crash events could not be read: %v", err)
+		}
+		return
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(s.dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			Logger.Printf("Persisted crash event %s could not be read: %v", entry.Name(), err)
+			continue
+		}
+		// Remove the event before sending it, a broken event is not retried
+		// forever.
+		_ = os.Remove(path)
+		var event Event
+		if err := json.Unmarshal(data, &event); err != nil {
+			Logger.Printf("Persisted crash event %s is invalid: %v", entry.Name(), err)
+			continue
+		}
+		transport.SendEvent(&event)
+	}
+}
+
+// handleCrashSignals reports the given signals as crashes, see
+// ClientOptions.CrashSignals.
+func (client *Client) handleCrashSignals(signals []os.Signal) {
+	if len(signals) == 0 {
+		return
+	}
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, signals...)
+	go func() {
+		sig := <-c
+		signal.Stop(c)
+		client.captureCrashSignal(sig)
+		client.Flush(crashSignalTimeout)
+
+		signal.Reset(sig)
+		if p, err := os.FindProcess(os.Getpid()); err == nil && p.Signal(sig) == nil {
+			// Give the runtime the time to terminate the process.
+			time.Sleep(time.Second)
+		}
+		os.Exit(2)
+	}()
+}
+
+// captureCrashSignal captures a fatal event for a received crash signal with
+// the stacks of all goroutines, and persists it.
+func (client *Client) captureCrashSignal(sig os.Signal) *EventID {
+	event := NewEvent()
+	event.Level = LevelFatal
+	event.Message = fmt.Sprintf("Received signal %v", sig)
+	// The first goroutine is the one handling the signal.
+	event.Threads = goroutineThreads(event, client.options.MaxGoroutines+1)[1:]
+	event.sdkMetaData.crash = true
+	return client.CaptureEvent(event, nil, nil)
+}
@@ -0,0 +1,88 @@
+package sentry
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func crashFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestCrashPersistence(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "crashes")
+	transport := &TransportMock{}
+	client, err := NewClient(ClientOptions{Dsn: testDsn, Transport: transport, CrashDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client.CaptureMessage("not a crash", nil, nil)
+	func() {
+		defer client.Recover(nil, nil, nil)
+		panic("crash")
+	}()
+	files := crashFiles(t, dir)
+	if len(files) != 1 {
+		t.Fatalf("got crash files %v, want one", files)
+	}
+	crash := transport.lastEvent
+
+	// A new client sends the crash left over by the first one.
+	transport = &TransportMock{}
+	if _, err := NewClient(ClientOptions{Dsn: testDsn, Transport: transport, CrashDir: dir}); err != nil {
+		t.Fatal(err)
+	}
+	events := transport.Events()
+	if len(events) != 1 {
+		t.Fatalf("got %d events at the next start, want 1", len(events))
+	}
+	assertEqual(t, events[0].EventID, crash.EventID)
+	assertEqual(t, events[0].Message, "crash")
+	assertEqual(t, events[0].Level, LevelFatal)
+	if files := crashFiles(t, dir); len(files) != 0 {
+		t.Errorf("got crash files %v after sending them", files)
+	}
+}
+
+func TestCrashPersistenceRemovesDelivered(t *testing.T) {
+	dir := t.TempDir()
+	transport := &TransportMock{}
+	client, _ := NewClient(ClientOptions{Dsn: testDsn, Transport: transport, CrashDir: dir})
+
+	for _, outcome := range []DeliveryOutcome{DeliveryFailed, DeliverySent} {
+		func() {
+			defer client.Recover(nil, nil, nil)
+			panic(string(outcome))
+		}()
+		transport.lastEvent.sdkMetaData.delivery.resolve(outcome)
+	}
+	files := crashFiles(t, dir)
+	if len(files) != 1 {
+		t.Fatalf("got crash files %v, want only the failed one", files)
+	}
+}
+
+func TestCaptureCrashSignal(t *testing.T) {
+	dir := t.TempDir()
+	transport := &TransportMock{}
+	client, _ := NewClient(ClientOptions{Dsn: testDsn, Transport: transport, CrashDir: dir})
+
+	client.captureCrashSignal(os.Signal(syscall.SIGTERM))
+	event := transport.lastEvent
+	assertEqual(t, event.Level, LevelFatal)
+	assertEqual(t, event.Message, "Received signal terminated")
+	if len(event.Threads) == 0 {
+		t.Error("missing goroutine threads")
+	}
+	if files := crashFiles(t, dir); len(files) != 1 {
+		t.Errorf("got crash files %v, want one", files)
+	}
+}
@@ -0,0 +1,176 @@
+package sentry
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// DeliveryOutcome describes what happened to a captured event.
+type DeliveryOutcome string
+
+// Possible delivery outcomes.
+const (
+	// DeliveryPending means the outcome is not known yet.
+	DeliveryPending DeliveryOutcome = ""
+	// DeliverySent means the event was accepted by the server.
+	DeliverySent DeliveryOutcome = "sent"
+	// DeliveryRateLimited means the event was not sent because of a rate
+	// limit imposed by the server.
+	DeliveryRateLimited DeliveryOutcome = "rate_limited"
+	// DeliveryDropped means the event was discarded by the SDK, for example
+	// because of sampling, an event processor, BeforeSend or a full
+	// transport buffer.
+	DeliveryDropped DeliveryOutcome = "dropped"
+	// DeliveryFailed means sending the event failed, or the server rejected
+	// it.
+	DeliveryFailed DeliveryOutcome = "failed"
+)
+
+// Delivery tracks the delivery of a single event. Pass it to the client in
+// EventHint.Delivery and wait for the outcome on critical paths, to verify
+// that an error actually left the process:
+//
+//	delivery := sentry.NewDelivery()
+//	hub.Client().CaptureException(err, &sentry.EventHint{Delivery: delivery}, hub.Scope())
+//	outcome, err := delivery.Wait(ctx)
+//
+// The built-in transports report the outcome of every event. Events passed to
+// custom transports stay pending once they are handed over to them. The zero
+// value is a pending Delivery, like the one returned by NewDelivery.
+type Delivery struct {
+	once sync.Once
+	// initDone creates done, so that the zero value can be used.
+	initDone sync.Once
+	done     chan struct{}
+	outcome  DeliveryOutcome
+	// onResolve, if set, is called with the outcome when it is known.
+	onResolve func(DeliveryOutcome)
+}
+
+// NewDelivery returns a new pending Delivery.
+func NewDelivery() *Delivery {
+	return &Delivery{}
+}
+
+// Done returns a channel that is closed once the outcome is known.
+func (d *Delivery) Done() <-chan struct{} {
+	return d.doneChan()
+}
+
+func (d *Delivery) doneChan() chan struct{} {
+	d.initDone.Do(func() {
+		d.done = make(chan struct{})
+	})
+	return d.done
+}
+
+// Outcome returns the outcome of the delivery, or DeliveryPending if it is
+// not known yet.
+func (d *Delivery) Outcome() DeliveryOutcome {
+	select {
+	case <-d.doneChan():
+		return d.outcome
+	default:
+		return DeliveryPending
+	}
+}
+
+// Wait blocks until the outcome of the delivery is known or ctx is done. In
+// the latter case, it returns DeliveryPending and the error of ctx.
+func (d *Delivery) Wait(ctx context.Context) (DeliveryOutcome, error) {
+	select {
+	case <-d.doneChan():
+		return d.outcome, nil
+	case <-ctx.Done():
+		return DeliveryPending, ctx.Err()
+	}
+}
+
+// resolve records the outcome of the delivery. Only the first call has an
+// effect. It is safe to call on a nil Delivery.
+func (d *Delivery) resolve(outcome DeliveryOutcome) {
+	if d == nil {
+		return
+	}
+	d.once.Do(func() {
+		d.outcome = outcome
+		close(d.doneChan())
+		if d.onResolve != nil {
+			d.onResolve(outcome)
+		}
+	})
+}
+
+// DropReason describes why an event was discarded. The values match the
+// discard reasons of Sentry's client reports.
+type DropReason string
+
+// Possible reasons for discarding an event.
+const (
+	// DropReasonSampleRate means the event was not sampled.
+	DropReasonSampleRate DropReason = "sample_rate"
+	// DropReasonEventProcessor means an event processor or an integration
+	// discarded the event.
+	DropReasonEventProcessor DropReason = "event_processor"
+	// DropReasonBeforeSend means BeforeSend or BeforeSendTransaction returned
+	// nil.
+	DropReasonBeforeSend DropReason = "before_send"
+	// DropReasonEventBudget means the budget of the category of the event in
+	// ClientOptions.EventBudgets was used up.
+	DropReasonEventBudget DropReason = "event_budget"
+	// DropReasonErrorStorm means the event was counted in the aggregate of
+	// an error storm instead, see ClientOptions.ErrorStormThreshold.
+	DropReasonErrorStorm DropReason = "error_storm"
+	// DropReasonProtocolCompatibility means the type of the event is not
+	// part of the protocol selected by ClientOptions.ProtocolCompatibility.
+	DropReasonProtocolCompatibility DropReason = "protocol_compatibility"
+	// DropReasonQueueOverflow means the transport buffer was full.
+	DropReasonQueueOverflow DropReason = "queue_overflow"
+	// DropReasonRateLimit means the server imposed a rate limit on the
+	// category of the event.
+	DropReasonRateLimit DropReason = "ratelimit_backoff"
+	// DropReasonNetworkError means the request to the server failed.
+	DropReasonNetworkError DropReason = "network_error"
+	// DropReasonSendError means the server rejected the event.
+	DropReasonSendError DropReason = "send_error"
+	// DropReasonInternalError means the SDK could not prepare the event for
+	// sending, for example because of an invalid DSN.
+	DropReasonInternalError DropReason = "internal_sdk_error"
+)
+
+// outcome returns the delivery outcome corresponding to the drop reason.
+func (r DropReason) outcome() DeliveryOutcome {
+	switch r {
+	case DropReasonRateLimit:
+		return DeliveryRateLimited
+	case DropReasonNetworkError, DropReasonSendError:
+		return DeliveryFailed
+	default:
+		return DeliveryDropped
+	}
+}
+
+// dropEvent resolves the delivery of a discarded event and reports it to the
+// OnEventDropped callback, if any.
+func dropEvent(onEventDropped func(*Event, DropReason, string), event *Event, reason DropReason) {
+	category := string(categoryFor(event.Type))
+	debugLog(LevelInfo, "Event dropped", "event_id", event.EventID, "reason", reason, "category", category)
+	event.sdkMetaData.delivery.resolve(reason.outcome())
+	if onEventDropped != nil {
+		onEventDropped(event, reason, category)
+	}
+}
+
+// dropReasonFromResponse returns the reason for an event to be discarded by
+// the server, if it was.
+func dropReasonFromResponse(statusCode int) (DropReason, bool) {
+	switch {
+	case statusCode == http.StatusTooManyRequests:
+		return DropReasonRateLimit, true
+	case statusCode >= 400:
+		return DropReasonSendError, true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,130 @@
+package sentry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDeliveryOutcome(t *testing.T) {
+	tests := map[string]struct {
+		status     int
+		beforeSend func(event *Event, hint *EventHint) *Event
+		want       DeliveryOutcome
+		wantReason DropReason
+	}{
+		"sent":         {status: http.StatusOK, want: DeliverySent},
+		"rate limited": {status: http.StatusTooManyRequests, want: DeliveryRateLimited, wantReason: DropReasonRateLimit},
+		"failed":       {status: http.StatusInternalServerError, want: DeliveryFailed, wantReason: DropReasonSendError},
+		"dropped": {
+			status:     http.StatusOK,
+			beforeSend: func(*Event, *EventHint) *Event { return nil },
+			want:       DeliveryDropped,
+			wantReason: DropReasonBeforeSend,
+		},
+	}
+	for name, tt := range tests {
+		for _, transport := range []Transport{NewHTTPTransport(), NewHTTPSyncTransport()} {
+			transport := transport
+			t.Run(name, func(t *testing.T) {
+				srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+					w.WriteHeader(tt.status)
+				}))
+				defer srv.Close()
+
+				var reason DropReason
+				client, err := NewClient(ClientOptions{
+					Dsn:        strings.Replace(srv.URL, "://", "://public@", 1) + "/1",
+					Transport:  transport,
+					BeforeSend: tt.beforeSend,
+					OnEventDropped: func(event *Event, r DropReason, category string) {
+						assertEqual(t, category, "error")
+						reason = r
+					},
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				delivery := NewDelivery()
+				client.CaptureException(errors.New("boom"), &EventHint{Delivery: delivery}, NewScope())
+
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				got, err := delivery.Wait(ctx)
+				if err != nil {
+					t.Fatal(err)
+				}
+				assertEqual(t, got, tt.want)
+				assertEqual(t, reason, tt.wantReason)
+			})
+		}
+	}
+}
+
+func TestDeliveryWaitTimeout(t *testing.T) {
+	delivery := NewDelivery()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	got, err := delivery.Wait(ctx)
+	assertEqual(t, got, DeliveryPending)
+	assertEqual(t, err, context.Canceled)
+	assertEqual(t, delivery.Outcome(), DeliveryPending)
+
+	delivery.resolve(DeliverySent)
+	delivery.resolve(DeliveryFailed)
+	assertEqual(t, delivery.Outcome(), DeliverySent)
+}
+
+func TestDeliveryZeroValue(t *testing.T) {
+	var delivery Delivery
+	assertEqual(t, delivery.Outcome(), DeliveryPending)
+	delivery.resolve(DeliverySent)
+	select {
+	case <-delivery.Done():
+	default:
+		t.Fatal("Done() is not closed after the outcome is known")
+	}
+	got, err := delivery.Wait(context.Background())
+	assertEqual(t, got, DeliverySent)
+	assertEqual(t, err, nil)
+}
+
+func TestOnEventDroppedBeforeSending(t *testing.T) {
+	type drop struct {
+		reason   DropReason
+		category string
+	}
+	var drops []drop
+	ctx := NewTestContext(ClientOptions{
+		EnableTracing:      true,
+		TracesSampleRate:   1.0,
+		Transport:          &TransportMock{},
+		IgnoreTransactions: []string{"ignored"},
+		OnEventDropped: func(_ *Event, reason DropReason, category string) {
+			drops = append(drops, drop{reason, category})
+		},
+	})
+	hub := GetHubFromContext(ctx)
+	hub.Scope().AddEventProcessor(func(event *Event, _ *EventHint) *Event {
+		if event.Message == "drop" {
+			return nil
+		}
+		return event
+	})
+
+	hub.CaptureMessage("drop")
+	StartTransaction(ctx, "unsampled", WithSpanSampled(SampledFalse)).Finish()
+	StartTransaction(ctx, "ignored").Finish()
+
+	assertEqual(t, drops, []drop{
+		{DropReasonEventProcessor, "error"},
+		{DropReasonSampleRate, "transaction"},
+		{DropReasonEventProcessor, "transaction"},
+	})
+}
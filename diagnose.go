@@ -0,0 +1,222 @@
+package sentry
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DiagnosisProblem classifies why Diagnose could not deliver its test
+// envelope.
+type DiagnosisProblem string
+
+// The problems found by Diagnose.
+const (
+	// DiagnosisOK means the envelope was accepted.
+	DiagnosisOK DiagnosisProblem = ""
+	// DiagnosisInvalidDSN means the DSN could not be parsed.
+	DiagnosisInvalidDSN DiagnosisProblem = "invalid_dsn"
+	// DiagnosisDNS means the host of the DSN or of the proxy could not be
+	// resolved.
+	DiagnosisDNS DiagnosisProblem = "dns"
+	// DiagnosisProxy means the proxy could not be reached or refused to
+	// forward the request.
+	DiagnosisProxy DiagnosisProblem = "proxy"
+	// DiagnosisTLS means the TLS handshake failed, e.g. because the
+	// certificate of the server is not trusted. See ClientOptions.CaCerts.
+	DiagnosisTLS DiagnosisProblem = "tls"
+	// DiagnosisConnection means the connection failed or timed out.
+	DiagnosisConnection DiagnosisProblem = "connection"
+	// DiagnosisAuth means the server rejected the key of the DSN, or the
+	// project does not exist.
+	DiagnosisAuth DiagnosisProblem = "auth"
+	// DiagnosisRateLimited means the server accepted the key but rate limits
+	// the project.
+	DiagnosisRateLimited DiagnosisProblem = "rate_limited"
+	// DiagnosisServer means the server responded with another error status.
+	DiagnosisServer DiagnosisProblem = "server"
+)
+
+// maxDiagnosisResponseBytes is the part of the response body kept in a
+// DiagnosisReport.
+const maxDiagnosisResponseBytes = 1024
+
+// DiagnosisReport is the result of Diagnose.
+type DiagnosisReport struct {
+	// DSN is the DSN that was checked, and Endpoint the URL the envelope
+	// was sent to.
+	DSN      string
+	Endpoint string
+	// Proxy is the URL of the proxy the request went through, if any. It is
+	// only known if neither ClientOptions.HTTPClient nor HTTPTransport are
+	// set.
+	Proxy string
+	// Problem classifies Err, the error that occurred, if any.
+	Problem DiagnosisProblem
+	Err     error
+	// StatusCode and Response are the status code and the start of the body
+	// of the response to the envelope, if there was one.
+	StatusCode int
+	Response   string
+	// Duration is the duration of the round trip.
+	Duration time.Duration
+}
+
+// OK reports whether the test envelope was accepted.
+func (r *DiagnosisReport) OK() bool {
+	return r.Problem == DiagnosisOK
+}
+
+func (r *DiagnosisReport) String() string {
+	var b strings.Builder
+	if r.OK() {
+		fmt.Fprintf(&b, "OK: %s accepted the test envelope in %v", r.Endpoint, r.Duration)
+	} else {
+		fmt.Fprintf(&b, "Problem %s: %v", r.Problem, r.Err)
+	}
+	if r.Proxy != "" {
+		fmt.Fprintf(&b, " (via proxy %s)", r.Proxy)
+	}
+	if r.StatusCode != 0 && !r.OK() {
+		fmt.Fprintf(&b, "\nResponse %d: %s", r.StatusCode, r.Response)
+	}
+	return b.String()
+}
+
+// Diagnose checks that the SDK configured with options can deliver events.
+// It validates the DSN and sends a test envelope holding an empty client
+// report, which creates no data in Sentry, with the proxy, TLS and HTTP
+// client settings of options. Failures are classified in the report, so that
+// DNS, proxy, TLS and authentication problems can be told apart. The round
+// trip is bounded by ctx.
+//
+// Diagnose is meant for troubleshooting, such as a health endpoint or a
+// command line flag, rather than for every start of the program.
+func Diagnose(ctx context.Context, options ClientOptions) *DiagnosisReport {
+	report := &DiagnosisReport{DSN: options.Dsn}
+	dsn, err := NewDsn(options.Dsn)
+	if err != nil {
+		report.Problem, report.Err = DiagnosisInvalidDSN, err
+		return report
+	}
+	report.Endpoint = dsn.GetAPIURL().String()
+
+	request, err := diagnosisRequest(ctx, dsn)
+	if err != nil {
+		report.Problem, report.Err = DiagnosisInvalidDSN, err
+		return report
+	}
+
+	client := options.HTTPClient
+	if client == nil {
+		transport := options.HTTPTransport
+		if transport == nil {
+			proxy := getProxyConfig(options)
+			if u, err := proxy(request); err == nil && u != nil {
+				report.Proxy = u.Redacted()
+			}
+			transport = &http.Transport{
+				Proxy:           proxy,
+				TLSClientConfig: getTLSConfig(options),
+			}
+		}
+		client = &http.Client{Transport: transport}
+	}
+
+	start := time.Now()
+	response, err := client.Do(request)
+	report.Duration = time.Since(start)
+	if err != nil {
+		report.Problem, report.Err = diagnoseError(err), err
+		return report
+	}
+	defer response.Body.Close()
+
+	report.StatusCode = response.StatusCode
+	body, _ := io.ReadAll(io.LimitReader(response.Body, maxDiagnosisResponseBytes))
+	report.Response = string(body)
+	switch {
+	case response.StatusCode < 300:
+		return report
+	case response.StatusCode == http.StatusUnauthorized || response.StatusCode == http.StatusForbidden ||
+		response.StatusCode == http.StatusNotFound:
+		report.Problem = DiagnosisAuth
+	case response.StatusCode == http.StatusTooManyRequests:
+		report.Problem = DiagnosisRateLimited
+	case response.StatusCode == http.StatusProxyAuthRequired:
+		report.Problem = DiagnosisProxy
+	default:
+		report.Problem = DiagnosisServer
+	}
+	report.Err = fmt.Errorf("sentry responded with status %d", response.StatusCode)
+	return report
+}
+
+// diagnosisRequest returns the request of the test envelope of Diagnose.
+func diagnosisRequest(ctx context.Context, dsn *Dsn) (*http.Request, error) {
+	now := time.Now().UTC()
+	report, err := json.Marshal(map[string]interface{}{
+		"timestamp":        now,
+		"discarded_events": []interface{}{},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var envelope bytes.Buffer
+	enc := json.NewEncoder(&envelope)
+	if err := enc.Encode(map[string]interface{}{
+		"dsn":     dsn.String(),
+		"sent_at": now,
+		"sdk":     map[string]string{"name": sdkIdentifier, "version": SDKVersion},
+	}); err != nil {
+		return nil, err
+	}
+	if err := enc.Encode(map[string]interface{}{"type": "client_report", "length": len(report)}); err != nil {
+		return nil, err
+	}
+	envelope.Write(report)
+	envelope.WriteByte('\n')
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, dsn.GetAPIURL().String(), &envelope)
+	if err != nil {
+		return nil, err
+	}
+	setEnvelopeRequestHeaders(request, dsn, sdkIdentifier, SDKVersion)
+	return request, nil
+}
+
+// diagnoseError classifies an error of http.Client.Do.
+func diagnoseError(err error) DiagnosisProblem {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "proxyconnect" {
+		return DiagnosisProxy
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return DiagnosisDNS
+	}
+	var (
+		unknownAuthority x509.UnknownAuthorityError
+		hostname         x509.HostnameError
+		invalid          x509.CertificateInvalidError
+		record           tls.RecordHeaderError
+	)
+	if errors.As(err, &unknownAuthority) || errors.As(err, &hostname) ||
+		errors.As(err, &invalid) || errors.As(err, &record) ||
+		strings.Contains(err.Error(), "tls: ") {
+		return DiagnosisTLS
+	}
+	if strings.Contains(err.Error(), "proxy") {
+		return DiagnosisProxy
+	}
+	return DiagnosisConnection
+}
@@ -0,0 +1,79 @@
+package sentry
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func diagnosisDsn(url string) string {
+	return strings.Replace(url, "//", "//pubkey@", 1) + "/1"
+}
+
+func TestDiagnose(t *testing.T) {
+	var lines []string
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		if !strings.Contains(r.Header.Get("X-Sentry-Auth"), "sentry_key=pubkey") {
+			t.Errorf("X-Sentry-Auth = %q", r.Header.Get("X-Sentry-Auth"))
+		}
+	}))
+	defer ok.Close()
+
+	report := Diagnose(context.Background(), ClientOptions{Dsn: diagnosisDsn(ok.URL)})
+	if !report.OK() || report.StatusCode != http.StatusOK || report.Err != nil {
+		t.Fatalf("report = %+v", report)
+	}
+	assertEqual(t, report.Endpoint, ok.URL+"/api/1/envelope/")
+	if len(lines) != 3 || !strings.Contains(lines[1], `"type":"client_report"`) {
+		t.Errorf("envelope = %q", lines)
+	}
+}
+
+func TestDiagnoseProblems(t *testing.T) {
+	unauthorized := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"detail":"invalid api key"}`, http.StatusUnauthorized)
+	}))
+	defer unauthorized.Close()
+	limited := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer limited.Close()
+	untrusted := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer untrusted.Close()
+	closed := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	closed.Close()
+
+	tests := []struct {
+		name    string
+		options ClientOptions
+		want    DiagnosisProblem
+	}{
+		{"invalid DSN", ClientOptions{Dsn: "https://example.com"}, DiagnosisInvalidDSN},
+		{"auth", ClientOptions{Dsn: diagnosisDsn(unauthorized.URL)}, DiagnosisAuth},
+		{"rate limited", ClientOptions{Dsn: diagnosisDsn(limited.URL)}, DiagnosisRateLimited},
+		{"tls", ClientOptions{Dsn: diagnosisDsn(untrusted.URL)}, DiagnosisTLS},
+		{"connection", ClientOptions{Dsn: diagnosisDsn(closed.URL)}, DiagnosisConnection},
+		{"proxy", ClientOptions{Dsn: "http://pubkey@example.com/1", HTTPProxy: closed.URL}, DiagnosisProxy},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := Diagnose(context.Background(), tt.options)
+			if report.Problem != tt.want || report.Err == nil {
+				t.Errorf("report = %+v, want problem %q", report, tt.want)
+			}
+			if !strings.Contains(report.String(), string(tt.want)) {
+				t.Errorf("String() = %q", report.String())
+			}
+		})
+	}
+
+	report := Diagnose(context.Background(), ClientOptions{Dsn: diagnosisDsn(unauthorized.URL)})
+	assertEqual(t, report.Response, "{\"detail\":\"invalid api key\"}\n")
+}
@@ -179,6 +179,22 @@ func (dsn Dsn) GetProjectID() string {
 	return dsn.projectID
 }
 
+// GetOrgID returns the ID of the organization the DSN belongs to, as encoded
+// in the first label of hosts like "o123.ingest.sentry.io". It returns an
+// empty string for hosts without one, such as those of self-hosted Sentry.
+func (dsn Dsn) GetOrgID() string {
+	label, _, found := strings.Cut(dsn.host, ".")
+	if !found || len(label) < 2 || label[0] != 'o' {
+		return ""
+	}
+	for _, c := range label[1:] {
+		if c < '0' || c > '9' {
+			return ""
+		}
+	}
+	return label[1:]
+}
+
 // GetAPIURL returns the URL of the envelope endpoint of the project
 // associated with the DSN.
 func (dsn Dsn) GetAPIURL() *url.URL {
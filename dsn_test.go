@@ -301,3 +301,24 @@ func TestGetProjectID(t *testing.T) {
 		assertEqual(t, dsn.GetProjectID(), tt.want)
 	}
 }
+
+func TestGetOrgID(t *testing.T) {
+	tests := []struct {
+		dsn  string
+		want string
+	}{
+		{"https://public@o123.ingest.sentry.io/42", "123"},
+		{"https://public@o123.ingest.us.sentry.io/42", "123"},
+		{"https://public@sentry.example.com/42", ""},
+		{"https://public@o.example.com/42", ""},
+		{"https://public@o1a.example.com/42", ""},
+		{"https://public@o123/42", ""},
+	}
+	for _, tt := range tests {
+		dsn, err := NewDsn(tt.dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertEqual(t, dsn.GetOrgID(), tt.want)
+	}
+}
@@ -1,9 +1,11 @@
 package sentry
 
 import (
+	"sort"
 	"strconv"
 	"strings"
 
+	"github.com/getsentry/sentry-go/internal/intern"
 	"github.com/getsentry/sentry-go/internal/otel/baggage"
 )
 
@@ -15,29 +17,102 @@ const (
 type DynamicSamplingContext struct {
 	Entries map[string]string
 	Frozen  bool
+
+	// thirdParty holds the members of the baggage header the DSC was parsed
+	// from that don't belong to Sentry. They are written back with the
+	// entries so that continuing a trace doesn't drop other vendors' baggage.
+	thirdParty []baggage.Member
 }
 
+// DynamicSamplingContextFromHeader parses the sentry-* members of a baggage
+// header into the entries of a DSC. The other members are kept and written
+// back by String. It fails if any member of the header is malformed, see
+// DynamicSamplingContextFromHeaderLenient for a variant that doesn't.
 func DynamicSamplingContextFromHeader(header []byte) (DynamicSamplingContext, error) {
-	bag, err := baggage.Parse(string(header))
-	if err != nil {
+	if _, err := baggage.Parse(string(header)); err != nil {
 		return DynamicSamplingContext{}, err
 	}
+	return dynamicSamplingContextFromMembers(baggageMembers(header)), nil
+}
+
+// DynamicSamplingContextFromHeaderLenient parses the sentry-* members of a
+// baggage header, skipping those that are malformed instead of failing. The
+// skipped members are reported to the debug logger.
+func DynamicSamplingContextFromHeaderLenient(header []byte) DynamicSamplingContext {
+	return dynamicSamplingContextFromMembers(baggageMembers(header))
+}
+
+// baggageMembers returns the members of a baggage header in the order they
+// appear in, which baggage.Parse doesn't keep. Malformed members are skipped.
+func baggageMembers(header []byte) []baggage.Member {
+	var members []baggage.Member
+	for _, s := range strings.Split(string(header), ",") {
+		if strings.TrimSpace(s) == "" {
+			continue
+		}
+		bag, err := baggage.Parse(s)
+		if err != nil {
+			Logger.Printf("Skipping malformed baggage member %q: %v", s, err)
+			continue
+		}
+		members = append(members, bag.Members()...)
+	}
+	return members
+}
+
+// parseDynamicSamplingContext parses header leniently unless strict is set.
+func parseDynamicSamplingContext(header []byte, strict bool) (DynamicSamplingContext, error) {
+	if strict {
+		return DynamicSamplingContextFromHeader(header)
+	}
+	return DynamicSamplingContextFromHeaderLenient(header), nil
+}
 
+func dynamicSamplingContextFromMembers(members []baggage.Member) DynamicSamplingContext {
 	entries := map[string]string{}
-	for _, member := range bag.Members() {
-		// We only store baggage members if their key starts with "sentry-".
-		if k, v := member.Key(), member.Value(); strings.HasPrefix(k, sentryPrefix) {
-			entries[strings.TrimPrefix(k, sentryPrefix)] = v
+	var thirdParty []baggage.Member
+	for _, member := range members {
+		k, v := member.Key(), member.Value()
+		if !strings.HasPrefix(k, sentryPrefix) {
+			thirdParty = append(thirdParty, member)
+			continue
 		}
+		k, v, ok := normalizeDSCEntry(strings.TrimPrefix(k, sentryPrefix), v)
+		if !ok {
+			continue
+		}
+		// Interning lets the header be freed once it has been parsed.
+		entries[intern.String(k)] = intern.String(v)
 	}
 
 	return DynamicSamplingContext{
 		Entries: entries,
 		// If there's at least one Sentry value, we consider the DSC frozen
-		Frozen: len(entries) > 0,
-	}, nil
+		Frozen:     len(entries) > 0,
+		thirdParty: thirdParty,
+	}
 }
 
+// DynamicSamplingContextFromTransaction returns a frozen dynamic sampling
+// context built from the properties of the transaction span and of the client
+// bound to its context. The transaction entry is only set if
+// ClientOptions.TransactionNameQuality, or HasGoodTransactionName, accepts the
+// name of span.
+//
+// The sample_rate entry is the first one set of:
+//
+//  1. the rate returned by ClientOptions.TracesSampler, or implied by a
+//     sampling decision passed to StartTransaction;
+//  2. ClientOptions.TracesSampleRate;
+//  3. the sample_rate of the parent's dynamic sampling context, if span
+//     continues a trace.
+//
+// The parent's dynamic sampling context is the one set on span that is not
+// frozen yet, or else the one of the baggage header span continues, see
+// ContinueFromHeaders. Its other entries fill in those that span doesn't provide. With
+// ClientOptions.PreferParentSamplingContext set, the parent's entries take
+// precedence instead, except for trace_id and sampled, which always describe
+// span.
 func DynamicSamplingContextFromTransaction(span *Span) DynamicSamplingContext {
 	entries := map[string]string{}
 
@@ -55,35 +130,94 @@ func DynamicSamplingContextFromTransaction(span *Span) DynamicSamplingContext {
 	if traceID := span.TraceID.String(); traceID != "" {
 		entries["trace_id"] = traceID
 	}
-	if sampleRate := span.sampleRate; sampleRate != 0 {
+	if sampleRate, ok := transactionSampleRate(span, client); ok {
 		entries["sample_rate"] = strconv.FormatFloat(sampleRate, 'f', -1, 64)
 	}
+	entries["sample_rand"] = formatSampleRand(span.sampleRand)
 
 	if dsn := client.dsn; dsn != nil {
 		if publicKey := dsn.publicKey; publicKey != "" {
 			entries["public_key"] = publicKey
 		}
 	}
+	if orgID := dscOrgID(client); orgID != "" {
+		entries["org_id"] = orgID
+	}
 	if release := client.options.Release; release != "" {
 		entries["release"] = release
 	}
-	if environment := client.options.Environment; environment != "" {
+	if environment := client.runtimeOptions().Environment; environment != "" {
 		entries["environment"] = environment
 	}
 
-	// Only include the transaction name if it's of good quality (not empty and not SourceURL)
-	if span.Source != "" && span.Source != SourceURL {
-		if span.IsTransaction() {
-			entries["transaction"] = span.Name
-		}
+	if span.IsTransaction() && transactionNameQuality(span, client) {
+		entries["transaction"] = span.Name
 	}
 
 	entries["sampled"] = strconv.FormatBool(span.Sampled.Bool())
 
+	addDSCTags(entries, &client.options, func(tag string) (string, bool) {
+		if v, ok := span.Tags[tag]; ok {
+			return v, true
+		}
+		v, ok := span.scopeTags[tag]
+		return v, ok
+	})
+
+	parent := span.incomingSamplingEntries
+	if dsc := span.dynamicSamplingContext; !dsc.IsFrozen() {
+		parent = dsc.Entries
+	}
+	if len(parent) > 0 {
+		preferParent := client.options.PreferParentSamplingContext
+		for k, v := range normalizeDSCEntries(parent) {
+			if k == "trace_id" || k == "sampled" {
+				continue
+			}
+			if _, ok := entries[k]; !ok || preferParent {
+				entries[k] = v
+			}
+		}
+	}
+
 	return DynamicSamplingContext{
-		Entries: entries,
-		Frozen:  true,
+		Entries:    normalizeDSCEntries(entries),
+		Frozen:     true,
+		thirdParty: span.dynamicSamplingContext.thirdParty,
+	}
+}
+
+// HasGoodTransactionName reports whether the name of transaction is of good
+// quality: it has a source, and the source is not SourceURL, whose names are
+// raw URLs. It is the default of ClientOptions.TransactionNameQuality, which
+// decides whether the name is part of the dynamic sampling context.
+func HasGoodTransactionName(transaction *Span) bool {
+	return transaction.Source != "" && transaction.Source != SourceURL
+}
+
+// transactionNameQuality applies ClientOptions.TransactionNameQuality, or
+// HasGoodTransactionName if it is not set.
+func transactionNameQuality(transaction *Span, client *Client) bool {
+	if quality := client.options.TransactionNameQuality; quality != nil {
+		return quality(transaction)
+	}
+	return HasGoodTransactionName(transaction)
+}
+
+// transactionSampleRate returns the sample rate of the first two sources
+// listed in DynamicSamplingContextFromTransaction that is set. The rate of the
+// parent is merged in by the caller.
+func transactionSampleRate(span *Span, client *Client) (float64, bool) {
+	switch span.sampleRateSource {
+	case sampleRateFromDecision, sampleRateFromClient:
+		return span.sampleRate, span.sampleRate != 0
+	}
+	// The decision was inherited, the rate it was made with is unknown.
+	if !client.options.EnableTracing {
+		return 0, false
 	}
+	sampleRate := client.runtimeOptions().TracesSampleRate
+	return sampleRate, sampleRate != 0
 }
 
 func (d DynamicSamplingContext) HasEntries() bool {
@@ -95,23 +229,106 @@ func (d DynamicSamplingContext) IsFrozen() bool {
 }
 
 func (d DynamicSamplingContext) String() string {
-	members := []baggage.Member{}
+	return d.baggageString(maxBaggageSize)
+}
+
+// baggageString serializes the DSC as a baggage header of at most limit bytes.
+// Entries that don't fit are dropped in reverse dscEntryPriority order, then
+// third-party members are added while there is room. The output only depends
+// on the contents of the DSC.
+func (d DynamicSamplingContext) baggageString(limit int) string {
+	members := make([]baggage.Member, 0, len(d.Entries))
 	for k, entry := range d.Entries {
+		k, entry, ok := normalizeDSCEntry(k, entry)
+		if !ok {
+			continue
+		}
 		member, err := baggage.NewMember(sentryPrefix+k, entry)
 		if err != nil {
+			Logger.Printf("Dropping dynamic sampling context entry %q: %v", k, err)
 			continue
 		}
 		members = append(members, member)
 	}
-	if len(members) > 0 {
-		baggage, err := baggage.New(members...)
-		if err != nil {
-			return ""
+	sort.Slice(members, func(i, j int) bool {
+		pi, pj := dscEntryRank(members[i].Key()), dscEntryRank(members[j].Key())
+		if pi != pj {
+			return pi < pj
+		}
+		return members[i].Key() < members[j].Key()
+	})
+
+	size, kept := 0, make([]baggage.Member, 0, len(members)+len(d.thirdParty))
+	add := func(member baggage.Member) bool {
+		n := len(member.String())
+		if size > 0 {
+			n++ // list delimiter
+		}
+		if size+n > limit || len(kept) == maxBaggageMembers {
+			return false
+		}
+		size += n
+		kept = append(kept, member)
+		return true
+	}
+	for _, member := range members {
+		if !add(member) {
+			Logger.Printf("Dropping dynamic sampling context entry %q: baggage header would exceed %d bytes",
+				strings.TrimPrefix(member.Key(), sentryPrefix), limit)
+		}
+	}
+	thirdParty := 0
+	for _, member := range d.thirdParty {
+		if add(member) {
+			thirdParty++
+		} else {
+			Logger.Printf("Dropping baggage member %q: baggage header would exceed its limits", member.Key())
 		}
-		return baggage.String()
 	}
 
-	return ""
+	// Write the entries sorted by key, so that the header is the same every
+	// time, followed by the third-party members in their original order.
+	entries := kept[:len(kept)-thirdParty]
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Key() < entries[j].Key()
+	})
+	var b strings.Builder
+	b.Grow(size)
+	for i, member := range kept {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(member.String())
+	}
+	return b.String()
+}
+
+// maxBaggageSize is the size limit of a baggage header in bytes set by the
+// W3C Baggage specification. Servers and proxies commonly reject requests
+// with larger headers.
+const maxBaggageSize = 8192
+
+// maxBaggageMembers is the limit on the number of members of a baggage header
+// enforced by the baggage package.
+const maxBaggageMembers = 180
+
+// dscEntryPriority lists DSC entries from the most to the least important
+// one. Entries that are not listed rank last. trace_id, sampled and
+// public_key are what Relay needs to apply the sampling decision of the trace
+// at all.
+var dscEntryPriority = []string{
+	"trace_id", "sampled", "public_key", "sample_rate", "sample_rand",
+	"org_id", "environment", "release", "transaction", "user_segment",
+}
+
+func dscEntryRank(key string) int {
+	key = strings.TrimPrefix(key, sentryPrefix)
+	for i, k := range dscEntryPriority {
+		if k == key {
+			return i
+		}
+	}
+	return len(dscEntryPriority)
 }
 
 // Constructs a new DynamicSamplingContext using a scope and client. Accessing
@@ -132,7 +349,7 @@ func DynamicSamplingContextFromScope(scope *Scope, client *Client) DynamicSampli
 	if traceID := propagationContext.TraceID.String(); traceID != "" {
 		entries["trace_id"] = traceID
 	}
-	if sampleRate := client.options.TracesSampleRate; sampleRate != 0 {
+	if sampleRate := client.runtimeOptions().TracesSampleRate; sampleRate != 0 {
 		entries["sample_rate"] = strconv.FormatFloat(sampleRate, 'f', -1, 64)
 	}
 
@@ -141,15 +358,100 @@ func DynamicSamplingContextFromScope(scope *Scope, client *Client) DynamicSampli
 			entries["public_key"] = publicKey
 		}
 	}
+	if orgID := dscOrgID(client); orgID != "" {
+		entries["org_id"] = orgID
+	}
 	if release := client.options.Release; release != "" {
 		entries["release"] = release
 	}
-	if environment := client.options.Environment; environment != "" {
+	if environment := client.runtimeOptions().Environment; environment != "" {
 		entries["environment"] = environment
 	}
+	addDSCTags(entries, &client.options, func(tag string) (string, bool) {
+		v, ok := scope.tags[tag]
+		return v, ok
+	})
 
 	return DynamicSamplingContext{
-		Entries: entries,
+		Entries: normalizeDSCEntries(entries),
 		Frozen:  true,
 	}
 }
+
+// addDSCTags adds the entries of options.DynamicSamplingContextTags for
+// the tags that lookup finds, keeping the entries already set.
+func addDSCTags(entries map[string]string, options *ClientOptions, lookup func(tag string) (string, bool)) {
+	for tag, key := range options.DynamicSamplingContextTags {
+		if _, ok := entries[key]; ok {
+			continue
+		}
+		if value, ok := lookup(tag); ok {
+			entries[key] = value
+		}
+	}
+}
+
+// dscOrgID returns ClientOptions.OrgID, or the organization ID encoded in the
+// DSN if that is not set.
+func dscOrgID(client *Client) string {
+	if orgID := client.options.OrgID; orgID != "" {
+		return orgID
+	}
+	if client.dsn != nil {
+		return client.dsn.GetOrgID()
+	}
+	return ""
+}
+
+// normalizeDSCEntries returns the entries that normalizeDSCEntry keeps, in
+// their normalized form.
+func normalizeDSCEntries(entries map[string]string) map[string]string {
+	normalized := make(map[string]string, len(entries))
+	for key, value := range entries {
+		if key, value, ok := normalizeDSCEntry(key, value); ok {
+			normalized[key] = value
+		}
+	}
+	return normalized
+}
+
+// normalizeDSCEntry trims the key and value of a DSC entry and brings the
+// values of sample_rate and sampled into their canonical form. It reports
+// false, logging the reason, for entries that are empty, have a key that is
+// invalid in a baggage header or a value out of range. Values need no
+// escaping here, they are percent-encoded when the baggage header is
+// written.
+func normalizeDSCEntry(key, value string) (string, string, bool) {
+	key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+	if key == "" || value == "" {
+		return "", "", false
+	}
+	if _, err := baggage.NewMember(sentryPrefix+key, value); err != nil {
+		Logger.Printf("Dropping dynamic sampling context entry %q: %v", key, err)
+		return "", "", false
+	}
+
+	switch key {
+	case "sample_rate":
+		rate, err := strconv.ParseFloat(value, 64)
+		if err != nil || !(rate >= 0 && rate <= 1) {
+			Logger.Printf("Dropping dynamic sampling context entry sample_rate=%q: not a rate between 0 and 1", value)
+			return "", "", false
+		}
+		value = strconv.FormatFloat(rate, 'f', -1, 64)
+	case "sample_rand":
+		// Kept as is, other SDKs may format it differently.
+		if r, err := strconv.ParseFloat(value, 64); err != nil || !(r >= 0 && r < 1) {
+			Logger.Printf("Dropping dynamic sampling context entry sample_rand=%q: not a number in [0, 1)", value)
+			return "", "", false
+		}
+	case "sampled":
+		sampled, err := strconv.ParseBool(value)
+		if err != nil {
+			Logger.Printf("Dropping dynamic sampling context entry sampled=%q: not a boolean", value)
+			return "", "", false
+		}
+		value = strconv.FormatBool(sampled)
+	}
+	return key, value, true
+}
@@ -2,6 +2,7 @@ package sentry
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/getsentry/sentry-go/internal/testutils"
@@ -25,8 +26,9 @@ func TestDynamicSamplingContextFromHeader(t *testing.T) {
 		{
 			input: []byte("other-vendor-key1=value1;value2, other-vendor-key2=value3"),
 			want: DynamicSamplingContext{
-				Frozen:  false,
-				Entries: map[string]string{},
+				Frozen:     false,
+				Entries:    map[string]string{},
+				thirdParty: baggageMembers([]byte("other-vendor-key1=value1;value2, other-vendor-key2=value3")),
 			},
 		},
 		// Sentry-only baggage
@@ -51,6 +53,19 @@ func TestDynamicSamplingContextFromHeader(t *testing.T) {
 					"public_key":  "public",
 					"sample_rate": "1",
 				},
+				thirdParty: baggageMembers([]byte("foo=bar;foo;bar;bar=baz")),
+			},
+		},
+		// Invalid entries are dropped, others normalized
+		{
+			input: []byte("sentry-trace_id=d49d9bf66f13450b81f65bc51cf49c03,sentry-sample_rate=1.5,sentry-sampled=TRUE,sentry-release=%201.0%20"),
+			want: DynamicSamplingContext{
+				Frozen: true,
+				Entries: map[string]string{
+					"trace_id": "d49d9bf66f13450b81f65bc51cf49c03",
+					"sampled":  "true",
+					"release":  "1.0",
+				},
 			},
 		},
 		// Invalid baggage value
@@ -72,6 +87,70 @@ func TestDynamicSamplingContextFromHeader(t *testing.T) {
 	}
 }
 
+func TestDynamicSamplingContextFromHeaderLenient(t *testing.T) {
+	header := []byte("sentry-trace_id=d49d9bf66f13450b81f65bc51cf49c03,invalid_baggage,, other=1,sentry-release=1.0=%zz")
+
+	if _, err := DynamicSamplingContextFromHeader(header); err == nil {
+		t.Error("DynamicSamplingContextFromHeader: expected an error")
+	}
+
+	got := DynamicSamplingContextFromHeaderLenient(header)
+	assertEqual(t, got, DynamicSamplingContext{
+		Frozen:     true,
+		Entries:    map[string]string{"trace_id": "d49d9bf66f13450b81f65bc51cf49c03"},
+		thirdParty: baggageMembers([]byte("other=1")),
+	})
+}
+
+func TestContinueFromHeadersLenientBaggage(t *testing.T) {
+	const trace = "d49d9bf66f13450b81f65bc51cf49c03-a9f442f9330b4e09-1"
+	const baggage = "sentry-release=1.0.0,invalid_baggage"
+	for _, lenient := range []bool{false, true} {
+		ctx := NewTestContext(ClientOptions{EnableTracing: true, LenientBaggageParsing: lenient})
+		hub := GetHubFromContext(ctx)
+
+		_, err := hub.ContinueTrace(trace, baggage)
+		if got := err != nil; got == lenient {
+			t.Errorf("lenient=%t: ContinueTrace error = %v", lenient, err)
+		}
+
+		txn := StartTransaction(ctx, "name", ContinueFromHeaders(trace, baggage))
+		if got := txn.dynamicSamplingContext.Entries["release"] == "1.0.0"; got != lenient {
+			t.Errorf("lenient=%t: DSC = %v", lenient, txn.dynamicSamplingContext.Entries)
+		}
+
+		hub.Scope().SetPropagationContext(NewPropagationContext())
+		ContinueTraceFromCarrier(ctx, MapCarrier{SentryTraceHeader: trace, SentryBaggageHeader: baggage})
+		if got := hub.Scope().PropagationContext().TraceID.String() == trace[:32]; got != lenient {
+			t.Errorf("lenient=%t: ContinueTraceFromCarrier propagation context = %+v", lenient, hub.Scope().PropagationContext())
+		}
+	}
+}
+
+func TestThirdPartyBaggageIsPropagated(t *testing.T) {
+	ctx := NewTestContext(ClientOptions{EnableTracing: true, TracesSampleRate: 1})
+	const trace = "d49d9bf66f13450b81f65bc51cf49c03-a9f442f9330b4e09-1"
+
+	txn := StartTransaction(ctx, "name", ContinueFromHeaders(trace, "sentry-sample_rand=0.5,other=1;prop,more=%20x"))
+	testutils.AssertBaggageStringsEqual(t, txn.StartChild("op").ToBaggage(), "sentry-sample_rand=0.5,other=1;prop,more=%20x")
+
+	// Without Sentry members, the transaction's own DSC is sent with them.
+	txn = StartTransaction(ctx, "name", ContinueFromHeaders("", "other=1"))
+	dsc, err := DynamicSamplingContextFromHeader([]byte(txn.ToBaggage()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, dsc.Entries["trace_id"], txn.TraceID.String())
+	assertEqual(t, dsc.thirdParty, baggageMembers([]byte("other=1")))
+
+	// Third-party members yield to Sentry entries when space is short.
+	dsc = DynamicSamplingContext{
+		Entries:    map[string]string{"trace_id": "d49d9bf66f13450b81f65bc51cf49c03"},
+		thirdParty: baggageMembers([]byte("other=1")),
+	}
+	assertEqual(t, dsc.baggageString(len("sentry-trace_id=d49d9bf66f13450b81f65bc51cf49c03")), "sentry-trace_id=d49d9bf66f13450b81f65bc51cf49c03")
+}
+
 func TestDynamicSamplingContextFromTransaction(t *testing.T) {
 	tests := []struct {
 		input *Span
@@ -92,6 +171,7 @@ func TestDynamicSamplingContextFromTransaction(t *testing.T) {
 				})
 				txn := StartTransaction(ctx, "name", WithTransactionSource(SourceCustom))
 				txn.TraceID = TraceIDFromHex("d49d9bf66f13450b81f65bc51cf49c03")
+				txn.sampleRand = 0.123456
 				return txn
 			}(),
 			want: DynamicSamplingContext{
@@ -103,6 +183,7 @@ func TestDynamicSamplingContextFromTransaction(t *testing.T) {
 					"release":     "1.0.0",
 					"environment": "test",
 					"transaction": "name",
+					"sample_rand": "0.123456",
 					"sampled":     "true",
 				},
 			},
@@ -118,15 +199,17 @@ func TestDynamicSamplingContextFromTransaction(t *testing.T) {
 				})
 				txn := StartTransaction(ctx, "name", WithTransactionSource(SourceURL))
 				txn.TraceID = TraceIDFromHex("d49d9bf66f13450b81f65bc51cf49c03")
+				txn.sampleRand = 0.123456
 				return txn
 			}(),
 			want: DynamicSamplingContext{
 				Frozen: true,
 				Entries: map[string]string{
-					"trace_id":   "d49d9bf66f13450b81f65bc51cf49c03",
-					"public_key": "public",
-					"release":    "1.0.0",
-					"sampled":    "false",
+					"trace_id":    "d49d9bf66f13450b81f65bc51cf49c03",
+					"public_key":  "public",
+					"release":     "1.0.0",
+					"sample_rand": "0.123456",
+					"sampled":     "false",
 				},
 			},
 		},
@@ -150,6 +233,168 @@ func TestDynamicSamplingContextFromTransaction(t *testing.T) {
 	}
 }
 
+func TestDynamicSamplingContextFromTransactionSampleRate(t *testing.T) {
+	const trace = "d49d9bf66f13450b81f65bc51cf49c03-a9f442f9330b4e09-1"
+	parent := DynamicSamplingContext{
+		Entries: map[string]string{"sample_rate": "0.25", "release": "parent"},
+	}
+	tests := map[string]struct {
+		options ClientOptions
+		span    []SpanOption
+		parent  bool
+		want    map[string]string
+	}{
+		"sampler": {
+			options: ClientOptions{
+				TracesSampleRate: 1.0,
+				TracesSampler:    TracesSampler(func(SamplingContext) float64 { return 0.3 }),
+			},
+			want: map[string]string{"sample_rate": "0.3", "release": "1.0.0"},
+		},
+		"explicit decision": {
+			options: ClientOptions{TracesSampleRate: 0.1},
+			span:    []SpanOption{WithSpanSampled(SampledTrue)},
+			want:    map[string]string{"sample_rate": "1", "release": "1.0.0"},
+		},
+		"inherited decision uses the client rate": {
+			options: ClientOptions{TracesSampleRate: 0.5},
+			span:    []SpanOption{ContinueFromTrace(trace)},
+			parent:  true,
+			want:    map[string]string{"sample_rate": "0.5", "release": "1.0.0"},
+		},
+		"inherited decision falls back to the parent": {
+			span:   []SpanOption{ContinueFromTrace(trace)},
+			parent: true,
+			want:   map[string]string{"sample_rate": "0.25", "release": "1.0.0"},
+		},
+		"prefer parent": {
+			options: ClientOptions{TracesSampleRate: 0.5, PreferParentSamplingContext: true},
+			span:    []SpanOption{ContinueFromTrace(trace)},
+			parent:  true,
+			want:    map[string]string{"sample_rate": "0.25", "release": "parent"},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			tt.options.EnableTracing = true
+			tt.options.Release = "1.0.0"
+			ctx := NewTestContext(tt.options)
+			txn := StartTransaction(ctx, "name", tt.span...)
+			if tt.parent {
+				txn.SetDynamicSamplingContext(parent)
+			}
+			got := DynamicSamplingContextFromTransaction(txn).Entries
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("%s = %q, want %q", k, got[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestDynamicSamplingContextFromTransactionContinuingHeaders(t *testing.T) {
+	const (
+		trace   = "d49d9bf66f13450b81f65bc51cf49c03-a9f442f9330b4e09-1"
+		baggage = "sentry-sample_rate=0.25,sentry-sample_rand=0.5,sentry-release=parent,sentry-environment=upstream"
+	)
+	tests := map[string]struct {
+		options ClientOptions
+		want    map[string]string
+	}{
+		"own entries first": {
+			options: ClientOptions{TracesSampleRate: 0.5},
+			want:    map[string]string{"sample_rate": "0.5", "release": "1.0.0", "environment": "upstream"},
+		},
+		"falls back to the parent": {
+			want: map[string]string{"sample_rate": "0.25", "release": "1.0.0", "environment": "upstream"},
+		},
+		"prefer parent": {
+			options: ClientOptions{TracesSampleRate: 0.5, PreferParentSamplingContext: true},
+			want:    map[string]string{"sample_rate": "0.25", "release": "parent", "environment": "upstream"},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			tt.options.EnableTracing = true
+			tt.options.Release = "1.0.0"
+			ctx := NewTestContext(tt.options)
+			txn := StartTransaction(ctx, "name", ContinueFromHeaders(trace, baggage))
+			got := DynamicSamplingContextFromTransaction(txn).Entries
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("%s = %q, want %q", k, got[k], v)
+				}
+			}
+			// The incoming context is still propagated unchanged.
+			testutils.AssertBaggageStringsEqual(t, txn.ToBaggage(), baggage)
+		})
+	}
+}
+
+func TestDynamicSamplingContextOrgID(t *testing.T) {
+	tests := map[string]struct {
+		options ClientOptions
+		want    string
+	}{
+		"from DSN":    {ClientOptions{Dsn: "https://public@o123.ingest.sentry.io/1"}, "123"},
+		"from option": {ClientOptions{Dsn: "https://public@o123.ingest.sentry.io/1", OrgID: "456"}, "456"},
+		"none":        {ClientOptions{Dsn: "https://public@sentry.example.com/1"}, ""},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			tt.options.EnableTracing = true
+			ctx := NewTestContext(tt.options)
+			hub := GetHubFromContext(ctx)
+
+			txn := StartTransaction(ctx, "name")
+			if got := DynamicSamplingContextFromTransaction(txn).Entries["org_id"]; got != tt.want {
+				t.Errorf("transaction org_id = %q, want %q", got, tt.want)
+			}
+			if got := DynamicSamplingContextFromScope(hub.Scope(), hub.Client()).Entries["org_id"]; got != tt.want {
+				t.Errorf("scope org_id = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// An incoming org_id is propagated.
+	ctx := NewTestContext(ClientOptions{EnableTracing: true, OrgID: "456"})
+	txn := StartTransaction(ctx, "name", ContinueFromHeaders(
+		"d49d9bf66f13450b81f65bc51cf49c03-a9f442f9330b4e09-1", "sentry-org_id=123,sentry-sample_rand=0.5"))
+	testutils.AssertBaggageStringsEqual(t, txn.ToBaggage(), "sentry-org_id=123,sentry-sample_rand=0.5")
+}
+
+func TestDynamicSamplingContextTags(t *testing.T) {
+	ctx := NewTestContext(ClientOptions{
+		Dsn:           "https://public@sentry.example.com/1",
+		EnableTracing: true,
+		Release:       "1.0",
+		DynamicSamplingContextTags: map[string]string{
+			"plan":    "plan_tier",
+			"segment": "user_segment",
+			"release": "release",
+		},
+	})
+	hub := GetHubFromContext(ctx)
+	hub.Scope().SetTag("plan", "enterprise")
+	hub.Scope().SetTag("segment", "vip")
+	hub.Scope().SetTag("release", "fake")
+
+	entries := DynamicSamplingContextFromScope(hub.Scope(), hub.Client()).Entries
+	assertEqual(t, entries["plan_tier"], "enterprise")
+	assertEqual(t, entries["user_segment"], "vip")
+	// Tags don't replace entries set by the SDK.
+	assertEqual(t, entries["release"], "1.0")
+
+	txn := StartTransaction(ctx, "name")
+	txn.SetTag("segment", "trial")
+	// Tags set on the scope after the transaction started don't apply.
+	hub.Scope().SetTag("plan", "free")
+	entries = txn.frozenDynamicSamplingContext().Entries
+	assertEqual(t, entries["plan_tier"], "enterprise")
+	assertEqual(t, entries["user_segment"], "trial")
+}
+
 func TestHasEntries(t *testing.T) {
 	var dsc DynamicSamplingContext
 
@@ -181,6 +426,77 @@ func TestString(t *testing.T) {
 	testutils.AssertBaggageStringsEqual(t, dsc.String(), "sentry-trace_id=d49d9bf66f13450b81f65bc51cf49c03,sentry-public_key=public,sentry-sample_rate=1")
 }
 
+func TestStringIsSorted(t *testing.T) {
+	dsc, err := DynamicSamplingContextFromHeader([]byte(
+		"zz=1,sentry-trace_id=d49d9bf66f13450b81f65bc51cf49c03,sentry-release=1.0,aa=2,sentry-environment=prod,sentry-sample_rate=1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = "sentry-environment=prod,sentry-release=1.0,sentry-sample_rate=1,sentry-trace_id=d49d9bf66f13450b81f65bc51cf49c03,zz=1,aa=2"
+	for i := 0; i < 10; i++ {
+		if got := dsc.String(); got != want {
+			t.Fatalf("String() = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestStringNormalizesEntries(t *testing.T) {
+	dsc := DynamicSamplingContext{
+		Frozen: true,
+		Entries: map[string]string{
+			"trace_id":    "d49d9bf66f13450b81f65bc51cf49c03",
+			"release":     " my app,1.0 ",
+			"sample_rate": "0.50",
+			"bad key":     "dropped",
+			"empty":       " ",
+		},
+	}
+	testutils.AssertBaggageStringsEqual(t, dsc.String(),
+		"sentry-trace_id=d49d9bf66f13450b81f65bc51cf49c03,sentry-release=my%20app%2C1.0,sentry-sample_rate=0.5")
+}
+
+func TestStringLimitsSize(t *testing.T) {
+	dsc := DynamicSamplingContext{
+		Frozen: true,
+		Entries: map[string]string{
+			"trace_id":    "d49d9bf66f13450b81f65bc51cf49c03",
+			"sampled":     "true",
+			"public_key":  "public",
+			"release":     "1.0",
+			"transaction": strings.Repeat("a", maxBaggageSize),
+		},
+	}
+	testutils.AssertBaggageStringsEqual(t, dsc.String(),
+		"sentry-trace_id=d49d9bf66f13450b81f65bc51cf49c03,sentry-sampled=true,sentry-public_key=public,sentry-release=1.0")
+
+	// Entries are dropped from the least important one.
+	const traceAndSampled = "sentry-trace_id=d49d9bf66f13450b81f65bc51cf49c03,sentry-sampled=true"
+	testutils.AssertBaggageStringsEqual(t, dsc.baggageString(len(traceAndSampled)), traceAndSampled)
+}
+
+func TestNormalizeDSCEntry(t *testing.T) {
+	tests := []struct {
+		key, value string
+		wantValue  string
+		wantOK     bool
+	}{
+		{"release", " 1.0 ", "1.0", true},
+		{"sample_rate", "1e-1", "0.1", true},
+		{"sample_rate", "-0.1", "", false},
+		{"sample_rate", "NaN", "", false},
+		{"sampled", "1", "true", true},
+		{"sampled", "yes", "", false},
+		{"transaction", "", "", false},
+		{"key=", "value", "", false},
+	}
+	for _, tt := range tests {
+		_, value, ok := normalizeDSCEntry(tt.key, tt.value)
+		if value != tt.wantValue || ok != tt.wantOK {
+			t.Errorf("normalizeDSCEntry(%q, %q) = %q, %t, want %q, %t", tt.key, tt.value, value, ok, tt.wantValue, tt.wantOK)
+		}
+	}
+}
+
 func TestDynamicSamplingContextFromScope(t *testing.T) {
 	tests := map[string]struct {
 		scope    *Scope
@@ -245,3 +561,27 @@ func TestDynamicSamplingContextFromScope(t *testing.T) {
 		})
 	}
 }
+
+func TestDynamicSamplingContextTransactionNameQuality(t *testing.T) {
+	ctx := NewTestContext(ClientOptions{EnableTracing: true})
+	txn := StartTransaction(ctx, "GET /users/42", WithTransactionSource(SourceURL))
+	_, ok := DynamicSamplingContextFromTransaction(txn).Entries["transaction"]
+	assertEqual(t, ok, false)
+	txn = StartTransaction(ctx, "GET /users/{id}", WithTransactionSource(SourceRoute))
+	assertEqual(t, DynamicSamplingContextFromTransaction(txn).Entries["transaction"], "GET /users/{id}")
+
+	ctx = NewTestContext(ClientOptions{
+		EnableTracing: true,
+		TransactionNameQuality: func(transaction *Span) bool {
+			if transaction.Op == "health" {
+				return false
+			}
+			return transaction.Source == SourceURL || HasGoodTransactionName(transaction)
+		},
+	})
+	txn = StartTransaction(ctx, "GET /users/{id}", WithTransactionSource(SourceURL))
+	assertEqual(t, DynamicSamplingContextFromTransaction(txn).Entries["transaction"], "GET /users/{id}")
+	txn = StartTransaction(ctx, "GET /healthz", WithTransactionSource(SourceRoute), WithOpName("health"))
+	_, ok = DynamicSamplingContextFromTransaction(txn).Entries["transaction"]
+	assertEqual(t, ok, false)
+}
@@ -97,7 +97,7 @@ func (h *handler) handle(next echo.HandlerFunc) echo.HandlerFunc {
 			transaction.Finish()
 		}()
 
-		hub.Scope().SetRequest(r)
+		hub.SetRequest(r)
 		ctx.Set(valuesKey, hub)
 		ctx.Set(transactionKey, transaction)
 		defer h.recoverWithSentry(hub, r)
@@ -0,0 +1,69 @@
+package sentry
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+)
+
+// Operation of the spans recorded by CaptureExceptionWithSpan.
+const errorSpanOp = "error"
+
+// SpanStatusFromError returns the status of a span that failed with err:
+// SpanStatusOK if err is nil, the status returned by a SpanStatus method of
+// err or of an error it wraps, the matching status for context and file
+// system errors, and SpanStatusInternalError otherwise.
+func SpanStatusFromError(err error) SpanStatus {
+	if err == nil {
+		return SpanStatusOK
+	}
+	var withStatus interface{ SpanStatus() SpanStatus }
+	if errors.As(err, &withStatus) {
+		return withStatus.SpanStatus()
+	}
+	switch {
+	case errors.Is(err, context.Canceled):
+		return SpanStatusCanceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return SpanStatusDeadlineExceeded
+	case errors.Is(err, os.ErrNotExist):
+		return SpanStatusNotFound
+	case errors.Is(err, os.ErrExist):
+		return SpanStatusAlreadyExists
+	case errors.Is(err, os.ErrPermission):
+		return SpanStatusPermissionDenied
+	}
+	return SpanStatusInternalError
+}
+
+// CaptureExceptionWithSpan is like CaptureExceptionWithContext, but first
+// records a short "error" span at the capture point, as a child of the span
+// in ctx. The span is described by the type of exception, has the status
+// returned by SpanStatusFromError and the ID of the event as its "event_id"
+// data, and the event belongs to it, so that the issue links to the exact
+// place of the failure in the waterfall of the transaction. Without a span in
+// ctx, no span is recorded.
+func (hub *Hub) CaptureExceptionWithSpan(ctx context.Context, exception error) *EventID {
+	if SpanFromContext(ctx) == nil {
+		return hub.CaptureExceptionWithContext(ctx, exception)
+	}
+
+	description := "<nil>"
+	if exception != nil {
+		description = reflect.TypeOf(exception).String()
+	}
+	span := StartSpan(ctx, errorSpanOp, WithDescription(description))
+	defer span.Finish()
+	span.Status = SpanStatusFromError(exception)
+
+	var eventID *EventID
+	hub.WithScope(func(scope *Scope) {
+		scope.SetSpan(span)
+		eventID = hub.CaptureExceptionWithContext(span.Context(), exception)
+	})
+	if eventID != nil {
+		span.SetData("event_id", string(*eventID))
+	}
+	return eventID
+}
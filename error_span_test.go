@@ -0,0 +1,73 @@
+package sentry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"testing"
+)
+
+type statusError struct{}
+
+func (statusError) Error() string          { return "quota" }
+func (statusError) SpanStatus() SpanStatus { return SpanStatusResourceExhausted }
+
+func TestSpanStatusFromError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want SpanStatus
+	}{
+		{nil, SpanStatusOK},
+		{errors.New("failure"), SpanStatusInternalError},
+		{fmt.Errorf("query: %w", context.Canceled), SpanStatusCanceled},
+		{context.DeadlineExceeded, SpanStatusDeadlineExceeded},
+		{&fs.PathError{Op: "open", Path: "x", Err: fs.ErrNotExist}, SpanStatusNotFound},
+		{fs.ErrExist, SpanStatusAlreadyExists},
+		{fs.ErrPermission, SpanStatusPermissionDenied},
+		{fmt.Errorf("charge: %w", statusError{}), SpanStatusResourceExhausted},
+	}
+	for _, tt := range tests {
+		assertEqual(t, SpanStatusFromError(tt.err), tt.want)
+	}
+}
+
+func TestCaptureExceptionWithSpan(t *testing.T) {
+	ctx := NewTestContext(ClientOptions{EnableTracing: true, TracesSampleRate: 1})
+	hub := GetHubFromContext(ctx)
+	transaction := StartTransaction(ctx, "checkout")
+	eventID := CaptureExceptionWithSpan(transaction.Context(), fmt.Errorf("charge: %w", context.DeadlineExceeded))
+	transaction.Finish()
+
+	if eventID == nil {
+		t.Fatal("event not captured")
+	}
+	assertEqual(t, hub.LastEventID(), *eventID)
+	events := hub.Client().Transport.(*TransportMock).Events()
+	assertEqual(t, len(events), 2)
+	errorEvent, transactionEvent := events[0], events[1]
+	assertEqual(t, len(transactionEvent.Spans), 1)
+	span := transactionEvent.Spans[0]
+	assertEqual(t, span.Op, "error")
+	assertEqual(t, span.Description, "*fmt.wrapError")
+	assertEqual(t, span.Status, SpanStatusDeadlineExceeded)
+	assertEqual(t, span.ParentSpanID, transaction.SpanID)
+	assertEqual(t, span.Data["event_id"], string(*eventID))
+	assertEqual(t, errorEvent.Contexts["trace"]["span_id"], span.SpanID)
+	assertEqual(t, errorEvent.Contexts["trace"]["trace_id"], transaction.TraceID)
+
+	// The span of the error is not left on the scope.
+	hub.CaptureException(errors.New("later"))
+	_, onScope := hub.Client().Transport.(*TransportMock).lastEvent.Contexts["trace"]["parent_span_id"]
+	assertEqual(t, onScope, false)
+}
+
+func TestCaptureExceptionWithSpanWithoutTransaction(t *testing.T) {
+	ctx := NewTestContext(ClientOptions{EnableTracing: true, TracesSampleRate: 1})
+	eventID := CaptureExceptionWithSpan(ctx, errors.New("failure"))
+	if eventID == nil {
+		t.Fatal("event not captured")
+	}
+	events := hubFromContext(ctx).Client().Transport.(*TransportMock).Events()
+	assertEqual(t, len(events), 1)
+}
@@ -0,0 +1,254 @@
+package sentry
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// errorStormWindow is the window ClientOptions.ErrorStormThreshold counts the
+// events of an issue in.
+const errorStormWindow = time.Minute
+
+// defaultErrorStormInterval is the interval of the aggregates of an error
+// storm unless ClientOptions.ErrorStormInterval is set.
+const defaultErrorStormInterval = time.Minute
+
+// maxStormIssues is the number of issues tracked by errorStorms above which
+// the ones without a storm that were not seen in the current window are
+// forgotten.
+const maxStormIssues = 1000
+
+// errorStorms detects the issues whose events exceed
+// ClientOptions.ErrorStormThreshold and aggregates their events while they
+// do.
+type errorStorms struct {
+	threshold int
+	interval  time.Duration
+	// now and send, if set, let a timer send the aggregates that are due
+	// while storms are active, also when no more events arrive.
+	now  func() time.Time
+	send func(aggregates []*Event)
+
+	mu     sync.Mutex
+	issues map[string]*stormIssue
+	// active is the number of issues in a storm.
+	active int
+	// timer sends the aggregates that are due while storms are active.
+	timer *time.Timer
+}
+
+// stormIssue counts the events of an issue.
+type stormIssue struct {
+	// windowStart is the beginning of the current window, count the number
+	// of events within it.
+	windowStart time.Time
+	count       int
+
+	// storm is set while the events of the issue are aggregated.
+	storm bool
+	// nextAggregate is when the next aggregate is due.
+	nextAggregate time.Time
+	// The events suppressed since the last aggregate.
+	suppressed  int
+	first, last time.Time
+	sample      *Event
+}
+
+func newErrorStorms(threshold int, interval time.Duration) *errorStorms {
+	if interval <= 0 {
+		interval = defaultErrorStormInterval
+	}
+	return &errorStorms{
+		threshold: threshold,
+		interval:  interval,
+		issues:    make(map[string]*stormIssue),
+	}
+}
+
+// take reports whether event may be sent at now, and counts it either way.
+// It returns the aggregates of all issues that are due, if any.
+func (s *errorStorms) take(event *Event, now time.Time) (bool, []*Event) {
+	key := stormKey(event)
+	if key == "" {
+		return true, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var aggregates []*Event
+	issue, ok := s.issues[key]
+	if !ok {
+		if len(s.issues) >= maxStormIssues {
+			s.pruneLocked(now)
+		}
+		issue = &stormIssue{windowStart: now}
+		s.issues[key] = issue
+	}
+	if now.Sub(issue.windowStart) >= errorStormWindow {
+		if issue.storm && s.subsided(issue, now) {
+			// The storm subsided, the last aggregate covers its tail.
+			aggregates = appendAggregate(aggregates, key, issue, s.threshold)
+			s.endStormLocked(issue)
+		}
+		issue.windowStart = now
+		issue.count = 0
+	}
+	issue.count++
+
+	if !issue.storm && issue.count > s.threshold {
+		issue.storm = true
+		issue.nextAggregate = now.Add(s.interval)
+		s.active++
+	}
+	if issue.storm {
+		if issue.suppressed == 0 {
+			issue.first = now
+			issue.sample = event
+		}
+		issue.suppressed++
+		issue.last = now
+	}
+	aggregates = append(aggregates, s.dueLocked(now)...)
+	s.armLocked()
+	return !issue.storm, aggregates
+}
+
+// subsided reports whether the storm of issue is over at now, the end of its
+// window or later: the window had no more events than the threshold, or no
+// event arrived in the following one.
+func (s *errorStorms) subsided(issue *stormIssue, now time.Time) bool {
+	return issue.count <= s.threshold || now.Sub(issue.windowStart) >= 2*errorStormWindow
+}
+
+func (s *errorStorms) endStormLocked(issue *stormIssue) {
+	issue.storm = false
+	s.active--
+}
+
+// dueLocked returns the aggregates of all storms that are due at now, and
+// the last aggregates of the storms that subsided.
+func (s *errorStorms) dueLocked(now time.Time) []*Event {
+	if s.active == 0 {
+		return nil
+	}
+	var aggregates []*Event
+	for key, issue := range s.issues {
+		if !issue.storm {
+			continue
+		}
+		if now.Sub(issue.windowStart) >= errorStormWindow && s.subsided(issue, now) {
+			aggregates = appendAggregate(aggregates, key, issue, s.threshold)
+			s.endStormLocked(issue)
+			continue
+		}
+		if issue.suppressed > 0 && !now.Before(issue.nextAggregate) {
+			aggregates = appendAggregate(aggregates, key, issue, s.threshold)
+			issue.nextAggregate = now.Add(s.interval)
+		}
+	}
+	return aggregates
+}
+
+// armLocked starts the timer if storms are active and it is not running.
+func (s *errorStorms) armLocked() {
+	if s.active > 0 && s.timer == nil && s.send != nil {
+		s.timer = time.AfterFunc(s.interval, s.tick)
+	}
+}
+
+// stop stops the timer for good. Aggregates are then only sent with events
+// and by drain.
+func (s *errorStorms) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	s.send = nil
+}
+
+// tick sends the aggregates that are due, and keeps the timer running while
+// storms are active.
+func (s *errorStorms) tick() {
+	s.mu.Lock()
+	send := s.send
+	if send == nil {
+		s.mu.Unlock()
+		return
+	}
+	s.timer = nil
+	aggregates := s.dueLocked(s.now())
+	s.armLocked()
+	s.mu.Unlock()
+	send(aggregates)
+}
+
+// drain returns the aggregates of the events suppressed so far, and resets
+// their count. The storms go on.
+func (s *errorStorms) drain() []*Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var aggregates []*Event
+	for key, issue := range s.issues {
+		aggregates = appendAggregate(aggregates, key, issue, s.threshold)
+	}
+	return aggregates
+}
+
+func (s *errorStorms) pruneLocked(now time.Time) {
+	for key, issue := range s.issues {
+		if !issue.storm && now.Sub(issue.windowStart) >= errorStormWindow {
+			delete(s.issues, key)
+		}
+	}
+}
+
+// appendAggregate appends the aggregate of the events of issue suppressed
+// since the last one to aggregates, if there are any.
+func appendAggregate(aggregates []*Event, key string, issue *stormIssue, threshold int) []*Event {
+	if issue.suppressed == 0 {
+		return aggregates
+	}
+	sample := issue.sample
+	event := NewEvent()
+	event.Level = sample.Level
+	if event.Level == "" {
+		event.Level = LevelError
+	}
+	event.Message = fmt.Sprintf("Error storm: %d occurrences of %s", issue.suppressed, key)
+	event.Exception = sample.Exception
+	event.Fingerprint = sample.Fingerprint
+	event.Contexts["error_storm"] = Context{
+		"count":      issue.suppressed,
+		"first_seen": issue.first,
+		"last_seen":  issue.last,
+		"threshold":  threshold,
+	}
+	event.sdkMetaData.stormAggregate = true
+	issue.suppressed = 0
+	issue.sample = nil
+	return append(aggregates, event)
+}
+
+// stormKey returns the issue event is counted towards: its fingerprint, or
+// else the type of its exception and the frame it was raised in, or else its
+// message. Events without any are not counted.
+func stormKey(event *Event) string {
+	if len(event.Fingerprint) > 0 {
+		return strings.Join(event.Fingerprint, " ")
+	}
+	if n := len(event.Exception); n > 0 {
+		exception := event.Exception[n-1]
+		key := exception.Type
+		if exception.Stacktrace != nil && len(exception.Stacktrace.Frames) > 0 {
+			frame := exception.Stacktrace.Frames[len(exception.Stacktrace.Frames)-1]
+			key += fmt.Sprintf(" at %s.%s:%d", frame.Module, frame.Function, frame.Lineno)
+		}
+		return key
+	}
+	return event.Message
+}
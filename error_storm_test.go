@@ -0,0 +1,130 @@
+package sentry
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestErrorStormsTake(t *testing.T) {
+	storms := newErrorStorms(2, 30*time.Second)
+	now := time.Now()
+	event := &Event{Message: "boom"}
+
+	for i, want := range []bool{true, true, false, false} {
+		ok, aggregates := storms.take(event, now)
+		assertEqual(t, ok, want)
+		assertEqual(t, len(aggregates), 0, i)
+	}
+	if ok, _ := storms.take(&Event{Message: "other"}, now); !ok {
+		t.Error("other issues must not be suppressed")
+	}
+
+	// The first aggregate is due after the interval.
+	ok, aggregates := storms.take(event, now.Add(30*time.Second))
+	assertEqual(t, ok, false)
+	assertEqual(t, len(aggregates), 1)
+	assertEqual(t, aggregates[0].Contexts["error_storm"]["count"], 3)
+	assertEqual(t, aggregates[0].Contexts["error_storm"]["first_seen"], now)
+
+	// The storm goes on in the next minute, with fewer events.
+	next := now.Add(time.Minute)
+	ok, aggregates = storms.take(event, next)
+	assertEqual(t, ok, false)
+	assertEqual(t, len(aggregates), 1)
+	ok, aggregates = storms.take(event, next.Add(10*time.Second))
+	assertEqual(t, ok, false)
+	assertEqual(t, len(aggregates), 0)
+
+	// The minute had no more than the threshold, which ends the storm. The
+	// last aggregate covers its tail.
+	ok, aggregates = storms.take(event, next.Add(time.Minute))
+	assertEqual(t, ok, true)
+	assertEqual(t, len(aggregates), 1)
+	assertEqual(t, aggregates[0].Contexts["error_storm"]["count"], 1)
+	assertEqual(t, aggregates[0].Contexts["error_storm"]["last_seen"], next.Add(10*time.Second))
+	assertEqual(t, len(storms.drain()), 0)
+}
+
+func TestStormKey(t *testing.T) {
+	assertEqual(t, stormKey(&Event{Fingerprint: []string{"a", "b"}, Message: "m"}), "a b")
+	assertEqual(t, stormKey(&Event{Message: "m"}), "m")
+	assertEqual(t, stormKey(&Event{Exception: []Exception{
+		{Type: "*errors.errorString", Stacktrace: &Stacktrace{Frames: []Frame{{Module: "main", Function: "run", Lineno: 5}}}},
+	}}), "*errors.errorString at main.run:5")
+	assertEqual(t, stormKey(&Event{}), "")
+}
+
+func TestClientErrorStorm(t *testing.T) {
+	transport := &TransportMock{}
+	var dropped []DropReason
+	client, err := NewClient(ClientOptions{
+		Transport:           transport,
+		ErrorStormThreshold: 2,
+		OnEventDropped: func(_ *Event, reason DropReason, _ string) {
+			dropped = append(dropped, reason)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	scope := NewScope()
+	for i := 0; i < 5; i++ {
+		client.CaptureException(errors.New("loop"), nil, scope)
+	}
+	assertEqual(t, len(transport.Events()), 2)
+	assertEqual(t, dropped, []DropReason{DropReasonErrorStorm, DropReasonErrorStorm, DropReasonErrorStorm})
+
+	client.Flush(time.Second)
+	assertEqual(t, len(transport.Events()), 3)
+	aggregate := transport.lastEvent
+	assertEqual(t, aggregate.Level, LevelError)
+	assertEqual(t, aggregate.Contexts["error_storm"]["count"], 3)
+	if len(aggregate.Exception) != 1 || aggregate.Exception[0].Value != "loop" || aggregate.Exception[0].Stacktrace == nil {
+		t.Errorf("aggregate exception = %+v", aggregate.Exception)
+	}
+}
+
+func TestErrorStormsDueAcrossIssues(t *testing.T) {
+	storms := newErrorStorms(1, 30*time.Second)
+	now := time.Now()
+	storm := &Event{Message: "boom"}
+	storms.take(storm, now)
+	storms.take(storm, now)
+
+	// An event of another issue delivers the aggregate that is due.
+	ok, aggregates := storms.take(&Event{Message: "other"}, now.Add(30*time.Second))
+	assertEqual(t, ok, true)
+	assertEqual(t, len(aggregates), 1)
+	assertEqual(t, aggregates[0].Message, "Error storm: 1 occurrences of boom")
+}
+
+func TestClientErrorStormTimer(t *testing.T) {
+	transport := &TransportMock{}
+	client, err := NewClient(ClientOptions{
+		Transport:           transport,
+		ErrorStormThreshold: 1,
+		ErrorStormInterval:  10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	scope := NewScope()
+	// The fingerprint of the scope makes both messages one issue.
+	scope.SetFingerprint([]string{"loop"})
+	client.CaptureMessage("first", nil, scope)
+	client.CaptureMessage("second", nil, scope)
+	assertEqual(t, len(transport.Events()), 1)
+
+	// The aggregate is sent without another event of the storm.
+	deadline := time.Now().Add(5 * time.Second)
+	for len(transport.Events()) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("aggregate was not sent")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	aggregate := transport.Events()[1]
+	assertEqual(t, aggregate.Contexts["error_storm"]["count"], 1)
+	assertEqual(t, aggregate.Fingerprint, []string{"loop"})
+}
@@ -0,0 +1,118 @@
+package sentry
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultEventBudgetPeriod is the period of ClientOptions.EventBudgets unless
+// EventBudgetPeriod is set.
+const defaultEventBudgetPeriod = time.Hour
+
+// eventBudget counts the events of each category sent in fixed windows of
+// time, see ClientOptions.EventBudgets.
+type eventBudget struct {
+	limits map[string]int
+	period time.Duration
+
+	mu sync.Mutex
+	// start is the beginning of the current window.
+	start   time.Time
+	sent    map[string]int
+	dropped map[string]int
+}
+
+// budgetSummary describes the events dropped by an eventBudget within a
+// window.
+type budgetSummary struct {
+	start, end time.Time
+	dropped    map[string]int
+}
+
+func newEventBudget(limits map[string]int, period time.Duration) *eventBudget {
+	if period <= 0 {
+		period = defaultEventBudgetPeriod
+	}
+	return &eventBudget{
+		limits:  limits,
+		period:  period,
+		sent:    make(map[string]int),
+		dropped: make(map[string]int),
+	}
+}
+
+// take reports whether an event of category may be sent at now, and counts
+// it either way. When now starts a new window, it also returns the summary of
+// the events dropped in the previous window, if any.
+func (b *eventBudget) take(category string, now time.Time) (bool, *budgetSummary) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var summary *budgetSummary
+	if now.Sub(b.start) >= b.period {
+		summary = b.drainLocked(now)
+		b.start = now
+		b.sent = make(map[string]int)
+	}
+
+	limit, ok := b.limits[category]
+	if !ok || b.sent[category] < limit {
+		b.sent[category]++
+		return true, summary
+	}
+	b.dropped[category]++
+	return false, summary
+}
+
+// drain returns the summary of the events dropped in the current window so
+// far, if any, and resets their count.
+func (b *eventBudget) drain(now time.Time) *budgetSummary {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.drainLocked(now)
+}
+
+func (b *eventBudget) drainLocked(now time.Time) *budgetSummary {
+	if len(b.dropped) == 0 {
+		return nil
+	}
+	summary := &budgetSummary{start: b.start, end: now, dropped: b.dropped}
+	b.dropped = make(map[string]int)
+	return summary
+}
+
+// event returns the event reporting the summary. It is sent regardless of the
+// budget.
+func (s *budgetSummary) event(limits map[string]int) *Event {
+	categories := make([]string, 0, len(s.dropped))
+	for category := range s.dropped {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	counts := make([]string, len(categories))
+	dropped := make(map[string]interface{}, len(categories))
+	for i, category := range categories {
+		counts[i] = fmt.Sprintf("%d %s", s.dropped[category], category)
+		dropped[category] = s.dropped[category]
+	}
+	budgets := make(map[string]interface{}, len(limits))
+	for category, limit := range limits {
+		budgets[category] = limit
+	}
+
+	event := NewEvent()
+	event.Level = LevelWarning
+	event.Message = fmt.Sprintf("Event budget exceeded, dropped events: %s", strings.Join(counts, ", "))
+	event.Fingerprint = []string{"sentry-go-event-budget"}
+	event.Contexts["event_budget"] = Context{
+		"window_start": s.start,
+		"window_end":   s.end,
+		"dropped":      dropped,
+		"budgets":      budgets,
+	}
+	event.sdkMetaData.budgetSummary = true
+	return event
+}
@@ -0,0 +1,89 @@
+package sentry
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEventBudgetTake(t *testing.T) {
+	budget := newEventBudget(map[string]int{"error": 2}, time.Minute)
+	now := time.Now()
+
+	for i, want := range []bool{true, true, false, false} {
+		ok, summary := budget.take("error", now)
+		assertEqual(t, ok, want)
+		if summary != nil {
+			t.Errorf("take %d: unexpected summary %+v", i, summary)
+		}
+	}
+	if ok, _ := budget.take("transaction", now); !ok {
+		t.Error("categories without a budget must not be capped")
+	}
+
+	ok, summary := budget.take("error", now.Add(time.Minute))
+	assertEqual(t, ok, true)
+	if summary == nil {
+		t.Fatal("expected a summary of the previous window")
+	}
+	assertEqual(t, summary.dropped, map[string]int{"error": 2})
+	assertEqual(t, budget.drain(now), (*budgetSummary)(nil))
+}
+
+func TestClientEventBudget(t *testing.T) {
+	transport := &TransportMock{}
+	var dropped []DropReason
+	client, err := NewClient(ClientOptions{
+		Transport:    transport,
+		EventBudgets: map[string]int{"error": 1},
+		OnEventDropped: func(_ *Event, reason DropReason, _ string) {
+			dropped = append(dropped, reason)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	scope := NewScope()
+	for i := 0; i < 3; i++ {
+		client.CaptureException(errors.New("loop"), nil, scope)
+	}
+	assertEqual(t, len(transport.events), 1)
+	assertEqual(t, dropped, []DropReason{DropReasonEventBudget, DropReasonEventBudget})
+
+	client.Flush(time.Second)
+	assertEqual(t, len(transport.events), 2)
+	summary := transport.lastEvent
+	assertEqual(t, summary.Level, LevelWarning)
+	assertEqual(t, summary.Fingerprint, []string{"sentry-go-event-budget"})
+	assertEqual(t, summary.Contexts["event_budget"]["dropped"], map[string]interface{}{"error": 2})
+
+	// Nothing was dropped since the last summary.
+	client.Flush(time.Second)
+	assertEqual(t, len(transport.events), 2)
+}
+
+func TestClientEventBudgetIgnoresFilteredEvents(t *testing.T) {
+	transport := &TransportMock{}
+	client, err := NewClient(ClientOptions{
+		Transport:    transport,
+		EventBudgets: map[string]int{"error": 1},
+		BeforeSend: func(event *Event, _ *EventHint) *Event {
+			if event.Message == "noise" {
+				return nil
+			}
+			return event
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	scope := NewScope()
+	client.CaptureMessage("noise", nil, scope)
+	client.CaptureMessage("noise", nil, scope)
+	client.CaptureMessage("signal", nil, scope)
+	assertEqual(t, len(transport.events), 1)
+
+	// No summary, nothing was dropped by the budget.
+	client.Flush(time.Second)
+	assertEqual(t, len(transport.events), 1)
+}
@@ -0,0 +1,122 @@
+package sentry
+
+import (
+	"sync"
+	"time"
+)
+
+// defaultEventPipelineSize is the number of events waiting for the event
+// processors and BeforeSend in an eventPipeline unless
+// ClientOptions.AsyncEventProcessingQueueSize is set.
+const defaultEventPipelineSize = 100
+
+// eventPipeline runs the client event processors and BeforeSend of the
+// events of a client on a single goroutine, see
+// ClientOptions.AsyncEventProcessing.
+type eventPipeline struct {
+	client *Client
+	queue  chan pipelineItem
+
+	// stopped is closed when the worker returns after close.
+	stopped chan struct{}
+
+	mu sync.Mutex
+	// pending counts the events enqueued but not yet handed to the
+	// transport.
+	pending int
+	// idle is closed when pending drops to zero.
+	idle chan struct{}
+	// closed is set by close, queue is closed then.
+	closed bool
+}
+
+type pipelineItem struct {
+	event    *Event
+	hint     *EventHint
+	deadline *captureDeadline
+}
+
+func newEventPipeline(client *Client, size int) *eventPipeline {
+	if size <= 0 {
+		size = defaultEventPipelineSize
+	}
+	p := &eventPipeline{
+		client:  client,
+		queue:   make(chan pipelineItem, size),
+		stopped: make(chan struct{}),
+	}
+	go p.worker()
+	return p
+}
+
+// enqueue hands event over to the worker. It reports false if the queue is
+// full, or if the pipeline is closed, which closed reports.
+func (p *eventPipeline) enqueue(event *Event, hint *EventHint, deadline *captureDeadline) (ok, closed bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return false, true
+	}
+
+	select {
+	case p.queue <- pipelineItem{event: event, hint: hint, deadline: deadline}:
+		if p.pending == 0 {
+			p.idle = make(chan struct{})
+		}
+		p.pending++
+		return true, false
+	default:
+		return false, false
+	}
+}
+
+// done records that an enqueued event left the pipeline.
+func (p *eventPipeline) done() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pending--
+	if p.pending == 0 {
+		close(p.idle)
+	}
+}
+
+func (p *eventPipeline) worker() {
+	defer close(p.stopped)
+	for item := range p.queue {
+		p.client.finishEvent(item.event, item.hint, item.deadline)
+		p.done()
+	}
+}
+
+// close lets the worker process the enqueued events and return, and waits
+// for it. Later events are not enqueued.
+func (p *eventPipeline) close() {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	p.mu.Unlock()
+	<-p.stopped
+}
+
+// wait waits until the enqueued events are handed to the transport, for at
+// most timeout. It reports whether they were.
+func (p *eventPipeline) wait(timeout time.Duration) bool {
+	p.mu.Lock()
+	if p.pending == 0 {
+		p.mu.Unlock()
+		return true
+	}
+	idle := p.idle
+	p.mu.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-idle:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
@@ -0,0 +1,111 @@
+package sentry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAsyncEventProcessing(t *testing.T) {
+	release := make(chan struct{})
+	var dropped []DropReason
+	transport := &TransportMock{}
+	client, err := NewClient(ClientOptions{
+		Dsn:                           testDsn,
+		Transport:                     transport,
+		AsyncEventProcessing:          true,
+		AsyncEventProcessingQueueSize: 1,
+		BeforeSend: func(event *Event, hint *EventHint) *Event {
+			<-release
+			event.Tags["before_send"] = "done"
+			return event
+		},
+		OnEventDropped: func(_ *Event, reason DropReason, _ string) {
+			dropped = append(dropped, reason)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	scope := NewScope()
+	scope.SetTag("request", "first")
+
+	// The first event blocks the worker, the second fills the queue and the
+	// third overflows it.
+	first := client.CaptureMessage("first", nil, scope)
+	if first == nil {
+		t.Fatal("no event ID")
+	}
+	for deadline := time.Now().Add(time.Second); len(client.pipeline.queue) > 0 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	scope.SetTag("request", "second")
+	client.CaptureMessage("second", nil, scope)
+	if id := client.CaptureMessage("third", nil, scope); id != nil {
+		t.Errorf("overflowing event was enqueued: %s", *id)
+	}
+	assertEqual(t, dropped, []DropReason{DropReasonQueueOverflow})
+	assertEqual(t, len(transport.Events()), 0)
+
+	if client.Flush(10 * time.Millisecond) {
+		t.Error("Flush returned true with a blocked pipeline")
+	}
+	close(release)
+	if !client.Flush(time.Second) {
+		t.Fatal("Flush timed out")
+	}
+	events := transport.Events()
+	assertEqual(t, len(events), 2)
+	assertEqual(t, events[0].EventID, *first)
+	assertEqual(t, events[0].Tags, map[string]string{"request": "first", "before_send": "done"})
+	assertEqual(t, events[1].Tags["request"], "second")
+}
+
+func TestAsyncEventProcessingClose(t *testing.T) {
+	release := make(chan struct{})
+	transport := &TransportMock{}
+	client, err := NewClient(ClientOptions{
+		Dsn:                  testDsn,
+		Transport:            transport,
+		AsyncEventProcessing: true,
+		BeforeSend: func(event *Event, hint *EventHint) *Event {
+			<-release
+			return event
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.CaptureMessage("first", nil, nil)
+	client.CaptureMessage("second", nil, nil)
+
+	closed := make(chan struct{})
+	go func() {
+		client.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+		t.Fatal("Close returned before the enqueued events were processed")
+	case <-time.After(10 * time.Millisecond):
+	}
+	close(release)
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("Close timed out")
+	}
+	select {
+	case <-client.pipeline.stopped:
+	default:
+		t.Error("worker is still running")
+	}
+	assertEqual(t, len(transport.Events()), 2)
+
+	// Events captured after Close are processed synchronously.
+	if id := client.CaptureMessage("third", nil, nil); id == nil {
+		t.Fatal("event captured after Close was dropped")
+	}
+	assertEqual(t, len(transport.Events()), 3)
+	// Closing twice is fine.
+	client.Close()
+}
@@ -0,0 +1,106 @@
+package sentry
+
+import (
+	"sort"
+)
+
+// EventProcessorOption configures an event processor added with
+// Scope.AddEventProcessor, Client.AddEventProcessor or
+// AddGlobalEventProcessor.
+type EventProcessorOption func(p *eventProcessorEntry)
+
+// WithEventProcessorName names an event processor, such that it can be
+// removed again with the RemoveEventProcessor method of the scope or client,
+// or with RemoveGlobalEventProcessor. Adding a processor with the name of an
+// existing one replaces the existing processor.
+func WithEventProcessorName(name string) EventProcessorOption {
+	return func(p *eventProcessorEntry) {
+		p.name = name
+	}
+}
+
+// WithEventProcessorPriority sets the priority of an event processor.
+// Processors with a higher priority run first, processors with the same
+// priority run in the order they were added. The default priority is 0.
+func WithEventProcessorPriority(priority int) EventProcessorOption {
+	return func(p *eventProcessorEntry) {
+		p.priority = priority
+	}
+}
+
+type eventProcessorEntry struct {
+	name      string
+	priority  int
+	processor EventProcessor
+}
+
+// eventProcessors is a list of event processors ordered by priority. Lists are
+// never modified in place, such that they can be shared between scopes.
+type eventProcessors []eventProcessorEntry
+
+// add returns a new list with processor added.
+func (ps eventProcessors) add(processor EventProcessor, opts []EventProcessorOption) eventProcessors {
+	entry := eventProcessorEntry{processor: processor}
+	for _, opt := range opts {
+		opt(&entry)
+	}
+
+	list := make(eventProcessors, 0, len(ps)+1)
+	for _, p := range ps {
+		if entry.name == "" || p.name != entry.name {
+			list = append(list, p)
+		}
+	}
+	list = append(list, entry)
+	list.sort()
+	return list
+}
+
+// remove returns a new list without the processor called name, and whether
+// there was one. Unnamed processors cannot be removed, an empty name returns
+// ps as is.
+func (ps eventProcessors) remove(name string) (eventProcessors, bool) {
+	if name == "" {
+		return ps, false
+	}
+	list := make(eventProcessors, 0, len(ps))
+	for _, p := range ps {
+		if p.name != name {
+			list = append(list, p)
+		}
+	}
+	return list, len(list) != len(ps)
+}
+
+// concat returns a new list with the processors of both lists.
+func (ps eventProcessors) concat(other eventProcessors) eventProcessors {
+	list := make(eventProcessors, 0, len(ps)+len(other))
+	list = append(list, ps...)
+	list = append(list, other...)
+	list.sort()
+	return list
+}
+
+func (ps eventProcessors) sort() {
+	sort.SliceStable(ps, func(i, j int) bool {
+		return ps[i].priority > ps[j].priority
+	})
+}
+
+// apply runs the processors on event and returns the resulting event, or nil
+// if one of them dropped it. kind names the list in log messages.
+func (ps eventProcessors) apply(event *Event, hint *EventHint, kind string) *Event {
+	for _, p := range ps {
+		id := event.EventID
+		event = p.processor(event, hint)
+		if event == nil {
+			if p.name != "" {
+				Logger.Printf("Event dropped by the %s EventProcessor %q: %s\n", kind, p.name, id)
+			} else {
+				Logger.Printf("Event dropped by one of the %s EventProcessors: %s\n", kind, id)
+			}
+			return nil
+		}
+	}
+	return event
+}
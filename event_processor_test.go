@@ -0,0 +1,67 @@
+package sentry
+
+import (
+	"testing"
+)
+
+func appendMessage(s string) EventProcessor {
+	return func(event *Event, _ *EventHint) *Event {
+		event.Message += s
+		return event
+	}
+}
+
+func TestEventProcessorPriorityAndNames(t *testing.T) {
+	scope := NewScope()
+	scope.AddEventProcessor(appendMessage("a"))
+	scope.AddEventProcessor(appendMessage("b"), WithEventProcessorPriority(10))
+	scope.AddEventProcessor(appendMessage("c"), WithEventProcessorName("c"))
+	scope.AddEventProcessor(appendMessage("d"), WithEventProcessorPriority(-1))
+	scope.AddEventProcessor(appendMessage("e"), WithEventProcessorPriority(10))
+
+	event := scope.ApplyToEvent(NewEvent(), nil, nil)
+	assertEqual(t, event.Message, "beacd")
+
+	// Replacing keeps a single processor with the name.
+	scope.AddEventProcessor(appendMessage("C"), WithEventProcessorName("c"), WithEventProcessorPriority(20))
+	event = scope.ApplyToEvent(NewEvent(), nil, nil)
+	assertEqual(t, event.Message, "Cbead")
+
+	assertEqual(t, scope.RemoveEventProcessor("c"), true)
+	assertEqual(t, scope.RemoveEventProcessor("c"), false)
+	// An empty name doesn't remove the unnamed processors.
+	assertEqual(t, scope.RemoveEventProcessor(""), false)
+	event = scope.ApplyToEvent(NewEvent(), nil, nil)
+	assertEqual(t, event.Message, "bead")
+}
+
+func TestEventProcessorRemovalDoesNotAffectClones(t *testing.T) {
+	scope := NewScope()
+	scope.AddEventProcessor(appendMessage("a"), WithEventProcessorName("a"))
+	clone := scope.Clone()
+
+	clone.RemoveEventProcessor("a")
+	clone.AddEventProcessor(appendMessage("b"))
+
+	assertEqual(t, scope.ApplyToEvent(NewEvent(), nil, nil).Message, "a")
+	assertEqual(t, clone.ApplyToEvent(NewEvent(), nil, nil).Message, "b")
+}
+
+func TestClientRemoveEventProcessor(t *testing.T) {
+	transport := &TransportMock{}
+	client, err := NewClient(ClientOptions{Transport: transport})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.AddEventProcessor(appendMessage(" client"), WithEventProcessorName("test"))
+	AddGlobalEventProcessor(appendMessage(" global"), WithEventProcessorName("test"))
+	defer RemoveGlobalEventProcessor("test")
+
+	client.CaptureMessage("message", nil, NewScope())
+	assertEqual(t, transport.lastEvent.Message, "message client global")
+
+	assertEqual(t, client.RemoveEventProcessor("test"), true)
+	assertEqual(t, RemoveGlobalEventProcessor("test"), true)
+	client.CaptureMessage("message", nil, NewScope())
+	assertEqual(t, transport.lastEvent.Message, "message")
+}
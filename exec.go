@@ -0,0 +1,106 @@
+package sentry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// maxCommandStderr is the number of trailing bytes of the standard error of a
+// failed command attached to its event.
+const maxCommandStderr = 4096
+
+// RunCommand runs cmd like cmd.Run. If the command exits with a non-zero
+// status, it captures an event with the hub of ctx that carries the command
+// line, the exit code and the tail of the standard error of the command. The
+// values of arguments that look sensitive, such as "--password secret" or
+// "TOKEN=secret", are filtered from the command line.
+//
+// The standard error is still written to cmd.Stderr, if set. RunCommand
+// returns the error of cmd.Run.
+func RunCommand(ctx context.Context, cmd *exec.Cmd) error {
+	stderr := &tailWriter{max: maxCommandStderr}
+	if cmd.Stderr != nil {
+		cmd.Stderr = io.MultiWriter(cmd.Stderr, stderr)
+	} else {
+		cmd.Stderr = stderr
+	}
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return err
+	}
+
+	hub := hubFromContext(ctx)
+	client := hub.Client()
+	if client == nil {
+		return err
+	}
+	name := filepath.Base(cmd.Path)
+	code := exitErr.ExitCode()
+	event := client.eventFromMessage(fmt.Sprintf("Command %s exited with code %d", name, code), LevelError)
+	event.Fingerprint = []string{"command", name, strconv.Itoa(code)}
+	event.Tags["command"] = name
+	event.Tags["exit_code"] = strconv.Itoa(code)
+	event.Contexts["command"] = Context{
+		"args":      scrubCommandArgs(cmd.Args),
+		"dir":       cmd.Dir,
+		"exit_code": code,
+		"stderr":    string(stderr.buf),
+	}
+	hint := &EventHint{OriginalException: err, Context: ctx}
+	hub.CaptureEventWithHint(event, hint)
+	return err
+}
+
+// scrubCommandArgs returns a copy of args with the values of sensitive
+// arguments filtered. An argument is sensitive if, like for GraphQL
+// variables, its name contains one of sensitiveVariableNames. The name is the
+// part before "=", or a flag whose value is the next argument.
+func scrubCommandArgs(args []string) []string {
+	scrubbed := make([]string, len(args))
+	filterNext := false
+	for i, arg := range args {
+		if filterNext {
+			scrubbed[i] = filteredValue
+			filterNext = false
+			continue
+		}
+		scrubbed[i] = arg
+		name, _, hasValue := strings.Cut(arg, "=")
+		if !isSensitiveVariable(strings.TrimLeft(name, "-")) {
+			continue
+		}
+		if hasValue {
+			scrubbed[i] = name + "=" + filteredValue
+		} else if strings.HasPrefix(arg, "-") {
+			filterNext = true
+		}
+	}
+	return scrubbed
+}
+
+// tailWriter keeps the last max bytes written to it.
+type tailWriter struct {
+	buf []byte
+	max int
+}
+
+func (w *tailWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	if len(p) >= w.max {
+		w.buf = append(w.buf[:0], p[len(p)-w.max:]...)
+		return n, nil
+	}
+	if over := len(w.buf) + len(p) - w.max; over > 0 {
+		w.buf = append(w.buf[:0], w.buf[over:]...)
+	}
+	w.buf = append(w.buf, p...)
+	return n, nil
+}
@@ -0,0 +1,61 @@
+package sentry
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestRunCommand(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not available")
+	}
+	ctx := NewTestContext(ClientOptions{})
+	transport := hubFromContext(ctx).Client().Transport.(*TransportMock)
+
+	if err := RunCommand(ctx, exec.Command(sh, "-c", "exit 0")); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, transport.lastEvent, (*Event)(nil))
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(sh, "-c", "echo 'disk full' >&2; exit 3", "--password", "hunter2", "API_TOKEN=abc")
+	cmd.Stderr = &stderr
+	if err := RunCommand(ctx, cmd); err == nil {
+		t.Fatal("expected an error")
+	}
+	assertEqual(t, stderr.String(), "disk full\n")
+
+	event := transport.lastEvent
+	assertEqual(t, event.Level, LevelError)
+	assertEqual(t, event.Message, "Command sh exited with code 3")
+	assertEqual(t, event.Tags["exit_code"], "3")
+	command := event.Contexts["command"]
+	assertEqual(t, command["exit_code"], 3)
+	assertEqual(t, command["stderr"], "disk full\n")
+	args := fmt.Sprint(command["args"])
+	if strings.Contains(args, "hunter2") || strings.Contains(args, "abc") {
+		t.Errorf("args not scrubbed: %s", args)
+	}
+}
+
+func TestScrubCommandArgs(t *testing.T) {
+	got := scrubCommandArgs([]string{"deploy", "--token", "t", "--secret-key=s", "-v", "DB_PASSWORD=p", "user=me"})
+	want := []string{"deploy", "--token", filteredValue, "--secret-key=" + filteredValue, "-v", "DB_PASSWORD=" + filteredValue, "user=me"}
+	assertEqual(t, got, want)
+}
+
+func TestTailWriter(t *testing.T) {
+	w := &tailWriter{max: 4}
+	for _, s := range []string{"ab", "cd", "ef", "0123456"} {
+		_, _ = w.Write([]byte(s))
+	}
+	assertEqual(t, string(w.buf), "3456")
+	w.buf = nil
+	_, _ = w.Write([]byte("abc"))
+	_, _ = w.Write([]byte("de"))
+	assertEqual(t, string(w.buf), "bcde")
+}
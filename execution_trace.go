@@ -0,0 +1,44 @@
+package sentry
+
+import (
+	"sync"
+	"time"
+)
+
+// defaultExecutionTraceMaxBytes is the default of
+// ClientOptions.ExecutionTraceMaxBytes.
+const defaultExecutionTraceMaxBytes = 4 << 20
+
+// executionTraceInterval is the minimum time between two execution trace
+// snapshots.
+const executionTraceInterval = time.Minute
+
+var (
+	startExecutionTraceOnce sync.Once
+
+	executionTraceMu sync.Mutex
+	// lastExecutionTrace is the time of the last snapshot.
+	lastExecutionTrace time.Time
+)
+
+// executionTraceSnapshot returns a snapshot of the flight recorder as an
+// attachment, or nil if flight recording is not active, a snapshot was taken
+// less than executionTraceInterval before now or it failed.
+func executionTraceSnapshot(now time.Time) *Attachment {
+	executionTraceMu.Lock()
+	defer executionTraceMu.Unlock()
+
+	if !lastExecutionTrace.IsZero() && now.Sub(lastExecutionTrace) < executionTraceInterval {
+		return nil
+	}
+	payload := writeExecutionTrace()
+	if payload == nil {
+		return nil
+	}
+	lastExecutionTrace = now
+	return &Attachment{
+		Filename:    "trace.out",
+		ContentType: "application/octet-stream",
+		Payload:     payload,
+	}
+}
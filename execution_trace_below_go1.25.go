@@ -0,0 +1,12 @@
+//go:build !go1.25
+
+package sentry
+
+// startExecutionTrace does nothing, flight recording requires Go 1.25.
+func startExecutionTrace(uint64) {
+	Logger.Println("Execution trace flight recording requires Go 1.25 or later")
+}
+
+func writeExecutionTrace() []byte {
+	return nil
+}
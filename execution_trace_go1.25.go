@@ -0,0 +1,38 @@
+//go:build go1.25
+
+package sentry
+
+import (
+	"bytes"
+	"runtime/trace"
+)
+
+// flightRecorder is the flight recorder started by startExecutionTrace.
+var flightRecorder *trace.FlightRecorder
+
+// startExecutionTrace starts recording the most recent maxBytes of the
+// execution trace. It must only be called once.
+func startExecutionTrace(maxBytes uint64) {
+	fr := trace.NewFlightRecorder(trace.FlightRecorderConfig{MaxBytes: maxBytes})
+	if err := fr.Start(); err != nil {
+		Logger.Printf("Execution trace flight recording could not be started: %v", err)
+		return
+	}
+	executionTraceMu.Lock()
+	flightRecorder = fr
+	executionTraceMu.Unlock()
+}
+
+// writeExecutionTrace returns a snapshot of the flight recorder, or nil if it
+// is not recording. It must be called with executionTraceMu held.
+func writeExecutionTrace() []byte {
+	if flightRecorder == nil {
+		return nil
+	}
+	var b bytes.Buffer
+	if _, err := flightRecorder.WriteTo(&b); err != nil {
+		Logger.Printf("Execution trace snapshot failed: %v", err)
+		return nil
+	}
+	return b.Bytes()
+}
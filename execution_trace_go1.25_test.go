@@ -0,0 +1,43 @@
+//go:build go1.25
+
+package sentry
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestExecutionTraceSnapshot(t *testing.T) {
+	transport := &TransportMock{}
+	client, _ := NewClient(ClientOptions{
+		Dsn:                     testDsn,
+		Transport:               transport,
+		EnableTracing:           true,
+		TracesSampleRate:        1,
+		ExecutionTraceThreshold: time.Millisecond,
+	})
+	ctx := SetHubOnContext(context.Background(), NewHub(client, NewScope()))
+	executionTraceMu.Lock()
+	lastExecutionTrace = time.Time{}
+	executionTraceMu.Unlock()
+
+	finish := func(d time.Duration) *Event {
+		span := StartTransaction(ctx, "transaction")
+		span.EndTime = span.StartTime.Add(d)
+		span.Finish()
+		return transport.lastEvent
+	}
+
+	if event := finish(0); len(event.Attachments) != 0 {
+		t.Errorf("fast transaction got %d attachments, want none", len(event.Attachments))
+	}
+	event := finish(time.Second)
+	if len(event.Attachments) != 1 || event.Attachments[0].Filename != "trace.out" ||
+		len(event.Attachments[0].Payload) == 0 {
+		t.Fatalf("slow transaction got attachments %v, want an execution trace", event.Attachments)
+	}
+	if event := finish(time.Second); len(event.Attachments) != 0 {
+		t.Error("execution trace snapshots should be limited to one per interval")
+	}
+}
@@ -0,0 +1,59 @@
+// Package sentryexpvar publishes the statistics of Sentry clients with the
+// expvar package, such that the behavior of the SDK can be observed with
+// existing monitoring, for example by scraping /debug/vars.
+//
+// It is a separate package because importing expvar registers the
+// /debug/vars handler on http.DefaultServeMux.
+package sentryexpvar
+
+import (
+	"expvar"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// Publish publishes the statistics of the client of sentry.CurrentHub under
+// name, see Var. Like expvar.Publish, it panics if name is already in use.
+func Publish(name string) {
+	expvar.Publish(name, Var(func() *sentry.Client {
+		return sentry.CurrentHub().Client()
+	}))
+}
+
+// Var returns an expvar.Var reporting the statistics of the client returned
+// by client, see sentry.Client.Stats. client is called on every read, such
+// that a client bound later with sentry.Init is reported. If it returns nil,
+// the Var is null.
+//
+// The statistics are reported as a JSON object with the keys "captured",
+// "sent", "dropped", "queue_depth", "queue_capacity", "flushes",
+// "flush_duration_seconds", "last_transport_error",
+// "last_transport_error_time" and "rate_limits".
+func Var(client func() *sentry.Client) expvar.Var {
+	return expvar.Func(func() interface{} {
+		c := client()
+		if c == nil {
+			return nil
+		}
+		return statsMap(c.Stats())
+	})
+}
+
+func statsMap(stats sentry.ClientStats) map[string]interface{} {
+	m := map[string]interface{}{
+		"captured":               stats.Captured,
+		"sent":                   stats.Sent,
+		"dropped":                stats.Dropped,
+		"queue_depth":            stats.QueueDepth,
+		"queue_capacity":         stats.QueueCapacity,
+		"flushes":                stats.Flushes,
+		"flush_duration_seconds": stats.FlushDuration.Seconds(),
+		"rate_limits":            stats.RateLimits,
+	}
+	if stats.LastTransportError != nil {
+		m["last_transport_error"] = stats.LastTransportError.Error()
+		m["last_transport_error_time"] = stats.LastTransportErrorTime.Format(time.RFC3339Nano)
+	}
+	return m
+}
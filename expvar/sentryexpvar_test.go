@@ -0,0 +1,54 @@
+package sentryexpvar
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/google/go-cmp/cmp"
+)
+
+type noopTransport struct{}
+
+func (noopTransport) Configure(sentry.ClientOptions) {}
+func (noopTransport) SendEvent(*sentry.Event)        {}
+func (noopTransport) Flush(time.Duration) bool       { return true }
+
+func TestVar(t *testing.T) {
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		Transport: noopTransport{},
+		BeforeSend: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.CaptureMessage("dropped", nil, sentry.NewScope())
+
+	var got map[string]interface{}
+	if err := json.Unmarshal([]byte(Var(func() *sentry.Client { return client }).String()), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"captured":               1.0,
+		"sent":                   map[string]interface{}{},
+		"dropped":                map[string]interface{}{"before_send": map[string]interface{}{"error": 1.0}},
+		"queue_depth":            0.0,
+		"queue_capacity":         0.0,
+		"flushes":                0.0,
+		"flush_duration_seconds": 0.0,
+		"rate_limits":            map[string]interface{}{},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Var() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestVarWithoutClient(t *testing.T) {
+	v := Var(func() *sentry.Client { return nil })
+	if got := v.String(); got != "null" {
+		t.Errorf("Var() = %s, want null", got)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/getsentry/sentry-go"
+	"github.com/getsentry/sentry-go/internal/intern"
 	"github.com/valyala/fasthttp"
 )
 
@@ -73,7 +74,7 @@ func (h *Handler) Handle(handler fasthttp.RequestHandler) fasthttp.RequestHandle
 			sentry.WithSpanOrigin(sentry.SpanOriginFastHTTP),
 		}
 
-		method := string(ctx.Method())
+		method := intern.Bytes(ctx.Method())
 
 		transaction := sentry.StartTransaction(
 			sentry.SetHubOnContext(ctx, hub),
@@ -89,9 +90,8 @@ func (h *Handler) Handle(handler fasthttp.RequestHandler) fasthttp.RequestHandle
 
 		transaction.SetData("http.request.method", method)
 
-		scope := hub.Scope()
-		scope.SetRequest(convertedHTTPRequest)
-		scope.SetRequestBody(ctx.Request.Body())
+		hub.SetRequest(convertedHTTPRequest)
+		hub.SetRequestBody(ctx.Request.Body())
 		ctx.SetUserValue(valuesKey, hub)
 		ctx.SetUserValue(transactionKey, transaction)
 		defer h.recoverWithSentry(hub, ctx)
@@ -141,7 +141,7 @@ func convert(ctx *fasthttp.RequestCtx) *http.Request {
 
 	r := new(http.Request)
 
-	r.Method = string(ctx.Method())
+	r.Method = intern.Bytes(ctx.Method())
 	uri := ctx.URI()
 	// Ignore error.
 	r.URL, _ = url.Parse(fmt.Sprintf("%s://%s%s", uri.Scheme(), uri.Host(), uri.Path()))
@@ -150,7 +150,7 @@ func convert(ctx *fasthttp.RequestCtx) *http.Request {
 	r.Header = make(http.Header)
 	r.Header.Add("Host", string(ctx.Host()))
 	ctx.Request.Header.VisitAll(func(key, value []byte) {
-		r.Header.Add(string(key), string(value))
+		r.Header.Add(intern.Bytes(key), string(value))
 	})
 	r.Host = string(ctx.Host())
 
@@ -0,0 +1,214 @@
+package sentry
+
+import (
+	"bufio"
+	"bytes"
+	"debug/elf"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/getsentry/sentry-go/internal/traceparser"
+)
+
+// crashOutputPrefix is the prefix of the files holding the crash output of
+// the Go runtime, see ClientOptions.CaptureFatalCrashes.
+const crashOutputPrefix = "crash-output-"
+
+// crashOutputHeader starts every crash output file, followed by the
+// executable that wrote it.
+const crashOutputHeader = "sentry-go crash output:"
+
+var startCrashOutputOnce sync.Once
+
+// crashOutputName is the name of the crash output file of this process. The
+// PID alone is not unique across runs, containers for example always run as
+// PID 1, so a random suffix keeps the file of an earlier run with the same
+// PID from being overwritten before it is sent.
+var crashOutputName = crashOutputPrefix + strconv.Itoa(os.Getpid()) + "-" + uuid()[:8] + ".txt"
+
+// crashOutputPath returns the path of the crash output file of this process.
+func (s *crashStore) crashOutputPath() string {
+	return filepath.Join(s.dir, crashOutputName)
+}
+
+// crashOutputFile creates the crash output file of this process and writes
+// the header identifying the executable.
+func (s *crashStore) crashOutputFile() (*os.File, error) {
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return nil, err
+	}
+	f, err := os.Create(s.crashOutputPath())
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fmt.Fprintf(f, "%s %s\n", crashOutputHeader, executableID()); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// sendCrashOutputs captures an event for every crash output left by an
+// earlier process, and removes the files of earlier processes.
+func (s *crashStore) sendCrashOutputs(client *Client) {
+	paths, err := filepath.Glob(filepath.Join(s.dir, crashOutputPrefix+"*.txt"))
+	if err != nil {
+		return
+	}
+	for _, path := range paths {
+		if path == s.crashOutputPath() {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		_ = os.Remove(path)
+		if err != nil {
+			continue
+		}
+		if event := eventFromCrashOutput(data); event != nil {
+			// The file was last written when the process crashed.
+			event.Timestamp = info.ModTime()
+			client.CaptureEvent(event, nil, nil)
+		}
+	}
+}
+
+// eventFromCrashOutput creates an event from the contents of a crash output
+// file, or returns nil if the process did not crash.
+func eventFromCrashOutput(data []byte) *Event {
+	var exe string
+	if header, rest, ok := bytes.Cut(data, []byte{'\n'}); ok && bytes.HasPrefix(header, []byte(crashOutputHeader)) {
+		exe = strings.TrimSpace(string(header[len(crashOutputHeader):]))
+		data = rest
+	}
+	output := bytes.TrimSpace(data)
+	if len(output) == 0 {
+		return nil
+	}
+
+	exception := Exception{
+		Mechanism: &Mechanism{Type: "fatal_crash"},
+	}
+	exception.Mechanism.SetUnhandled()
+	var nativePC string
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for first := true; scanner.Scan(); first = false {
+		line := scanner.Text()
+		switch {
+		case first:
+			// For example "panic: ...", "fatal error: ..." or "SIGSEGV: ...".
+			if kind, message, ok := strings.Cut(line, ": "); ok {
+				exception.Type, exception.Value = kind, message
+			} else {
+				exception.Type = line
+			}
+		case strings.HasPrefix(line, "PC="):
+			nativePC = strings.Fields(line)[0][len("PC="):]
+		case line == "signal arrived during cgo execution":
+			exception.Mechanism.Data = map[string]any{"cgo": true}
+		}
+	}
+
+	event := NewEvent()
+	event.Level = LevelFatal
+	traces := traceparser.Parse(output)
+	for i := 0; i < traces.Length(); i++ {
+		trace := traces.Item(i)
+		if trace.GoID() == 0 {
+			continue
+		}
+		event.Threads = append(event.Threads, Thread{
+			ID:         strconv.FormatUint(trace.GoID(), 10),
+			Name:       goroutineName(trace),
+			Stacktrace: goroutineStacktrace(trace),
+		})
+	}
+
+	var stacktrace *Stacktrace
+	if len(event.Threads) > 0 {
+		// The runtime prints the crashing goroutine first.
+		event.Threads[0].Crashed = true
+		event.Threads[0].Current = true
+		stacktrace = event.Threads[0].Stacktrace
+	}
+	if nativePC != "" {
+		if stacktrace == nil {
+			stacktrace = &Stacktrace{}
+		}
+		frame := Frame{
+			InstructionAddr: nativePC,
+			Platform:        "native",
+			InApp:           true,
+		}
+		if pc, err := strconv.ParseUint(strings.TrimPrefix(nativePC, "0x"), 16, 64); err == nil {
+			frame.Function = nativeSymbol(exe, pc)
+		}
+		// The native frame is the innermost one, and frames are ordered
+		// from the outermost.
+		frames := append([]Frame(nil), stacktrace.Frames...)
+		stacktrace = &Stacktrace{Frames: append(frames, frame)}
+	}
+	exception.Stacktrace = stacktrace
+	event.Exception = []Exception{exception}
+
+	event.Attachments = append(event.Attachments, &Attachment{
+		Filename:    "crash.txt",
+		ContentType: "text/plain",
+		Payload:     output,
+	})
+	return event
+}
+
+// executableID identifies the running executable by its size, modification
+// time and path, to tell whether it was replaced since a crash.
+func executableID() string {
+	exe, err := os.Executable()
+	if err != nil {
+		return ""
+	}
+	info, err := os.Stat(exe)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%d %d %s", info.Size(), info.ModTime().UnixNano(), exe)
+}
+
+// nativeSymbol returns the name of the function containing pc in the symbol
+// table of the executable identified by exe, or "" if the executable changed,
+// it has no symbol table or it is position independent.
+func nativeSymbol(exe string, pc uint64) string {
+	if exe == "" || exe != executableID() {
+		return ""
+	}
+	fields := strings.SplitN(exe, " ", 3)
+	if len(fields) != 3 {
+		return ""
+	}
+	f, err := elf.Open(fields[2])
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	if f.Type != elf.ET_EXEC {
+		// The load address of position independent executables is unknown.
+		return ""
+	}
+	symbols, err := f.Symbols()
+	if err != nil {
+		return ""
+	}
+	for _, s := range symbols {
+		if elf.ST_TYPE(s.Info) == elf.STT_FUNC && pc >= s.Value && pc < s.Value+s.Size {
+			return s.Name
+		}
+	}
+	return ""
+}
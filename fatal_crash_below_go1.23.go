@@ -0,0 +1,9 @@
+//go:build !go1.23
+
+package sentry
+
+// startCrashOutput does nothing, recording the crash output of the runtime
+// requires Go 1.23.
+func (s *crashStore) startCrashOutput() {
+	Logger.Println("Capturing fatal crashes requires Go 1.23 or later")
+}
@@ -0,0 +1,19 @@
+//go:build go1.23
+
+package sentry
+
+import "runtime/debug"
+
+// startCrashOutput directs the crash output of the Go runtime to the crash
+// output file of this process, in addition to standard error.
+func (s *crashStore) startCrashOutput() {
+	f, err := s.crashOutputFile()
+	if err != nil {
+		Logger.Printf("Crash output could not be recorded: %v", err)
+		return
+	}
+	defer f.Close()
+	if err := debug.SetCrashOutput(f, debug.CrashOptions{}); err != nil {
+		Logger.Printf("Crash output could not be recorded: %v", err)
+	}
+}
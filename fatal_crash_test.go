@@ -0,0 +1,97 @@
+package sentry
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strconv"
+	"testing"
+)
+
+func TestEventFromCrashOutput(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "crash_output_cgo.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	event := eventFromCrashOutput(data)
+	if event == nil {
+		t.Fatal("got no event")
+	}
+	assertEqual(t, event.Level, LevelFatal)
+	exception := event.Exception[0]
+	assertEqual(t, exception.Type, "SIGSEGV")
+	assertEqual(t, exception.Value, "segmentation violation")
+	assertEqual(t, *exception.Mechanism.Handled, false)
+	assertEqual(t, exception.Mechanism.Data, map[string]any{"cgo": true})
+
+	var functions []string
+	for _, f := range exception.Stacktrace.Frames {
+		functions = append(functions, f.Function)
+	}
+	// The executable in the header does not exist, so the native frame is
+	// not symbolized.
+	assertEqual(t, functions, []string{"main", "_Cfunc_crash", ""})
+	assertEqual(t, exception.Stacktrace.Frames[2].InstructionAddr, "0x485fa0")
+
+	if len(event.Threads) != 2 {
+		t.Fatalf("got %d threads, want 2", len(event.Threads))
+	}
+	assertEqual(t, event.Threads[0].Name, "goroutine 1 gp=0x3fd84120c1e0 m=0 mp=0x546140 [syscall]")
+	assertEqual(t, event.Threads[0].Crashed, true)
+	assertEqual(t, event.Threads[1].Crashed, false)
+	assertEqual(t, event.Attachments[0].Filename, "crash.txt")
+}
+
+func TestEventFromCrashOutputWithoutCrash(t *testing.T) {
+	if event := eventFromCrashOutput([]byte(crashOutputHeader + " 1 2 /app\n")); event != nil {
+		t.Errorf("got event %v for a process that did not crash", event)
+	}
+}
+
+func TestSendCrashOutputs(t *testing.T) {
+	dir := t.TempDir()
+	store := newCrashStore(dir)
+	// An earlier run with the same PID.
+	left := filepath.Join(dir, crashOutputPrefix+strconv.Itoa(os.Getpid())+"-00000000.txt")
+	if err := os.WriteFile(left, []byte("panic: boom\n\ngoroutine 1 [running]:\nmain.main()\n\t/app/main.go:3 +0x1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	f, err := store.crashOutputFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	transport := &TransportMock{}
+	client, _ := NewClient(ClientOptions{Dsn: testDsn, Transport: transport})
+	store.sendCrashOutputs(client)
+
+	events := transport.Events()
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	assertEqual(t, events[0].Exception[0].Type, "panic")
+	assertEqual(t, events[0].Exception[0].Value, "boom")
+	if _, err := os.Stat(left); !os.IsNotExist(err) {
+		t.Error("crash output of an earlier process was not removed")
+	}
+	if _, err := os.Stat(store.crashOutputPath()); err != nil {
+		t.Errorf("crash output of this process was removed: %v", err)
+	}
+}
+
+func TestNativeSymbol(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("symbolization requires an ELF executable")
+	}
+	pc := reflect.ValueOf(eventFromCrashOutput).Pointer()
+	got := nativeSymbol(executableID(), uint64(pc))
+	if got == "" {
+		t.Skip("the test executable is position independent or stripped")
+	}
+	assertEqual(t, got, "github.com/getsentry/sentry-go.eventFromCrashOutput")
+	assertEqual(t, nativeSymbol(fmt.Sprintf("1 2 %s", os.Args[0]), uint64(pc)), "")
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/gofiber/fiber/v2/utils"
 
 	"github.com/getsentry/sentry-go"
+	"github.com/getsentry/sentry-go/internal/intern"
 )
 
 const (
@@ -89,9 +90,8 @@ func (h *handler) handle(ctx *fiber.Ctx) error {
 
 	transaction.SetData("http.request.method", method)
 
-	scope := hub.Scope()
-	scope.SetRequest(convertedHTTPRequest)
-	scope.SetRequestBody(ctx.Request().Body())
+	hub.SetRequest(convertedHTTPRequest)
+	hub.SetRequestBody(ctx.Request().Body())
 	ctx.Locals(valuesKey, hub)
 	ctx.Locals(transactionKey, transaction)
 	defer h.recoverWithSentry(hub, ctx)
@@ -143,7 +143,7 @@ func convert(ctx *fiber.Ctx) *http.Request {
 	// Headers
 	r.Header = make(http.Header)
 	ctx.Request().Header.VisitAll(func(key, value []byte) {
-		r.Header.Add(string(key), string(value))
+		r.Header.Add(intern.Bytes(key), string(value))
 	})
 	r.Host = utils.CopyString(ctx.Hostname())
 
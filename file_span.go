@@ -0,0 +1,201 @@
+package sentry
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Operations of file I/O spans.
+const (
+	FileRead  = "file.read"
+	FileWrite = "file.write"
+)
+
+// A FileSpan is a span describing reading or writing a file.
+type FileSpan struct {
+	*Span
+}
+
+// StartFileSpan starts a span for the file operation, FileRead or FileWrite, on
+// the file at path. The span is a child of the span stored in ctx. Its
+// description is the base name of the file; the path is recorded with the home
+// directory of the user replaced by "~".
+//
+// Without a span in ctx, StartFileSpan returns nil rather than starting a
+// transaction for a single file operation. The methods of a nil FileSpan do
+// nothing.
+func StartFileSpan(ctx context.Context, operation, path string) *FileSpan {
+	if SpanFromContext(ctx) == nil {
+		return nil
+	}
+	span := StartSpan(ctx, operation, WithDescription(filepath.Base(path)))
+	span.SetData("file.path", scrubFilePath(path))
+	return &FileSpan{Span: span}
+}
+
+// SetSize records the number of bytes read or written.
+func (s *FileSpan) SetSize(n int64) {
+	if s == nil {
+		return
+	}
+	s.SetData("file.size", n)
+}
+
+// Finish finishes the span, see Span.Finish.
+func (s *FileSpan) Finish() {
+	if s == nil {
+		return
+	}
+	s.Span.Finish()
+}
+
+// finish records the outcome of the operation, n bytes read or written or
+// err, and finishes the span.
+func (s *FileSpan) finish(n int64, err error) {
+	if s == nil {
+		return
+	}
+	if err != nil {
+		s.Status = SpanStatusInternalError
+	}
+	s.SetSize(n)
+	s.Finish()
+}
+
+// ReadFile is like os.ReadFile, recording the read as a FileRead span of the
+// span stored in ctx, if any.
+func ReadFile(ctx context.Context, name string) ([]byte, error) {
+	span := StartFileSpan(ctx, FileRead, name)
+	data, err := os.ReadFile(name)
+	span.finish(int64(len(data)), err)
+	return data, err
+}
+
+// WriteFile is like os.WriteFile, recording the write as a FileWrite span of
+// the span stored in ctx, if any.
+func WriteFile(ctx context.Context, name string, data []byte, perm os.FileMode) error {
+	span := StartFileSpan(ctx, FileWrite, name)
+	err := os.WriteFile(name, data, perm)
+	n := int64(len(data))
+	if err != nil {
+		n = 0
+	}
+	span.finish(n, err)
+	return err
+}
+
+// NewFileReader returns a reader that reads from r, the contents of the file at
+// path, and records the reads as a single FileRead span of the span stored in
+// ctx, if any. The span starts now and finishes at the end of the file, on the
+// first error, or when the reader is closed, whichever comes first. Close
+// closes r if it is an io.Closer.
+func NewFileReader(ctx context.Context, r io.Reader, path string) io.ReadCloser {
+	return &fileReader{r: r, span: StartFileSpan(ctx, FileRead, path)}
+}
+
+type fileReader struct {
+	r    io.Reader
+	span *FileSpan
+	n    int64
+	once sync.Once
+}
+
+func (f *fileReader) Read(p []byte) (int, error) {
+	n, err := f.r.Read(p)
+	f.n += int64(n)
+	if err != nil {
+		f.finish(err)
+	}
+	return n, err
+}
+
+func (f *fileReader) Close() error {
+	f.finish(nil)
+	if c, ok := f.r.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+func (f *fileReader) finish(err error) {
+	f.once.Do(func() {
+		if err == io.EOF { //nolint:errorlint // readers return io.EOF unwrapped
+			err = nil
+		}
+		f.span.finish(f.n, err)
+	})
+}
+
+// NewFileWriter returns a writer that writes to w, the file at path, and
+// records the writes as a single FileWrite span of the span stored in ctx, if
+// any. The span starts now and finishes on the first error or when the writer
+// is closed. Close closes w if it is an io.Closer.
+func NewFileWriter(ctx context.Context, w io.Writer, path string) io.WriteCloser {
+	return &fileWriter{w: w, span: StartFileSpan(ctx, FileWrite, path)}
+}
+
+type fileWriter struct {
+	w    io.Writer
+	span *FileSpan
+	n    int64
+	once sync.Once
+}
+
+func (f *fileWriter) Write(p []byte) (int, error) {
+	n, err := f.w.Write(p)
+	f.n += int64(n)
+	if err != nil {
+		f.finish(err)
+	}
+	return n, err
+}
+
+func (f *fileWriter) Close() error {
+	var err error
+	if c, ok := f.w.(io.Closer); ok {
+		err = c.Close()
+	}
+	f.finish(err)
+	return err
+}
+
+func (f *fileWriter) finish(err error) {
+	f.once.Do(func() {
+		f.span.finish(f.n, err)
+	})
+}
+
+var (
+	homeDirOnce sync.Once
+	homeDirPath string
+)
+
+// homeDir returns the home directory of the user, or "" if unknown.
+func homeDir() string {
+	homeDirOnce.Do(func() {
+		if dir, err := os.UserHomeDir(); err == nil {
+			homeDirPath = filepath.Clean(dir)
+		}
+	})
+	return homeDirPath
+}
+
+// scrubFilePath returns path with the home directory of the user, which
+// usually contains the user name, replaced by "~".
+func scrubFilePath(path string) string {
+	home := homeDir()
+	if home == "" || home == string(filepath.Separator) {
+		return path
+	}
+	if path == home {
+		return "~"
+	}
+	if prefix := home + string(filepath.Separator); strings.HasPrefix(path, prefix) {
+		return "~" + string(filepath.Separator) + path[len(prefix):]
+	}
+	return path
+}
@@ -0,0 +1,112 @@
+package sentry
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileSpans(t *testing.T) {
+	ctx := NewTestContext(ClientOptions{EnableTracing: true, TracesSampleRate: 1.0})
+	transaction := StartTransaction(ctx, "name")
+	ctx = transaction.Context()
+	name := filepath.Join(t.TempDir(), "data.txt")
+
+	if err := WriteFile(ctx, name, []byte("hello"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ReadFile(ctx, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, string(data), "hello")
+	if _, err := ReadFile(ctx, name+".missing"); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	w := NewFileWriter(ctx, &strings.Builder{}, name)
+	_, _ = io.WriteString(w, "abc")
+	_, _ = io.WriteString(w, "de")
+	w.Close()
+	r := NewFileReader(ctx, strings.NewReader("0123456789"), name)
+	_, _ = io.ReadAll(r)
+	r.Close()
+
+	spans := transaction.spanRecorder().children()
+	assertEqual(t, len(spans), 5)
+	for i, want := range []struct {
+		op     string
+		size   int64
+		status SpanStatus
+	}{
+		{FileWrite, 5, SpanStatusUndefined},
+		{FileRead, 5, SpanStatusUndefined},
+		{FileRead, 0, SpanStatusInternalError},
+		{FileWrite, 5, SpanStatusUndefined},
+		{FileRead, 10, SpanStatusUndefined},
+	} {
+		span := spans[i]
+		assertEqual(t, span.Op, want.op)
+		assertEqual(t, span.Status, want.status)
+		if want.status == SpanStatusUndefined {
+			assertEqual(t, span.Data["file.size"], want.size)
+		}
+		assertEqual(t, span.ParentSpanID, transaction.SpanID)
+		if span.EndTime.IsZero() {
+			t.Errorf("span %d was not finished", i)
+		}
+	}
+	assertEqual(t, spans[0].Description, "data.txt")
+}
+
+func TestFileSpansWithoutTransaction(t *testing.T) {
+	transport := &TransportMock{}
+	ctx := NewTestContext(ClientOptions{EnableTracing: true, TracesSampleRate: 1.0, Transport: transport})
+	name := filepath.Join(t.TempDir(), "data.txt")
+
+	if span := StartFileSpan(ctx, FileRead, name); span != nil {
+		t.Errorf("started a span without a parent: %v", span)
+	}
+	if err := WriteFile(ctx, name, []byte("hello"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadFile(ctx, name); err != nil {
+		t.Fatal(err)
+	}
+	w := NewFileWriter(ctx, &strings.Builder{}, name)
+	_, _ = io.WriteString(w, "abc")
+	w.Close()
+	r := NewFileReader(ctx, strings.NewReader("0123456789"), name)
+	_, _ = io.ReadAll(r)
+	r.Close()
+
+	assertEqual(t, len(transport.Events()), 0)
+}
+
+func TestFileWriterFailure(t *testing.T) {
+	ctx := NewTestContext(ClientOptions{EnableTracing: true, TracesSampleRate: 1.0})
+	transaction := StartTransaction(ctx, "name")
+
+	w := NewFileWriter(transaction.Context(), failingWriter{}, "out")
+	if _, err := w.Write([]byte("x")); err == nil {
+		t.Fatal("expected an error")
+	}
+	assertEqual(t, transaction.spanRecorder().children()[0].Status, SpanStatusInternalError)
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestScrubFilePath(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+	assertEqual(t, scrubFilePath(filepath.Join(home, "secrets", "key.pem")), filepath.Join("~", "secrets", "key.pem"))
+	assertEqual(t, scrubFilePath(home), "~")
+	assertEqual(t, scrubFilePath(home+"other"), home+"other")
+}
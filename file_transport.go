@@ -0,0 +1,262 @@
+package sentry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// envelopeFileExt is the extension of the envelope files written by
+// FileTransport.
+const envelopeFileExt = ".envelope"
+
+// ================================
+// FileTransport
+// ================================
+
+// FileTransport is an implementation of Transport that writes every event as
+// an envelope file to a directory instead of sending it to Sentry. The files
+// use the standard envelope format, so they can be inspected or archived, and
+// ReplayEnvelopes sends them to Sentry later. Use it on systems without
+// network access to Sentry.
+//
+// Events written to the directory are reported as DeliverySent.
+type FileTransport struct {
+	dir            string
+	dsn            *Dsn
+	onEventDropped func(*Event, DropReason, string)
+	eventEncoder   EventEncoder
+	envelopeHeader func(EnvelopeHeader, *Event)
+	clock          Clock
+
+	// mu serializes writes, keeping the file names in capture order.
+	mu sync.Mutex
+}
+
+// NewFileTransport returns a new FileTransport writing envelopes to dir, which
+// is created if it does not exist.
+func NewFileTransport(dir string) *FileTransport {
+	return &FileTransport{dir: dir}
+}
+
+// Configure is called by the Client itself, providing it it's own ClientOptions.
+// The DSN is written to the envelopes, where ReplayEnvelopes reads it from.
+func (t *FileTransport) Configure(options ClientOptions) {
+	t.onEventDropped = options.OnEventDropped
+	t.eventEncoder = options.EventEncoder
+	t.envelopeHeader = options.EnvelopeHeader
+	t.clock = options.Clock
+
+	dsn, err := NewDsn(options.Dsn)
+	if err != nil {
+		Logger.Printf("%v\n", err)
+		return
+	}
+	t.dsn = dsn
+
+	if err := os.MkdirAll(t.dir, 0o700); err != nil {
+		Logger.Printf("Cannot create envelope directory: %v\n", err)
+	}
+}
+
+// SendEvent writes the envelope of event to a new file in the directory.
+func (t *FileTransport) SendEvent(event *Event) {
+	if t.dsn == nil {
+		dropEvent(t.onEventDropped, event, DropReasonInternalError)
+		return
+	}
+
+	body := getRequestBodyFromEventBuffer(event, new(bytes.Buffer), t.eventEncoder)
+	if body == nil {
+		dropEvent(t.onEventDropped, event, DropReasonInternalError)
+		return
+	}
+	envelope, _, err := envelopeReader(event, t.dsn, clockOrDefault(t.clock).Now(), body, t.envelopeHeader)
+	if err != nil {
+		dropEvent(t.onEventDropped, event, DropReasonInternalError)
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	name := fmt.Sprintf("%020d-%s%s", clockOrDefault(t.clock).Now().UnixNano(), event.EventID, envelopeFileExt)
+	if err := writeFileAtomic(filepath.Join(t.dir, name), envelope); err != nil {
+		debugLog(LevelError, "Writing envelope failed", "event_id", event.EventID, "error", err)
+		dropEvent(t.onEventDropped, event, DropReasonInternalError)
+		return
+	}
+	Logger.Printf("Wrote event [%s] to %s", event.EventID, name)
+	event.sdkMetaData.delivery.resolve(DeliverySent)
+}
+
+// Flush is a no-op for FileTransport, events are written as they are sent. It
+// always returns true immediately.
+func (t *FileTransport) Flush(time.Duration) bool {
+	return true
+}
+
+// writeFileAtomic writes the contents of r to a file at name, such that the
+// file only appears once it is complete.
+func writeFileAtomic(name string, r io.Reader) error {
+	f, err := os.CreateTemp(filepath.Dir(name), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), name)
+}
+
+// ReplayOptions configure ReplayEnvelopes.
+type ReplayOptions struct {
+	// Dsn, if set, is the DSN the envelopes are sent to instead of the one
+	// in their header.
+	Dsn string
+	// HTTPClient is the client the envelopes are sent with. Defaults to
+	// http.DefaultClient.
+	HTTPClient *http.Client
+	// EnvelopeHeader, if set, is called with the header of every envelope
+	// before it is sent, after dsn and sent_at are updated. The event is
+	// nil, and the values read from the file are json.RawMessage.
+	EnvelopeHeader func(header EnvelopeHeader, event *Event)
+}
+
+// ReplayEnvelopes sends the envelopes written by FileTransport to dir to
+// Sentry, oldest first, and returns how many were sent. Each file is removed
+// once the envelope is accepted, or rejected for good by Sentry. Replaying
+// stops at the first envelope that could not be delivered because of a
+// network error, a rate limit or a server error, keeping it and the newer
+// envelopes for the next replay.
+//
+// The sent_at header of the envelopes is updated, so that Sentry doesn't
+// mistake the delay for clock drift and shift the timestamps of the events.
+func ReplayEnvelopes(ctx context.Context, dir string, options ReplayOptions) (int, error) {
+	client := options.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	var override *Dsn
+	if options.Dsn != "" {
+		dsn, err := NewDsn(options.Dsn)
+		if err != nil {
+			return 0, err
+		}
+		override = dsn
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), envelopeFileExt) {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+
+	sent := 0
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		req, err := replayRequest(ctx, path, override, options.EnvelopeHeader)
+		if err != nil {
+			// The file is corrupt, replaying it again won't help.
+			Logger.Printf("Dropping envelope %s: %v", name, err)
+			_ = os.Remove(path)
+			continue
+		}
+		response, err := client.Do(req)
+		if err != nil {
+			return sent, err
+		}
+		_, _ = io.CopyN(io.Discard, response.Body, maxDrainResponseBytes)
+		response.Body.Close()
+		switch {
+		case response.StatusCode == http.StatusTooManyRequests || response.StatusCode >= 500:
+			return sent, fmt.Errorf("replaying envelope %s: %s", name, response.Status)
+		case response.StatusCode >= 400:
+			Logger.Printf("Envelope %s rejected by Sentry: %s", name, response.Status)
+		default:
+			sent++
+		}
+		if err := os.Remove(path); err != nil {
+			return sent, err
+		}
+	}
+	return sent, nil
+}
+
+// replayRequest returns the request sending the envelope stored at path to
+// Sentry, or to override if not nil.
+func replayRequest(ctx context.Context, path string, override *Dsn, hook func(EnvelopeHeader, *Event)) (*http.Request, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	line, items, ok := bytes.Cut(data, []byte("\n"))
+	if !ok {
+		return nil, errors.New("envelope has no items")
+	}
+	var header map[string]json.RawMessage
+	if err := json.Unmarshal(line, &header); err != nil {
+		return nil, fmt.Errorf("invalid envelope header: %w", err)
+	}
+	var fields struct {
+		Dsn string      `json:"dsn"`
+		Sdk envelopeSdk `json:"sdk"`
+	}
+	if err := json.Unmarshal(line, &fields); err != nil {
+		return nil, fmt.Errorf("invalid envelope header: %w", err)
+	}
+
+	dsn := override
+	if dsn == nil {
+		if dsn, err = NewDsn(fields.Dsn); err != nil {
+			return nil, err
+		}
+	}
+	header["dsn"], _ = json.Marshal(dsn.String())
+	header["sent_at"], _ = json.Marshal(time.Now())
+	var value interface{} = header
+	if hook != nil {
+		h := make(EnvelopeHeader, len(header))
+		for k, v := range header {
+			h[k] = v
+		}
+		hook(h, nil)
+		value = h
+	}
+	line, err = json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	b := bytes.NewBuffer(make([]byte, 0, len(line)+1+len(items)))
+	b.Write(line)
+	b.WriteByte('\n')
+	b.Write(items)
+
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, dsn.GetAPIURL().String(), b)
+	if err != nil {
+		return nil, err
+	}
+	setEnvelopeRequestHeaders(r, dsn, fields.Sdk.Name, fields.Sdk.Version)
+	return r, nil
+}
@@ -0,0 +1,87 @@
+package sentry
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestFileTransportAndReplay(t *testing.T) {
+	dir := t.TempDir()
+	client, err := NewClient(ClientOptions{
+		Dsn:       "http://public@example.com/1",
+		Transport: NewFileTransport(dir),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	scope := NewScope()
+	first := client.CaptureMessage("first", nil, scope)
+	second := client.CaptureMessage("second", nil, scope)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, len(entries), 2)
+	data, err := os.ReadFile(filepath.Join(dir, entries[0].Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, _, _ := bytes.Cut(data, []byte("\n"))
+	if !bytes.Contains(header, []byte(`"dsn":"http://public@example.com/1"`)) {
+		t.Errorf("envelope header = %s", header)
+	}
+
+	var mu sync.Mutex
+	var bodies []string
+	status := http.StatusInternalServerError
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !strings.Contains(r.Header.Get("X-Sentry-Auth"), "sentry_key=replay") {
+			t.Errorf("X-Sentry-Auth = %q", r.Header.Get("X-Sentry-Auth"))
+		}
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+	options := ReplayOptions{Dsn: strings.Replace(srv.URL, "http://", "http://replay@", 1) + "/2"}
+
+	// A server error stops the replay and keeps the envelopes.
+	sent, err := ReplayEnvelopes(context.Background(), dir, options)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	assertEqual(t, sent, 0)
+	entries, _ = os.ReadDir(dir)
+	assertEqual(t, len(entries), 2)
+
+	status = http.StatusOK
+	bodies = nil
+	sent, err = ReplayEnvelopes(context.Background(), dir, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, sent, 2)
+	entries, _ = os.ReadDir(dir)
+	assertEqual(t, len(entries), 0)
+
+	assertEqual(t, len(bodies), 2)
+	for i, id := range []*EventID{first, second} {
+		if !strings.Contains(bodies[i], string(*id)) {
+			t.Errorf("envelope %d does not contain event %s:\n%s", i, *id, bodies[i])
+		}
+		if !strings.Contains(bodies[i], "replay@") {
+			t.Errorf("envelope %d was not sent with the replay DSN:\n%s", i, bodies[i])
+		}
+	}
+}
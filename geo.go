@@ -0,0 +1,63 @@
+package sentry
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Geo is the geographical location of a user, see ClientOptions.GeoResolver.
+type Geo struct {
+	City        string `json:"city,omitempty"`
+	CountryCode string `json:"country_code,omitempty"`
+	Region      string `json:"region,omitempty"`
+}
+
+// requestClientIP returns the address of the client of r, preferring the
+// first X-Forwarded-For and then the X-Real-Ip header over the remote
+// address of the connection.
+func requestClientIP(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		ip, _, _ := strings.Cut(forwarded, ",")
+		return strings.TrimSpace(ip)
+	}
+	if ip := r.Header.Get("X-Real-Ip"); ip != "" {
+		return ip
+	}
+	if ip, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return ip
+	}
+	return r.RemoteAddr
+}
+
+// geoIP returns the address to resolve the location of the user of event
+// from, or "" if there is none or the location is already known.
+func geoIP(event *Event) string {
+	if event.User.Geo != nil {
+		return ""
+	}
+	if event.User.IPAddress != "" {
+		return event.User.IPAddress
+	}
+	return event.sdkMetaData.clientIP
+}
+
+// resolveGeo returns event with the location of its user set with resolver,
+// if any. The event may still be used by the caller of SendEvent, so the
+// location is set on a shallow copy.
+func resolveGeo(resolver func(ip string) *Geo, event *Event) *Event {
+	if resolver == nil {
+		return event
+	}
+	ip := geoIP(event)
+	if ip == "" {
+		return event
+	}
+	geo := resolver(ip)
+	if geo == nil {
+		return event
+	}
+	resolved := *event
+	resolved.User.Geo = geo
+	return &resolved
+}
@@ -0,0 +1,85 @@
+package sentry
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/getsentry/sentry-go/internal/testutils"
+)
+
+func TestRequestClientIP(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	assertEqual(t, requestClientIP(r), "192.0.2.1")
+	r.Header.Set("X-Real-Ip", "198.51.100.2")
+	assertEqual(t, requestClientIP(r), "198.51.100.2")
+	r.Header.Set("X-Forwarded-For", "203.0.113.3, 198.51.100.2")
+	assertEqual(t, requestClientIP(r), "203.0.113.3")
+}
+
+func TestScopeClientIP(t *testing.T) {
+	scope := NewScope()
+	scope.SetRequest(httptest.NewRequest("GET", "/", nil))
+	event := scope.ApplyToEvent(NewEvent(), nil, nil)
+	assertEqual(t, event.sdkMetaData.clientIP, "192.0.2.1")
+	// Without PII, the address is not part of the request.
+	assertEqual(t, event.Request.Env, map[string]string(nil))
+}
+
+func TestGeoResolver(t *testing.T) {
+	for _, tr := range []Transport{NewHTTPTransport(), NewHTTPSyncTransport()} {
+		var mu sync.Mutex
+		var bodies [][]byte
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			b, _ := io.ReadAll(r.Body)
+			mu.Lock()
+			bodies = append(bodies, b)
+			mu.Unlock()
+		}))
+
+		var resolved []string
+		tr.Configure(ClientOptions{
+			Dsn: strings.Replace(srv.URL, "//", "//pubkey@", 1) + "/1",
+			GeoResolver: func(ip string) *Geo {
+				resolved = append(resolved, ip)
+				if ip == "192.0.2.1" {
+					return &Geo{CountryCode: "DE", City: "Berlin"}
+				}
+				return nil
+			},
+		})
+
+		first := NewEvent()
+		first.sdkMetaData.clientIP = "192.0.2.1"
+		tr.SendEvent(first)
+		event := NewEvent()
+		event.User.Geo = &Geo{CountryCode: "FR"}
+		event.sdkMetaData.clientIP = "192.0.2.1"
+		tr.SendEvent(event)
+		event = NewEvent()
+		event.User.IPAddress = "198.51.100.2"
+		tr.SendEvent(event)
+		if !tr.Flush(testutils.FlushTimeout()) {
+			t.Fatal("Flush timed out")
+		}
+		srv.Close()
+
+		// The event of the caller is left alone.
+		assertEqual(t, first.User.Geo, (*Geo)(nil))
+		assertEqual(t, resolved, []string{"192.0.2.1", "198.51.100.2"})
+		assertEqual(t, len(bodies), 3)
+		if !bytes.Contains(bodies[0], []byte(`"user":{"geo":{"city":"Berlin","country_code":"DE"}}`)) {
+			t.Errorf("%T: geo missing: %s", tr, bodies[0])
+		}
+		if bytes.Contains(bodies[0], []byte("192.0.2.1")) {
+			t.Errorf("%T: client IP sent: %s", tr, bodies[0])
+		}
+		if !bytes.Contains(bodies[1], []byte(`"geo":{"country_code":"FR"}`)) {
+			t.Errorf("%T: geo overwritten: %s", tr, bodies[1])
+		}
+	}
+}
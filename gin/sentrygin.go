@@ -90,7 +90,7 @@ func (h *handler) handle(c *gin.Context) {
 	}()
 
 	c.Request = c.Request.WithContext(transaction.Context())
-	hub.Scope().SetRequest(c.Request)
+	hub.SetRequest(c.Request)
 	c.Set(valuesKey, hub)
 	defer h.recoverWithSentry(hub, c.Request)
 	c.Next()
@@ -0,0 +1,74 @@
+package sentry
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// GoOptions configure Go.
+type GoOptions struct {
+	// Repanic configures whether to panic again after recovering from and
+	// reporting a panic. Note that a panic in a goroutine that is not
+	// recovered terminates the program.
+	Repanic bool
+	// WaitForDelivery configures whether to block the goroutine until the
+	// panic event was sent to Sentry, or Timeout is reached. It is implied by
+	// Repanic, as the program terminates right after the panic otherwise.
+	WaitForDelivery bool
+	// Timeout for the delivery of panic events. Defaults to 2s. Only relevant
+	// when WaitForDelivery or Repanic is true.
+	Timeout time.Duration
+	// Errors, if non-nil, receives an error for every recovered panic, after
+	// the panic was reported to Sentry. The send blocks, use a buffered channel
+	// if nobody is guaranteed to receive from it.
+	Errors chan<- error
+}
+
+// Go runs f in a new goroutine with its own hub, cloned from the hub in ctx or
+// the current hub. The context passed to f carries the cloned hub and, if ctx
+// holds a span, the new hub's scope is linked to it such that errors captured
+// in f are associated with the parent trace.
+//
+// Panics in f are recovered and reported to Sentry. See GoOptions for how to
+// handle recovered panics further.
+func Go(ctx context.Context, f func(ctx context.Context), options GoOptions) {
+	timeout := options.Timeout
+	if timeout == 0 {
+		timeout = 2 * time.Second
+	}
+
+	hub := hubFromContext(ctx).Clone()
+	if span := SpanFromContext(ctx); span != nil {
+		hub.Scope().SetSpan(span)
+	}
+	ctx = SetHubOnContext(ctx, hub)
+
+	go func() {
+		defer func() {
+			err := recover()
+			if err == nil {
+				return
+			}
+			eventID := hub.RecoverWithContext(ctx, err)
+			if eventID != nil && (options.WaitForDelivery || options.Repanic) {
+				hub.Flush(timeout)
+			}
+			if options.Errors != nil {
+				options.Errors <- panicError(err)
+			}
+			if options.Repanic {
+				panic(err)
+			}
+		}()
+		f(ctx)
+	}()
+}
+
+// panicError converts a recovered panic value to an error.
+func panicError(v interface{}) error {
+	if err, ok := v.(error); ok {
+		return fmt.Errorf("panic: %w", err)
+	}
+	return fmt.Errorf("panic: %v", v)
+}
@@ -0,0 +1,96 @@
+package sentry
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGoRecoversPanics(t *testing.T) {
+	transport := &TransportMock{}
+	ctx := NewTestContext(ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		Transport:        transport,
+	})
+	transaction := StartTransaction(ctx, "parent")
+
+	errs := make(chan error, 1)
+	Go(transaction.Context(), func(ctx context.Context) {
+		if GetHubFromContext(ctx) == GetHubFromContext(transaction.Context()) {
+			t.Error("goroutine must not share the parent hub")
+		}
+		panic(errors.New("boom"))
+	}, GoOptions{Errors: errs})
+
+	select {
+	case err := <-errs:
+		assertEqual(t, err.Error(), "panic: boom")
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the recovered panic")
+	}
+
+	events := transport.Events()
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	event := events[0]
+	assertEqual(t, event.Level, LevelFatal)
+	assertEqual(t, event.Contexts["trace"]["trace_id"], transaction.TraceID)
+	assertEqual(t, event.Contexts["trace"]["span_id"], transaction.SpanID)
+}
+
+func TestGoWithoutPanic(t *testing.T) {
+	done := make(chan struct{})
+	Go(context.Background(), func(ctx context.Context) {
+		if !HasHubOnContext(ctx) {
+			t.Error("expected a hub on the context")
+		}
+		close(done)
+	}, GoOptions{})
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the goroutine")
+	}
+}
+
+// printingTransport writes to stdout when it is flushed, such that a parent
+// process can observe it.
+type printingTransport struct {
+	TransportMock
+}
+
+func (t *printingTransport) Flush(_ time.Duration) bool {
+	if len(t.Events()) > 0 {
+		os.Stdout.WriteString("flushed\n")
+	}
+	return true
+}
+
+func TestGoRepanicFlushes(t *testing.T) {
+	// A repanicking goroutine terminates the program, run it in a subprocess.
+	if os.Getenv("SENTRY_TEST_GO_REPANIC") == "1" {
+		ctx := NewTestContext(ClientOptions{Transport: &printingTransport{}})
+		Go(ctx, func(context.Context) {
+			panic("boom")
+		}, GoOptions{Repanic: true})
+		time.Sleep(10 * time.Second)
+		return
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestGoRepanicFlushes$")
+	cmd.Env = append(os.Environ(), "SENTRY_TEST_GO_REPANIC=1")
+	out, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Fatalf("expected the subprocess to crash, output:\n%s", out)
+	}
+	if !strings.Contains(string(out), "flushed\npanic: boom") {
+		t.Errorf("expected the event to be flushed before repanicking, output:\n%s", out)
+	}
+}
@@ -0,0 +1,148 @@
+package sentry
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const graphqlContextKey = "graphql"
+
+// filteredValue replaces the values of sensitive GraphQL variables.
+const filteredValue = "[Filtered]"
+
+// sensitiveVariableNames are the substrings of variable names whose values
+// are filtered from GraphQL events. Names are compared in lower case, with
+// underscores and dashes removed.
+var sensitiveVariableNames = []string{
+	"password", "passwd", "secret", "token", "apikey", "authorization",
+	"credential", "cookie", "session", "creditcard", "cardnumber", "cvv", "ssn",
+}
+
+// GraphQLOperation describes the GraphQL operation and field a resolver error
+// occurred in. It does not depend on a particular GraphQL server library;
+// fill it in from the request and field context of the resolver.
+type GraphQLOperation struct {
+	// Name is the name of the operation, e.g. "GetUser".
+	Name string
+	// Type is the type of the operation: "query", "mutation" or
+	// "subscription".
+	Type string
+	// Path is the response path of the field whose resolver failed, e.g.
+	// []interface{}{"user", 0, "email"}.
+	Path []interface{}
+	// Resolver identifies the resolver that failed, conventionally as
+	// "Type.field", e.g. "User.email". Errors are grouped by resolver. If
+	// empty, the field names of Path are used instead.
+	Resolver string
+	// Variables are the variables of the operation. Values of variables with
+	// sensitive names, such as "password" or "token", are filtered.
+	Variables map[string]interface{}
+}
+
+// resolver returns the identifier of the resolver errors are grouped by.
+func (op GraphQLOperation) resolver() string {
+	if op.Resolver != "" {
+		return op.Resolver
+	}
+	fields := make([]string, 0, len(op.Path))
+	for _, p := range op.Path {
+		// Skip list indices, errors in any element are the same issue.
+		if s, ok := p.(string); ok {
+			fields = append(fields, s)
+		}
+	}
+	return strings.Join(fields, ".")
+}
+
+// context returns the graphql context of events for errors in op.
+func (op GraphQLOperation) context() Context {
+	c := Context{}
+	if op.Name != "" {
+		c["operation_name"] = op.Name
+	}
+	if op.Type != "" {
+		c["operation_type"] = op.Type
+	}
+	if len(op.Path) > 0 {
+		c["path"] = op.Path
+	}
+	if resolver := op.resolver(); resolver != "" {
+		c["resolver"] = resolver
+	}
+	if op.Variables != nil {
+		c["variables"] = sanitizeGraphQLVariables(op.Variables)
+	}
+	return c
+}
+
+// CaptureGraphQLError captures err, returned by a resolver while executing
+// operation. The event carries the operation in the graphql context and is
+// grouped by the resolver rather than by the endpoint that served the
+// request. ctx is passed to the client in the EventHint.
+func (hub *Hub) CaptureGraphQLError(ctx context.Context, err error, operation GraphQLOperation) *EventID {
+	client := hub.Client()
+	if client == nil {
+		return nil
+	}
+	hint := &EventHint{OriginalException: err, Context: ctx}
+	event := client.eventFromException(err, LevelError)
+	event.Contexts[graphqlContextKey] = operation.context()
+	if operation.Name != "" {
+		event.Tags["graphql.operation_name"] = operation.Name
+	}
+	if resolver := operation.resolver(); resolver != "" {
+		event.Tags["graphql.resolver"] = resolver
+		event.Fingerprint = []string{"graphql", resolver, "{{ error.type }}"}
+	}
+	return hub.CaptureEventWithHint(event, hint)
+}
+
+// CaptureGraphQLError captures a GraphQL resolver error with the hub stored in
+// ctx, if any. See Hub.CaptureGraphQLError.
+func CaptureGraphQLError(ctx context.Context, err error, operation GraphQLOperation) *EventID {
+	return hubFromContext(ctx).CaptureGraphQLError(ctx, err, operation)
+}
+
+// sanitizeGraphQLVariables returns a copy of variables with the values of
+// sensitive variables, including nested input object fields, filtered.
+func sanitizeGraphQLVariables(variables map[string]interface{}) map[string]interface{} {
+	sanitized := make(map[string]interface{}, len(variables))
+	for name, value := range variables {
+		if isSensitiveVariable(name) {
+			sanitized[name] = filteredValue
+		} else {
+			sanitized[name] = sanitizeGraphQLValue(value)
+		}
+	}
+	return sanitized
+}
+
+func sanitizeGraphQLValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return sanitizeGraphQLVariables(v)
+	case []interface{}:
+		sanitized := make([]interface{}, len(v))
+		for i, e := range v {
+			sanitized[i] = sanitizeGraphQLValue(e)
+		}
+		return sanitized
+	case string, bool, nil, int, int32, int64, float32, float64:
+		return v
+	default:
+		// Custom scalars are sent as their string representation, they
+		// may not survive the JSON encoding of the event otherwise.
+		return fmt.Sprint(v)
+	}
+}
+
+func isSensitiveVariable(name string) bool {
+	name = strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(name))
+	for _, s := range sensitiveVariableNames {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+package sentry
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestCaptureGraphQLError(t *testing.T) {
+	hub, client, _ := setupHubTest()
+	transport := client.Transport.(*TransportMock)
+
+	eventID := hub.CaptureGraphQLError(context.Background(), errors.New("user not found"), GraphQLOperation{
+		Name:     "GetUser",
+		Type:     "query",
+		Path:     []interface{}{"users", 1, "email"},
+		Resolver: "User.email",
+		Variables: map[string]interface{}{
+			"id":       "42",
+			"password": "hunter2",
+			"input": map[string]interface{}{
+				"apiKey": "secret",
+				"tags":   []interface{}{"a", map[string]interface{}{"session_token": "x"}},
+			},
+		},
+	})
+	assertEqual(t, *eventID, hub.LastEventID())
+
+	event := transport.lastEvent
+	assertEqual(t, event.Level, LevelError)
+	assertEqual(t, event.Fingerprint, []string{"graphql", "User.email", "{{ error.type }}"})
+	assertEqual(t, event.Tags["graphql.operation_name"], "GetUser")
+	assertEqual(t, event.Contexts["graphql"], Context{
+		"operation_name": "GetUser",
+		"operation_type": "query",
+		"path":           []interface{}{"users", 1, "email"},
+		"resolver":       "User.email",
+		"variables": map[string]interface{}{
+			"id":       "42",
+			"password": filteredValue,
+			"input": map[string]interface{}{
+				"apiKey": filteredValue,
+				"tags":   []interface{}{"a", map[string]interface{}{"session_token": filteredValue}},
+			},
+		},
+	})
+}
+
+func TestGraphQLOperationResolverFromPath(t *testing.T) {
+	op := GraphQLOperation{Path: []interface{}{"users", 3, "posts", 0, "title"}}
+	assertEqual(t, op.resolver(), "users.posts.title")
+}
@@ -0,0 +1,126 @@
+package sentry
+
+// GroupingStrategy selects how the client fingerprints error events, see
+// ClientOptions.GroupingStrategy. The fingerprints leave out line numbers and
+// files, so that issues survive code moving within a function, and are only
+// computed for events without a fingerprint of their own or of the scope.
+type GroupingStrategy int
+
+const (
+	// GroupingDefault leaves grouping to Sentry.
+	GroupingDefault GroupingStrategy = iota
+	// GroupingFullStack groups events by the types of their exceptions and
+	// the functions of all the frames of their stack traces.
+	GroupingFullStack
+	// GroupingTypeAndTopFrames groups events by the type of their exception
+	// and the innermost ClientOptions.GroupingFrames frames of the
+	// application in its stack trace. Changes to the callers, to the
+	// libraries and to what the compiler inlines below them don't split
+	// issues.
+	GroupingTypeAndTopFrames
+	// GroupingMessage groups events by their message, or else by the value
+	// of their exception.
+	GroupingMessage
+)
+
+func (strategy GroupingStrategy) String() string {
+	switch strategy {
+	case GroupingDefault:
+		return "default"
+	case GroupingFullStack:
+		return "full_stack"
+	case GroupingTypeAndTopFrames:
+		return "type_and_top_frames"
+	case GroupingMessage:
+		return "message"
+	}
+	return "unknown"
+}
+
+// defaultGroupingFrames is the number of frames of GroupingTypeAndTopFrames
+// unless ClientOptions.GroupingFrames is set.
+const defaultGroupingFrames = 3
+
+// applyGrouping sets the fingerprint of event according to
+// ClientOptions.GroupingStrategy, unless it already has one.
+func (client *Client) applyGrouping(event *Event) {
+	if client.options.GroupingStrategy == GroupingDefault || event.Type != "" || len(event.Fingerprint) > 0 {
+		return
+	}
+	switch client.options.GroupingStrategy {
+	case GroupingFullStack:
+		event.Fingerprint = fullStackFingerprint(event)
+	case GroupingTypeAndTopFrames:
+		n := client.options.GroupingFrames
+		if n <= 0 {
+			n = defaultGroupingFrames
+		}
+		event.Fingerprint = topFramesFingerprint(event, n)
+	case GroupingMessage:
+		event.Fingerprint = messageFingerprint(event)
+	}
+}
+
+func fullStackFingerprint(event *Event) []string {
+	var fingerprint []string
+	for _, exception := range event.Exception {
+		fingerprint = append(fingerprint, exception.Type)
+		if exception.Stacktrace != nil {
+			for _, frame := range exception.Stacktrace.Frames {
+				fingerprint = append(fingerprint, frameFingerprint(frame))
+			}
+		}
+	}
+	return fingerprint
+}
+
+// topFramesFingerprint returns the type of the innermost exception of event
+// and its innermost n frames of the application, or of any frames if it has
+// none of the application.
+func topFramesFingerprint(event *Event, n int) []string {
+	if len(event.Exception) == 0 {
+		return nil
+	}
+	exception := event.Exception[len(event.Exception)-1]
+	fingerprint := []string{exception.Type}
+	if exception.Stacktrace == nil {
+		return fingerprint
+	}
+	frames := exception.Stacktrace.Frames
+	top := innermostFrames(frames, n, true)
+	if len(top) == 0 {
+		top = innermostFrames(frames, n, false)
+	}
+	return append(fingerprint, top...)
+}
+
+// innermostFrames returns the fingerprints of the innermost n of frames, of
+// the application only if inApp is set, innermost first.
+func innermostFrames(frames []Frame, n int, inApp bool) []string {
+	var top []string
+	for i := len(frames) - 1; i >= 0 && len(top) < n; i-- {
+		if frames[i].InApp || !inApp {
+			top = append(top, frameFingerprint(frames[i]))
+		}
+	}
+	return top
+}
+
+func messageFingerprint(event *Event) []string {
+	if event.Message != "" {
+		return []string{event.Message}
+	}
+	if len(event.Exception) > 0 {
+		if value := event.Exception[len(event.Exception)-1].Value; value != "" {
+			return []string{value}
+		}
+	}
+	return nil
+}
+
+func frameFingerprint(frame Frame) string {
+	if frame.Module == "" {
+		return frame.Function
+	}
+	return frame.Module + "." + frame.Function
+}
@@ -0,0 +1,62 @@
+package sentry
+
+import (
+	"testing"
+)
+
+func TestApplyGrouping(t *testing.T) {
+	exception := func() []Exception {
+		return []Exception{
+			{Type: "*net.OpError", Value: "dial tcp: timeout"},
+			{Type: "*shop.ChargeError", Value: "charge 42 failed", Stacktrace: &Stacktrace{Frames: []Frame{
+				{Module: "main", Function: "main", Lineno: 10, InApp: true},
+				{Module: "example.com/shop", Function: "Checkout", Lineno: 20, InApp: true},
+				{Module: "example.com/shop", Function: "charge", Lineno: 30, InApp: true},
+				{Module: "net/http", Function: "(*Client).Do", Lineno: 40},
+			}}},
+		}
+	}
+	tests := []struct {
+		strategy GroupingStrategy
+		frames   int
+		event    *Event
+		want     []string
+	}{
+		{GroupingDefault, 0, &Event{Exception: exception()}, nil},
+		{GroupingFullStack, 0, &Event{Exception: exception()}, []string{
+			"*net.OpError", "*shop.ChargeError", "main.main", "example.com/shop.Checkout",
+			"example.com/shop.charge", "net/http.(*Client).Do",
+		}},
+		{GroupingTypeAndTopFrames, 0, &Event{Exception: exception()}, []string{
+			"*shop.ChargeError", "example.com/shop.charge", "example.com/shop.Checkout", "main.main",
+		}},
+		{GroupingTypeAndTopFrames, 1, &Event{Exception: exception()}, []string{
+			"*shop.ChargeError", "example.com/shop.charge",
+		}},
+		{GroupingTypeAndTopFrames, 1, &Event{Exception: []Exception{{
+			Type: "*errors.errorString", Stacktrace: &Stacktrace{Frames: []Frame{{Function: "f"}, {Function: "g"}}},
+		}}}, []string{"*errors.errorString", "g"}},
+		{GroupingMessage, 0, &Event{Exception: exception()}, []string{"charge 42 failed"}},
+		{GroupingMessage, 0, &Event{Message: "cache miss"}, []string{"cache miss"}},
+		{GroupingFullStack, 0, &Event{Exception: exception(), Fingerprint: []string{"custom"}}, []string{"custom"}},
+		{GroupingFullStack, 0, &Event{Type: transactionType}, nil},
+	}
+	for _, tt := range tests {
+		client := &Client{options: ClientOptions{GroupingStrategy: tt.strategy, GroupingFrames: tt.frames}}
+		client.applyGrouping(tt.event)
+		assertEqual(t, tt.event.Fingerprint, tt.want)
+	}
+}
+
+func TestGroupingScopeFingerprint(t *testing.T) {
+	hub, client, scope := setupHubTest()
+	client.options.GroupingStrategy = GroupingMessage
+	transport := client.Transport.(*TransportMock)
+
+	hub.CaptureMessage("cache miss")
+	assertEqual(t, transport.lastEvent.Fingerprint, []string{"cache miss"})
+
+	scope.SetFingerprint([]string{"cache"})
+	hub.CaptureMessage("cache miss")
+	assertEqual(t, transport.lastEvent.Fingerprint, []string{"cache"})
+}
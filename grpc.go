@@ -0,0 +1,116 @@
+package sentry
+
+import "context"
+
+// gRPC status codes, with the values of google.golang.org/grpc/codes. The SDK
+// doesn't depend on gRPC; convert a codes.Code with uint32(code).
+const (
+	grpcOK uint32 = iota
+	grpcCanceled
+	grpcUnknown
+	grpcInvalidArgument
+	grpcDeadlineExceeded
+	grpcNotFound
+	grpcAlreadyExists
+	grpcPermissionDenied
+	grpcResourceExhausted
+	grpcFailedPrecondition
+	grpcAborted
+	grpcOutOfRange
+	grpcUnimplemented
+	grpcInternal
+	grpcUnavailable
+	grpcDataLoss
+	grpcUnauthenticated
+	maxGRPCCode
+)
+
+// grpcCodeNames are the names of the gRPC status codes, as returned by
+// codes.Code.String.
+var grpcCodeNames = [maxGRPCCode]string{
+	"OK", "Canceled", "Unknown", "InvalidArgument", "DeadlineExceeded",
+	"NotFound", "AlreadyExists", "PermissionDenied", "ResourceExhausted",
+	"FailedPrecondition", "Aborted", "OutOfRange", "Unimplemented",
+	"Internal", "Unavailable", "DataLoss", "Unauthenticated",
+}
+
+// GRPCtoSpanStatus converts a gRPC status code to a SpanStatus.
+func GRPCtoSpanStatus(code uint32) SpanStatus {
+	if code >= maxGRPCCode {
+		return SpanStatusUnknown
+	}
+	// The span statuses follow the gRPC codes, in the same order.
+	return SpanStatusOK + SpanStatus(code)
+}
+
+// GRPCEventLevels maps the gRPC status codes that are reported to Sentry as
+// error events to the level of the events. Calls failing with any other code
+// only set the status of their span.
+type GRPCEventLevels map[uint32]Level
+
+// DefaultGRPCEventLevels reports the codes that indicate a failure of the
+// server as errors, like the other Sentry SDKs. Codes caused by the client,
+// such as NotFound or InvalidArgument, are not reported.
+var DefaultGRPCEventLevels = GRPCEventLevels{
+	grpcUnknown:          LevelError,
+	grpcDeadlineExceeded: LevelError,
+	grpcUnimplemented:    LevelError,
+	grpcInternal:         LevelError,
+	grpcUnavailable:      LevelError,
+	grpcDataLoss:         LevelError,
+}
+
+// Level returns the level of the event reported for code and whether an event
+// is reported at all. A nil map is the same as DefaultGRPCEventLevels.
+func (levels GRPCEventLevels) Level(code uint32) (Level, bool) {
+	if levels == nil {
+		levels = DefaultGRPCEventLevels
+	}
+	level, ok := levels[code]
+	return level, ok
+}
+
+// ReportGRPCStatus records the outcome of a gRPC call that finished with code
+// and err, for use in interceptors. It sets the status of span, if not nil,
+// and captures err with the hub of ctx if levels reports code as an event.
+// The metadata of the call is passed in EventHint.Metadata if the trace was
+// continued with ContinueTraceFromCarrier and a MetadataCarrier. It returns
+// the ID of the event, or nil if none was captured.
+func ReportGRPCStatus(ctx context.Context, span *Span, code uint32, err error, levels GRPCEventLevels) *EventID {
+	if span != nil {
+		span.Status = GRPCtoSpanStatus(code)
+		span.SetData("rpc.grpc.status_code", code)
+	}
+	if err == nil {
+		return nil
+	}
+	level, ok := levels.Level(code)
+	if !ok {
+		return nil
+	}
+
+	hub := hubFromContext(ctx)
+	client := hub.Client()
+	if client == nil {
+		return nil
+	}
+	hint := &EventHint{OriginalException: err, Context: ctx, Metadata: grpcMetadata(hub.Scope())}
+	event := client.eventFromException(err, client.MapSeverity(SeverityGRPCCode, code, level))
+	if code < maxGRPCCode {
+		event.Tags["grpc.status_code"] = grpcCodeNames[code]
+	}
+	return hub.CaptureEventWithHint(event, hint)
+}
+
+// grpcMetadata returns the metadata of the gRPC call scope is used for, if its
+// carrier is a MetadataCarrier.
+func grpcMetadata(scope *Scope) map[string][]string {
+	if scope == nil {
+		return nil
+	}
+	scope.mu.RLock()
+	defer scope.mu.RUnlock()
+
+	md, _ := scope.carrier.(MetadataCarrier)
+	return md
+}
@@ -0,0 +1,79 @@
+package sentry
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestGRPCtoSpanStatus(t *testing.T) {
+	tests := map[uint32]SpanStatus{
+		grpcOK:              SpanStatusOK,
+		grpcCanceled:        SpanStatusCanceled,
+		grpcNotFound:        SpanStatusNotFound,
+		grpcInternal:        SpanStatusInternalError,
+		grpcUnauthenticated: SpanStatusUnauthenticated,
+		42:                  SpanStatusUnknown,
+	}
+	for code, want := range tests {
+		assertEqual(t, GRPCtoSpanStatus(code), want)
+	}
+}
+
+func TestReportGRPCStatus(t *testing.T) {
+	ctx := NewTestContext(ClientOptions{EnableTracing: true, TracesSampleRate: 1.0})
+	transport := hubFromContext(ctx).Client().Transport.(*TransportMock)
+	err := errors.New("rpc failed")
+
+	tests := []struct {
+		name      string
+		code      uint32
+		levels    GRPCEventLevels
+		wantEvent bool
+		wantLevel Level
+	}{
+		{name: "internal by default", code: grpcInternal, wantEvent: true, wantLevel: LevelError},
+		{name: "not found by default", code: grpcNotFound},
+		{
+			name:      "not found when configured",
+			code:      grpcNotFound,
+			levels:    GRPCEventLevels{grpcNotFound: LevelWarning},
+			wantEvent: true,
+			wantLevel: LevelWarning,
+		},
+		{name: "internal when not configured", code: grpcInternal, levels: GRPCEventLevels{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport.lastEvent = nil
+			span := StartSpan(ctx, "grpc.server")
+			defer span.Finish()
+			eventID := ReportGRPCStatus(span.Context(), span, tt.code, err, tt.levels)
+
+			assertEqual(t, span.Status, GRPCtoSpanStatus(tt.code))
+			assertEqual(t, eventID != nil, tt.wantEvent)
+			if tt.wantEvent {
+				assertEqual(t, transport.lastEvent.Level, tt.wantLevel)
+				assertEqual(t, transport.lastEvent.Tags["grpc.status_code"], grpcCodeNames[tt.code])
+			}
+		})
+	}
+
+	assertEqual(t, ReportGRPCStatus(ctx, nil, grpcOK, nil, nil) == nil, true)
+}
+
+func TestReportGRPCStatusMetadata(t *testing.T) {
+	var hints []*EventHint
+	ctx := NewTestContext(ClientOptions{
+		BeforeSend: func(event *Event, hint *EventHint) *Event {
+			hints = append(hints, hint)
+			return event
+		},
+	})
+	md := map[string][]string{"user-agent": {"grpc-go/1.60.0"}}
+	ContinueTraceFromCarrier(ctx, MetadataCarrier(md))
+
+	ReportGRPCStatus(ctx, nil, grpcInternal, errors.New("rpc failed"), nil)
+	assertEqual(t, len(hints), 1)
+	assertEqual(t, hints[0].Metadata, md)
+	assertEqual(t, hints[0].Carrier, TraceCarrier(MetadataCarrier(md)))
+}
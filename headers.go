@@ -0,0 +1,79 @@
+package sentry
+
+import (
+	"net/http"
+	"strings"
+)
+
+// DefaultHeaderDenylist are the headers whose values are filtered from
+// events and breadcrumbs unless ClientOptions.HeaderDenylist is set. To add
+// to them, set HeaderDenylist to append(DefaultHeaderDenylist, ...).
+var DefaultHeaderDenylist = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Api-Key",
+}
+
+// FilterHeaders applies ClientOptions.HeaderAllowlist and HeaderDenylist
+// to headers in place: headers that are not allowed are removed, the values
+// of denied ones are replaced with "[Filtered]". The Host header is always
+// kept. Integrations attaching headers to events call it for users; it is
+// safe to call on a nil client, which applies the defaults.
+func (client *Client) FilterHeaders(headers map[string]string) {
+	var allow, deny []string
+	if client != nil {
+		allow, deny = client.options.HeaderAllowlist, client.options.HeaderDenylist
+	}
+	if deny == nil {
+		deny = DefaultHeaderDenylist
+	}
+	for name := range headers {
+		if name == "Host" {
+			continue
+		}
+		if len(allow) > 0 && !containsFold(allow, name) {
+			delete(headers, name)
+		} else if containsFold(deny, name) {
+			headers[name] = filteredValue
+		}
+	}
+}
+
+// filterBreadcrumbHeaders applies FilterHeaders to the "headers" data of
+// breadcrumb, if any. The data is copied rather than modified.
+func (client *Client) filterBreadcrumbHeaders(breadcrumb *Breadcrumb) {
+	var headers map[string]string
+	switch h := breadcrumb.Data["headers"].(type) {
+	case map[string]string:
+		headers = make(map[string]string, len(h))
+		for k, v := range h {
+			headers[k] = v
+		}
+	case http.Header:
+		headers = make(map[string]string, len(h))
+		for k, v := range h {
+			headers[k] = strings.Join(v, ",")
+		}
+	default:
+		return
+	}
+	client.FilterHeaders(headers)
+	data := make(map[string]interface{}, len(breadcrumb.Data))
+	for k, v := range breadcrumb.Data {
+		data[k] = v
+	}
+	data["headers"] = headers
+	breadcrumb.Data = data
+}
+
+// containsFold reports whether names contains name, ignoring case.
+func containsFold(names []string, name string) bool {
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
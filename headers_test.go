@@ -0,0 +1,63 @@
+package sentry
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestFilterHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		options ClientOptions
+		want    map[string]string
+	}{
+		{
+			name: "default denylist",
+			want: map[string]string{
+				"Host": "example.com", "Authorization": "[Filtered]", "X-Api-Key": "[Filtered]",
+				"X-Tenant-Secret": "s", "Accept": "*/*",
+			},
+		},
+		{
+			name:    "custom denylist",
+			options: ClientOptions{HeaderDenylist: append(DefaultHeaderDenylist, "x-tenant-secret")},
+			want: map[string]string{
+				"Host": "example.com", "Authorization": "[Filtered]", "X-Api-Key": "[Filtered]",
+				"X-Tenant-Secret": "[Filtered]", "Accept": "*/*",
+			},
+		},
+		{
+			name:    "allowlist",
+			options: ClientOptions{HeaderAllowlist: []string{"Accept", "Authorization"}},
+			want:    map[string]string{"Host": "example.com", "Authorization": "[Filtered]", "Accept": "*/*"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := map[string]string{
+				"Host": "example.com", "Authorization": "Bearer x", "X-Api-Key": "k",
+				"X-Tenant-Secret": "s", "Accept": "*/*",
+			}
+			client := &Client{options: tt.options}
+			client.FilterHeaders(headers)
+			assertEqual(t, headers, tt.want)
+		})
+	}
+
+	var client *Client
+	headers := map[string]string{"Cookie": "a=b"}
+	client.FilterHeaders(headers)
+	assertEqual(t, headers, map[string]string{"Cookie": "[Filtered]"})
+}
+
+func TestBreadcrumbHeaders(t *testing.T) {
+	hub, _, scope := setupHubTest()
+	header := http.Header{"Authorization": {"Bearer x"}, "Accept": {"*/*"}}
+	hub.AddBreadcrumb(&Breadcrumb{Category: "http", Data: map[string]interface{}{"headers": header, "url": "/"}}, nil)
+
+	breadcrumbs := scope.breadcrumbs.list()
+	assertEqual(t, len(breadcrumbs), 1)
+	assertEqual(t, breadcrumbs[0].Data["headers"], map[string]string{"Authorization": "[Filtered]", "Accept": "*/*"})
+	assertEqual(t, breadcrumbs[0].Data["url"], "/")
+	assertEqual(t, header.Get("Authorization"), "Bearer x")
+}
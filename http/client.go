@@ -0,0 +1,290 @@
+package sentryhttp
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// A StatusCodeRange is an inclusive range of HTTP status codes.
+type StatusCodeRange struct {
+	Min int
+	Max int
+}
+
+// Contains reports whether code is within the range.
+func (r StatusCodeRange) Contains(code int) bool {
+	return code >= r.Min && code <= r.Max
+}
+
+// defaultFailedRequestStatusCodes are the status codes reported as failed
+// requests unless configured otherwise, matching the other Sentry SDKs.
+var defaultFailedRequestStatusCodes = []StatusCodeRange{{Min: 500, Max: 599}}
+
+// sensitiveResponseHeaders are left out of the response context unless
+// cookies are allowed by the PII options of the client.
+var sensitiveResponseHeaders = map[string]struct{}{
+	"Set-Cookie": {},
+}
+
+// TransportOptions configure a Transport.
+type TransportOptions struct {
+	// FailedRequestStatusCodes are the response status codes for which an
+	// error event is reported to Sentry. Defaults to 500-599. Set it to an
+	// empty, non-nil slice to disable failed-request events.
+	FailedRequestStatusCodes []StatusCodeRange
+	// FailedRequestTargets restricts failed-request events to requests whose
+	// URL matches at least one of the regular expressions. If empty, failed
+	// requests to any URL are reported.
+	FailedRequestTargets []*regexp.Regexp
+	// BreadcrumbSampleEvery, if greater than 1, records a breadcrumb for only
+	// every nth request to each host, starting with the first. Use it for
+	// chatty internal APIs. By default, every request is recorded.
+	BreadcrumbSampleEvery int
+	// DisableBreadcrumbs disables the breadcrumbs recorded for requests.
+	DisableBreadcrumbs bool
+	// StripQueryString leaves the query string of request URLs out of
+	// breadcrumbs and failed-request events.
+	StripQueryString bool
+	// RedactHeaders are the names of request and response headers whose
+	// values are replaced with "[Filtered]" in failed-request events.
+	RedactHeaders []string
+}
+
+// filteredHeaderValue replaces the values of redacted headers.
+const filteredHeaderValue = "[Filtered]"
+
+// A Transport is an http.RoundTripper that instruments outgoing requests. It
+// records every request as an "http.client" span of the span in the request
+// context and as a breadcrumb, propagates the trace to the server and reports
+// responses with a failed status code to Sentry as errors.
+type Transport struct {
+	base                     http.RoundTripper
+	failedRequestStatusCodes []StatusCodeRange
+	failedRequestTargets     []*regexp.Regexp
+	breadcrumbSampleEvery    int
+	disableBreadcrumbs       bool
+	stripQueryString         bool
+	redactHeaders            map[string]struct{}
+
+	mu sync.Mutex
+	// requestsByHost counts the requests to each host while breadcrumbs
+	// are sampled.
+	requestsByHost map[string]int
+}
+
+// NewTransport returns a new Transport that sends requests with base, or with
+// http.DefaultTransport if base is nil.
+//
+//	client := &http.Client{Transport: sentryhttp.NewTransport(nil, sentryhttp.TransportOptions{})}
+func NewTransport(base http.RoundTripper, options TransportOptions) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	statusCodes := options.FailedRequestStatusCodes
+	if statusCodes == nil {
+		statusCodes = defaultFailedRequestStatusCodes
+	}
+	redactHeaders := make(map[string]struct{}, len(options.RedactHeaders))
+	for _, h := range options.RedactHeaders {
+		redactHeaders[http.CanonicalHeaderKey(h)] = struct{}{}
+	}
+	return &Transport{
+		base:                     base,
+		failedRequestStatusCodes: statusCodes,
+		failedRequestTargets:     options.FailedRequestTargets,
+		breadcrumbSampleEvery:    options.BreadcrumbSampleEvery,
+		disableBreadcrumbs:       options.DisableBreadcrumbs,
+		stripQueryString:         options.StripQueryString,
+		redactHeaders:            redactHeaders,
+		requestsByHost:           make(map[string]int),
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	ctx := r.Context()
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub()
+	}
+
+	var span *sentry.Span
+	if sentry.SpanFromContext(ctx) != nil {
+		span = sentry.StartSpan(ctx, "http.client",
+			sentry.WithDescription(fmt.Sprintf("%s %s", r.Method, stripURL(r))),
+			sentry.WithSpanOrigin(sentry.SpanOriginStdLib),
+		)
+		defer span.Finish()
+		span.SetData("http.request.method", r.Method)
+		span.SetData("url", stripURL(r))
+		span.SetData("server.address", r.URL.Hostname())
+		ctx = span.Context()
+	}
+
+	// A RoundTripper must not modify the request it is given.
+	r = r.Clone(ctx)
+	sentry.InjectTraceHeaders(ctx, r.Header)
+
+	resp, err := t.base.RoundTrip(r)
+	t.addBreadcrumb(hub, r, resp)
+	if err != nil {
+		if span != nil {
+			span.Status = sentry.SpanStatusInternalError
+		}
+		return nil, err
+	}
+
+	if span != nil {
+		span.Status = sentry.HTTPtoSpanStatus(resp.StatusCode)
+		span.SetData("http.response.status_code", resp.StatusCode)
+	}
+	if t.isFailedRequest(r, resp) {
+		hub.CaptureEventWithHint(t.failedRequestEvent(hub, r, resp), &sentry.EventHint{
+			Context:    r.Context(),
+			Request:    r,
+			Response:   resp,
+			StatusCode: resp.StatusCode,
+		})
+	}
+	return resp, nil
+}
+
+// addBreadcrumb records the request r as a breadcrumb, unless breadcrumbs are
+// disabled or r is not sampled. resp is nil if the request failed.
+func (t *Transport) addBreadcrumb(hub *sentry.Hub, r *http.Request, resp *http.Response) {
+	if t.disableBreadcrumbs || !t.sampleBreadcrumb(r.URL.Host) {
+		return
+	}
+	data := map[string]interface{}{
+		"url":    stripURL(r),
+		"method": r.Method,
+	}
+	if r.URL.RawQuery != "" && !t.stripQueryString {
+		data["http.query"] = r.URL.RawQuery
+	}
+	breadcrumb := &sentry.Breadcrumb{
+		Type:     "http",
+		Category: "http",
+		Data:     data,
+		Level:    sentry.LevelInfo,
+	}
+	hint := &sentry.BreadcrumbHint{"request": r}
+	if resp == nil {
+		breadcrumb.Level = sentry.LevelError
+	} else {
+		data["status_code"] = resp.StatusCode
+		(*hint)["response"] = resp
+		switch {
+		case resp.StatusCode >= 500:
+			breadcrumb.Level = sentry.LevelError
+		case resp.StatusCode >= 400:
+			breadcrumb.Level = sentry.LevelWarning
+		}
+		breadcrumb.Level = hub.Client().MapSeverity(sentry.SeverityHTTPStatus, resp.StatusCode, breadcrumb.Level)
+	}
+	hub.AddBreadcrumb(breadcrumb, hint)
+}
+
+// sampleBreadcrumb reports whether to record a breadcrumb for the next
+// request to host.
+func (t *Transport) sampleBreadcrumb(host string) bool {
+	if t.breadcrumbSampleEvery <= 1 {
+		return true
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	n := t.requestsByHost[host]
+	t.requestsByHost[host] = (n + 1) % t.breadcrumbSampleEvery
+	return n == 0
+}
+
+// isFailedRequest reports whether resp is to be reported as an error.
+func (t *Transport) isFailedRequest(r *http.Request, resp *http.Response) bool {
+	failed := false
+	for _, codes := range t.failedRequestStatusCodes {
+		if codes.Contains(resp.StatusCode) {
+			failed = true
+			break
+		}
+	}
+	if !failed || len(t.failedRequestTargets) == 0 {
+		return failed
+	}
+	url := r.URL.String()
+	for _, target := range t.failedRequestTargets {
+		if target.MatchString(url) {
+			return true
+		}
+	}
+	return false
+}
+
+// failedRequestEvent returns the event reported for the failed request r,
+// with the request and the response attached as context.
+func (t *Transport) failedRequestEvent(hub *sentry.Hub, r *http.Request, resp *http.Response) *sentry.Event {
+	message := fmt.Sprintf("HTTP Client Error with status code: %d", resp.StatusCode)
+
+	event := sentry.NewEvent()
+	event.Level = hub.Client().MapSeverity(sentry.SeverityHTTPStatus, resp.StatusCode, sentry.LevelError)
+	event.Message = message
+	event.Exception = []sentry.Exception{{
+		Type:      "HTTPClientError",
+		Value:     message,
+		Mechanism: &sentry.Mechanism{Type: "http.client"},
+	}}
+
+	// NewRequest describes incoming requests, fix up the URL and host from
+	// the request URL of the client.
+	request := sentry.NewRequest(r)
+	request.URL = stripURL(r)
+	if r.Host == "" {
+		request.Headers["Host"] = r.URL.Host
+	}
+	if t.stripQueryString {
+		request.QueryString = ""
+	}
+	for k := range request.Headers {
+		if _, ok := t.redactHeaders[k]; ok {
+			request.Headers[k] = filteredHeaderValue
+		}
+	}
+	event.Request = request
+
+	sendCookies := hub.Client().PII().Cookies
+	headers := map[string]string{}
+	for k, v := range resp.Header {
+		if _, ok := sensitiveResponseHeaders[k]; ok && !sendCookies {
+			continue
+		}
+		headers[k] = strings.Join(v, ",")
+	}
+	hub.Client().FilterHeaders(headers)
+	for k := range headers {
+		if _, ok := t.redactHeaders[k]; ok {
+			headers[k] = filteredHeaderValue
+		}
+	}
+	response := sentry.Context{
+		"status_code": resp.StatusCode,
+		"headers":     headers,
+	}
+	if resp.ContentLength >= 0 {
+		response["body_size"] = resp.ContentLength
+	}
+	event.Contexts["response"] = response
+
+	return event
+}
+
+// stripURL returns the URL of r without query string, fragment and user
+// information.
+func stripURL(r *http.Request) string {
+	u := *r.URL
+	u.User, u.RawQuery, u.Fragment, u.RawFragment = nil, "", "", ""
+	return u.String()
+}
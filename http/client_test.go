@@ -0,0 +1,242 @@
+package sentryhttp_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+	sentryhttp "github.com/getsentry/sentry-go/http"
+)
+
+func TestTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(sentry.SentryTraceHeader) == "" {
+			t.Errorf("missing %s header", sentry.SentryTraceHeader)
+		}
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.Header().Set("X-Request-Id", "abc")
+		switch r.URL.Path {
+		case "/error":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "/not-found":
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name       string
+		options    sentryhttp.TransportOptions
+		path       string
+		wantEvent  bool
+		wantStatus sentry.SpanStatus
+	}{
+		{
+			name:       "ok",
+			path:       "/ok",
+			wantStatus: sentry.SpanStatusOK,
+		},
+		{
+			name:       "server error",
+			path:       "/error?token=secret",
+			wantEvent:  true,
+			wantStatus: sentry.SpanStatusInternalError,
+		},
+		{
+			name:       "not found by default",
+			path:       "/not-found",
+			wantStatus: sentry.SpanStatusNotFound,
+		},
+		{
+			name: "not found when configured",
+			options: sentryhttp.TransportOptions{
+				FailedRequestStatusCodes: []sentryhttp.StatusCodeRange{{Min: 400, Max: 499}},
+			},
+			path:       "/not-found",
+			wantEvent:  true,
+			wantStatus: sentry.SpanStatusNotFound,
+		},
+		{
+			name: "disabled",
+			options: sentryhttp.TransportOptions{
+				FailedRequestStatusCodes: []sentryhttp.StatusCodeRange{},
+			},
+			path:       "/error",
+			wantStatus: sentry.SpanStatusInternalError,
+		},
+		{
+			name: "target not matched",
+			options: sentryhttp.TransportOptions{
+				FailedRequestTargets: []*regexp.Regexp{regexp.MustCompile(`/other`)},
+			},
+			path:       "/error",
+			wantStatus: sentry.SpanStatusInternalError,
+		},
+		{
+			name: "target matched",
+			options: sentryhttp.TransportOptions{
+				FailedRequestTargets: []*regexp.Regexp{regexp.MustCompile(`/err`)},
+			},
+			path:       "/error",
+			wantEvent:  true,
+			wantStatus: sentry.SpanStatusInternalError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var events, transactions []*sentry.Event
+			var hints []*sentry.EventHint
+			client, err := sentry.NewClient(sentry.ClientOptions{
+				EnableTracing:    true,
+				TracesSampleRate: 1.0,
+				BeforeSend: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+					events = append(events, event)
+					hints = append(hints, hint)
+					return nil
+				},
+				BeforeSendTransaction: func(event *sentry.Event, _ *sentry.EventHint) *sentry.Event {
+					transactions = append(transactions, event)
+					return nil
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			hub := sentry.NewHub(client, sentry.NewScope())
+			ctx := sentry.SetHubOnContext(context.Background(), hub)
+			transaction := sentry.StartTransaction(ctx, "test")
+
+			httpClient := &http.Client{Transport: sentryhttp.NewTransport(nil, tt.options)}
+			req, err := http.NewRequestWithContext(transaction.Context(), http.MethodGet, srv.URL+tt.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := httpClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if req.Header.Get(sentry.SentryTraceHeader) != "" {
+				t.Error("the request of the caller was modified")
+			}
+			transaction.Finish()
+
+			if len(transactions) != 1 || len(transactions[0].Spans) != 1 {
+				t.Fatalf("got transactions %v, want one with one span", transactions)
+			}
+			span := transactions[0].Spans[0]
+			if span.Op != "http.client" || span.Status != tt.wantStatus {
+				t.Errorf("got span %q with status %v, want http.client with %v", span.Op, span.Status, tt.wantStatus)
+			}
+			path, _, _ := strings.Cut(tt.path, "?")
+			wantURL := srv.URL + path
+			if want := "GET " + wantURL; span.Description != want {
+				t.Errorf("span description = %q, want %q", span.Description, want)
+			}
+
+			if !tt.wantEvent {
+				if len(events) != 0 {
+					t.Fatalf("got %d events, want none", len(events))
+				}
+				return
+			}
+			if len(events) != 1 {
+				t.Fatalf("got %d events, want 1", len(events))
+			}
+			event := events[0]
+			if event.Level != sentry.LevelError || event.Exception[0].Mechanism.Type != "http.client" {
+				t.Errorf("unexpected event %+v", event)
+			}
+			if event.Request.URL != wantURL {
+				t.Errorf("request URL = %q", event.Request.URL)
+			}
+			response := event.Contexts["response"]
+			if response["status_code"] != span.Data["http.response.status_code"] {
+				t.Errorf("response status_code = %v, span has %v", response["status_code"], span.Data["http.response.status_code"])
+			}
+			headers := response["headers"].(map[string]string)
+			if _, ok := headers["Set-Cookie"]; ok {
+				t.Error("Set-Cookie header was not scrubbed")
+			}
+			if headers["X-Request-Id"] != "abc" {
+				t.Errorf("headers = %v, want X-Request-Id", headers)
+			}
+			if hint := hints[0]; hint.Request == nil || hint.Response == nil || hint.StatusCode != resp.StatusCode {
+				t.Errorf("hint = %+v, want the request and the response", hint)
+			}
+		})
+	}
+}
+
+func TestTransportBreadcrumbs(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/error" {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+
+	var breadcrumbs []*sentry.Breadcrumb
+	var events []*sentry.Event
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		BeforeBreadcrumb: func(breadcrumb *sentry.Breadcrumb, hint *sentry.BreadcrumbHint) *sentry.Breadcrumb {
+			if _, ok := (*hint)["request"].(*http.Request); !ok {
+				t.Errorf("breadcrumb hint without request: %v", hint)
+			}
+			breadcrumbs = append(breadcrumbs, breadcrumb)
+			return breadcrumb
+		},
+		BeforeSend: func(event *sentry.Event, _ *sentry.EventHint) *sentry.Event {
+			events = append(events, event)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := sentry.SetHubOnContext(context.Background(), sentry.NewHub(client, sentry.NewScope()))
+
+	httpClient := &http.Client{Transport: sentryhttp.NewTransport(nil, sentryhttp.TransportOptions{
+		BreadcrumbSampleEvery: 3,
+		StripQueryString:      true,
+		RedactHeaders:         []string{"x-api-key"},
+	})}
+	for _, path := range []string{"/a?token=1", "/b", "/c", "/error?token=2", "/d", "/e", "/f"} {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("X-Api-Key", "secret")
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	var urls []string
+	for _, b := range breadcrumbs {
+		urls = append(urls, b.Data["url"].(string))
+		if _, ok := b.Data["http.query"]; ok {
+			t.Errorf("breadcrumb with query string: %v", b.Data)
+		}
+	}
+	if want := []string{srv.URL + "/a", srv.URL + "/error", srv.URL + "/f"}; strings.Join(urls, " ") != strings.Join(want, " ") {
+		t.Errorf("breadcrumb URLs = %v, want %v", urls, want)
+	}
+	if breadcrumbs[1].Level != sentry.LevelError || breadcrumbs[1].Data["status_code"] != http.StatusBadGateway {
+		t.Errorf("breadcrumb of failed request = %+v", breadcrumbs[1])
+	}
+
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	request := events[0].Request
+	if request.QueryString != "" || request.Headers["X-Api-Key"] != "[Filtered]" {
+		t.Errorf("request of failed-request event = %+v", request)
+	}
+}
@@ -1,5 +1,5 @@
-// Package sentryhttp provides Sentry integration for servers based on the
-// net/http package.
+// Package sentryhttp provides Sentry integration for servers and clients based
+// on the net/http package.
 package sentryhttp
 
 import (
@@ -20,6 +20,7 @@ type Handler struct {
 	repanic         bool
 	waitForDelivery bool
 	timeout         time.Duration
+	userExtractor   sentry.UserExtractor
 }
 
 // Options configure a Handler.
@@ -47,6 +48,10 @@ type Options struct {
 	// If the timeout is reached, the current goroutine is no longer blocked
 	// waiting, but the delivery is not canceled.
 	Timeout time.Duration
+	// UserExtractor, if set, identifies the user of each request, such as
+	// sentry.JWTUser or sentry.BasicAuthUser, and sets it on the scope of the
+	// request.
+	UserExtractor sentry.UserExtractor
 }
 
 // New returns a new Handler. Use the Handle and HandleFunc methods to wrap
@@ -60,6 +65,7 @@ func New(options Options) *Handler {
 		repanic:         options.Repanic,
 		timeout:         timeout,
 		waitForDelivery: options.WaitForDelivery,
+		userExtractor:   options.UserExtractor,
 	}
 }
 
@@ -120,7 +126,8 @@ func (h *Handler) handle(handler http.Handler) http.HandlerFunc {
 		// information on the transaction accordingly (status, tag,
 		// level?, ...).
 		r = r.WithContext(transaction.Context())
-		hub.Scope().SetRequest(r)
+		hub.SetRequest(r)
+		hub.ExtractUser(r.Context(), r.Header, h.userExtractor)
 
 		defer h.recoverWithSentry(hub, r)
 		handler.ServeHTTP(rw, r)
@@ -356,3 +356,32 @@ func TestIntegration(t *testing.T) {
 		t.Fatalf("Transaction status codes mismatch (-want +got):\n%s", diff)
 	}
 }
+
+func TestUserExtractor(t *testing.T) {
+	var got *sentry.Event
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		BeforeSend: func(event *sentry.Event, _ *sentry.EventHint) *sentry.Event {
+			got = event
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+
+	handler := sentryhttp.New(sentryhttp.Options{UserExtractor: sentry.HeaderUser("X-User-Id")}).
+		HandleFunc(func(_ http.ResponseWriter, r *http.Request) {
+			sentry.GetHubFromContext(r.Context()).CaptureMessage("test")
+		})
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-User-Id", "42")
+	handler(httptest.NewRecorder(), r.WithContext(sentry.SetHubOnContext(r.Context(), hub)))
+
+	if got == nil {
+		t.Fatal("no event captured")
+	}
+	if diff := cmp.Diff(sentry.User{ID: "42"}, got.User); diff != "" {
+		t.Errorf("User mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -2,6 +2,7 @@ package sentry
 
 import (
 	"context"
+	"net/http"
 	"sync"
 	"time"
 )
@@ -42,8 +43,22 @@ type Hub struct {
 	mu          sync.RWMutex
 	stack       *stack
 	lastEventID EventID
+	// isolationScope is shared by all layers of the stack. Never nil.
+	isolationScope *Scope
+	// router and routedClients are set by BindClients, protected by mu.
+	router        EventRouter
+	routedClients []*Client
 }
 
+// EventRouter selects the client that captures an event, for hubs bound to
+// multiple clients with Hub.BindClients. This allows sending, for example, the
+// errors of one service to a separate Sentry project.
+//
+// The event has the tags of the scopes it is captured with already set, but
+// is otherwise not yet processed. Modifications of the event made by the
+// router are discarded. Returning nil selects the client of the hub.
+type EventRouter func(event *Event, hint *EventHint) *Client
+
 type layer struct {
 	// mu protects concurrent reads and writes to client.
 	mu     sync.RWMutex
@@ -75,6 +90,7 @@ func NewHub(client *Client, scope *Scope) *Hub {
 			client: client,
 			scope:  scope,
 		}},
+		isolationScope: NewScope(),
 	}
 	return &hub
 }
@@ -116,13 +132,33 @@ func (hub *Hub) stackTop() *layer {
 }
 
 // Clone returns a copy of the current Hub with top-most scope and client copied over.
+//
+// The isolation scope is copied as well, so cloning a hub, as HTTP middleware
+// does for every request, isolates the data of the request from other
+// requests.
 func (hub *Hub) Clone() *Hub {
 	top := hub.stackTop()
 	scope := top.scope
 	if scope != nil {
 		scope = scope.Clone()
 	}
-	return NewHub(top.Client(), scope)
+	isolationScope := hub.isolationScope
+	if isolationScope != nil {
+		isolationScope = isolationScope.Clone()
+	} else {
+		isolationScope = NewScope()
+	}
+	hub.mu.RLock()
+	defer hub.mu.RUnlock()
+	return &Hub{
+		stack: &stack{{
+			client: top.Client(),
+			scope:  scope,
+		}},
+		isolationScope: isolationScope,
+		router:         hub.router,
+		routedClients:  hub.routedClients,
+	}
 }
 
 // Scope returns top-level Scope of the current Hub or nil if no Scope is bound.
@@ -131,6 +167,29 @@ func (hub *Hub) Scope() *Scope {
 	return top.scope
 }
 
+// IsolationScope returns the isolation scope of the hub.
+//
+// Unlike the current scope returned by Scope, the isolation scope is not
+// affected by PushScope and PopScope: data set on it from within WithScope
+// persists and is applied to all events captured through the hub. Its data is
+// applied after the data of the global scope and before the data of the
+// current scope. Use it to set data in middleware that must end up on events
+// captured deeper in the call stack.
+func (hub *Hub) IsolationScope() *Scope {
+	return hub.isolationScope
+}
+
+// eventScope returns the scope to apply to events captured through the hub,
+// combining the isolation scope and the current scope. It returns nil if the
+// hub has no current scope.
+func (hub *Hub) eventScope() *Scope {
+	scope := hub.Scope()
+	if scope == nil || hub.isolationScope == nil || hub.isolationScope.isEmpty() {
+		return scope
+	}
+	return mergeScopes(hub.isolationScope, scope)
+}
+
 // Client returns top-level Client of the current Hub or nil if no Client is bound.
 func (hub *Hub) Client() *Client {
 	top := hub.stackTop()
@@ -185,6 +244,50 @@ func (hub *Hub) BindClient(client *Client) {
 	top.SetClient(client)
 }
 
+// BindClients binds client to the hub like BindClient, and additionally
+// routes events captured through the hub to the client returned by router.
+// Events are created with the options of client, but are processed and sent
+// by the client they are routed to.
+//
+// others lists the clients router may return, such that they are flushed
+// together with client by Flush. Calling BindClients with a nil router
+// disables routing.
+func (hub *Hub) BindClients(client *Client, router EventRouter, others ...*Client) {
+	hub.BindClient(client)
+
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	hub.router = router
+	hub.routedClients = nil
+	if router != nil {
+		hub.routedClients = append([]*Client(nil), others...)
+	}
+}
+
+// route returns the client to capture event with, client unless the hint or
+// the router of the hub selects a different one.
+func (hub *Hub) route(client *Client, event *Event, hint *EventHint, scope *Scope) *Client {
+	if hint != nil && hint.Client != nil {
+		return hint.Client
+	}
+	hub.mu.RLock()
+	router := hub.router
+	hub.mu.RUnlock()
+	if router == nil || event == nil {
+		return client
+	}
+	event.resolveStacktraces()
+
+	// The scope is applied to the event by the client it is routed to, so
+	// let the router see the tags on a copy.
+	routed := *event
+	routed.Tags = scope.applyTags(globalScope.applyTags(event.Tags))
+	if c := router(&routed, hint); c != nil {
+		return c
+	}
+	return client
+}
+
 // WithScope runs f in an isolated temporary scope.
 //
 // It is useful when extra data should be sent with a single capture call, for
@@ -217,11 +320,28 @@ func (hub *Hub) ConfigureScope(f func(scope *Scope)) {
 // passing it a top-level Scope.
 // Returns EventID if successfully, or nil if there's no Scope or Client available.
 func (hub *Hub) CaptureEvent(event *Event) *EventID {
-	client, scope := hub.Client(), hub.Scope()
+	client, scope := hub.Client(), hub.eventScope()
 	if client == nil || scope == nil {
 		return nil
 	}
-	eventID := client.CaptureEvent(event, nil, scope)
+	eventID := hub.route(client, event, nil, scope).CaptureEvent(event, nil, scope)
+
+	if event.Type != transactionType && eventID != nil {
+		hub.mu.Lock()
+		hub.lastEventID = *eventID
+		hub.mu.Unlock()
+	}
+	return eventID
+}
+
+// CaptureEventWithHint is like CaptureEvent, but passes hint to the client
+// along with the event.
+func (hub *Hub) CaptureEventWithHint(event *Event, hint *EventHint) *EventID {
+	client, scope := hub.Client(), hub.eventScope()
+	if client == nil || scope == nil {
+		return nil
+	}
+	eventID := hub.route(client, event, hint, scope).CaptureEvent(event, hint, scope)
 
 	if event.Type != transactionType && eventID != nil {
 		hub.mu.Lock()
@@ -235,11 +355,12 @@ func (hub *Hub) CaptureEvent(event *Event) *EventID {
 // passing it a top-level Scope.
 // Returns EventID if successfully, or nil if there's no Scope or Client available.
 func (hub *Hub) CaptureMessage(message string) *EventID {
-	client, scope := hub.Client(), hub.Scope()
+	client, scope := hub.Client(), hub.eventScope()
 	if client == nil || scope == nil {
 		return nil
 	}
-	eventID := client.CaptureMessage(message, nil, scope)
+	event := client.eventFromMessage(message, LevelInfo)
+	eventID := hub.route(client, event, nil, scope).CaptureEvent(event, nil, scope)
 
 	if eventID != nil {
 		hub.mu.Lock()
@@ -249,15 +370,85 @@ func (hub *Hub) CaptureMessage(message string) *EventID {
 	return eventID
 }
 
+// CaptureMessageThrottled captures message like CaptureMessage, but collapses
+// the occurrences of the same message within window into a single event,
+// for log-style messages emitted in tight loops. The event is sent when the
+// window of the first occurrence ends, or when the client is flushed, with
+// the number of occurrences in the "occurrences" extra. It is captured with
+// the scope of the first occurrence.
+//
+// All occurrences return the ID of the event. Returns nil if there's no Scope
+// or Client available.
+func (hub *Hub) CaptureMessageThrottled(message string, window time.Duration) *EventID {
+	client, scope := hub.Client(), hub.eventScope()
+	if client == nil || scope == nil {
+		return nil
+	}
+	eventID := client.throttle.add(message, window, func() *throttledMessage {
+		event := client.eventFromMessage(message, LevelInfo)
+		event.EventID = EventID(uuid())
+		return &throttledMessage{
+			client: hub.route(client, event, nil, scope),
+			event:  event,
+			scope:  scope.Clone(),
+		}
+	})
+
+	hub.mu.Lock()
+	hub.lastEventID = eventID
+	hub.mu.Unlock()
+	return &eventID
+}
+
 // CaptureException calls the method of a same name on currently bound Client instance
 // passing it a top-level Scope.
 // Returns EventID if successfully, or nil if there's no Scope or Client available.
 func (hub *Hub) CaptureException(exception error) *EventID {
-	client, scope := hub.Client(), hub.Scope()
+	client, scope := hub.Client(), hub.eventScope()
 	if client == nil || scope == nil {
 		return nil
 	}
-	eventID := client.CaptureException(exception, &EventHint{OriginalException: exception}, scope)
+	hint := &EventHint{OriginalException: exception}
+	event := client.eventFromException(exception, client.MapSeverity(SeverityError, exception, LevelError))
+	eventID := hub.route(client, event, hint, scope).CaptureEvent(event, hint, scope)
+
+	if eventID != nil {
+		hub.mu.Lock()
+		hub.lastEventID = *eventID
+		hub.mu.Unlock()
+	}
+	return eventID
+}
+
+// CaptureExceptionWithHint is like CaptureException, but passes hint to the
+// client, for example to send the event with hint.Client. The
+// OriginalException of the hint is set to exception if it is nil.
+func (hub *Hub) CaptureExceptionWithHint(exception error, hint *EventHint) *EventID {
+	client := hub.Client()
+	if client == nil {
+		return nil
+	}
+	if hint == nil {
+		hint = &EventHint{}
+	}
+	if hint.OriginalException == nil {
+		hint.OriginalException = exception
+	}
+	event := client.eventFromException(exception, client.MapSeverity(SeverityError, exception, LevelError))
+	return hub.CaptureEventWithHint(event, hint)
+}
+
+// CaptureExceptionWithContext is like CaptureException, but additionally
+// passes ctx to the client in the EventHint. If ctx is already canceled or
+// past its deadline at capture time, the event is tagged accordingly.
+func (hub *Hub) CaptureExceptionWithContext(ctx context.Context, exception error) *EventID {
+	client, scope := hub.Client(), hub.eventScope()
+	if client == nil || scope == nil {
+		return nil
+	}
+	hint := &EventHint{OriginalException: exception, Context: ctx}
+	event := client.eventFromException(exception, client.MapSeverity(SeverityError, exception, LevelError))
+	eventID := hub.route(client, event, hint, scope).CaptureEvent(event, hint, scope)
 
 	if eventID != nil {
 		hub.mu.Lock()
@@ -271,7 +462,7 @@ func (hub *Hub) CaptureException(exception error) *EventID {
 // passing it a top-level Scope.
 // Returns CheckInID if the check-in was captured successfully, or nil otherwise.
 func (hub *Hub) CaptureCheckIn(checkIn *CheckIn, monitorConfig *MonitorConfig) *EventID {
-	client, scope := hub.Client(), hub.Scope()
+	client, scope := hub.Client(), hub.eventScope()
 	if client == nil {
 		return nil
 	}
@@ -297,6 +488,12 @@ func (hub *Hub) AddBreadcrumb(breadcrumb *Breadcrumb, hint *BreadcrumbHint) {
 		return
 	}
 
+	if breadcrumb.Timestamp.IsZero() {
+		breadcrumb.Timestamp = client.now()
+	}
+	client.filterBreadcrumbHeaders(breadcrumb)
+	client.filterBreadcrumbQuery(breadcrumb)
+
 	if client.options.BeforeBreadcrumb != nil {
 		if hint == nil {
 			hint = &BreadcrumbHint{}
@@ -316,6 +513,30 @@ func (hub *Hub) AddBreadcrumb(breadcrumb *Breadcrumb, hint *BreadcrumbHint) {
 	hub.Scope().AddBreadcrumb(breadcrumb, max)
 }
 
+// SetRequest sets the request on the current scope, buffering as much of its
+// body as ClientOptions.MaxRequestBodySize of the bound client allows. See
+// Scope.SetRequest.
+func (hub *Hub) SetRequest(r *http.Request) {
+	hub.Scope().setRequest(r, hub.maxRequestBodySize().limit())
+}
+
+// SetRequestBody sets the request body on the current scope, unless it is
+// larger than ClientOptions.MaxRequestBodySize of the bound client allows.
+// See Scope.SetRequestBody.
+func (hub *Hub) SetRequestBody(b []byte) {
+	hub.Scope().setRequestBody(b, hub.maxRequestBodySize().limit())
+}
+
+func (hub *Hub) maxRequestBodySize() RequestBodySize {
+	if client := hub.Client(); client != nil {
+		if !client.PII().RequestBodies || client.loadShedding() >= LoadSheddingRequestBodies {
+			return RequestBodySizeNever
+		}
+		return client.options.MaxRequestBodySize
+	}
+	return RequestBodySizeMedium
+}
+
 // Recover calls the method of a same name on currently bound Client instance
 // passing it a top-level Scope.
 // Returns EventID if successfully, or nil if there's no Scope or Client available.
@@ -323,11 +544,11 @@ func (hub *Hub) Recover(err interface{}) *EventID {
 	if err == nil {
 		err = recover()
 	}
-	client, scope := hub.Client(), hub.Scope()
+	client, scope := hub.Client(), hub.eventScope()
 	if client == nil || scope == nil {
 		return nil
 	}
-	return client.Recover(err, &EventHint{RecoveredException: err}, scope)
+	return hub.recover(nil, client, err, scope)
 }
 
 // RecoverWithContext calls the method of a same name on currently bound Client instance
@@ -337,11 +558,19 @@ func (hub *Hub) RecoverWithContext(ctx context.Context, err interface{}) *EventI
 	if err == nil {
 		err = recover()
 	}
-	client, scope := hub.Client(), hub.Scope()
+	client, scope := hub.Client(), hub.eventScope()
 	if client == nil || scope == nil {
 		return nil
 	}
-	return client.RecoverWithContext(ctx, err, &EventHint{RecoveredException: err}, scope)
+	return hub.recover(ctx, client, err, scope)
+}
+
+func (hub *Hub) recover(ctx context.Context, client *Client, err interface{}, scope *Scope) *EventID {
+	if err == nil {
+		return nil
+	}
+	event, hint := client.eventFromRecovered(ctx, err, &EventHint{RecoveredException: err})
+	return hub.route(client, event, hint, scope).CaptureEvent(event, hint, scope)
 }
 
 // Flush waits until the underlying Transport sends any buffered events to the
@@ -349,7 +578,8 @@ func (hub *Hub) RecoverWithContext(ctx context.Context, err interface{}) *EventI
 // the timeout was reached. In that case, some events may not have been sent.
 //
 // Flush should be called before terminating the program to avoid
-// unintentionally dropping events.
+// unintentionally dropping events. The clients events are routed to with
+// BindClients are flushed as well.
 //
 // Do not call Flush indiscriminately after every call to CaptureEvent,
 // CaptureException or CaptureMessage. Instead, to have the SDK send events over
@@ -362,21 +592,37 @@ func (hub *Hub) Flush(timeout time.Duration) bool {
 		return false
 	}
 
-	return client.Flush(timeout)
+	hub.mu.RLock()
+	others := hub.routedClients
+	hub.mu.RUnlock()
+	if len(others) == 0 {
+		return client.Flush(timeout)
+	}
+
+	// Flush all clients within the same timeout.
+	deadline := time.Now().Add(timeout)
+	ok := client.Flush(timeout)
+	for _, other := range others {
+		if other != nil && other != client {
+			ok = other.Flush(time.Until(deadline)) && ok
+		}
+	}
+	return ok
 }
 
 // Continue a trace based on HTTP header values. If performance is enabled this
 // returns a SpanOption that can be used to start a transaction, otherwise nil.
 func (hub *Hub) ContinueTrace(trace, baggage string) (SpanOption, error) {
 	scope := hub.Scope()
-	propagationContext, err := PropagationContextFromHeaders(trace, baggage)
+	client := hub.Client()
+	strict := client == nil || !client.options.LenientBaggageParsing
+	propagationContext, err := propagationContextFromHeaders(trace, baggage, strict, clientIDGenerator(client))
 	if err != nil {
 		return nil, err
 	}
 
 	scope.SetPropagationContext(propagationContext)
 
-	client := hub.Client()
 	if client != nil && client.options.EnableTracing {
 		return ContinueFromHeaders(trace, baggage), nil
 	}
@@ -392,25 +638,39 @@ func HasHubOnContext(ctx context.Context) bool {
 	return ok
 }
 
-// GetHubFromContext tries to retrieve Hub instance from the given Context struct
-// or return nil if one is not found.
+// GetHubFromContext tries to retrieve Hub instance from the given Context struct,
+// falling back to the hub bound to the calling goroutine with
+// BindHubToGoroutine. It returns nil if neither is found.
 func GetHubFromContext(ctx context.Context) *Hub {
 	if hub, ok := ctx.Value(HubContextKey).(*Hub); ok {
 		return hub
 	}
-	return nil
+	return goroutineHub()
 }
 
-// hubFromContext returns either a hub stored in the context or the current hub.
-// The return value is guaranteed to be non-nil, unlike GetHubFromContext.
+// hubFromContext returns either a hub stored in the context, the hub bound to
+// the calling goroutine or the current hub. The return value is guaranteed to
+// be non-nil, unlike GetHubFromContext.
 func hubFromContext(ctx context.Context) *Hub {
 	if hub, ok := ctx.Value(HubContextKey).(*Hub); ok {
 		return hub
 	}
-	return currentHub
+	return localHub()
 }
 
 // SetHubOnContext stores given Hub instance on the Context struct and returns a new Context.
 func SetHubOnContext(ctx context.Context, hub *Hub) context.Context {
 	return context.WithValue(ctx, HubContextKey, hub)
 }
+
+// DetachedContext returns a new context that is not canceled when ctx is, and
+// carries a clone of the hub and the span of ctx. It is meant for handing off
+// background work that outlives a request, but whose errors and spans should
+// still be linked to the trace of the request.
+func DetachedContext(ctx context.Context) context.Context {
+	detached := SetHubOnContext(context.Background(), hubFromContext(ctx).Clone())
+	if span := SpanFromContext(ctx); span != nil {
+		detached = context.WithValue(detached, spanContextKey{}, span)
+	}
+	return detached
+}
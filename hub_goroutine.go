@@ -0,0 +1,74 @@
+package sentry
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// goroutineHubs associates goroutine IDs with hubs bound with
+// BindHubToGoroutine.
+var goroutineHubs sync.Map // map[uint64]*Hub
+
+// numGoroutineHubs counts the entries of goroutineHubs, such that looking up
+// the hub of a goroutine is free as long as the mechanism is not used.
+var numGoroutineHubs int64
+
+// BindHubToGoroutine associates hub with the calling goroutine and returns a
+// function that removes the association again. Call it, typically deferred,
+// before the goroutine returns.
+//
+// While bound, GetHubFromContext returns hub for contexts that carry no hub,
+// and the package-level functions like CaptureException and ConfigureScope use
+// it instead of the current hub. This is meant for legacy code paths that do
+// not propagate a context; prefer passing the hub in a context where possible.
+//
+// The association is not inherited by goroutines started by the calling
+// goroutine.
+func BindHubToGoroutine(hub *Hub) (unbind func()) {
+	id := goroutineID()
+	if _, loaded := goroutineHubs.LoadOrStore(id, hub); loaded {
+		goroutineHubs.Store(id, hub)
+	} else {
+		atomic.AddInt64(&numGoroutineHubs, 1)
+	}
+	return func() {
+		if _, loaded := goroutineHubs.LoadAndDelete(id); loaded {
+			atomic.AddInt64(&numGoroutineHubs, -1)
+		}
+	}
+}
+
+// goroutineHub returns the hub bound to the calling goroutine, or nil.
+func goroutineHub() *Hub {
+	if atomic.LoadInt64(&numGoroutineHubs) == 0 {
+		return nil
+	}
+	if hub, ok := goroutineHubs.Load(goroutineID()); ok {
+		return hub.(*Hub)
+	}
+	return nil
+}
+
+// localHub returns the hub bound to the calling goroutine, or the current hub.
+func localHub() *Hub {
+	if hub := goroutineHub(); hub != nil {
+		return hub
+	}
+	return CurrentHub()
+}
+
+// goroutineID returns the ID of the calling goroutine, as listed in the first
+// line of its stack trace: "goroutine 123 [running]:".
+func goroutineID() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i >= 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseUint(string(b), 10, 64)
+	return id
+}
@@ -0,0 +1,60 @@
+package sentry
+
+import (
+	"context"
+	"testing"
+)
+
+func TestBindHubToGoroutine(t *testing.T) {
+	hub, client, _ := setupHubTest()
+	transport := client.Transport.(*TransportMock)
+
+	if GetHubFromContext(context.Background()) != nil {
+		t.Fatal("expected no hub before binding")
+	}
+
+	unbind := BindHubToGoroutine(hub)
+	assertEqual(t, GetHubFromContext(context.Background()), hub)
+	assertEqual(t, hubFromContext(context.Background()), hub)
+
+	other := NewHub(nil, NewScope())
+	assertEqual(t, GetHubFromContext(SetHubOnContext(context.Background(), other)), other)
+
+	CaptureMessage("bound")
+	assertEqual(t, transport.lastEvent.Message, "bound")
+
+	done := make(chan *Hub)
+	go func() {
+		done <- GetHubFromContext(context.Background())
+	}()
+	if got := <-done; got != nil {
+		t.Errorf("other goroutines must not see the bound hub, got %v", got)
+	}
+
+	unbind()
+	if GetHubFromContext(context.Background()) != nil {
+		t.Error("expected no hub after unbinding")
+	}
+	assertEqual(t, hubFromContext(context.Background()), currentHub)
+}
+
+func TestGoroutineID(t *testing.T) {
+	id := goroutineID()
+	if id == 0 {
+		t.Fatal("expected a goroutine ID")
+	}
+	assertEqual(t, goroutineID(), id)
+
+	done := make(chan uint64)
+	go func() { done <- goroutineID() }()
+	if other := <-done; other == id || other == 0 {
+		t.Errorf("got goroutine ID %d in another goroutine, want a different, non-zero ID", other)
+	}
+}
+
+func BenchmarkGetHubFromContextUnbound(b *testing.B) {
+	ctx := context.Background()
+	for i := 0; i < b.N; i++ {
+		_ = GetHubFromContext(ctx)
+	}
+}
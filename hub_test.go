@@ -4,6 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"sync"
 	"testing"
 
@@ -111,6 +115,66 @@ func TestBindClient(t *testing.T) {
 	}
 }
 
+func TestBindClients(t *testing.T) {
+	defaultTransport, paymentsTransport := &TransportMock{}, &TransportMock{}
+	defaultClient, _ := NewClient(ClientOptions{Dsn: testDsn, Transport: defaultTransport})
+	paymentsClient, _ := NewClient(ClientOptions{Dsn: testDsn, Transport: paymentsTransport})
+	hub := NewHub(nil, NewScope())
+	hub.BindClients(defaultClient, func(event *Event, hint *EventHint) *Client {
+		if event.Tags["service"] == "payments" {
+			return paymentsClient
+		}
+		return nil
+	}, paymentsClient)
+
+	hub.CaptureMessage("default")
+	hub.WithScope(func(scope *Scope) {
+		scope.SetTag("service", "payments")
+		hub.CaptureException(errors.New("payments"))
+		hub.Recover("payments panic")
+	})
+	hub.Clone().CaptureEvent(&Event{Message: "cloned", Tags: map[string]string{"service": "payments"}})
+
+	messages := func(transport *TransportMock) []string {
+		var m []string
+		for _, event := range transport.Events() {
+			if event.Message != "" {
+				m = append(m, event.Message)
+			} else {
+				m = append(m, event.Exception[0].Value)
+			}
+		}
+		return m
+	}
+	assertEqual(t, messages(defaultTransport), []string{"default"})
+	assertEqual(t, messages(paymentsTransport), []string{"payments", "payments panic", "cloned"})
+
+	hub.BindClients(defaultClient, nil)
+	hub.Scope().SetTag("service", "payments")
+	hub.CaptureMessage("unrouted")
+	assertEqual(t, messages(defaultTransport), []string{"default", "unrouted"})
+}
+
+func TestCaptureExceptionWithHintClient(t *testing.T) {
+	hub, _, _ := setupHubTest()
+	defaultTransport := hub.Client().Transport.(*TransportMock)
+	libraryTransport := &TransportMock{}
+	libraryClient, _ := NewClient(ClientOptions{Dsn: testDsn, Transport: libraryTransport})
+	hub.BindClients(hub.Client(), func(*Event, *EventHint) *Client { return nil })
+
+	eventID := hub.CaptureExceptionWithHint(errors.New("library"), &EventHint{Client: libraryClient})
+	if eventID == nil {
+		t.Fatal("event was not captured")
+	}
+	assertEqual(t, len(defaultTransport.Events()), 0)
+	assertEqual(t, len(libraryTransport.Events()), 1)
+	assertEqual(t, libraryTransport.lastEvent.EventID, *eventID)
+	assertEqual(t, hub.LastEventID(), *eventID)
+
+	hub.CaptureExceptionWithHint(errors.New("default"), nil)
+	assertEqual(t, len(defaultTransport.Events()), 1)
+}
+
 func TestWithScopeCreatesIsolatedScope(t *testing.T) {
 	hub, _, _ := setupHubTest()
 
@@ -194,6 +258,18 @@ func TestLastEventIDUpdatesAfterCaptures(t *testing.T) {
 	assertEqual(t, *eventID, hub.LastEventID())
 }
 
+func TestCaptureExceptionWithContext(t *testing.T) {
+	hub, client, _ := setupHubTest()
+	transport := client.Transport.(*TransportMock)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	eventID := hub.CaptureExceptionWithContext(ctx, ctx.Err())
+	assertEqual(t, *eventID, hub.LastEventID())
+	assertEqual(t, transport.lastEvent.Tags["context.error"], "canceled")
+}
+
 func TestLastEventIDNotChangedForTransactions(t *testing.T) {
 	hub, _, _ := setupHubTest()
 
@@ -230,7 +306,7 @@ func TestAddBreadcrumbRespectMaxBreadcrumbsOption(t *testing.T) {
 	hub.AddBreadcrumb(breadcrumb, nil)
 	hub.AddBreadcrumb(breadcrumb, nil)
 
-	assertEqual(t, len(scope.breadcrumbs), 2)
+	assertEqual(t, scope.breadcrumbs.len(), 2)
 }
 
 func TestAddBreadcrumbSkipAllBreadcrumbsIfMaxBreadcrumbsIsLessThanZero(t *testing.T) {
@@ -243,7 +319,7 @@ func TestAddBreadcrumbSkipAllBreadcrumbsIfMaxBreadcrumbsIsLessThanZero(t *testin
 	hub.AddBreadcrumb(breadcrumb, nil)
 	hub.AddBreadcrumb(breadcrumb, nil)
 
-	assertEqual(t, len(scope.breadcrumbs), 0)
+	assertEqual(t, scope.breadcrumbs.len(), 0)
 }
 
 func TestAddBreadcrumbShouldNeverExceedMaxBreadcrumbsConst(t *testing.T) {
@@ -256,7 +332,7 @@ func TestAddBreadcrumbShouldNeverExceedMaxBreadcrumbsConst(t *testing.T) {
 		hub.AddBreadcrumb(breadcrumb, nil)
 	}
 
-	assertEqual(t, len(scope.breadcrumbs), 100)
+	assertEqual(t, scope.breadcrumbs.len(), 100)
 }
 
 func TestAddBreadcrumbShouldWorkWithoutClient(t *testing.T) {
@@ -268,7 +344,7 @@ func TestAddBreadcrumbShouldWorkWithoutClient(t *testing.T) {
 		hub.AddBreadcrumb(breadcrumb, nil)
 	}
 
-	assertEqual(t, len(scope.breadcrumbs), 100)
+	assertEqual(t, scope.breadcrumbs.len(), 100)
 }
 
 func TestAddBreadcrumbCallsBeforeBreadcrumbCallback(t *testing.T) {
@@ -280,8 +356,8 @@ func TestAddBreadcrumbCallsBeforeBreadcrumbCallback(t *testing.T) {
 
 	hub.AddBreadcrumb(&Breadcrumb{Message: "Breadcrumb"}, nil)
 
-	assertEqual(t, len(scope.breadcrumbs), 1)
-	assertEqual(t, "Breadcrumb_wat", scope.breadcrumbs[0].Message)
+	assertEqual(t, scope.breadcrumbs.len(), 1)
+	assertEqual(t, "Breadcrumb_wat", scope.breadcrumbs.list()[0].Message)
 }
 
 func TestBeforeBreadcrumbCallbackCanDropABreadcrumb(t *testing.T) {
@@ -293,7 +369,7 @@ func TestBeforeBreadcrumbCallbackCanDropABreadcrumb(t *testing.T) {
 	hub.AddBreadcrumb(&Breadcrumb{Message: "Breadcrumb"}, nil)
 	hub.AddBreadcrumb(&Breadcrumb{Message: "Breadcrumb"}, nil)
 
-	assertEqual(t, len(scope.breadcrumbs), 0)
+	assertEqual(t, scope.breadcrumbs.len(), 0)
 }
 
 func TestBeforeBreadcrumbGetAccessToEventHint(t *testing.T) {
@@ -310,8 +386,8 @@ func TestBeforeBreadcrumbGetAccessToEventHint(t *testing.T) {
 
 	hub.AddBreadcrumb(&Breadcrumb{Message: "Breadcrumb"}, &BreadcrumbHint{"foo": "_oh"})
 
-	assertEqual(t, len(scope.breadcrumbs), 1)
-	assertEqual(t, "Breadcrumb_oh", scope.breadcrumbs[0].Message)
+	assertEqual(t, scope.breadcrumbs.len(), 1)
+	assertEqual(t, "Breadcrumb_oh", scope.breadcrumbs.list()[0].Message)
 }
 
 func TestHasHubOnContextReturnsTrueIfHubIsThere(t *testing.T) {
@@ -424,6 +500,45 @@ func TestGetHubFromContextReturnsNilIfHubIsNotThere(t *testing.T) {
 	}
 }
 
+func TestDetachedContext(t *testing.T) {
+	hub, _, _ := setupHubTest()
+	hub.Scope().SetTag("request", "tag")
+	ctx, cancel := context.WithCancel(SetHubOnContext(context.Background(), hub))
+	span := StartSpan(ctx, "request")
+	ctx = span.Context()
+
+	detached := DetachedContext(ctx)
+	cancel()
+
+	if detached.Err() != nil {
+		t.Errorf("detached context canceled: %v", detached.Err())
+	}
+	if got := SpanFromContext(detached); got != span {
+		t.Errorf("SpanFromContext() = %v, want %v", got, span)
+	}
+	detachedHub := GetHubFromContext(detached)
+	if detachedHub == nil || detachedHub == hub {
+		t.Fatalf("GetHubFromContext() = %p, want a clone of %p", detachedHub, hub)
+	}
+	assertEqual(t, detachedHub.Scope().tags, map[string]string{"request": "tag"})
+
+	child := StartSpan(detached, "background")
+	assertEqual(t, child.ParentSpanID, span.SpanID)
+	assertEqual(t, child.TraceID, span.TraceID)
+}
+
+func TestDetachedContextWithoutSpan(t *testing.T) {
+	hub, _, _ := setupHubTest()
+	detached := DetachedContext(SetHubOnContext(context.Background(), hub))
+
+	if span := SpanFromContext(detached); span != nil {
+		t.Errorf("SpanFromContext() = %v, want nil", span)
+	}
+	if GetHubFromContext(detached) == hub {
+		t.Error("DetachedContext() did not clone the hub")
+	}
+}
+
 func TestSetHubOnContextReturnsNewContext(t *testing.T) {
 	hub, _, _ := setupHubTest()
 	ctx := context.Background()
@@ -494,3 +609,86 @@ func TestConcurrentHubClone(t *testing.T) {
 		t.Errorf("Events mismatch (-want +got):\n%s", diff)
 	}
 }
+
+func TestIsolationScope(t *testing.T) {
+	hub, client, _ := setupHubTest()
+	transport := client.Transport.(*TransportMock)
+
+	hub.Scope().SetTag("scope", "current")
+	hub.Scope().SetTag("shared", "current")
+	hub.WithScope(func(scope *Scope) {
+		// Set inside WithScope, survives PopScope.
+		hub.IsolationScope().SetTag("isolation", "yes")
+		hub.IsolationScope().SetTag("shared", "isolation")
+		hub.IsolationScope().SetLevel(LevelWarning)
+	})
+	hub.CaptureMessage("message")
+
+	event := transport.lastEvent
+	assertEqual(t, event.Tags, map[string]string{
+		"scope":     "current",
+		"isolation": "yes",
+		"shared":    "current",
+	})
+	assertEqual(t, event.Level, LevelWarning)
+
+	clone := hub.Clone()
+	clone.IsolationScope().SetTag("isolation", "clone")
+	hub.CaptureMessage("message")
+	assertEqual(t, transport.lastEvent.Tags["isolation"], "yes")
+}
+
+func TestGlobalScope(t *testing.T) {
+	defer GlobalScope().Clear()
+	GlobalScope().SetTag("global", "yes")
+	GlobalScope().SetTag("shared", "global")
+
+	hub, client, scope := setupHubTest()
+	transport := client.Transport.(*TransportMock)
+	hub.IsolationScope().SetTag("shared", "isolation")
+	scope.SetTag("current", "yes")
+
+	hub.CaptureMessage("message")
+	assertEqual(t, transport.lastEvent.Tags, map[string]string{
+		"global":  "yes",
+		"shared":  "isolation",
+		"current": "yes",
+	})
+
+	client.CaptureMessage("direct", nil, nil)
+	assertEqual(t, transport.lastEvent.Tags["global"], "yes")
+}
+
+func TestHubSetRequestMaxRequestBodySize(t *testing.T) {
+	body := strings.Repeat("a", 2000)
+	tests := map[RequestBodySize]string{
+		"":                    body,
+		RequestBodySizeNever:  "",
+		RequestBodySizeSmall:  "",
+		RequestBodySizeMedium: body,
+		RequestBodySizeAlways: body,
+	}
+	for size, want := range tests {
+		t.Run(string(size), func(t *testing.T) {
+			client, _ := NewClient(ClientOptions{Transport: &TransportMock{}, MaxRequestBodySize: size})
+			hub := NewHub(client, NewScope())
+
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+			hub.SetRequest(r)
+			// The handler still gets the whole body.
+			if b, _ := io.ReadAll(r.Body); string(b) != body {
+				t.Fatalf("handler read %d bytes, want %d", len(b), len(body))
+			}
+			event := hub.Scope().ApplyToEvent(NewEvent(), nil, client)
+			if event.Request.Data != want {
+				t.Errorf("request data has %d bytes, want %d", len(event.Request.Data), len(want))
+			}
+
+			hub.SetRequestBody([]byte(body))
+			event = hub.Scope().ApplyToEvent(NewEvent(), nil, client)
+			if event.Request.Data != want {
+				t.Errorf("SetRequestBody: request data has %d bytes, want %d", len(event.Request.Data), len(want))
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package sentry
+
+import "crypto/rand"
+
+// IDGenerator generates the trace and span IDs of spans, see
+// ClientOptions.IDGenerator. Custom generators allow deterministic IDs in
+// tests, or IDs that are time-ordered or correlated with an external system.
+// Implementations must be safe for concurrent use and should return IDs that
+// are unique with high probability.
+type IDGenerator interface {
+	// TraceID returns the ID of a new trace.
+	TraceID() TraceID
+	// SpanID returns the ID of a new span.
+	SpanID() SpanID
+}
+
+// randomIDGenerator is the default IDGenerator, returning random IDs.
+type randomIDGenerator struct{}
+
+func (randomIDGenerator) TraceID() (id TraceID) {
+	// Implementation note:
+	//
+	// While math/rand is ~2x faster than crypto/rand (exact
+	// difference depends on hardware / OS), crypto/rand is probably
+	// fast enough and a safer choice.
+	//
+	// For reference, OpenTelemetry [1] uses crypto/rand to seed
+	// math/rand. AFAICT this approach does not preserve the
+	// properties from crypto/rand that make it suitable for
+	// cryptography. While it might be debatable whether those
+	// properties are important for us here, again, we're taking the
+	// safer path.
+	//
+	// See [2a] & [2b] for a discussion of some of the properties we
+	// obtain by using crypto/rand and [3a] & [3b] for why we avoid
+	// math/rand.
+	//
+	// Because the math/rand seed has only 64 bits (int64), if the
+	// first thing we do after seeding an RNG is to read in a random
+	// TraceID, there are only 2^64 possible values. Compared to
+	// UUID v4 that have 122 random bits, there is a much greater
+	// chance of collision [4a] & [4b].
+	//
+	// [1]:  https://github.com/open-telemetry/opentelemetry-go/blob/958041ddf619a128/sdk/trace/trace.go#L25-L31
+	// [2a]: https://security.stackexchange.com/q/120352/246345
+	// [2b]: https://security.stackexchange.com/a/120365/246345
+	// [3a]: https://github.com/golang/go/issues/11871#issuecomment-126333686
+	// [3b]: https://github.com/golang/go/issues/11871#issuecomment-126357889
+	// [4a]: https://en.wikipedia.org/wiki/Universally_unique_identifier#Collisions
+	// [4b]: https://www.wolframalpha.com/input/?i=sqrt%282*2%5E64*ln%281%2F%281-0.5%29%29%29
+	if _, err := rand.Read(id[:]); err != nil {
+		panic(err)
+	}
+	return id
+}
+
+func (randomIDGenerator) SpanID() (id SpanID) {
+	if _, err := rand.Read(id[:]); err != nil {
+		panic(err)
+	}
+	return id
+}
+
+// idGeneratorOrDefault returns ids, or the random generator if ids is nil.
+func idGeneratorOrDefault(ids IDGenerator) IDGenerator {
+	if ids == nil {
+		return randomIDGenerator{}
+	}
+	return ids
+}
+
+// clientIDGenerator returns the IDGenerator of client, or the random generator
+// if client is nil or has none.
+func clientIDGenerator(client *Client) IDGenerator {
+	if client == nil {
+		return randomIDGenerator{}
+	}
+	return idGeneratorOrDefault(client.options.IDGenerator)
+}
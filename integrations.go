@@ -1,6 +1,8 @@
 package sentry
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"regexp"
@@ -8,6 +10,33 @@ import (
 	"runtime/debug"
 	"strings"
 	"sync"
+	"time"
+)
+
+// Names of the default integrations, for use with
+// ClientOptions.DisabledIntegrations.
+const (
+	// IntegrationModules adds the versions of the modules the program is
+	// built with to Event.Modules.
+	IntegrationModules = "Modules"
+	// IntegrationEnvironment adds the device, os and runtime contexts.
+	IntegrationEnvironment = "Environment"
+	// IntegrationCloudEnvironment adds the cloud resource and Kubernetes
+	// contexts when running on a cloud platform.
+	IntegrationCloudEnvironment = "CloudEnvironment"
+	// IntegrationIgnoreErrors applies IgnoreErrors and IgnoreErrorMatchers.
+	IntegrationIgnoreErrors = "IgnoreErrors"
+	// IntegrationIgnoreTransactions applies IgnoreTransactions and
+	// IgnoreTransactionMatchers.
+	IntegrationIgnoreTransactions = "IgnoreTransactions"
+	// IntegrationContextifyFrames adds the source code around the frames of
+	// stack traces.
+	IntegrationContextifyFrames = "ContextifyFrames"
+	// IntegrationGlobalTags applies ClientOptions.Tags and the SENTRY_TAGS_
+	// environment variables.
+	IntegrationGlobalTags = "GlobalTags"
+	// IntegrationContextCancellation tags events captured with a canceled context.
+	IntegrationContextCancellation = "ContextCancellation"
 )
 
 // ================================
@@ -20,7 +49,7 @@ type modulesIntegration struct {
 }
 
 func (mi *modulesIntegration) Name() string {
-	return "Modules"
+	return IntegrationModules
 }
 
 func (mi *modulesIntegration) SetupOnce(client *Client) {
@@ -60,19 +89,37 @@ func extractModules(info *debug.BuildInfo) map[string]string {
 // Environment Integration
 // ================================
 
-type environmentIntegration struct{}
+type environmentIntegration struct {
+	// Static contextual information, determined once when the integration is
+	// set up.
+	device  Context
+	os      Context
+	runtime Context
+}
 
 func (ei *environmentIntegration) Name() string {
-	return "Environment"
+	return IntegrationEnvironment
 }
 
 func (ei *environmentIntegration) SetupOnce(client *Client) {
+	ei.device = DeviceContext{
+		Arch:   runtime.GOARCH,
+		NumCPU: runtime.NumCPU(),
+	}.Map()
+	ei.os = OSContext{
+		Name: runtime.GOOS,
+	}.Map()
+	ei.runtime = RuntimeContext{
+		Name:    "go",
+		Version: runtime.Version(),
+	}.Map()
+
 	client.AddEventProcessor(ei.processor)
 }
 
 func (ei *environmentIntegration) processor(event *Event, _ *EventHint) *Event {
 	// Initialize maps as necessary.
-	contextNames := []string{"device", "os", "runtime"}
+	contextNames := []string{deviceContextKey, osContextKey, runtimeContextKey}
 	if event.Contexts == nil {
 		event.Contexts = make(map[string]Context, len(contextNames))
 	}
@@ -82,60 +129,217 @@ func (ei *environmentIntegration) processor(event *Event, _ *EventHint) *Event {
 		}
 	}
 
-	// Set contextual information preserving existing data. For each context, if
-	// the existing value is not of type map[string]interface{}, then no
-	// additional information is added.
-	if deviceContext, ok := event.Contexts["device"]; ok {
-		if _, ok := deviceContext["arch"]; !ok {
-			deviceContext["arch"] = runtime.GOARCH
+	// Set contextual information preserving existing data.
+	mergeContext(event.Contexts[deviceContextKey], ei.device)
+	mergeContext(event.Contexts[osContextKey], ei.os)
+	mergeContext(event.Contexts[runtimeContextKey], ei.runtime)
+
+	runtimeContext := event.Contexts[runtimeContextKey]
+	if _, ok := runtimeContext["go_numroutines"]; !ok {
+		runtimeContext["go_numroutines"] = runtime.NumGoroutine()
+	}
+	if _, ok := runtimeContext["go_maxprocs"]; !ok {
+		runtimeContext["go_maxprocs"] = runtime.GOMAXPROCS(0)
+	}
+	if _, ok := runtimeContext["go_numcgocalls"]; !ok {
+		runtimeContext["go_numcgocalls"] = runtime.NumCgoCall()
+	}
+	return event
+}
+
+// mergeContext copies the keys from src that are not yet set in dst.
+func mergeContext(dst, src Context) {
+	for k, v := range src {
+		if _, ok := dst[k]; !ok {
+			dst[k] = v
+		}
+	}
+}
+
+// ================================
+// Cloud Environment Integration
+// ================================
+
+type cloudEnvironmentIntegration struct {
+	cloudResource Context
+	kubernetes    Context
+}
+
+func (ci *cloudEnvironmentIntegration) Name() string {
+	return IntegrationCloudEnvironment
+}
+
+func (ci *cloudEnvironmentIntegration) SetupOnce(client *Client) {
+	env := detectCloudEnvironment(os.Getenv, os.ReadFile)
+	ci.cloudResource = env.cloudResource.Map()
+	ci.kubernetes = env.kubernetes.Map()
+	if client.options.ServerName == "" {
+		client.options.ServerName = env.serverName
+	}
+
+	if len(ci.cloudResource) > 0 || len(ci.kubernetes) > 0 {
+		client.AddEventProcessor(ci.processor)
+	}
+}
+
+func (ci *cloudEnvironmentIntegration) processor(event *Event, _ *EventHint) *Event {
+	if event.Contexts == nil {
+		event.Contexts = make(map[string]Context, 2)
+	}
+	for name, context := range map[string]Context{
+		cloudResourceContextKey: ci.cloudResource,
+		kubernetesContextKey:    ci.kubernetes,
+	} {
+		if len(context) == 0 {
+			continue
+		}
+		if event.Contexts[name] == nil {
+			event.Contexts[name] = make(Context, len(context))
+		}
+		mergeContext(event.Contexts[name], context)
+	}
+	return event
+}
+
+// cloudEnvironment describes the environment detected by
+// detectCloudEnvironment.
+type cloudEnvironment struct {
+	cloudResource CloudResourceContext
+	kubernetes    KubernetesContext
+	// serverName is a better default for ClientOptions.ServerName than the
+	// host name, empty if there is none.
+	serverName string
+}
+
+// detectCloudEnvironment detects Kubernetes pods and the cloud platform the
+// program runs on from environment variables and the DMI information of the
+// host. It does not query metadata services, which would delay the start of
+// the program and fail outside of the cloud.
+func detectCloudEnvironment(getenv func(string) string, readFile func(string) ([]byte, error)) cloudEnvironment {
+	var env cloudEnvironment
+
+	if getenv("KUBERNETES_SERVICE_HOST") != "" {
+		// POD_NAMESPACE, POD_NAME and NODE_NAME are the conventional names
+		// for exposing pod fields through the downward API.
+		env.kubernetes = KubernetesContext{
+			Namespace: getenv("POD_NAMESPACE"),
+			PodName:   getenv("POD_NAME"),
+			NodeName:  getenv("NODE_NAME"),
+		}
+		if env.kubernetes.Namespace == "" {
+			if b, err := readFile("/var/run/secrets/kubernetes.io/serviceaccount/namespace"); err == nil {
+				env.kubernetes.Namespace = strings.TrimSpace(string(b))
+			}
 		}
-		if _, ok := deviceContext["num_cpu"]; !ok {
-			deviceContext["num_cpu"] = runtime.NumCPU()
+		if env.kubernetes.PodName == "" {
+			env.kubernetes.PodName = getenv("HOSTNAME")
 		}
+		env.serverName = env.kubernetes.PodName
 	}
-	if osContext, ok := event.Contexts["os"]; ok {
-		if _, ok := osContext["name"]; !ok {
-			osContext["name"] = runtime.GOOS
+
+	dmi := func(name string) string {
+		b, err := readFile("/sys/class/dmi/id/" + name)
+		if err != nil {
+			return ""
 		}
+		return strings.TrimSpace(string(b))
 	}
-	if runtimeContext, ok := event.Contexts["runtime"]; ok {
-		if _, ok := runtimeContext["name"]; !ok {
-			runtimeContext["name"] = "go"
+
+	switch {
+	case getenv("AWS_LAMBDA_FUNCTION_NAME") != "":
+		env.cloudResource = CloudResourceContext{
+			Provider: "aws",
+			Platform: "aws_lambda",
+			Region:   getenv("AWS_REGION"),
+		}
+		if env.serverName == "" {
+			env.serverName = getenv("AWS_LAMBDA_FUNCTION_NAME")
+		}
+	case getenv("ECS_CONTAINER_METADATA_URI_V4") != "" || strings.HasPrefix(getenv("AWS_EXECUTION_ENV"), "AWS_ECS"):
+		env.cloudResource = CloudResourceContext{
+			Provider: "aws",
+			Platform: "aws_ecs",
+			Region:   getenv("AWS_REGION"),
 		}
-		if _, ok := runtimeContext["version"]; !ok {
-			runtimeContext["version"] = runtime.Version()
+	case getenv("K_SERVICE") != "" || getenv("CLOUD_RUN_JOB") != "":
+		env.cloudResource = CloudResourceContext{
+			Provider:  "gcp",
+			Platform:  "gcp_cloud_run",
+			AccountID: getenv("GOOGLE_CLOUD_PROJECT"),
 		}
-		if _, ok := runtimeContext["go_numroutines"]; !ok {
-			runtimeContext["go_numroutines"] = runtime.NumGoroutine()
+		if env.serverName == "" {
+			env.serverName = getenv("K_REVISION")
 		}
-		if _, ok := runtimeContext["go_maxprocs"]; !ok {
-			runtimeContext["go_maxprocs"] = runtime.GOMAXPROCS(0)
+	case dmi("sys_vendor") == "Amazon EC2":
+		env.cloudResource = CloudResourceContext{
+			Provider: "aws",
+			Platform: "aws_ec2",
+			Region:   getenv("AWS_REGION"),
+			HostType: dmi("product_name"),
 		}
-		if _, ok := runtimeContext["go_numcgocalls"]; !ok {
-			runtimeContext["go_numcgocalls"] = runtime.NumCgoCall()
+	case dmi("product_name") == "Google Compute Engine":
+		env.cloudResource = CloudResourceContext{
+			Provider: "gcp",
+			Platform: "gcp_compute_engine",
 		}
 	}
-	return event
+
+	return env
 }
 
 // ================================
 // Ignore Errors Integration
 // ================================
 
+// An ErrorMatcher reports whether an error should be ignored. See
+// ClientOptions.IgnoreErrorMatchers.
+type ErrorMatcher func(err error) bool
+
+// IgnoreErrorIs returns an ErrorMatcher that matches any error for which
+// errors.Is(err, target) reports true, for example context.Canceled or io.EOF.
+func IgnoreErrorIs(target error) ErrorMatcher {
+	return func(err error) bool {
+		return errors.Is(err, target)
+	}
+}
+
+// IgnoreErrorType returns an ErrorMatcher that matches any error that has an
+// error of type T in its chain, as reported by errors.As.
+//
+//	sentry.IgnoreErrorType[*net.OpError]()
+func IgnoreErrorType[T error]() ErrorMatcher {
+	return func(err error) bool {
+		var target T
+		return errors.As(err, &target)
+	}
+}
+
 type ignoreErrorsIntegration struct {
 	ignoreErrors []*regexp.Regexp
+	matchers     []ErrorMatcher
 }
 
 func (iei *ignoreErrorsIntegration) Name() string {
-	return "IgnoreErrors"
+	return IntegrationIgnoreErrors
 }
 
 func (iei *ignoreErrorsIntegration) SetupOnce(client *Client) {
 	iei.ignoreErrors = transformStringsIntoRegexps(client.options.IgnoreErrors)
+	iei.matchers = client.options.IgnoreErrorMatchers
 	client.AddEventProcessor(iei.processor)
 }
 
-func (iei *ignoreErrorsIntegration) processor(event *Event, _ *EventHint) *Event {
+func (iei *ignoreErrorsIntegration) processor(event *Event, hint *EventHint) *Event {
+	if err := originalError(hint); err != nil {
+		for _, match := range iei.matchers {
+			if match != nil && match(err) {
+				Logger.Printf("Event dropped due to being matched by `IgnoreErrorMatchers` option."+
+					"| Error matched: %s", err)
+				return nil
+			}
+		}
+	}
+
 	suspects := getIgnoreErrorsSuspects(event)
 
 	for _, suspect := range suspects {
@@ -164,6 +368,21 @@ func transformStringsIntoRegexps(strings []string) []*regexp.Regexp {
 	return exprs
 }
 
+// originalError returns the error an event was created from, if the hint
+// carries one.
+func originalError(hint *EventHint) error {
+	if hint == nil {
+		return nil
+	}
+	if hint.OriginalException != nil {
+		return hint.OriginalException
+	}
+	if err, ok := hint.RecoveredException.(error); ok {
+		return err
+	}
+	return nil
+}
+
 func getIgnoreErrorsSuspects(event *Event) []string {
 	suspects := []string{}
 
@@ -182,34 +401,63 @@ func getIgnoreErrorsSuspects(event *Event) []string {
 // Ignore Transactions Integration
 // ================================
 
+// A TransactionMatcher reports whether the transaction rooted at span should
+// be ignored. See ClientOptions.IgnoreTransactionMatchers.
+type TransactionMatcher func(span *Span) bool
+
 type ignoreTransactionsIntegration struct {
 	ignoreTransactions []*regexp.Regexp
+	matchers           []TransactionMatcher
 }
 
 func (iei *ignoreTransactionsIntegration) Name() string {
-	return "IgnoreTransactions"
+	return IntegrationIgnoreTransactions
 }
 
 func (iei *ignoreTransactionsIntegration) SetupOnce(client *Client) {
 	iei.ignoreTransactions = transformStringsIntoRegexps(client.options.IgnoreTransactions)
+	iei.matchers = client.options.IgnoreTransactionMatchers
+	client.transactionFilter = iei
 	client.AddEventProcessor(iei.processor)
 }
 
+// ignoreTransaction is called when a sampled transaction finishes, before it
+// is converted into an event, so that ignored transactions never pay the cost
+// of being assembled and serialized.
+func (iei *ignoreTransactionsIntegration) ignoreTransaction(span *Span) bool {
+	for _, match := range iei.matchers {
+		if match != nil && match(span) {
+			Logger.Printf("Transaction dropped due to being matched by `IgnoreTransactionMatchers` option."+
+				"| Transaction: %s", span.Name)
+			return true
+		}
+	}
+
+	return iei.matchName(span.Name)
+}
+
 func (iei *ignoreTransactionsIntegration) processor(event *Event, _ *EventHint) *Event {
-	suspect := event.Transaction
+	if iei.matchName(event.Transaction) {
+		return nil
+	}
+
+	return event
+}
+
+func (iei *ignoreTransactionsIntegration) matchName(suspect string) bool {
 	if suspect == "" {
-		return event
+		return false
 	}
 
 	for _, pattern := range iei.ignoreTransactions {
 		if pattern.Match([]byte(suspect)) || strings.Contains(suspect, pattern.String()) {
 			Logger.Printf("Transaction dropped due to being matched by `IgnoreTransactions` option."+
 				"| Value matched: %s | Filter used: %s", suspect, pattern)
-			return nil
+			return true
 		}
 	}
 
-	return event
+	return false
 }
 
 // ================================
@@ -217,16 +465,18 @@ func (iei *ignoreTransactionsIntegration) processor(event *Event, _ *EventHint)
 // ================================
 
 type contextifyFramesIntegration struct {
+	client          *Client
 	sr              sourceReader
 	contextLines    int
 	cachedLocations sync.Map
 }
 
 func (cfi *contextifyFramesIntegration) Name() string {
-	return "ContextifyFrames"
+	return IntegrationContextifyFrames
 }
 
 func (cfi *contextifyFramesIntegration) SetupOnce(client *Client) {
+	cfi.client = client
 	cfi.sr = newSourceReader()
 	cfi.contextLines = 5
 
@@ -234,6 +484,10 @@ func (cfi *contextifyFramesIntegration) SetupOnce(client *Client) {
 }
 
 func (cfi *contextifyFramesIntegration) processor(event *Event, _ *EventHint) *Event {
+	if cfi.client.loadShedding() >= LoadSheddingSourceContext {
+		return event
+	}
+
 	// Range over all exceptions
 	for _, ex := range event.Exception {
 		// If it has no stacktrace, just bail out
@@ -339,7 +593,7 @@ type globalTagsIntegration struct {
 }
 
 func (ti *globalTagsIntegration) Name() string {
-	return "GlobalTags"
+	return IntegrationGlobalTags
 }
 
 func (ti *globalTagsIntegration) SetupOnce(client *Client) {
@@ -380,12 +634,65 @@ func (ti *globalTagsIntegration) processor(event *Event, _ *EventHint) *Event {
 func loadEnvTags() map[string]string {
 	tags := map[string]string{}
 	for _, pair := range os.Environ() {
-		parts := strings.Split(pair, "=")
-		if !strings.HasPrefix(parts[0], envTagsPrefix) {
+		key, value, _ := strings.Cut(pair, "=")
+		tag := strings.TrimPrefix(key, envTagsPrefix)
+		if tag == key || tag == "" {
 			continue
 		}
-		tag := strings.TrimPrefix(parts[0], envTagsPrefix)
-		tags[tag] = parts[1]
+		tags[tag] = value
 	}
 	return tags
 }
+
+// ================================
+// Context Cancellation Integration
+// ================================
+
+type contextCancellationIntegration struct{}
+
+func (cci *contextCancellationIntegration) Name() string {
+	return IntegrationContextCancellation
+}
+
+func (cci *contextCancellationIntegration) SetupOnce(client *Client) {
+	client.AddEventProcessor(cci.processor)
+}
+
+// processor tags events that were captured with a context that was already
+// canceled or past its deadline, so that errors caused by timeouts and client
+// disconnects can be told apart from other failures.
+func (cci *contextCancellationIntegration) processor(event *Event, hint *EventHint) *Event {
+	if hint == nil || hint.Context == nil {
+		return event
+	}
+	ctx := hint.Context
+
+	err := ctx.Err()
+	if err == nil {
+		return event
+	}
+
+	if event.Tags == nil {
+		event.Tags = make(map[string]string)
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		event.Tags["context.error"] = "deadline_exceeded"
+	} else {
+		event.Tags["context.error"] = "canceled"
+	}
+
+	if cause := contextCause(ctx); cause != nil && cause != err {
+		event.Tags["context.cause"] = cause.Error()
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		event.Tags["context.deadline_remaining"] = time.Until(deadline).Round(time.Millisecond).String()
+	}
+
+	if transaction := TransactionFromContext(ctx); transaction != nil && !transaction.StartTime.IsZero() {
+		event.Tags["context.elapsed"] = time.Since(transaction.StartTime).Round(time.Millisecond).String()
+	}
+
+	return event
+}
@@ -1,12 +1,18 @@
 package sentry
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"regexp"
 	"runtime/debug"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 )
@@ -185,6 +191,65 @@ func TestIgnoreErrorsIntegration(t *testing.T) {
 	}
 }
 
+type ignoredError struct{}
+
+func (ignoredError) Error() string { return "ignored" }
+
+func TestIgnoreErrorsIntegrationMatchers(t *testing.T) {
+	iei := ignoreErrorsIntegration{
+		matchers: []ErrorMatcher{
+			IgnoreErrorIs(context.Canceled),
+			IgnoreErrorType[ignoredError](),
+			func(err error) bool { return err.Error() == "custom" },
+		},
+	}
+
+	tests := []struct {
+		name    string
+		hint    *EventHint
+		dropped bool
+	}{
+		{"NilHint", nil, false},
+		{"NoError", &EventHint{}, false},
+		{"ErrorsIs", &EventHint{OriginalException: fmt.Errorf("op: %w", context.Canceled)}, true},
+		{"ErrorsAs", &EventHint{OriginalException: fmt.Errorf("op: %w", ignoredError{})}, true},
+		{"Func", &EventHint{OriginalException: errors.New("custom")}, true},
+		{"Recovered", &EventHint{RecoveredException: context.Canceled}, true},
+		{"NotMatched", &EventHint{OriginalException: io.EOF}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := iei.processor(&Event{Message: "msg"}, tt.hint)
+			if (got == nil) != tt.dropped {
+				t.Errorf("dropped = %t, want %t", got == nil, tt.dropped)
+			}
+		})
+	}
+}
+
+func TestIgnoreErrorMatchersOption(t *testing.T) {
+	transport := &TransportMock{}
+	client, err := NewClient(ClientOptions{
+		Transport:           transport,
+		IgnoreErrorMatchers: []ErrorMatcher{IgnoreErrorIs(io.EOF)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client.CaptureException(fmt.Errorf("read: %w", io.EOF), nil, NewScope())
+	hint := &EventHint{}
+	client.CaptureException(errors.New("other"), hint, NewScope())
+
+	if got := len(transport.Events()); got != 1 {
+		t.Fatalf("got %d events, want 1", got)
+	}
+	assertEqual(t, transport.lastEvent.Exception[0].Value, "other")
+	if hint.OriginalException != nil {
+		t.Errorf("the caller's hint was modified: %v", hint.OriginalException)
+	}
+}
+
 func TestIgnoreTransactionsIntegration(t *testing.T) {
 	iei := ignoreTransactionsIntegration{
 		ignoreTransactions: []*regexp.Regexp{
@@ -227,6 +292,29 @@ func TestIgnoreTransactionsIntegration(t *testing.T) {
 	}
 }
 
+func TestIgnoreTransactionMatchers(t *testing.T) {
+	transport := &TransportMock{}
+	ctx := NewTestContext(ClientOptions{
+		EnableTracing:      true,
+		TracesSampleRate:   1.0,
+		Transport:          transport,
+		IgnoreTransactions: []string{"^GET /health$"},
+		IgnoreTransactionMatchers: []TransactionMatcher{
+			func(span *Span) bool { return span.Op == "metrics" },
+		},
+	})
+
+	StartTransaction(ctx, "GET /health").Finish()
+	StartTransaction(ctx, "scrape", WithOpName("metrics")).Finish()
+	StartTransaction(ctx, "GET /users").Finish()
+
+	events := transport.Events()
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	assertEqual(t, events[0].Transaction, "GET /users")
+}
+
 func TestContextifyFrames(t *testing.T) {
 	cfi := contextifyFramesIntegration{
 		sr:           newSourceReader(),
@@ -443,13 +531,121 @@ func TestEnvironmentIntegrationDoesNotOverrideExistingContexts(t *testing.T) {
 	}
 }
 
+func TestDetectCloudEnvironment(t *testing.T) {
+	tests := []struct {
+		name  string
+		env   map[string]string
+		files map[string]string
+		want  cloudEnvironment
+	}{
+		{
+			name: "None",
+			want: cloudEnvironment{},
+		},
+		{
+			name: "Kubernetes",
+			env: map[string]string{
+				"KUBERNETES_SERVICE_HOST": "10.0.0.1",
+				"HOSTNAME":                "api-7d9f-x2",
+				"NODE_NAME":               "node-1",
+			},
+			files: map[string]string{
+				"/var/run/secrets/kubernetes.io/serviceaccount/namespace": "payments\n",
+			},
+			want: cloudEnvironment{
+				kubernetes: KubernetesContext{Namespace: "payments", PodName: "api-7d9f-x2", NodeName: "node-1"},
+				serverName: "api-7d9f-x2",
+			},
+		},
+		{
+			name: "KubernetesOnEC2",
+			env: map[string]string{
+				"KUBERNETES_SERVICE_HOST": "10.0.0.1",
+				"POD_NAMESPACE":           "default",
+				"POD_NAME":                "api-0",
+				"AWS_REGION":              "eu-central-1",
+			},
+			files: map[string]string{
+				"/sys/class/dmi/id/sys_vendor":   "Amazon EC2\n",
+				"/sys/class/dmi/id/product_name": "m5.large\n",
+			},
+			want: cloudEnvironment{
+				cloudResource: CloudResourceContext{Provider: "aws", Platform: "aws_ec2", Region: "eu-central-1", HostType: "m5.large"},
+				kubernetes:    KubernetesContext{Namespace: "default", PodName: "api-0"},
+				serverName:    "api-0",
+			},
+		},
+		{
+			name: "ECS",
+			env:  map[string]string{"AWS_EXECUTION_ENV": "AWS_ECS_FARGATE", "AWS_REGION": "us-east-1"},
+			want: cloudEnvironment{
+				cloudResource: CloudResourceContext{Provider: "aws", Platform: "aws_ecs", Region: "us-east-1"},
+			},
+		},
+		{
+			name: "Lambda",
+			env:  map[string]string{"AWS_LAMBDA_FUNCTION_NAME": "resize", "AWS_REGION": "us-east-1"},
+			want: cloudEnvironment{
+				cloudResource: CloudResourceContext{Provider: "aws", Platform: "aws_lambda", Region: "us-east-1"},
+				serverName:    "resize",
+			},
+		},
+		{
+			name: "CloudRun",
+			env:  map[string]string{"K_SERVICE": "api", "K_REVISION": "api-00042", "GOOGLE_CLOUD_PROJECT": "acme"},
+			want: cloudEnvironment{
+				cloudResource: CloudResourceContext{Provider: "gcp", Platform: "gcp_cloud_run", AccountID: "acme"},
+				serverName:    "api-00042",
+			},
+		},
+		{
+			name:  "GCE",
+			files: map[string]string{"/sys/class/dmi/id/product_name": "Google Compute Engine"},
+			want: cloudEnvironment{
+				cloudResource: CloudResourceContext{Provider: "gcp", Platform: "gcp_compute_engine"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getenv := func(key string) string { return tt.env[key] }
+			readFile := func(name string) ([]byte, error) {
+				if content, ok := tt.files[name]; ok {
+					return []byte(content), nil
+				}
+				return nil, os.ErrNotExist
+			}
+			assertEqual(t, detectCloudEnvironment(getenv, readFile), tt.want)
+		})
+	}
+}
+
+func TestCloudEnvironmentIntegrationPreservesContexts(t *testing.T) {
+	ci := cloudEnvironmentIntegration{
+		cloudResource: CloudResourceContext{Provider: "aws", Region: "us-east-1"}.Map(),
+	}
+	event := &Event{Contexts: map[string]Context{
+		"cloud_resource": {"cloud.region": "eu-central-1"},
+	}}
+
+	event = ci.processor(event, nil)
+
+	assertEqual(t, event.Contexts, map[string]Context{
+		"cloud_resource": {"cloud.provider": "aws", "cloud.region": "eu-central-1"},
+	})
+}
+
 func TestGlobalTagsIntegration(t *testing.T) {
 	os.Setenv("SENTRY_TAGS_foo", "foo_value_env")
 	os.Setenv("SENTRY_TAGS_bar", "bar_value_env")
 	os.Setenv("SENTRY_TAGS_baz", "baz_value_env")
+	os.Setenv("SENTRY_TAGS_query", "a=b")
+	os.Setenv("SENTRY_TAGS_", "empty_name")
 	defer os.Unsetenv("SENTRY_TAGS_foo")
 	defer os.Unsetenv("SENTRY_TAGS_bar")
 	defer os.Unsetenv("SENTRY_TAGS_baz")
+	defer os.Unsetenv("SENTRY_TAGS_query")
+	defer os.Unsetenv("SENTRY_TAGS_")
 
 	transport := &TransportMock{}
 	client, err := NewClient(ClientOptions{
@@ -488,4 +684,89 @@ func TestGlobalTagsIntegration(t *testing.T) {
 		"baz_value_client_options",
 		"client options tag present if not overridden by scope and overrides env tag",
 	)
+	assertEqual(t,
+		transport.lastEvent.Tags["query"],
+		"a=b",
+		"env tag values may contain '='",
+	)
+	if _, ok := transport.lastEvent.Tags[""]; ok {
+		t.Error("env tag without a name should be ignored")
+	}
+}
+
+func TestContextCancellationIntegration(t *testing.T) {
+	cci := contextCancellationIntegration{}
+
+	t.Run("NoContext", func(t *testing.T) {
+		event := cci.processor(&Event{}, &EventHint{})
+		assertEqual(t, len(event.Tags), 0)
+	})
+
+	t.Run("ActiveContext", func(t *testing.T) {
+		event := cci.processor(&Event{}, &EventHint{Context: context.Background()})
+		assertEqual(t, len(event.Tags), 0)
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		event := cci.processor(&Event{}, &EventHint{Context: ctx})
+		assertEqual(t, event.Tags, map[string]string{"context.error": "canceled"})
+	})
+
+	t.Run("DeadlineExceeded", func(t *testing.T) {
+		ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+		defer cancel()
+		event := cci.processor(&Event{}, &EventHint{Context: ctx})
+		assertEqual(t, event.Tags["context.error"], "deadline_exceeded")
+		if !strings.HasPrefix(event.Tags["context.deadline_remaining"], "-") {
+			t.Errorf("got remaining deadline %q, want a negative duration", event.Tags["context.deadline_remaining"])
+		}
+	})
+
+	t.Run("Elapsed", func(t *testing.T) {
+		transaction := StartTransaction(context.Background(), "op")
+		transaction.StartTime = time.Now().Add(-time.Minute)
+		ctx, cancel := context.WithCancel(transaction.Context())
+		cancel()
+		event := cci.processor(&Event{}, &EventHint{Context: ctx})
+		assertEqual(t, event.Tags["context.elapsed"], "1m0s")
+	})
+}
+
+type namedIntegration string
+
+func (ni namedIntegration) Name() string { return string(ni) }
+
+func (ni namedIntegration) SetupOnce(*Client) {}
+
+func TestDisabledIntegrations(t *testing.T) {
+	client, err := NewClient(ClientOptions{
+		Integrations: func(defaults []Integration) []Integration {
+			return append(defaults, namedIntegration("Custom"), namedIntegration("Other"))
+		},
+		DisabledIntegrations: []string{IntegrationModules, "Other"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	installed := client.listIntegrations()
+	for _, name := range []string{IntegrationModules, "Other"} {
+		if client.integrationAlreadyInstalled(name) {
+			t.Errorf("integration %s is installed: %v", name, installed)
+		}
+	}
+	for _, name := range []string{"Custom", IntegrationEnvironment, IntegrationContextifyFrames} {
+		if !client.integrationAlreadyInstalled(name) {
+			t.Errorf("integration %s is not installed: %v", name, installed)
+		}
+	}
+
+	scope := NewScope()
+	transport := &TransportMock{}
+	client.Transport = transport
+	client.CaptureMessage("without modules", nil, scope)
+	if modules := transport.lastEvent.Modules; len(modules) != 0 {
+		t.Errorf("event.Modules = %v, want none", modules)
+	}
 }
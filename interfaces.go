@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"reflect"
@@ -77,6 +78,25 @@ type Breadcrumb struct {
 
 // MarshalJSON converts the Breadcrumb struct to JSON.
 func (b *Breadcrumb) MarshalJSON() ([]byte, error) {
+	return json.Marshal(b.jsonValue())
+}
+
+// breadcrumbFields aliases Breadcrumb to allow calling json.Marshal without an
+// infinite loop. It preserves all fields while none of the attached methods.
+type breadcrumbFields Breadcrumb
+
+// breadcrumbJSON is the JSON encoding of a breadcrumb. Events encode their
+// breadcrumbs as breadcrumbJSON, which saves calling MarshalJSON for every
+// breadcrumb.
+type breadcrumbJSON struct {
+	// Embed all of the fields of Breadcrumb.
+	*breadcrumbFields
+	// Timestamp shadows the original Timestamp field and remains nil for a
+	// zero time, triggering the omitempty behavior.
+	Timestamp *time.Time `json:"timestamp,omitempty"`
+}
+
+func (b *Breadcrumb) jsonValue() breadcrumbJSON {
 	// We want to omit time.Time zero values, otherwise the server will try to
 	// interpret dates too far in the past. However, encoding/json doesn't
 	// support the "omitempty" option for struct types. See
@@ -84,30 +104,57 @@ func (b *Breadcrumb) MarshalJSON() ([]byte, error) {
 	//
 	// We overcome the limitation and achieve what we want by shadowing fields
 	// and a few type tricks.
+	x := breadcrumbJSON{breadcrumbFields: (*breadcrumbFields)(b)}
+	if !b.Timestamp.IsZero() {
+		x.Timestamp = &b.Timestamp
+	}
+	return x
+}
 
-	// breadcrumb aliases Breadcrumb to allow calling json.Marshal without an
-	// infinite loop. It preserves all fields while none of the attached
-	// methods.
-	type breadcrumb Breadcrumb
-
-	if b.Timestamp.IsZero() {
-		return json.Marshal(struct {
-			// Embed all of the fields of Breadcrumb.
-			*breadcrumb
-			// Timestamp shadows the original Timestamp field and is meant to
-			// remain nil, triggering the omitempty behavior.
-			Timestamp json.RawMessage `json:"timestamp,omitempty"`
-		}{breadcrumb: (*breadcrumb)(b)})
+// breadcrumbsJSON returns the JSON encodings of breadcrumbs, nil breadcrumbs
+// are kept as nil.
+func breadcrumbsJSON(breadcrumbs []*Breadcrumb) []*breadcrumbJSON {
+	if len(breadcrumbs) == 0 {
+		return nil
+	}
+	values := make([]breadcrumbJSON, len(breadcrumbs))
+	result := make([]*breadcrumbJSON, len(breadcrumbs))
+	for i, b := range breadcrumbs {
+		if b != nil {
+			values[i] = b.jsonValue()
+			result[i] = &values[i]
+		}
 	}
-	return json.Marshal((*breadcrumb)(b))
+	return result
 }
 
 // Attachment allows associating files with your events to aid in investigation.
 // An event may contain one or more attachments.
+//
+// Attachments added to a scope with Scope.AddAttachment are sent with every
+// error and message event captured with the scope, and with transactions if
+// AddToTransactions is set.
 type Attachment struct {
 	Filename    string
 	ContentType string
 	Payload     []byte
+	// Reader provides the payload instead of Payload if it is set. It is read
+	// when the event is sent, at most MaxSize bytes of it.
+	//
+	// A Reader that is also an io.ReaderAt of known size, such as an
+	// *os.File, a *bytes.Reader or a *strings.Reader, is streamed into the
+	// request and its last MaxSize bytes are sent, which for a log file are
+	// its most recent lines. It can be sent with any number of events. Other
+	// readers are buffered in memory, cut after MaxSize bytes, and can only be
+	// sent once.
+	//
+	// The attachment is dropped if reading fails. Closing the Reader is up to
+	// the caller, after the event is sent.
+	Reader io.Reader
+	// MaxSize limits the size of the payload read from Reader. It defaults to
+	// 20 MiB.
+	MaxSize           int64
+	AddToTransactions bool
 }
 
 // User describes the user associated with an Event. If this is used, at least
@@ -120,6 +167,7 @@ type User struct {
 	Name      string            `json:"name,omitempty"`
 	Segment   string            `json:"segment,omitempty"`
 	Data      map[string]string `json:"data,omitempty"`
+	Geo       *Geo              `json:"geo,omitempty"`
 }
 
 func (u User) IsEmpty() bool {
@@ -151,6 +199,10 @@ func (u User) IsEmpty() bool {
 		return false
 	}
 
+	if u.Geo != nil {
+		return false
+	}
+
 	return true
 }
 
@@ -165,14 +217,6 @@ type Request struct {
 	Env         map[string]string `json:"env,omitempty"`
 }
 
-var sensitiveHeaders = map[string]struct{}{
-	"Authorization":       {},
-	"Proxy-Authorization": {},
-	"Cookie":              {},
-	"X-Forwarded-For":     {},
-	"X-Real-Ip":           {},
-}
-
 // NewRequest returns a new Sentry Request from the given http.Request.
 //
 // NewRequest avoids operations that depend on network access. In particular, it
@@ -188,34 +232,38 @@ func NewRequest(r *http.Request) *Request {
 	var env map[string]string
 	headers := map[string]string{}
 
-	if client := CurrentHub().Client(); client != nil && client.options.SendDefaultPII {
+	client := CurrentHub().Client()
+	pii := client.PII()
+	if pii.Cookies {
 		// We read only the first Cookie header because of the specification:
 		// https://tools.ietf.org/html/rfc6265#section-5.4
 		// When the user agent generates an HTTP request, the user agent MUST NOT
 		// attach more than one Cookie header field.
-		cookies = r.Header.Get("Cookie")
+		cookies = client.FilterCookies(r.Header.Get("Cookie"))
+	}
 
-		for k, v := range r.Header {
+	for k, v := range r.Header {
+		if pii.allowsHeader(k) {
 			headers[k] = strings.Join(v, ",")
 		}
+	}
+	if _, ok := headers["Cookie"]; ok {
+		headers["Cookie"] = client.FilterCookies(headers["Cookie"])
+	}
 
+	if pii.ClientIP {
 		if addr, port, err := net.SplitHostPort(r.RemoteAddr); err == nil {
 			env = map[string]string{"REMOTE_ADDR": addr, "REMOTE_PORT": port}
 		}
-	} else {
-		for k, v := range r.Header {
-			if _, ok := sensitiveHeaders[k]; !ok {
-				headers[k] = strings.Join(v, ",")
-			}
-		}
 	}
 
+	client.FilterHeaders(headers)
 	headers["Host"] = r.Host
 
 	return &Request{
 		URL:         url,
 		Method:      r.Method,
-		QueryString: r.URL.RawQuery,
+		QueryString: client.FilterQuery(r.URL.RawQuery),
 		Cookies:     cookies,
 		Headers:     headers,
 		Env:         env,
@@ -257,6 +305,30 @@ type Exception struct {
 type SDKMetaData struct {
 	dsc                DynamicSamplingContext
 	transactionProfile *profileInfo
+	// metricsSummary is the _metrics_summary of a transaction, see
+	// ClientOptions.SpanMetrics.
+	metricsSummary spanMetricsSummary
+	// legacyProtocol leaves out the fields ProtocolLegacy doesn't know when
+	// encoding the event.
+	legacyProtocol bool
+	// delivery receives the outcome of sending the event. May be nil.
+	delivery *Delivery
+	// crash marks events of panics, persisted if ClientOptions.CrashDir is
+	// set.
+	crash bool
+	// pendingStacktraces are filled in by Event.resolveStacktraces.
+	pendingStacktraces []pendingStacktrace
+	// budgetSummary marks the events summarizing the events dropped by
+	// ClientOptions.EventBudgets, which are exempt from the budgets.
+	budgetSummary bool
+	// stormAggregate marks the aggregates of error storms, see
+	// ClientOptions.ErrorStormThreshold.
+	stormAggregate bool
+	// deadline is set for events captured with ClientOptions.CaptureTimeout.
+	deadline *captureDeadline
+	// clientIP is the address of the client of the request of the event, for
+	// ClientOptions.GeoResolver. It is not sent.
+	clientIP string
 }
 
 // Contains information about how the name of the transaction was determined.
@@ -292,6 +364,13 @@ type DebugMetaImage struct {
 	UUID        string `json:"uuid,omitempty"`         // proguard
 }
 
+// Measurement is a numeric value of a transaction, with the unit of the
+// value, for example "millisecond" or "byte".
+type Measurement struct {
+	Value float64 `json:"value"`
+	Unit  string  `json:"unit,omitempty"`
+}
+
 // EventID is a hexadecimal string representing a unique uuid4 for an Event.
 // An EventID must be 32 characters long, lowercase and not have any dashes.
 type EventID string
@@ -332,6 +411,9 @@ type Event struct {
 	StartTime       time.Time        `json:"start_timestamp"`
 	Spans           []*Span          `json:"spans,omitempty"`
 	TransactionInfo *TransactionInfo `json:"transaction_info,omitempty"`
+	// Measurements are numeric values of the transaction, such as the
+	// scheduling delay reported by StartSchedulerWatchdog, by name.
+	Measurements map[string]Measurement `json:"measurements,omitempty"`
 
 	// The fields below are only relevant for crons/check ins
 
@@ -349,6 +431,13 @@ type Event struct {
 // into while unwrapping the errors. If maxErrorDepth is -1, we will
 // unwrap all errors in the chain.
 func (e *Event) SetException(exception error, maxErrorDepth int) {
+	e.setException(exception, maxErrorDepth)
+	e.resolveStacktraces()
+}
+
+// setException is like SetException, but leaves the frames of the stack
+// traces to Event.resolveStacktraces.
+func (e *Event) setException(exception error, maxErrorDepth int) {
 	if exception == nil {
 		return
 	}
@@ -360,7 +449,7 @@ func (e *Event) SetException(exception error, maxErrorDepth int) {
 		e.Exception = append(e.Exception, Exception{
 			Value:      err.Error(),
 			Type:       reflect.TypeOf(err).String(),
-			Stacktrace: ExtractStacktrace(err),
+			Stacktrace: e.pendingStacktrace(extractStacktracePCs(err), 0),
 		})
 
 		// Attempt to unwrap the error using the standard library's Unwrap method.
@@ -390,7 +479,7 @@ func (e *Event) SetException(exception error, maxErrorDepth int) {
 	// We only add to the most recent error to avoid duplication and because the
 	// current stack is most likely unrelated to errors deeper in the chain.
 	if e.Exception[0].Stacktrace == nil {
-		e.Exception[0].Stacktrace = NewStacktrace()
+		e.Exception[0].Stacktrace = e.pendingStacktrace(callers(), 0)
 	}
 
 	if len(e.Exception) <= 1 {
@@ -412,6 +501,28 @@ func (e *Event) SetException(exception error, maxErrorDepth int) {
 	}
 }
 
+// pendingStacktrace returns an empty Stacktrace that Event.resolveStacktraces
+// fills with the frames of pcs, or nil if there are no pcs.
+func (e *Event) pendingStacktrace(pcs []uintptr, skip int) *Stacktrace {
+	if len(pcs) == 0 {
+		return nil
+	}
+	stacktrace := &Stacktrace{}
+	e.sdkMetaData.pendingStacktraces = append(e.sdkMetaData.pendingStacktraces,
+		pendingStacktrace{stacktrace: stacktrace, pcs: pcs, skip: skip})
+	return stacktrace
+}
+
+// resolveStacktraces builds the frames of the stack traces captured with the
+// event. It must be called before anything outside of the SDK gets to see
+// the event.
+func (e *Event) resolveStacktraces() {
+	for _, p := range e.sdkMetaData.pendingStacktraces {
+		p.resolve()
+	}
+	e.sdkMetaData.pendingStacktraces = nil
+}
+
 // TODO: Event.Contexts map[string]interface{} => map[string]EventContext,
 // to prevent accidentally storing T when we mean *T.
 // For example, the TraceContext must be stored as *TraceContext to pick up the
@@ -420,6 +531,20 @@ func (e *Event) SetException(exception error, maxErrorDepth int) {
 
 // MarshalJSON converts the Event struct to JSON.
 func (e *Event) MarshalJSON() ([]byte, error) {
+	v, err := e.jsonValue(false)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+// jsonValue returns a value without a MarshalJSON method that encodes to the
+// JSON of the event. Encoding it directly saves the validation and copy of
+// the result of MarshalJSON that encoding/json makes for every value with a
+// MarshalJSON method. If flat is true, the same applies to the breadcrumbs
+// and spans of the event. Errors encoding them then no longer name their
+// type.
+func (e *Event) jsonValue(flat bool) (interface{}, error) {
 	// We want to omit time.Time zero values, otherwise the server will try to
 	// interpret dates too far in the past. However, encoding/json doesn't
 	// support the "omitempty" option for struct types. See
@@ -428,14 +553,27 @@ func (e *Event) MarshalJSON() ([]byte, error) {
 	// We overcome the limitation and achieve what we want by shadowing fields
 	// and a few type tricks.
 	if e.Type == transactionType {
-		return e.transactionMarshalJSON()
+		return e.transactionJSONValue(flat)
 	} else if e.Type == checkInType {
-		return e.checkInMarshalJSON()
+		return e.checkInJSONValue(), nil
 	}
-	return e.defaultMarshalJSON()
+	return e.defaultJSONValue(flat)
 }
 
-func (e *Event) defaultMarshalJSON() ([]byte, error) {
+// breadcrumbsJSONValue returns the breadcrumbs of the event to encode as the
+// shadowing breadcrumbs field of jsonValue, or nil if there are none.
+func (e *Event) breadcrumbsJSONValue(flat bool) interface{} {
+	switch {
+	case len(e.Breadcrumbs) == 0:
+		return nil
+	case flat:
+		return breadcrumbsJSON(e.Breadcrumbs)
+	default:
+		return e.Breadcrumbs
+	}
+}
+
+func (e *Event) defaultJSONValue(flat bool) (interface{}, error) {
 	// event aliases Event to allow calling json.Marshal without an infinite
 	// loop. It preserves all fields while none of the attached methods.
 	type event Event
@@ -443,6 +581,10 @@ func (e *Event) defaultMarshalJSON() ([]byte, error) {
 	// errorEvent is like Event with shadowed fields for customizing JSON
 	// marshaling.
 	type errorEvent struct {
+		// Breadcrumbs shadows the original Breadcrumbs field, see jsonValue.
+		// It comes first to keep the order of the fields.
+		Breadcrumbs interface{} `json:"breadcrumbs,omitempty"`
+
 		*event
 
 		// Timestamp shadows the original Timestamp field. It allows us to
@@ -457,9 +599,10 @@ func (e *Event) defaultMarshalJSON() ([]byte, error) {
 		StartTime       json.RawMessage `json:"start_timestamp,omitempty"`
 		Spans           json.RawMessage `json:"spans,omitempty"`
 		TransactionInfo json.RawMessage `json:"transaction_info,omitempty"`
+		Measurements    json.RawMessage `json:"measurements,omitempty"`
 	}
 
-	x := errorEvent{event: (*event)(e)}
+	x := errorEvent{event: (*event)(e), Breadcrumbs: e.breadcrumbsJSONValue(flat)}
 	if !e.Timestamp.IsZero() {
 		b, err := e.Timestamp.MarshalJSON()
 		if err != nil {
@@ -467,10 +610,10 @@ func (e *Event) defaultMarshalJSON() ([]byte, error) {
 		}
 		x.Timestamp = b
 	}
-	return json.Marshal(x)
+	return x, nil
 }
 
-func (e *Event) transactionMarshalJSON() ([]byte, error) {
+func (e *Event) transactionJSONValue(flat bool) (interface{}, error) {
 	// event aliases Event to allow calling json.Marshal without an infinite
 	// loop. It preserves all fields while none of the attached methods.
 	type event Event
@@ -478,16 +621,43 @@ func (e *Event) transactionMarshalJSON() ([]byte, error) {
 	// transactionEvent is like Event with shadowed fields for customizing JSON
 	// marshaling.
 	type transactionEvent struct {
+		// Breadcrumbs and Spans shadow the respective fields in Event, see
+		// jsonValue.
+		Breadcrumbs interface{} `json:"breadcrumbs,omitempty"`
+
 		*event
 
+		Spans interface{} `json:"spans,omitempty"`
+
 		// The fields below shadow the respective fields in Event. They allow us
 		// to include timestamps when non-zero and omit them otherwise.
 
 		StartTime json.RawMessage `json:"start_timestamp,omitempty"`
 		Timestamp json.RawMessage `json:"timestamp,omitempty"`
+
+		MetricsSummary spanMetricsSummary `json:"_metrics_summary,omitempty"`
 	}
 
-	x := transactionEvent{event: (*event)(e)}
+	x := transactionEvent{
+		event:          (*event)(e),
+		Breadcrumbs:    e.breadcrumbsJSONValue(flat),
+		MetricsSummary: e.sdkMetaData.metricsSummary,
+	}
+	switch {
+	case len(e.Spans) == 0:
+	case e.sdkMetaData.legacyProtocol:
+		spans := spansJSON(e.Spans)
+		for _, span := range spans {
+			if span != nil {
+				span.MetricsSummary = nil
+			}
+		}
+		x.Spans = spans
+	case flat:
+		x.Spans = spansJSON(e.Spans)
+	default:
+		x.Spans = e.Spans
+	}
 	if !e.Timestamp.IsZero() {
 		b, err := e.Timestamp.MarshalJSON()
 		if err != nil {
@@ -502,10 +672,10 @@ func (e *Event) transactionMarshalJSON() ([]byte, error) {
 		}
 		x.StartTime = b
 	}
-	return json.Marshal(x)
+	return x, nil
 }
 
-func (e *Event) checkInMarshalJSON() ([]byte, error) {
+func (e *Event) checkInJSONValue() interface{} {
 	checkIn := serializedCheckIn{
 		CheckInID:     string(e.CheckIn.ID),
 		MonitorSlug:   e.CheckIn.MonitorSlug,
@@ -525,7 +695,7 @@ func (e *Event) checkInMarshalJSON() ([]byte, error) {
 		}
 	}
 
-	return json.Marshal(checkIn)
+	return checkIn
 }
 
 // NewEvent creates a new Event.
@@ -548,6 +718,10 @@ type Thread struct {
 }
 
 // EventHint contains information that can be associated with an Event.
+//
+// The hint is passed to event processors, BeforeSend and
+// BeforeSendTransaction. Request is filled in from the scope or the context
+// of the hint when the event is captured in the scope of a request.
 type EventHint struct {
 	Data               interface{}
 	EventID            string
@@ -556,4 +730,23 @@ type EventHint struct {
 	Context            context.Context
 	Request            *http.Request
 	Response           *http.Response
+	// StatusCode is the status of the response to Request, if known when
+	// the event is captured and Response is not set, as on the server side.
+	StatusCode int
+	// Metadata is the metadata of the gRPC call the event is captured
+	// for, as in google.golang.org/grpc/metadata.MD. ReportGRPCStatus sets
+	// it, see ContinueTraceFromCarrier.
+	Metadata map[string][]string
+	// Carrier holds the headers of the message the event is captured for,
+	// for example when consuming from a queue. It is filled in from the
+	// scope, see ContinueTraceFromCarrier.
+	Carrier TraceCarrier
+	// Delivery, if non-nil, receives the delivery outcome of the event.
+	Delivery *Delivery
+	// Client, if non-nil, is the client the event is sent with instead of
+	// the client of the hub, for example one with the DSN of the project of
+	// a library embedded in many programs. It takes precedence over the
+	// router of the hub. The event is still created with the options of the
+	// client of the hub.
+	Client *Client
 }
@@ -91,10 +91,12 @@ func TestNewRequest(t *testing.T) {
 		Data:        "",
 		QueryString: "q=sentry",
 		Cookies:     "foo=bar",
+		// Credentials are filtered by DefaultHeaderDenylist, even with
+		// SendDefaultPII.
 		Headers: map[string]string{
-			"Authorization":       "Bearer 1234567890",
-			"Proxy-Authorization": "Bearer 123",
-			"Cookie":              "foo=bar",
+			"Authorization":       "[Filtered]",
+			"Proxy-Authorization": "[Filtered]",
+			"Cookie":              "[Filtered]",
 			"Host":                "example.com",
 			"X-Forwarded-For":     "127.0.0.1",
 			"X-Real-Ip":           "127.0.0.1",
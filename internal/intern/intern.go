@@ -0,0 +1,75 @@
+// Package intern deduplicates strings that appear over and over in the data
+// the SDK records, such as span operations, tag keys, span statuses and HTTP
+// header names.
+//
+// Only a fixed set of well-known strings is interned. The set never grows, so
+// lookups need no locking and arbitrary input cannot make it leak memory.
+package intern
+
+// known maps each well-known string to itself.
+var known = func() map[string]string {
+	m := make(map[string]string)
+	for _, group := range [][]string{
+		// Span operations.
+		{
+			"http.server", "http.client", "db", "db.query", "db.sql.query",
+			"db.sql.exec", "db.redis", "cache.get", "cache.put", "cache.remove",
+			"cache.flush", "queue.publish", "queue.process", "grpc.server",
+			"grpc.client", "function", "template.render", "ui.render", "file.read",
+			"file.write", "graphql.execute", "subprocess", "serialize",
+		},
+		// Span statuses.
+		{
+			"ok", "cancelled", "unknown", "invalid_argument", "deadline_exceeded",
+			"not_found", "already_exists", "permission_denied",
+			"resource_exhausted", "failed_precondition", "aborted", "out_of_range",
+			"unimplemented", "internal_error", "unavailable", "data_loss",
+			"unauthenticated",
+		},
+		// Tag keys and dynamic sampling context keys.
+		{
+			"environment", "release", "dist", "transaction", "level", "url",
+			"server_name", "user", "device", "os", "runtime", "browser",
+			"http.method", "http.status_code", "http.route", "http.url",
+			"http.request.method", "http.response.status_code", "db.system",
+			"db.operation", "trace_id", "public_key", "org_id", "sample_rate",
+			"sample_rand", "sampled", "user_segment", "true", "false",
+		},
+		// HTTP methods and header names in canonical form.
+		{
+			"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "CONNECT", "OPTIONS",
+			"TRACE", "Accept", "Accept-Encoding", "Accept-Language",
+			"Authorization", "Baggage", "Cache-Control", "Connection",
+			"Content-Length", "Content-Type", "Cookie", "Host", "Origin",
+			"Pragma", "Referer", "Sentry-Trace", "Traceparent", "Tracestate",
+			"Upgrade-Insecure-Requests", "User-Agent", "X-Forwarded-For",
+			"X-Forwarded-Host", "X-Forwarded-Proto", "X-Real-Ip",
+			"X-Request-Id",
+		},
+	} {
+		for _, s := range group {
+			m[s] = s
+		}
+	}
+	return m
+}()
+
+// String returns the interned copy of s if s is a well-known string, and s
+// otherwise. Interning lets the memory backing s be freed, for instance the
+// header a string was sliced from.
+func String(s string) string {
+	if k, ok := known[s]; ok {
+		return k
+	}
+	return s
+}
+
+// Bytes returns b as a string, without allocating if it is a well-known
+// string.
+func Bytes(b []byte) string {
+	// The compiler doesn't allocate for string(b) in a map index expression.
+	if k, ok := known[string(b)]; ok {
+		return k
+	}
+	return string(b)
+}
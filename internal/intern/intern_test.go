@@ -0,0 +1,31 @@
+package intern
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestString(t *testing.T) {
+	s := strings.Repeat("db.", 1) + "query"
+	got := String(s)
+	if got != "db.query" {
+		t.Fatalf("String(%q) = %q", s, got)
+	}
+	if got := String("custom.op"); got != "custom.op" {
+		t.Errorf("String(%q) = %q", "custom.op", got)
+	}
+}
+
+func TestBytes(t *testing.T) {
+	if got := Bytes([]byte("Content-Type")); got != "Content-Type" {
+		t.Errorf("Bytes(Content-Type) = %q", got)
+	}
+	if got := Bytes([]byte("X-Custom")); got != "X-Custom" {
+		t.Errorf("Bytes(X-Custom) = %q", got)
+	}
+
+	b := []byte("Content-Type")
+	if n := testing.AllocsPerRun(100, func() { Bytes(b) }); n != 0 {
+		t.Errorf("Bytes allocated %v times for a well-known string", n)
+	}
+}
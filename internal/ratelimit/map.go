@@ -18,6 +18,12 @@ func (m Map) IsRateLimited(c Category) bool {
 	return m.isRateLimited(c, time.Now())
 }
 
+// IsRateLimitedAt is like IsRateLimited, but checks the rate limit at the
+// given time instead of now.
+func (m Map) IsRateLimitedAt(c Category, now time.Time) bool {
+	return m.isRateLimited(c, now)
+}
+
 func (m Map) isRateLimited(c Category, now time.Time) bool {
 	return m.Deadline(c).After(Deadline(now))
 }
@@ -50,6 +56,12 @@ func FromResponse(r *http.Response) Map {
 	return fromResponse(r, time.Now())
 }
 
+// FromResponseAt is like FromResponse, but computes the deadlines of relative
+// rate limits from the given time instead of now.
+func FromResponseAt(r *http.Response, now time.Time) Map {
+	return fromResponse(r, now)
+}
+
 func fromResponse(r *http.Response, now time.Time) Map {
 	s := r.Header.Get("X-Sentry-Rate-Limits")
 	if s != "" {
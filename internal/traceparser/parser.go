@@ -72,6 +72,11 @@ func (t *Trace) GoID() (id uint64) {
 	return id
 }
 
+// Header returns the first line of the trace, e.g. "goroutine 7 [chan receive]:".
+func (t *Trace) Header() []byte {
+	return t.header
+}
+
 // UniqueIdentifier can be used as a map key to identify the trace.
 func (t *Trace) UniqueIdentifier() []byte {
 	return t.data
@@ -85,7 +85,7 @@ func (h *handler) handle(ctx iris.Context) {
 
 	transaction.SetData("http.request.method", ctx.Request().Method)
 
-	hub.Scope().SetRequest(ctx.Request())
+	hub.SetRequest(ctx.Request())
 	ctx.Values().Set(valuesKey, hub)
 	ctx.Values().Set(transactionKey, transaction)
 	defer h.recoverWithSentry(hub, ctx.Request())
@@ -0,0 +1,155 @@
+package sentry
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LoadSheddingLevel is the degree by which a client reduces the overhead of
+// the SDK under load, see ClientOptions.LoadShedding. Each level disables the
+// features of the previous ones and more; error capture is never disabled.
+type LoadSheddingLevel int32
+
+const (
+	// LoadSheddingNone keeps all features enabled.
+	LoadSheddingNone LoadSheddingLevel = iota
+	// LoadSheddingSourceContext leaves the source code around the frames of
+	// stack traces out of events.
+	LoadSheddingSourceContext
+	// LoadSheddingRequestBodies also neither buffers nor attaches the bodies
+	// of requests.
+	LoadSheddingRequestBodies
+	// LoadSheddingScopeData also leaves the breadcrumbs of the scope out of
+	// events, and does not record child spans. Transactions are still sent,
+	// with their root span only.
+	LoadSheddingScopeData
+)
+
+func (level LoadSheddingLevel) String() string {
+	switch level {
+	case LoadSheddingNone:
+		return "none"
+	case LoadSheddingSourceContext:
+		return "source_context"
+	case LoadSheddingRequestBodies:
+		return "request_bodies"
+	case LoadSheddingScopeData:
+		return "scope_data"
+	}
+	return "unknown"
+}
+
+// Defaults of LoadSheddingOptions.
+const (
+	defaultLoadSheddingCPUThreshold   = 0.9
+	defaultLoadSheddingQueueThreshold = 0.8
+	defaultLoadSheddingInterval       = 5 * time.Second
+)
+
+// LoadSheddingOptions configure ClientOptions.LoadShedding.
+type LoadSheddingOptions struct {
+	// CPUThreshold is the CPU usage of the process, as a fraction of
+	// GOMAXPROCS, above which the process is under pressure. Defaults to 0.9.
+	// The CPU usage is only available on Linux and macOS.
+	CPUThreshold float64
+	// QueueThreshold is the fill ratio of the queue of the transport above
+	// which the process is under pressure. Defaults to 0.8. The queue is only
+	// available with the transports of the SDK.
+	QueueThreshold float64
+	// Interval is the interval of the checks of the pressure. Each check
+	// under pressure raises the level by one, each other one lowers it by
+	// one. Defaults to 5s.
+	Interval time.Duration
+}
+
+// loadShedder holds the LoadSheddingLevel of a client. The pressure is
+// checked as events are captured and stats are read, at most once per
+// interval, rather than by a goroutine of its own.
+type loadShedder struct {
+	options LoadSheddingOptions
+	level   int32
+
+	mu        sync.Mutex
+	lastCheck time.Time
+	lastCPU   time.Duration
+}
+
+func newLoadShedder(options LoadSheddingOptions) *loadShedder {
+	if options.CPUThreshold <= 0 {
+		options.CPUThreshold = defaultLoadSheddingCPUThreshold
+	}
+	if options.QueueThreshold <= 0 {
+		options.QueueThreshold = defaultLoadSheddingQueueThreshold
+	}
+	if options.Interval <= 0 {
+		options.Interval = defaultLoadSheddingInterval
+	}
+	s := &loadShedder{options: options, lastCheck: time.Now()}
+	s.lastCPU, _ = readCPUTime()
+	return s
+}
+
+// current returns the current level.
+func (s *loadShedder) current() LoadSheddingLevel {
+	return LoadSheddingLevel(atomic.LoadInt32(&s.level))
+}
+
+// check updates the level if the interval passed since the last check.
+// queueFill is the fill ratio of the queue of the transport, or a negative
+// number if unknown.
+func (s *loadShedder) check(now time.Time, queueFill func() float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elapsed := now.Sub(s.lastCheck)
+	if elapsed < s.options.Interval {
+		return
+	}
+	s.lastCheck = now
+
+	pressure := queueFill() >= s.options.QueueThreshold
+	if cpu, err := readCPUTime(); err == nil {
+		usage := float64(cpu-s.lastCPU) / float64(elapsed) / float64(runtime.GOMAXPROCS(0))
+		s.lastCPU = cpu
+		pressure = pressure || usage >= s.options.CPUThreshold
+	}
+
+	level := s.current()
+	switch {
+	case pressure && level < LoadSheddingScopeData:
+		level++
+	case !pressure && level > LoadSheddingNone:
+		level--
+	default:
+		return
+	}
+	atomic.StoreInt32(&s.level, int32(level))
+	debugLog(LevelInfo, "Load shedding level changed", "level", level.String())
+}
+
+// loadShedding returns the current load shedding level of the client. It is
+// safe to call on a nil client.
+func (client *Client) loadShedding() LoadSheddingLevel {
+	if client == nil || client.shedder == nil {
+		return LoadSheddingNone
+	}
+	return client.shedder.current()
+}
+
+// checkLoad updates the load shedding level of the client, if enabled.
+func (client *Client) checkLoad() {
+	if client.shedder == nil {
+		return
+	}
+	client.shedder.check(time.Now(), func() float64 {
+		t, ok := client.Transport.(statsTransport)
+		if !ok || t.capacity() <= 0 {
+			return -1
+		}
+		var stats ClientStats
+		t.counters().fill(&stats)
+		return float64(stats.QueueDepth) / float64(t.capacity())
+	})
+}
@@ -0,0 +1,14 @@
+//go:build !linux && !darwin
+
+package sentry
+
+import (
+	"errors"
+	"time"
+)
+
+// readCPUTime returns the CPU time used by the process, which is not
+// supported on this platform.
+func readCPUTime() (time.Duration, error) {
+	return 0, errors.New("reading the CPU time is not supported")
+}
@@ -0,0 +1,68 @@
+package sentry
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLoadShedderCheck(t *testing.T) {
+	s := newLoadShedder(LoadSheddingOptions{CPUThreshold: 1e9, Interval: time.Second})
+	start := time.Now()
+	fill := 1.0
+	queueFill := func() float64 { return fill }
+
+	var levels []LoadSheddingLevel
+	for i := 1; i <= 7; i++ {
+		if i == 5 {
+			fill = 0
+		}
+		s.check(start.Add(time.Duration(i)*time.Second), queueFill)
+		// Checks within the interval don't change the level.
+		s.check(start.Add(time.Duration(i)*time.Second+time.Millisecond), queueFill)
+		levels = append(levels, s.current())
+	}
+	assertEqual(t, levels, []LoadSheddingLevel{
+		LoadSheddingSourceContext, LoadSheddingRequestBodies, LoadSheddingScopeData, LoadSheddingScopeData,
+		LoadSheddingRequestBodies, LoadSheddingSourceContext, LoadSheddingNone,
+	})
+}
+
+func TestLoadSheddingFeatures(t *testing.T) {
+	ctx := NewTestContext(ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1,
+		LoadShedding:     &LoadSheddingOptions{Interval: time.Hour},
+	})
+	hub := GetHubFromContext(ctx)
+	client := hub.Client()
+	atomic.StoreInt32(&client.shedder.level, int32(LoadSheddingScopeData))
+	assertEqual(t, client.Stats().LoadSheddingLevel, LoadSheddingScopeData)
+
+	hub.AddBreadcrumb(&Breadcrumb{Message: "crumb"}, nil)
+	hub.Scope().SetRequestBody([]byte("body"))
+	hub.CaptureException(errors.New("shed"))
+	event := client.Transport.(*TransportMock).lastEvent
+	if event == nil {
+		t.Fatal("errors must still be captured")
+	}
+	assertEqual(t, len(event.Breadcrumbs), 0)
+	for _, frame := range event.Exception[0].Stacktrace.Frames {
+		if len(frame.PreContext) > 0 || frame.ContextLine != "" {
+			t.Fatalf("frame %s has source context", frame.Function)
+		}
+	}
+
+	transaction := StartTransaction(ctx, "test")
+	transaction.StartChild("child").Finish()
+	transaction.Finish()
+	event = client.Transport.(*TransportMock).lastEvent
+	assertEqual(t, event.Type, transactionType)
+	assertEqual(t, len(event.Spans), 0)
+
+	atomic.StoreInt32(&client.shedder.level, int32(LoadSheddingNone))
+	hub.CaptureException(errors.New("full"))
+	event = client.Transport.(*TransportMock).lastEvent
+	assertEqual(t, len(event.Breadcrumbs), 1)
+}
@@ -0,0 +1,17 @@
+//go:build linux || darwin
+
+package sentry
+
+import (
+	"syscall"
+	"time"
+)
+
+// readCPUTime returns the user and system CPU time used by the process.
+func readCPUTime() (time.Duration, error) {
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err != nil {
+		return 0, err
+	}
+	return time.Duration(usage.Utime.Nano() + usage.Stime.Nano()), nil
+}
@@ -0,0 +1,101 @@
+package sentry
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// DebugLogger receives the debug messages of the SDK, see
+// ClientOptions.DebugLogger.
+//
+// keyvals holds alternating keys and values with details about the message,
+// such as the ID of an event, the reason it was dropped or the category of a
+// rate limit. Messages of SDK internals that carry no details are passed with
+// LevelDebug and no keyvals.
+type DebugLogger interface {
+	Log(level Level, msg string, keyvals ...interface{})
+}
+
+var (
+	debugLoggerMu sync.RWMutex
+	debugLogger   DebugLogger
+	// loggerPrefix and loggerFlags are the settings of Logger before a
+	// DebugLogger was installed, restored when it is removed.
+	loggerPrefix string
+	loggerFlags  int
+)
+
+// setDebugOutput directs the output of Logger to l if it is not nil, else to
+// w, or os.Stderr if w is nil, if debug is true, and discards it otherwise.
+func setDebugOutput(debug bool, w io.Writer, l DebugLogger) {
+	if !debug {
+		l = nil
+	}
+
+	debugLoggerMu.Lock()
+	switch {
+	case debugLogger == nil && l != nil:
+		loggerPrefix, loggerFlags = Logger.Prefix(), Logger.Flags()
+		// The DebugLogger adds timestamps and prefixes of its own.
+		Logger.SetPrefix("")
+		Logger.SetFlags(0)
+	case debugLogger != nil && l == nil:
+		Logger.SetPrefix(loggerPrefix)
+		Logger.SetFlags(loggerFlags)
+	}
+	debugLogger = l
+	debugLoggerMu.Unlock()
+
+	switch {
+	case !debug:
+		Logger.SetOutput(io.Discard)
+	case l != nil:
+		Logger.SetOutput(debugLoggerWriter{l})
+	case w != nil:
+		Logger.SetOutput(w)
+	default:
+		Logger.SetOutput(os.Stderr)
+	}
+}
+
+// debugLog logs msg with details to the DebugLogger if one is set, and to
+// Logger otherwise, with the details appended as key=value pairs.
+func debugLog(level Level, msg string, keyvals ...interface{}) {
+	debugLoggerMu.RLock()
+	l := debugLogger
+	debugLoggerMu.RUnlock()
+	if l != nil {
+		l.Log(level, msg, keyvals...)
+		return
+	}
+
+	if Logger.Writer() == io.Discard {
+		return
+	}
+	var b strings.Builder
+	b.WriteString(msg)
+	for i := 0; i < len(keyvals); i += 2 {
+		b.WriteByte(' ')
+		fmt.Fprint(&b, keyvals[i])
+		b.WriteByte('=')
+		if i+1 < len(keyvals) {
+			fmt.Fprint(&b, keyvals[i+1])
+		}
+	}
+	Logger.Print(b.String())
+}
+
+// debugLoggerWriter passes the messages written through Logger to a
+// DebugLogger.
+type debugLoggerWriter struct {
+	l DebugLogger
+}
+
+func (w debugLoggerWriter) Write(p []byte) (int, error) {
+	w.l.Log(LevelDebug, string(bytes.TrimRight(p, "\n")))
+	return len(p), nil
+}
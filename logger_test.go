@@ -0,0 +1,87 @@
+package sentry
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+type logEntry struct {
+	level   Level
+	msg     string
+	keyvals []interface{}
+}
+
+type recordingDebugLogger struct {
+	mu      sync.Mutex
+	entries []logEntry
+}
+
+func (l *recordingDebugLogger) Log(level Level, msg string, keyvals ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, logEntry{level, msg, keyvals})
+}
+
+func (l *recordingDebugLogger) find(msg string) (logEntry, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, e := range l.entries {
+		if e.msg == msg {
+			return e, true
+		}
+	}
+	return logEntry{}, false
+}
+
+func TestDebugLogger(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	logger := &recordingDebugLogger{}
+	defer setDebugOutput(false, nil, nil)
+	client, err := NewClient(ClientOptions{
+		Dsn:         "http://public@" + srv.Listener.Addr().String() + "/1",
+		Transport:   NewHTTPSyncTransport(),
+		Debug:       true,
+		DebugLogger: logger,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	eventID := client.CaptureMessage("rate limited", nil, NewScope())
+
+	entry, ok := logger.find("Event dropped")
+	if !ok {
+		t.Fatalf("no dropped event logged: %v", logger.entries)
+	}
+	assertEqual(t, entry.level, LevelInfo)
+	assertEqual(t, entry.keyvals, []interface{}{"event_id", *eventID, "reason", DropReasonRateLimit, "category", "error"})
+
+	if _, ok := logger.find("Integration installed: Environment"); !ok {
+		t.Errorf("Logger output not passed to the DebugLogger: %v", logger.entries)
+	}
+}
+
+func TestDebugLoggerRestoresLogger(t *testing.T) {
+	defer Logger.SetOutput(io.Discard)
+	setDebugOutput(true, nil, &recordingDebugLogger{})
+	assertEqual(t, Logger.Prefix(), "")
+
+	var b bytes.Buffer
+	setDebugOutput(true, &b, nil)
+	assertEqual(t, Logger.Prefix(), "[Sentry] ")
+	assertEqual(t, Logger.Flags(), log.LstdFlags)
+
+	Logger.SetFlags(0)
+	defer Logger.SetFlags(log.LstdFlags)
+	debugLog(LevelWarning, "Rate limited", "category", "error", "odd")
+	assertEqual(t, b.String(), "[Sentry] Rate limited category=error odd=\n")
+}
@@ -3,7 +3,9 @@ package sentrylogrus
 
 import (
 	"errors"
+	"math/rand"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -42,10 +44,24 @@ const (
 // It is not safe to configure the hook while logging is happening. Please
 // perform all configuration before using it.
 type Hook struct {
-	hub      *sentry.Hub
-	fallback FallbackFunc
-	keys     map[string]string
-	levels   []logrus.Level
+	hub         *sentry.Hub
+	fallback    FallbackFunc
+	keys        map[string]string
+	levels      []logrus.Level
+	sampleRates map[logrus.Level]float64
+
+	// rateLimit and ratePeriod are set by SetRateLimit, windows holds the
+	// current window per logger, protected by mu.
+	rateLimit  int
+	ratePeriod time.Duration
+	mu         sync.Mutex
+	windows    map[*logrus.Logger]*rateWindow
+}
+
+// rateWindow counts the entries of a logger sent within a period.
+type rateWindow struct {
+	start time.Time
+	count int
 }
 
 var _ logrus.Hook = &Hook{}
@@ -116,14 +132,68 @@ func (h *Hook) key(key string) string {
 	return key
 }
 
+// SetSampleRate sets the rate in the range [0.0, 1.0] at which entries of
+// level are sent to Sentry, for instance 0.01 to send 1% of the warnings.
+// Entries of levels without a sample rate are all sent.
+func (h *Hook) SetSampleRate(level logrus.Level, rate float64) {
+	if h.sampleRates == nil {
+		h.sampleRates = make(map[logrus.Level]float64)
+	}
+	h.sampleRates[level] = rate
+}
+
+// SetRateLimit limits the entries sent to Sentry to n per period for each
+// logger the hook is added to, so that a hot log line can't flood the
+// project. Entries beyond the limit are discarded. A non-positive n removes
+// the limit.
+func (h *Hook) SetRateLimit(n int, period time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.rateLimit = n
+	h.ratePeriod = period
+	h.windows = nil
+}
+
+// sample reports whether entry passes the sample rate of its level and the
+// rate limit of its logger.
+func (h *Hook) sample(entry *logrus.Entry) bool {
+	if rate, ok := h.sampleRates[entry.Level]; ok && rand.Float64() >= rate {
+		return false
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.rateLimit <= 0 {
+		return true
+	}
+	now := time.Now()
+	w := h.windows[entry.Logger]
+	if w == nil || now.Sub(w.start) >= h.ratePeriod {
+		if h.windows == nil {
+			h.windows = make(map[*logrus.Logger]*rateWindow)
+		}
+		w = &rateWindow{start: now}
+		h.windows[entry.Logger] = w
+	}
+	if w.count >= h.rateLimit {
+		return false
+	}
+	w.count++
+	return true
+}
+
 // Levels returns the list of logging levels that will be sent to
 // Sentry.
 func (h *Hook) Levels() []logrus.Level {
 	return h.levels
 }
 
-// Fire sends entry to Sentry.
+// Fire sends entry to Sentry, unless it is discarded by the sample rate of its
+// level or the rate limit, see SetSampleRate and SetRateLimit.
 func (h *Hook) Fire(entry *logrus.Entry) error {
+	if !h.sample(entry) {
+		return nil
+	}
 	event := h.entryToEvent(entry)
 	if id := h.hub.CaptureEvent(event); id == nil {
 		if h.fallback != nil {
@@ -150,7 +220,7 @@ func (h *Hook) entryToEvent(l *logrus.Entry) *sentry.Event {
 		data[k] = v
 	}
 	s := &sentry.Event{
-		Level:     levelMap[l.Level],
+		Level:     h.hub.Client().MapSeverity(sentry.SeverityLogLevel, l.Level, levelMap[l.Level]),
 		Extra:     data,
 		Message:   l.Message,
 		Timestamp: l.Time,
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -274,3 +275,37 @@ func Test_entryToEvent(t *testing.T) {
 		})
 	}
 }
+
+func TestHookSampling(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	sent := map[sentry.Level]int{}
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		BeforeSend: func(event *sentry.Event, _ *sentry.EventHint) *sentry.Event {
+			mu.Lock()
+			defer mu.Unlock()
+			sent[event.Level]++
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hook := NewFromClient([]logrus.Level{logrus.WarnLevel, logrus.ErrorLevel}, client)
+	hook.SetSampleRate(logrus.WarnLevel, 0)
+	hook.SetRateLimit(3, time.Hour)
+
+	hot, other := logrus.New(), logrus.New()
+	for i := 0; i < 10; i++ {
+		_ = hook.Fire(&logrus.Entry{Logger: hot, Level: logrus.WarnLevel})
+		_ = hook.Fire(&logrus.Entry{Logger: hot, Level: logrus.ErrorLevel})
+	}
+	_ = hook.Fire(&logrus.Entry{Logger: other, Level: logrus.ErrorLevel})
+
+	mu.Lock()
+	defer mu.Unlock()
+	if diff := cmp.Diff(map[sentry.Level]int{sentry.LevelError: 4}, sent); diff != "" {
+		t.Errorf("sent events mismatch (-want +got):\n%s", diff)
+	}
+}
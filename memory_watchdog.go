@@ -0,0 +1,132 @@
+package sentry
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"runtime/pprof"
+	"sync"
+	"time"
+)
+
+// defaultMemoryWatchdogInterval is the default interval between two checks of
+// a memory watchdog.
+const defaultMemoryWatchdogInterval = 10 * time.Second
+
+// MemoryWatchdogOptions configure StartMemoryWatchdog.
+type MemoryWatchdogOptions struct {
+	// HeapThreshold is the number of bytes of allocated heap objects above
+	// which an event is reported. Zero disables the heap check.
+	HeapThreshold uint64
+	// RSSThreshold is the resident set size in bytes above which an event is
+	// reported. Zero disables the RSS check. The RSS is only available on
+	// Linux, elsewhere the check never triggers.
+	RSSThreshold uint64
+	// Interval between two checks. Defaults to 10s.
+	Interval time.Duration
+	// Hub to report events to. Defaults to a clone of CurrentHub.
+	Hub *Hub
+}
+
+// StartMemoryWatchdog starts a goroutine that periodically checks the memory
+// usage of the program, and captures a warning event with a heap profile
+// attached when it crosses one of the thresholds. Another event is only
+// reported after the usage went below the threshold in the meantime, so that
+// a steadily high usage produces a single event.
+//
+// The profile is in the pprof format and can be inspected with
+// "go tool pprof". Call the returned function to stop the watchdog, it may be
+// called more than once.
+func StartMemoryWatchdog(options MemoryWatchdogOptions) (stop func()) {
+	if options.Interval <= 0 {
+		options.Interval = defaultMemoryWatchdogInterval
+	}
+	hub := options.Hub
+	if hub == nil {
+		hub = CurrentHub().Clone()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(options.Interval)
+		defer ticker.Stop()
+
+		w := memoryWatchdog{options: options, hub: hub}
+		for {
+			select {
+			case <-ticker.C:
+				w.check()
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
+
+// memoryWatchdog holds the state of the goroutine started by
+// StartMemoryWatchdog.
+type memoryWatchdog struct {
+	options MemoryWatchdogOptions
+	hub     *Hub
+	// heapExceeded and rssExceeded are true while the usage is above the
+	// threshold, after it was reported.
+	heapExceeded bool
+	rssExceeded  bool
+}
+
+func (w *memoryWatchdog) check() {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	rss, _ := readRSS()
+
+	var exceeded []string
+	if w.options.HeapThreshold > 0 {
+		over := stats.HeapAlloc > w.options.HeapThreshold
+		if over && !w.heapExceeded {
+			exceeded = append(exceeded, fmt.Sprintf("heap %d > %d bytes", stats.HeapAlloc, w.options.HeapThreshold))
+		}
+		w.heapExceeded = over
+	}
+	if w.options.RSSThreshold > 0 {
+		over := rss > w.options.RSSThreshold
+		if over && !w.rssExceeded {
+			exceeded = append(exceeded, fmt.Sprintf("RSS %d > %d bytes", rss, w.options.RSSThreshold))
+		}
+		w.rssExceeded = over
+	}
+	if len(exceeded) == 0 {
+		return
+	}
+
+	event := NewEvent()
+	event.Level = LevelWarning
+	event.Message = "Memory threshold exceeded: " + exceeded[0]
+	for _, e := range exceeded[1:] {
+		event.Message += ", " + e
+	}
+	memory := Context{
+		"heap_alloc":   stats.HeapAlloc,
+		"heap_sys":     stats.HeapSys,
+		"heap_objects": stats.HeapObjects,
+		"num_gc":       stats.NumGC,
+	}
+	if rss > 0 {
+		memory["rss"] = rss
+	}
+	event.Contexts["memory"] = memory
+	event.Fingerprint = []string{"sentry-memory-watchdog"}
+
+	var profile bytes.Buffer
+	if err := pprof.Lookup("heap").WriteTo(&profile, 0); err != nil {
+		Logger.Printf("Memory watchdog could not write the heap profile: %v", err)
+	} else {
+		event.Attachments = append(event.Attachments, &Attachment{
+			Filename:    "heap.pprof",
+			ContentType: "application/octet-stream",
+			Payload:     profile.Bytes(),
+		})
+	}
+	w.hub.CaptureEvent(event)
+}
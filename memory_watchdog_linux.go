@@ -0,0 +1,26 @@
+package sentry
+
+import (
+	"bytes"
+	"os"
+	"strconv"
+)
+
+// readRSS returns the resident set size of the process in bytes.
+func readRSS() (uint64, error) {
+	statm, err := os.ReadFile("/proc/self/statm")
+	if err != nil {
+		return 0, err
+	}
+	// statm holds the total program size followed by the resident set size,
+	// both in pages.
+	fields := bytes.Fields(statm)
+	if len(fields) < 2 {
+		return 0, strconv.ErrSyntax
+	}
+	pages, err := strconv.ParseUint(string(fields[1]), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return pages * uint64(os.Getpagesize()), nil
+}
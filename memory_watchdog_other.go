@@ -0,0 +1,11 @@
+//go:build !linux
+
+package sentry
+
+import "errors"
+
+// readRSS returns the resident set size of the process in bytes, which is not
+// supported on this platform.
+func readRSS() (uint64, error) {
+	return 0, errors.New("reading the RSS is not supported")
+}
@@ -0,0 +1,57 @@
+package sentry
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestMemoryWatchdog(t *testing.T) {
+	transport := &TransportMock{}
+	client, _ := NewClient(ClientOptions{Dsn: testDsn, Transport: transport})
+	w := memoryWatchdog{
+		options: MemoryWatchdogOptions{HeapThreshold: 1},
+		hub:     NewHub(client, NewScope()),
+	}
+
+	w.check()
+	w.check()
+	events := transport.Events()
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1 while the heap stays above the threshold", len(events))
+	}
+	event := events[0]
+	assertEqual(t, event.Level, LevelWarning)
+	if _, ok := event.Contexts["memory"]["heap_alloc"]; !ok {
+		t.Errorf("missing heap_alloc in memory context %v", event.Contexts["memory"])
+	}
+	if len(event.Attachments) != 1 || event.Attachments[0].Filename != "heap.pprof" ||
+		len(event.Attachments[0].Payload) == 0 {
+		t.Errorf("got attachments %v, want a heap profile", event.Attachments)
+	}
+
+	// Going below the threshold re-arms the watchdog.
+	w.options.HeapThreshold = ^uint64(0)
+	w.check()
+	w.options.HeapThreshold = 1
+	w.check()
+	assertEqual(t, len(transport.Events()), 2)
+}
+
+func TestMemoryWatchdogStopTwice(t *testing.T) {
+	stop := StartMemoryWatchdog(MemoryWatchdogOptions{Hub: NewHub(nil, NewScope())})
+	stop()
+	stop()
+}
+
+func TestReadRSS(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the RSS is only available on Linux")
+	}
+	rss, err := readRSS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rss == 0 {
+		t.Error("got an RSS of 0")
+	}
+}
@@ -0,0 +1,74 @@
+package sentry
+
+import (
+	"sync"
+	"time"
+)
+
+// messageThrottle collects the messages captured with
+// Hub.CaptureMessageThrottled, see there.
+type messageThrottle struct {
+	mu      sync.Mutex
+	pending map[string]*throttledMessage
+}
+
+// throttledMessage is the event of a throttled message, sent when its window
+// ends.
+type throttledMessage struct {
+	// client is the client the event is routed to.
+	client *Client
+	event  *Event
+	// scope is a clone of the scope of the first occurrence.
+	scope *Scope
+	count int
+	timer *time.Timer
+}
+
+// add counts an occurrence of message. The first occurrence within a window
+// creates the event with newMessage and schedules sending it when the window
+// ends. add returns the ID of the event.
+func (t *messageThrottle) add(message string, window time.Duration, newMessage func() *throttledMessage) EventID {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if m, ok := t.pending[message]; ok {
+		m.count++
+		return m.event.EventID
+	}
+	if t.pending == nil {
+		t.pending = make(map[string]*throttledMessage)
+	}
+	m := newMessage()
+	m.count = 1
+	t.pending[message] = m
+	m.timer = time.AfterFunc(window, func() {
+		t.mu.Lock()
+		pending := t.pending[message] == m
+		if pending {
+			delete(t.pending, message)
+		}
+		t.mu.Unlock()
+		if pending {
+			m.send()
+		}
+	})
+	return m.event.EventID
+}
+
+// flush sends the events of all pending messages right away.
+func (t *messageThrottle) flush() {
+	t.mu.Lock()
+	pending := t.pending
+	t.pending = nil
+	t.mu.Unlock()
+
+	for _, m := range pending {
+		m.timer.Stop()
+		m.send()
+	}
+}
+
+func (m *throttledMessage) send() {
+	m.event.Extra["occurrences"] = m.count
+	m.client.CaptureEvent(m.event, nil, m.scope)
+}
@@ -0,0 +1,43 @@
+package sentry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCaptureMessageThrottled(t *testing.T) {
+	hub, client, scope := setupHubTest()
+	transport := client.Transport.(*TransportMock)
+	scope.SetTag("loop", "first")
+
+	first := hub.CaptureMessageThrottled("disk almost full", time.Hour)
+	scope.SetTag("loop", "later")
+	for i := 0; i < 4; i++ {
+		assertEqual(t, *hub.CaptureMessageThrottled("disk almost full", time.Hour), *first)
+	}
+	other := hub.CaptureMessageThrottled("other", time.Hour)
+	assertEqual(t, hub.LastEventID(), *other)
+	assertEqual(t, len(transport.Events()), 0)
+
+	hub.Flush(time.Second)
+	events := transport.Events()
+	assertEqual(t, len(events), 2)
+	for _, event := range events {
+		switch event.Message {
+		case "disk almost full":
+			assertEqual(t, event.EventID, *first)
+			assertEqual(t, event.Extra["occurrences"], 5)
+			assertEqual(t, event.Tags["loop"], "first")
+		case "other":
+			assertEqual(t, event.Extra["occurrences"], 1)
+		}
+	}
+
+	// The window ends by itself.
+	hub.CaptureMessageThrottled("short", time.Millisecond)
+	deadline := time.Now().Add(time.Second)
+	for len(transport.Events()) < 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	assertEqual(t, len(transport.Events()), 3)
+}
@@ -72,7 +72,7 @@ func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request, next http.Ha
 		client.SetSDKIdentifier(sdkIdentifier)
 	}
 
-	hub.Scope().SetRequest(r)
+	hub.SetRequest(r)
 	ctx = sentry.SetHubOnContext(
 		context.WithValue(ctx, sentry.RequestContextKey, r),
 		hub,
@@ -103,7 +103,7 @@ func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request, next http.Ha
 	// information on the transaction accordingly (status, tag,
 	// level?, ...).
 	r = r.WithContext(transaction.Context())
-	hub.Scope().SetRequest(r)
+	hub.SetRequest(r)
 	defer h.recoverWithSentry(hub, r)
 	next(rw, r.WithContext(ctx))
 }
@@ -127,8 +127,8 @@ func (h *handler) recoverWithSentry(hub *sentry.Hub, r *http.Request) {
 // which let you "plug-in" to it's own handler.
 func PanicHandlerFunc(info *negroni.PanicInformation) {
 	hub := sentry.CurrentHub().Clone()
-	hub.WithScope(func(scope *sentry.Scope) {
-		scope.SetRequest(info.Request)
+	hub.WithScope(func(*sentry.Scope) {
+		hub.SetRequest(info.Request)
 		hub.RecoverWithContext(
 			context.WithValue(context.Background(), sentry.RequestContextKey, info.Request),
 			info.RecoveredPanic,
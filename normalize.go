@@ -0,0 +1,213 @@
+package sentry
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+const (
+	// maxNormalizeDepth is the maximum nesting of user-supplied values which
+	// is sent to Sentry. Deeper values are replaced with a placeholder.
+	maxNormalizeDepth = 10
+	// maxNormalizeBreadth is the maximum number of elements of a slice, an
+	// array or a map which is sent to Sentry.
+	maxNormalizeBreadth = 100
+)
+
+// normalizeEventData makes the user-supplied values in the extras, contexts
+// and breadcrumbs of event safe to encode as JSON, such that a single bad
+// value cannot make the marshaling of the whole event fail.
+//
+// Values are never modified in place, the maps and breadcrumbs of the event
+// are replaced by normalized copies instead, as they might be shared with a
+// scope or a span.
+func normalizeEventData(event *Event) {
+	if len(event.Extra) > 0 {
+		event.Extra = normalizeMap(event.Extra)
+	}
+
+	if len(event.Contexts) > 0 {
+		contexts := make(map[string]Context, len(event.Contexts))
+		for k, v := range event.Contexts {
+			contexts[k] = normalizeMap(v)
+		}
+		event.Contexts = contexts
+	}
+
+	if len(event.Breadcrumbs) > 0 {
+		breadcrumbs := make([]*Breadcrumb, len(event.Breadcrumbs))
+		for i, b := range event.Breadcrumbs {
+			if b == nil || len(b.Data) == 0 {
+				breadcrumbs[i] = b
+				continue
+			}
+			normalized := *b
+			normalized.Data = normalizeMap(b.Data)
+			breadcrumbs[i] = &normalized
+		}
+		event.Breadcrumbs = breadcrumbs
+	}
+}
+
+func normalizeMap(m map[string]interface{}) map[string]interface{} {
+	n := newNormalizer()
+	normalized := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		normalized[k] = n.normalize(v, 1)
+	}
+	return normalized
+}
+
+// normalizer converts arbitrary values to JSON-encodable ones.
+type normalizer struct {
+	// visiting holds the pointers, maps and slices on the path to the value
+	// currently being normalized, to break reference cycles.
+	visiting map[uintptr]bool
+}
+
+func newNormalizer() *normalizer {
+	return &normalizer{visiting: make(map[uintptr]bool)}
+}
+
+func (n *normalizer) normalize(v interface{}, depth int) interface{} {
+	switch v := v.(type) {
+	case nil, bool, string, json.Number,
+		int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64,
+		float32, float64:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case time.Duration:
+		return v.String()
+	case error:
+		if isNilValue(v) {
+			return nil
+		}
+		return v.Error()
+	case json.Marshaler:
+		if isNilValue(v) {
+			return nil
+		}
+		if _, err := json.Marshal(v); err != nil {
+			return fmt.Sprintf("%+v", v)
+		}
+		return v
+	case encoding.TextMarshaler:
+		if isNilValue(v) {
+			return nil
+		}
+		if _, err := v.MarshalText(); err != nil {
+			return fmt.Sprintf("%+v", v)
+		}
+		return v
+	case fmt.Stringer:
+		if isNilValue(v) {
+			return nil
+		}
+		return v.String()
+	}
+
+	if depth > maxNormalizeDepth {
+		return "[max depth reached]"
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		// Named types of basic kinds encode fine.
+		return v
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return nil
+		}
+		if rv.Kind() == reflect.Ptr {
+			if n.visiting[rv.Pointer()] {
+				return "[circular reference]"
+			}
+			n.visiting[rv.Pointer()] = true
+			defer delete(n.visiting, rv.Pointer())
+		}
+		return n.normalize(rv.Elem().Interface(), depth+1)
+	case reflect.Slice:
+		if rv.IsNil() {
+			return nil
+		}
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			// []byte encodes as base64.
+			return v
+		}
+		if n.visiting[rv.Pointer()] {
+			return "[circular reference]"
+		}
+		n.visiting[rv.Pointer()] = true
+		defer delete(n.visiting, rv.Pointer())
+		return n.normalizeList(rv, depth)
+	case reflect.Array:
+		return n.normalizeList(rv, depth)
+	case reflect.Map:
+		if rv.IsNil() {
+			return nil
+		}
+		if n.visiting[rv.Pointer()] {
+			return "[circular reference]"
+		}
+		n.visiting[rv.Pointer()] = true
+		defer delete(n.visiting, rv.Pointer())
+		return n.normalizeMapValue(rv, depth)
+	case reflect.Struct:
+		b, err := json.Marshal(v)
+		if err != nil || (string(b) == "{}" && rv.NumField() > 0) {
+			// The struct has no exported fields or cannot be encoded, fall
+			// back to its textual representation.
+			return fmt.Sprintf("%+v", v)
+		}
+		return v
+	case reflect.Complex64, reflect.Complex128:
+		return fmt.Sprint(v)
+	default:
+		// Functions, channels and unsafe pointers.
+		return fmt.Sprintf("[%T]", v)
+	}
+}
+
+func (n *normalizer) normalizeList(rv reflect.Value, depth int) interface{} {
+	length := rv.Len()
+	if length > maxNormalizeBreadth {
+		length = maxNormalizeBreadth
+	}
+	list := make([]interface{}, length)
+	for i := 0; i < length; i++ {
+		list[i] = n.normalize(rv.Index(i).Interface(), depth+1)
+	}
+	return list
+}
+
+func (n *normalizer) normalizeMapValue(rv reflect.Value, depth int) interface{} {
+	m := make(map[string]interface{}, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		if len(m) == maxNormalizeBreadth {
+			break
+		}
+		m[fmt.Sprint(iter.Key().Interface())] = n.normalize(iter.Value().Interface(), depth+1)
+	}
+	return m
+}
+
+// isNilValue reports whether v holds a nil pointer, as is possible for
+// interface values with a method set.
+func isNilValue(v interface{}) bool {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface, reflect.Func, reflect.Chan:
+		return rv.IsNil()
+	}
+	return false
+}
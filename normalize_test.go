@@ -0,0 +1,113 @@
+package sentry
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type normalizeStringer struct{}
+
+func (normalizeStringer) String() string { return "stringer" }
+
+type normalizeUnexported struct {
+	a int
+	b string
+}
+
+type normalizeNode struct {
+	Name string
+	Next *normalizeNode
+}
+
+func TestNormalize(t *testing.T) {
+	tests := map[string]struct {
+		value interface{}
+		want  string
+	}{
+		"string":     {"value", `"value"`},
+		"int":        {42, `42`},
+		"time":       {time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), `"2024-01-02T03:04:05Z"`},
+		"duration":   {1500 * time.Millisecond, `"1.5s"`},
+		"error":      {errors.New("boom"), `"boom"`},
+		"nil error":  {(*usageError)(nil), `null`},
+		"stringer":   {normalizeStringer{}, `"stringer"`},
+		"unexported": {normalizeUnexported{a: 1, b: "x"}, `"{a:1 b:x}"`},
+		"func":       {func() {}, `"[func()]"`},
+		"chan":       {make(chan int), `"[chan int]"`},
+		"complex":    {complex(1, 2), `"(1+2i)"`},
+		"map keys":   {map[int]time.Duration{1: time.Second}, `{"1":"1s"}`},
+		"slice":      {[]interface{}{1, func() {}}, `[1,"[func()]"]`},
+		"bytes":      {[]byte("hi"), `"aGk="`},
+		"span id":    {SpanID{1, 2, 3, 4, 5, 6, 7, 8}, `"0102030405060708"`},
+		"struct":     {normalizeNode{Name: "a"}, `{"Name":"a","Next":null}`},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := json.Marshal(newNormalizer().normalize(tt.value, 1))
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertEqual(t, string(got), tt.want)
+		})
+	}
+}
+
+func TestNormalizeBreaksCycles(t *testing.T) {
+	node := &normalizeNode{Name: "a"}
+	node.Next = node
+	m := map[string]interface{}{}
+	m["self"] = m
+
+	extra := normalizeMap(map[string]interface{}{"node": node, "map": m, "list": []*normalizeNode{node, node}})
+	if _, err := json.Marshal(extra); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, extra["map"], map[string]interface{}{"self": "[circular reference]"})
+	// The same pointer twice is not a cycle.
+	list := extra["list"].([]interface{})
+	assertEqual(t, list[0], list[1])
+}
+
+func TestNormalizeLimits(t *testing.T) {
+	long := make([]int, maxNormalizeBreadth+10)
+	nested := interface{}("leaf")
+	for i := 0; i < maxNormalizeDepth+5; i++ {
+		nested = []interface{}{nested}
+	}
+
+	extra := normalizeMap(map[string]interface{}{"long": long, "nested": nested})
+	assertEqual(t, len(extra["long"].([]interface{})), maxNormalizeBreadth)
+	b, err := json.Marshal(extra["nested"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "[max depth reached]") {
+		t.Errorf("expected nesting to be cut, got %s", b)
+	}
+}
+
+func TestNormalizeEventDataDoesNotModifySources(t *testing.T) {
+	extra := map[string]interface{}{"f": func() {}}
+	breadcrumb := &Breadcrumb{Data: map[string]interface{}{"d": time.Second}}
+	event := &Event{
+		Extra:       extra,
+		Contexts:    map[string]Context{"c": {"ch": make(chan int)}},
+		Breadcrumbs: []*Breadcrumb{breadcrumb},
+	}
+
+	normalizeEventData(event)
+
+	if _, err := json.Marshal(event); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, event.Breadcrumbs[0].Data["d"], "1s")
+	if _, ok := extra["f"].(func()); !ok {
+		t.Error("original extra was modified")
+	}
+	if _, ok := breadcrumb.Data["d"].(time.Duration); !ok {
+		t.Error("original breadcrumb was modified")
+	}
+}
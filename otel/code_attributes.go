@@ -0,0 +1,107 @@
+//go:build go1.18
+
+package sentryotel
+
+import (
+	"path/filepath"
+
+	"github.com/getsentry/sentry-go"
+	"go.opentelemetry.io/otel/attribute"
+	otelSdkTrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// linkCodeAttributes connects the frame an error event was captured at and
+// the span active at the capture, in both directions: the span gets the
+// code.* attributes of the frame, so that OpenTelemetry backends show where
+// it failed, and an exception without a stack trace gets the frame described
+// by the code.* attributes of the span, if it has them.
+func linkCodeAttributes(event *sentry.Event, otelSpan trace.Span) {
+	if frame, ok := culpritFrame(event); ok {
+		if otelSpan.IsRecording() {
+			otelSpan.SetAttributes(codeAttributes(frame)...)
+		}
+		return
+	}
+	if len(event.Exception) == 0 {
+		return
+	}
+	s, ok := otelSpan.(otelSdkTrace.ReadOnlySpan)
+	if !ok {
+		return
+	}
+	if frame, ok := frameFromCodeAttributes(s.Attributes()); ok {
+		exception := &event.Exception[len(event.Exception)-1]
+		exception.Stacktrace = &sentry.Stacktrace{Frames: []sentry.Frame{frame}}
+	}
+}
+
+// culpritFrame returns the innermost frame of the stack trace of the
+// exception of event, preferring frames of the application.
+func culpritFrame(event *sentry.Event) (sentry.Frame, bool) {
+	if len(event.Exception) == 0 {
+		return sentry.Frame{}, false
+	}
+	stacktrace := event.Exception[len(event.Exception)-1].Stacktrace
+	if stacktrace == nil || len(stacktrace.Frames) == 0 {
+		return sentry.Frame{}, false
+	}
+	frames := stacktrace.Frames
+	for i := len(frames) - 1; i >= 0; i-- {
+		if frames[i].InApp {
+			return frames[i], true
+		}
+	}
+	return frames[len(frames)-1], true
+}
+
+// codeAttributes returns the code.* attributes of the semantic conventions
+// describing frame.
+func codeAttributes(frame sentry.Frame) []attribute.KeyValue {
+	var attributes []attribute.KeyValue
+	if frame.Module != "" {
+		attributes = append(attributes, semconv.CodeNamespaceKey.String(frame.Module))
+	}
+	if frame.Function != "" {
+		attributes = append(attributes, semconv.CodeFunctionKey.String(frame.Function))
+	}
+	if path := frame.AbsPath; path != "" {
+		attributes = append(attributes, semconv.CodeFilepathKey.String(path))
+	} else if frame.Filename != "" {
+		attributes = append(attributes, semconv.CodeFilepathKey.String(frame.Filename))
+	}
+	if frame.Lineno > 0 {
+		attributes = append(attributes, semconv.CodeLineNumberKey.Int(frame.Lineno))
+	}
+	return attributes
+}
+
+// frameFromCodeAttributes returns the frame described by the code.*
+// attributes among attributes. It reports false if there is neither a
+// function nor a file.
+func frameFromCodeAttributes(attributes []attribute.KeyValue) (sentry.Frame, bool) {
+	var frame sentry.Frame
+	for _, kv := range attributes {
+		switch kv.Key {
+		case semconv.CodeNamespaceKey:
+			frame.Module = kv.Value.AsString()
+		case semconv.CodeFunctionKey:
+			frame.Function = kv.Value.AsString()
+		case semconv.CodeFilepathKey:
+			if path := kv.Value.AsString(); filepath.IsAbs(path) {
+				frame.AbsPath = path
+			} else {
+				frame.Filename = path
+			}
+		case semconv.CodeLineNumberKey:
+			frame.Lineno = int(kv.Value.AsInt64())
+		}
+	}
+	if frame.Function == "" && frame.AbsPath == "" && frame.Filename == "" {
+		return sentry.Frame{}, false
+	}
+	// Instrumentations describe the code of the application with them.
+	frame.InApp = true
+	return frame, true
+}
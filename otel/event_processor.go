@@ -36,5 +36,6 @@ func linkTraceContextToErrorEvent(event *sentry.Event, hint *sentry.EventHint) *
 	traceContext["trace_id"] = sentrySpan.TraceID.String()
 	traceContext["span_id"] = sentrySpan.SpanID.String()
 	traceContext["parent_span_id"] = sentrySpan.ParentSpanID.String()
+	linkCodeAttributes(event, trace.SpanFromContext(hint.Context))
 	return event
 }
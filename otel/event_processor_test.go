@@ -8,6 +8,9 @@ import (
 	"testing"
 
 	"github.com/getsentry/sentry-go"
+	"go.opentelemetry.io/otel/attribute"
+	otelSdkTrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
 )
 
 func TestLinkTraceContextToErrorEventSetsContext(t *testing.T) {
@@ -55,3 +58,61 @@ func TestLinkTraceContextToErrorEventSetsContext(t *testing.T) {
 		})
 	}
 }
+
+func TestLinkCodeAttributesToSpan(t *testing.T) {
+	_, _, tracer := setupSpanProcessorTest()
+	ctx, otelSpan := tracer.Start(emptyContextWithSentry(), "spanName")
+
+	culprit := sentry.Frame{Module: "example.com/shop", Function: "Checkout", AbsPath: "/src/shop/checkout.go", Lineno: 42, InApp: true}
+	event := &sentry.Event{Contexts: map[string]sentry.Context{}, Exception: []sentry.Exception{{
+		Type: "error",
+		Stacktrace: &sentry.Stacktrace{Frames: []sentry.Frame{
+			{Module: "main", Function: "main", AbsPath: "/src/main.go", Lineno: 10, InApp: true},
+			culprit,
+			{Module: "database/sql", Function: "(*DB).Query", AbsPath: "/go/src/database/sql/sql.go", Lineno: 1700},
+		}},
+	}}}
+	linkTraceContextToErrorEvent(event, &sentry.EventHint{Context: ctx})
+	otelSpan.End()
+
+	frame, _ := culpritFrame(event)
+	assertEqual(t, frame, culprit)
+	assertEqual(t, otelSpan.(otelSdkTrace.ReadOnlySpan).Attributes(), []attribute.KeyValue{
+		semconv.CodeNamespaceKey.String("example.com/shop"),
+		semconv.CodeFunctionKey.String("Checkout"),
+		semconv.CodeFilepathKey.String("/src/shop/checkout.go"),
+		semconv.CodeLineNumberKey.Int(42),
+	})
+}
+
+func TestLinkCodeAttributesToEvent(t *testing.T) {
+	_, _, tracer := setupSpanProcessorTest()
+	ctx, otelSpan := tracer.Start(emptyContextWithSentry(), "spanName")
+	otelSpan.SetAttributes(
+		semconv.CodeNamespaceKey.String("example.com/shop"),
+		semconv.CodeFunctionKey.String("Checkout"),
+		semconv.CodeFilepathKey.String("/src/shop/checkout.go"),
+		semconv.CodeLineNumberKey.Int(42),
+	)
+
+	event := &sentry.Event{Contexts: map[string]sentry.Context{}, Exception: []sentry.Exception{{Type: "error"}}}
+	linkTraceContextToErrorEvent(event, &sentry.EventHint{Context: ctx})
+	otelSpan.End()
+
+	assertEqual(t, event.Exception[0].Stacktrace, &sentry.Stacktrace{Frames: []sentry.Frame{{
+		Module:   "example.com/shop",
+		Function: "Checkout",
+		AbsPath:  "/src/shop/checkout.go",
+		Lineno:   42,
+		InApp:    true,
+	}}})
+}
+
+func TestFrameFromCodeAttributes(t *testing.T) {
+	if _, ok := frameFromCodeAttributes([]attribute.KeyValue{semconv.CodeLineNumberKey.Int(1)}); ok {
+		t.Error("frame without function or file")
+	}
+	frame, ok := frameFromCodeAttributes([]attribute.KeyValue{semconv.CodeFilepathKey.String("shop/checkout.go")})
+	assertEqual(t, ok, true)
+	assertEqual(t, frame, sentry.Frame{Filename: "shop/checkout.go", InApp: true})
+}
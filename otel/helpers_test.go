@@ -5,6 +5,7 @@ package sentryotel
 import (
 	"encoding/hex"
 	"sort"
+	"strconv"
 	"sync"
 	"testing"
 	"time"
@@ -48,6 +49,9 @@ func assertMapCarrierEqual(t *testing.T, got, want propagation.MapCarrier, userM
 		if key == sentry.SentryBaggageHeader {
 			gotBaggage, gotErr := baggage.Parse(gotValue)
 			wantBaggage, wantErr := baggage.Parse(wantValue)
+			if wantBaggage.Member("sentry-sample_rand").Key() == "" {
+				gotBaggage = withoutSampleRand(t, gotBaggage)
+			}
 
 			if diff := cmp.Diff(wantErr, gotErr); diff != "" {
 				t.Errorf("Comparing Baggage parsing errors (-want +got):\n%s", diff)
@@ -70,6 +74,23 @@ func assertMapCarrierEqual(t *testing.T, got, want propagation.MapCarrier, userM
 	}
 }
 
+// withoutSampleRand checks that the sentry-sample_rand member of bag, which
+// is random for new traces, is valid and removes it so that bag can be
+// compared with a fixed one.
+func withoutSampleRand(t *testing.T, bag baggage.Baggage) baggage.Baggage {
+	t.Helper()
+
+	member := bag.Member("sentry-sample_rand")
+	if member.Key() == "" {
+		return bag
+	}
+	value := member.Value()
+	if r, err := strconv.ParseFloat(value, 64); err != nil || r < 0 || r >= 1 {
+		t.Errorf("Invalid sentry-sample_rand in baggage: %q", value)
+	}
+	return bag.DeleteMember("sentry-sample_rand")
+}
+
 // FIXME: copied from tracing_test.go
 func TraceIDFromHex(s string) sentry.TraceID {
 	var id sentry.TraceID
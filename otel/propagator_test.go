@@ -219,10 +219,11 @@ func TestExtractSetsUndefinedDynamicSamplingContext(t *testing.T) {
 
 	ctx := propagator.Extract(context.Background(), carrier)
 
-	assertEqual(t,
-		ctx.Value(dynamicSamplingContextKey{}),
-		sentry.DynamicSamplingContext{Entries: map[string]string{}, Frozen: false},
-	)
+	dsc, _ := ctx.Value(dynamicSamplingContextKey{}).(sentry.DynamicSamplingContext)
+	assertEqual(t, dsc.Entries, map[string]string{})
+	assertEqual(t, dsc.Frozen, false)
+	// Third-party members are kept.
+	assertEqual(t, dsc.String(), "othervendor=bla")
 }
 
 // With sentry-trace header, no baggage header
@@ -276,18 +277,18 @@ func TestExtractSetsDefinedDynamicSamplingContext(t *testing.T) {
 
 	ctx := propagator.Extract(context.Background(), carrier)
 
+	dsc, _ := ctx.Value(dynamicSamplingContextKey{}).(sentry.DynamicSamplingContext)
 	assertEqual(t,
-		ctx.Value(dynamicSamplingContextKey{}),
-		sentry.DynamicSamplingContext{
-			Entries: map[string]string{
-				"environment": "production",
-				"public_key":  "abc",
-				"release":     "1.0.0",
-				"trace_id":    "d4cda95b652f4a1592b449d5929fda1b",
-				"transaction": "dsc-transaction",
-			},
-			Frozen: true},
+		dsc.Entries,
+		map[string]string{
+			"environment": "production",
+			"public_key":  "abc",
+			"release":     "1.0.0",
+			"trace_id":    "d4cda95b652f4a1592b449d5929fda1b",
+			"transaction": "dsc-transaction",
+		},
 	)
+	assertEqual(t, dsc.Frozen, true)
 }
 
 /// Integration tests
@@ -18,6 +18,10 @@ type sentrySpanProcessor struct{}
 // At the moment we do not support multiple instances.
 var sentrySpanProcessorInstance *sentrySpanProcessor
 
+// NewSentrySpanProcessor returns the span processor that converts the spans
+// of OpenTelemetry to Sentry spans and transactions. The hint passed to
+// BeforeSendTransaction with a transaction holds the ended root span as its
+// Data, an otelSdkTrace.ReadOnlySpan.
 func NewSentrySpanProcessor() otelSdkTrace.SpanProcessor {
 	if sentrySpanProcessorInstance != nil {
 		return sentrySpanProcessorInstance
@@ -79,6 +83,9 @@ func (ssp *sentrySpanProcessor) OnEnd(s otelSdkTrace.ReadOnlySpan) {
 
 	if sentrySpan.IsTransaction() {
 		updateTransactionWithOtelData(sentrySpan, s)
+		// Let BeforeSendTransaction look at the attributes that are not
+		// mapped to the transaction.
+		sentrySpan.SetHintData(s)
 	} else {
 		updateSpanWithOtelData(sentrySpan, s)
 	}
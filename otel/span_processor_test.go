@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/getsentry/sentry-go"
+	"github.com/getsentry/sentry-go/internal/otel/baggage"
 	"github.com/getsentry/sentry-go/internal/testutils"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/sdk/resource"
@@ -121,9 +122,10 @@ func TestOnStartRootSpan(t *testing.T) {
 	assertEqual(t, sentrySpan.Sampled, sentry.SampledTrue)
 	assertEqual(t, sentrySpan.Name, "spanName")
 
+	gotBaggage, _ := baggage.Parse(sentrySpan.ToBaggage())
 	testutils.AssertBaggageStringsEqual(
 		t,
-		sentrySpan.ToBaggage(),
+		withoutSampleRand(t, gotBaggage).String(),
 		"sentry-transaction=spanName,sentry-environment=testing,sentry-public_key=abc,sentry-release=1.2.3,sentry-sample_rate=1,sentry-sampled=true,sentry-trace_id="+otelTraceId.String(),
 	)
 }
@@ -256,17 +258,43 @@ func TestOnEndWithTransaction(t *testing.T) {
 	assertEqual(
 		t,
 		otelContextGot,
+		// Contexts are normalized, maps keyed by attribute.Key become
+		// map[string]interface{}.
 		map[string]interface{}{
-			"attributes": map[attribute.Key]interface{}{
+			"attributes": map[string]interface{}{
 				"key1": "value1",
 			},
-			"resource": map[attribute.Key]interface{}{
+			"resource": map[string]interface{}{
 				"service.name": "test-otel",
 			},
 		},
 	)
 }
 
+func TestOnEndHintSpan(t *testing.T) {
+	_, _, tracer := setupSpanProcessorTest()
+	var hintSpan otelSdkTrace.ReadOnlySpan
+	client, _ := sentry.NewClient(sentry.ClientOptions{
+		Dsn:              "https://abc@example.com/123",
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		Transport:        &TransportMock{},
+		BeforeSendTransaction: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			hintSpan, _ = hint.Data.(otelSdkTrace.ReadOnlySpan)
+			return event
+		},
+	})
+	ctx := sentry.SetHubOnContext(context.Background(), sentry.NewHub(client, sentry.NewScope()))
+	_, otelSpan := tracer.Start(ctx, "transactionName", trace.WithAttributes(attribute.String("unmapped", "value")))
+	otelSpan.End()
+
+	if hintSpan == nil {
+		t.Fatal("hint.Data is not the OpenTelemetry span")
+	}
+	assertEqual(t, hintSpan.SpanContext().SpanID(), otelSpan.SpanContext().SpanID())
+	assertEqual(t, hintSpan.Attributes(), []attribute.KeyValue{attribute.String("unmapped", "value")})
+}
+
 func TestOnEndWithChildSpan(t *testing.T) {
 	_, _, tracer := setupSpanProcessorTest()
 	ctx, otelRootSpan := tracer.Start(emptyContextWithSentry(), "rootSpan")
@@ -0,0 +1,56 @@
+package sentry
+
+// PIIOptions select the kinds of personally identifiable information (PII)
+// the SDK and its integrations attach to events, see ClientOptions.PII.
+type PIIOptions struct {
+	// ClientIP attaches the address of the client of HTTP requests, as the
+	// REMOTE_ADDR and the X-Forwarded-For and X-Real-Ip headers.
+	ClientIP bool
+	// Cookies attaches the cookies of HTTP requests and the Set-Cookie
+	// headers of HTTP responses, subject to ClientOptions.CookieDenylist.
+	Cookies bool
+	// Headers attaches the Authorization and Proxy-Authorization headers of
+	// HTTP requests, subject to ClientOptions.HeaderDenylist.
+	Headers bool
+	// RequestBodies attaches the bodies of HTTP requests, subject to
+	// ClientOptions.MaxRequestBodySize.
+	RequestBodies bool
+	// UserDetails lets integrations attach the usernames, names and email
+	// addresses of the users they identify, in addition to their IDs.
+	UserDetails bool
+}
+
+// PII returns the kinds of personally identifiable information the client
+// attaches to events. They are ClientOptions.PII if set, and otherwise all
+// kinds if ClientOptions.SendDefaultPII is enabled, and only request bodies
+// if it is not. It is safe to call on a nil client.
+func (client *Client) PII() PIIOptions {
+	if client == nil {
+		return PIIOptions{RequestBodies: true}
+	}
+	if client.options.PII != nil {
+		return *client.options.PII
+	}
+	on := client.options.SendDefaultPII
+	return PIIOptions{
+		ClientIP:      on,
+		Cookies:       on,
+		Headers:       on,
+		RequestBodies: true,
+		UserDetails:   on,
+	}
+}
+
+// allowsHeader reports whether the request header name may be attached to
+// events.
+func (pii PIIOptions) allowsHeader(name string) bool {
+	switch name {
+	case "Authorization", "Proxy-Authorization":
+		return pii.Headers
+	case "Cookie":
+		return pii.Cookies
+	case "X-Forwarded-For", "X-Real-Ip":
+		return pii.ClientIP
+	}
+	return true
+}
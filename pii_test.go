@@ -0,0 +1,47 @@
+package sentry
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClientPII(t *testing.T) {
+	var client *Client
+	assertEqual(t, client.PII(), PIIOptions{RequestBodies: true})
+
+	client = &Client{options: ClientOptions{SendDefaultPII: true}}
+	assertEqual(t, client.PII(), PIIOptions{ClientIP: true, Cookies: true, Headers: true, RequestBodies: true, UserDetails: true})
+
+	client = &Client{options: ClientOptions{SendDefaultPII: true, PII: &PIIOptions{Cookies: true}}}
+	assertEqual(t, client.PII(), PIIOptions{Cookies: true})
+}
+
+func TestNewRequestPII(t *testing.T) {
+	currentHub.BindClient(&Client{
+		options: ClientOptions{
+			PII:            &PIIOptions{Headers: true},
+			HeaderDenylist: []string{},
+		},
+	})
+	defer currentHub.stackTop().SetClient(nil)
+
+	r := httptest.NewRequest("POST", "/test/", strings.NewReader("body"))
+	r.Header.Add("Authorization", "Bearer 123")
+	r.Header.Add("Cookie", "foo=bar")
+	r.Header.Add("X-Forwarded-For", "127.0.0.1")
+
+	got := NewRequest(r)
+	assertEqual(t, got.Headers, map[string]string{"Authorization": "Bearer 123", "Host": "example.com"})
+	assertEqual(t, got.Cookies, "")
+	assertEqual(t, got.Env, map[string]string(nil))
+}
+
+func TestRequestBodiesPII(t *testing.T) {
+	hub, client, scope := setupHubTest()
+	client.options.PII = &PIIOptions{ClientIP: true}
+	hub.SetRequestBody([]byte("secret"))
+	if scope.requestBody != nil {
+		t.Errorf("request body attached: %q", scope.requestBody.Bytes())
+	}
+}
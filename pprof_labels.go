@@ -0,0 +1,21 @@
+package sentry
+
+import "runtime/pprof"
+
+// applyPprofLabels adds the pprof labels of the context of hint to event as
+// tags, keeping the tags already set.
+func applyPprofLabels(event *Event, hint *EventHint) {
+	ctx := hintContext(hint)
+	if ctx == nil {
+		return
+	}
+	var labels map[string]string
+	pprof.ForLabels(ctx, func(key, value string) bool {
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		labels[key] = value
+		return true
+	})
+	addMissingTags(event, labels)
+}
@@ -0,0 +1,25 @@
+package sentry
+
+import (
+	"context"
+	"errors"
+	"runtime/pprof"
+	"testing"
+)
+
+func TestAttachPprofLabels(t *testing.T) {
+	transport := &TransportMock{}
+	client, err := NewClient(ClientOptions{Transport: transport, AttachPprofLabels: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	scope := NewScope()
+	scope.SetTag("shard", "scope")
+
+	pprof.Do(context.Background(), pprof.Labels("job_id", "42", "shard", "7"), func(ctx context.Context) {
+		client.CaptureException(errors.New("job failed"), &EventHint{Context: ctx}, scope)
+	})
+	event := transport.lastEvent
+	assertEqual(t, event.Tags["job_id"], "42")
+	assertEqual(t, event.Tags["shard"], "scope")
+}
@@ -1,10 +1,11 @@
 package sentry
 
-import (
-	"crypto/rand"
-	"encoding/json"
-)
+import "encoding/json"
 
+// PropagationContext holds the trace information of a scope that has no span,
+// used to link errors to traces when performance monitoring is not in use. It
+// is propagated to downstream services in the "sentry-trace" and "baggage"
+// headers, see GetTraceHeader and GetBaggageHeader.
 type PropagationContext struct {
 	TraceID                TraceID                `json:"trace_id"`
 	SpanID                 SpanID                 `json:"span_id"`
@@ -27,6 +28,8 @@ func (p PropagationContext) MarshalJSON() ([]byte, error) {
 	})
 }
 
+// Map returns the propagation context as it is stored in the trace context of
+// events.
 func (p PropagationContext) Map() map[string]interface{} {
 	m := map[string]interface{}{
 		"trace_id": p.TraceID,
@@ -40,26 +43,32 @@ func (p PropagationContext) Map() map[string]interface{} {
 	return m
 }
 
+// NewPropagationContext returns a propagation context for a new trace. Its
+// trace ID and span ID are generated with the ClientOptions.IDGenerator of the
+// client of the current hub, and are random by default.
 func NewPropagationContext() PropagationContext {
-	p := PropagationContext{}
-
-	if _, err := rand.Read(p.TraceID[:]); err != nil {
-		panic(err)
-	}
+	return newPropagationContext(clientIDGenerator(CurrentHub().Client()))
+}
 
-	if _, err := rand.Read(p.SpanID[:]); err != nil {
-		panic(err)
+func newPropagationContext(ids IDGenerator) PropagationContext {
+	return PropagationContext{
+		TraceID: ids.TraceID(),
+		SpanID:  ids.SpanID(),
 	}
-
-	return p
 }
 
+// PropagationContextFromHeaders returns a propagation context that continues
+// the trace of the given "sentry-trace" and "baggage" header values. If trace
+// is empty or malformed, a new trace is started. IDs are generated like with
+// NewPropagationContext.
+//
+// The baggage header is parsed strictly: a malformed member makes it fail.
 func PropagationContextFromHeaders(trace, baggage string) (PropagationContext, error) {
-	p := NewPropagationContext()
+	return propagationContextFromHeaders(trace, baggage, true, clientIDGenerator(CurrentHub().Client()))
+}
 
-	if _, err := rand.Read(p.SpanID[:]); err != nil {
-		panic(err)
-	}
+func propagationContextFromHeaders(trace, baggage string, strictBaggage bool, ids IDGenerator) (PropagationContext, error) {
+	p := newPropagationContext(ids)
 
 	hasTrace := false
 	if trace != "" {
@@ -71,7 +80,7 @@ func PropagationContextFromHeaders(trace, baggage string) (PropagationContext, e
 	}
 
 	if baggage != "" {
-		dsc, err := DynamicSamplingContextFromHeader([]byte(baggage))
+		dsc, err := parseDynamicSamplingContext([]byte(baggage), strictBaggage)
 		if err != nil {
 			return PropagationContext{}, err
 		}
@@ -70,8 +70,9 @@ func TestPropagationContextFromHeaders(t *testing.T) {
 			baggageStr: "other-vendor-key1=value1;value2, other-vendor-key2=value3",
 			want: PropagationContext{
 				DynamicSamplingContext: DynamicSamplingContext{
-					Frozen:  false,
-					Entries: map[string]string{},
+					Frozen:     false,
+					Entries:    map[string]string{},
+					thirdParty: baggageMembers([]byte("other-vendor-key1=value1;value2, other-vendor-key2=value3")),
 				},
 			},
 		},
@@ -143,3 +144,32 @@ func TestNewPropagationContext(t *testing.T) {
 		t.Errorf("SpanID should not be zero")
 	}
 }
+
+func TestPropagationContextIDGenerator(t *testing.T) {
+	client, err := NewClient(ClientOptions{IDGenerator: &sequentialIDGenerator{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	CurrentHub().PushScope()
+	defer CurrentHub().PopScope()
+	CurrentHub().BindClient(client)
+
+	p := NewPropagationContext()
+	assertEqual(t, p.TraceID.String(), "00000000000000000000000000000001")
+	assertEqual(t, p.SpanID.String(), "0000000000000002")
+
+	const trace = "d49d9bf66f13450b81f65bc51cf49c03-a9f442f9330b4e09-1"
+	p, err = PropagationContextFromHeaders(trace, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, p.TraceID.String(), "d49d9bf66f13450b81f65bc51cf49c03")
+	assertEqual(t, p.SpanID.String(), "0000000000000004")
+
+	// Hubs use the generator of their own client.
+	hub := NewHub(client, NewScope())
+	if _, err := hub.ContinueTrace(trace, ""); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, hub.Scope().PropagationContext().SpanID.String(), "0000000000000006")
+}
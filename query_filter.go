@@ -0,0 +1,107 @@
+package sentry
+
+import (
+	"net/url"
+	"strings"
+)
+
+// FilterQuery returns rawQuery with the values of the parameters listed in
+// ClientOptions.QueryParamDenylist replaced with "[Filtered]". The other
+// parameters and their order are kept as is. It is safe to call on a nil
+// client.
+func (client *Client) FilterQuery(rawQuery string) string {
+	if client == nil || len(client.options.QueryParamDenylist) == 0 || rawQuery == "" {
+		return rawQuery
+	}
+	params := strings.Split(rawQuery, "&")
+	for i, param := range params {
+		key, _, _ := strings.Cut(param, "=")
+		if name, err := url.QueryUnescape(key); err == nil && containsFold(client.options.QueryParamDenylist, name) {
+			params[i] = key + "=" + filteredValue
+		}
+	}
+	return strings.Join(params, "&")
+}
+
+// FilterCookies returns the value of a Cookie header with the values of the
+// cookies listed in ClientOptions.CookieDenylist replaced with "[Filtered]".
+// It is safe to call on a nil client.
+func (client *Client) FilterCookies(cookies string) string {
+	if client == nil || len(client.options.CookieDenylist) == 0 || cookies == "" {
+		return cookies
+	}
+	parts := strings.Split(cookies, ";")
+	for i, part := range parts {
+		name, _, _ := strings.Cut(part, "=")
+		if containsFold(client.options.CookieDenylist, strings.TrimSpace(name)) {
+			parts[i] = name + "=" + filteredValue
+		}
+	}
+	return strings.Join(parts, ";")
+}
+
+// filterURL applies FilterQuery to the query string of a URL, or of a span
+// description such as "GET https://example.com/?token=secret".
+func (client *Client) filterURL(s string) string {
+	i := strings.IndexByte(s, '?')
+	if i < 0 {
+		return s
+	}
+	query, fragment := s[i+1:], ""
+	if j := strings.IndexByte(query, '#'); j >= 0 {
+		query, fragment = query[:j], query[j:]
+	}
+	return s[:i+1] + client.FilterQuery(query) + fragment
+}
+
+// filterBreadcrumbQuery applies FilterQuery to the "url" and "http.query"
+// data of breadcrumb. The data is copied rather than modified.
+func (client *Client) filterBreadcrumbQuery(breadcrumb *Breadcrumb) {
+	if len(client.options.QueryParamDenylist) == 0 {
+		return
+	}
+	var data map[string]interface{}
+	for key, filter := range map[string]func(string) string{
+		"url":        client.filterURL,
+		"http.query": client.FilterQuery,
+	} {
+		value, ok := breadcrumb.Data[key].(string)
+		if !ok {
+			continue
+		}
+		if filtered := filter(value); filtered != value {
+			if data == nil {
+				data = make(map[string]interface{}, len(breadcrumb.Data))
+				for k, v := range breadcrumb.Data {
+					data[k] = v
+				}
+			}
+			data[key] = filtered
+		}
+	}
+	if data != nil {
+		breadcrumb.Data = data
+	}
+}
+
+// filterSpanQueries applies FilterQuery to the descriptions and "url" data
+// of the HTTP spans among spans, which are finished.
+func (client *Client) filterSpanQueries(spans []*Span) {
+	if len(client.options.QueryParamDenylist) == 0 {
+		return
+	}
+	for _, span := range spans {
+		if !strings.HasPrefix(span.Op, "http") {
+			continue
+		}
+		span.Description = client.filterURL(span.Description)
+		span.mu.Lock()
+		if u, ok := span.Data["url"].(string); ok {
+			span.Data["url"] = client.filterURL(u)
+		}
+		if q, ok := span.Data["http.query"].(string); ok {
+			span.Data["http.query"] = client.FilterQuery(q)
+		}
+		span.mu.Unlock()
+	}
+}
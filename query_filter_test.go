@@ -0,0 +1,89 @@
+package sentry
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFilterQuery(t *testing.T) {
+	client := &Client{options: ClientOptions{QueryParamDenylist: []string{"token", "api key"}}}
+	tests := map[string]string{
+		"":                               "",
+		"q=go":                           "q=go",
+		"q=go&token=secret&page=2":       "q=go&token=[Filtered]&page=2",
+		"TOKEN=secret&token":             "TOKEN=[Filtered]&token=[Filtered]",
+		"api+key=1&api%20key=2&apikey=3": "api+key=[Filtered]&api%20key=[Filtered]&apikey=3",
+	}
+	for query, want := range tests {
+		assertEqual(t, client.FilterQuery(query), want)
+	}
+
+	var nilClient *Client
+	assertEqual(t, nilClient.FilterQuery("token=secret"), "token=secret")
+}
+
+func TestFilterCookies(t *testing.T) {
+	client := &Client{options: ClientOptions{CookieDenylist: []string{"session"}}}
+	assertEqual(t, client.FilterCookies("theme=dark; session=abc; Session=def"), "theme=dark; session=[Filtered]; Session=[Filtered]")
+	assertEqual(t, (&Client{}).FilterCookies("session=abc"), "session=abc")
+}
+
+func TestFilterURL(t *testing.T) {
+	client := &Client{options: ClientOptions{QueryParamDenylist: []string{"token"}}}
+	assertEqual(t, client.filterURL("GET https://example.com/?token=secret#top"), "GET https://example.com/?token=[Filtered]#top")
+	assertEqual(t, client.filterURL("GET https://example.com/#top"), "GET https://example.com/#top")
+}
+
+func TestBreadcrumbQuery(t *testing.T) {
+	hub, client, scope := setupHubTest()
+	client.options.QueryParamDenylist = []string{"token"}
+	data := map[string]interface{}{"url": "https://example.com/?token=secret", "http.query": "token=secret", "method": "GET"}
+	hub.AddBreadcrumb(&Breadcrumb{Category: "http", Data: data}, nil)
+
+	breadcrumbs := scope.breadcrumbs.list()
+	assertEqual(t, len(breadcrumbs), 1)
+	assertEqual(t, breadcrumbs[0].Data, map[string]interface{}{
+		"url": "https://example.com/?token=[Filtered]", "http.query": "token=[Filtered]", "method": "GET",
+	})
+	assertEqual(t, data["url"], "https://example.com/?token=secret")
+}
+
+func TestSpanQuery(t *testing.T) {
+	ctx := NewTestContext(ClientOptions{EnableTracing: true, TracesSampleRate: 1, QueryParamDenylist: []string{"token"}})
+	transaction := StartTransaction(ctx, "test")
+	span := transaction.StartChild("http.client", WithDescription("GET https://example.com/?token=secret&q=go"))
+	span.SetData("url", "https://example.com/?token=secret")
+	span.Finish()
+	db := transaction.StartChild("db.sql", WithDescription("SELECT 1 -- ?token=secret"))
+	db.Finish()
+	transaction.Finish()
+
+	events := hubFromContext(ctx).Client().Transport.(*TransportMock).Events()
+	assertEqual(t, len(events), 1)
+	spans := events[0].Spans
+	assertEqual(t, spans[0].Description, "GET https://example.com/?token=[Filtered]&q=go")
+	assertEqual(t, spans[0].Data["url"], "https://example.com/?token=[Filtered]")
+	assertEqual(t, spans[1].Description, "SELECT 1 -- ?token=secret")
+}
+
+func TestNewRequestQueryAndCookies(t *testing.T) {
+	client, err := NewClient(ClientOptions{
+		SendDefaultPII:     true,
+		QueryParamDenylist: []string{"token"},
+		CookieDenylist:     []string{"session"},
+		HeaderDenylist:     []string{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	currentHub := CurrentHub()
+	defer currentHub.BindClient(currentHub.Client())
+	currentHub.BindClient(client)
+
+	r := httptest.NewRequest("GET", "https://example.com/search?q=go&token=secret", nil)
+	r.Header.Set("Cookie", "theme=dark; session=abc")
+	request := NewRequest(r)
+	assertEqual(t, request.QueryString, "q=go&token=[Filtered]")
+	assertEqual(t, request.Cookies, "theme=dark; session=[Filtered]")
+	assertEqual(t, request.Headers["Cookie"], "theme=dark; session=[Filtered]")
+}
@@ -0,0 +1,85 @@
+package sentry
+
+import (
+	"context"
+	"time"
+)
+
+// Operations of queue spans, as recognized by the Queues insights of Sentry.
+// See https://develop.sentry.dev/sdk/telemetry/traces/modules/queues/.
+const (
+	QueuePublish = "queue.publish"
+	QueueProcess = "queue.process"
+)
+
+// QueueMessage describes a message sent through a message queue, for the spans
+// started by StartQueuePublishSpan and StartQueueProcessSpan. Zero fields are
+// not recorded.
+type QueueMessage struct {
+	// ID is the identifier of the message assigned by the queue or by the
+	// producer.
+	ID string
+	// Destination is the name of the queue or topic.
+	Destination string
+	// System identifies the queue system, e.g. "kafka", "rabbitmq" or "sqs".
+	System string
+	// BodySize is the size of the message body in bytes.
+	BodySize int
+	// RetryCount is the number of times the message has been delivered
+	// before this attempt to process it.
+	RetryCount int
+	// PublishedAt is the time the message was published. When processing
+	// the message, the time since then is recorded as its receive latency.
+	PublishedAt time.Time
+}
+
+// StartQueuePublishSpan starts a span for publishing msg. The span is a child of
+// the span stored in ctx, if any, like with StartSpan. Propagate the trace with
+// the message, for instance with InjectTraceHeaders, so that consumers
+// continue it.
+func StartQueuePublishSpan(ctx context.Context, msg QueueMessage, options ...SpanOption) *Span {
+	span := StartSpan(ctx, QueuePublish, append([]SpanOption{WithDescription(msg.Destination)}, options...)...)
+	msg.setData(span)
+	return span
+}
+
+// StartQueueProcessSpan starts a span for processing msg. Without a span in
+// ctx, it starts a transaction named after the destination of msg; pass
+// ContinueFromCarrier with the headers of the message to continue the trace of
+// the producer.
+func StartQueueProcessSpan(ctx context.Context, msg QueueMessage, options ...SpanOption) *Span {
+	defaults := []SpanOption{WithDescription(msg.Destination)}
+	if SpanFromContext(ctx) == nil && msg.Destination != "" {
+		defaults = append(defaults, WithTransactionName(msg.Destination), WithTransactionSource(SourceTask))
+	}
+	span := StartSpan(ctx, QueueProcess, append(defaults, options...)...)
+	msg.setData(span)
+	if msg.RetryCount > 0 {
+		span.SetData("messaging.message.retry.count", msg.RetryCount)
+	}
+	if !msg.PublishedAt.IsZero() {
+		latency := span.StartTime.Sub(msg.PublishedAt)
+		if latency < 0 {
+			// The clocks of producer and consumer disagree.
+			latency = 0
+		}
+		span.SetData("messaging.message.receive.latency", latency.Milliseconds())
+	}
+	return span
+}
+
+// setData records the attributes common to publish and process spans.
+func (msg QueueMessage) setData(span *Span) {
+	if msg.ID != "" {
+		span.SetData("messaging.message.id", msg.ID)
+	}
+	if msg.Destination != "" {
+		span.SetData("messaging.destination.name", msg.Destination)
+	}
+	if msg.System != "" {
+		span.SetData("messaging.system", msg.System)
+	}
+	if msg.BodySize > 0 {
+		span.SetData("messaging.message.body.size", msg.BodySize)
+	}
+}
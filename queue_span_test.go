@@ -0,0 +1,49 @@
+package sentry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQueueSpans(t *testing.T) {
+	ctx := NewTestContext(ClientOptions{EnableTracing: true, TracesSampleRate: 1.0})
+	transaction := StartTransaction(ctx, "producer")
+
+	publish := StartQueuePublishSpan(transaction.Context(), QueueMessage{
+		ID:          "msg-1",
+		Destination: "orders",
+		System:      "kafka",
+		BodySize:    512,
+	})
+	headers := MapCarrier{}
+	InjectTraceHeaders(publish.Context(), headers)
+	publish.Finish()
+
+	assertEqual(t, publish.Op, "queue.publish")
+	assertEqual(t, publish.ParentSpanID, transaction.SpanID)
+	assertEqual(t, publish.Description, "orders")
+	assertEqual(t, publish.Data, map[string]interface{}{
+		"messaging.message.id":        "msg-1",
+		"messaging.destination.name":  "orders",
+		"messaging.system":            "kafka",
+		"messaging.message.body.size": 512,
+	})
+
+	process := StartQueueProcessSpan(ctx, QueueMessage{
+		ID:          "msg-1",
+		Destination: "orders",
+		RetryCount:  2,
+		PublishedAt: time.Now().Add(-time.Second),
+	}, ContinueFromCarrier(headers))
+	process.Finish()
+
+	assertEqual(t, process.IsTransaction(), true)
+	assertEqual(t, process.Name, "orders")
+	assertEqual(t, process.Source, SourceTask)
+	assertEqual(t, process.TraceID, transaction.TraceID)
+	assertEqual(t, process.ParentSpanID, publish.SpanID)
+	assertEqual(t, process.Data["messaging.message.retry.count"], 2)
+	if latency := process.Data["messaging.message.receive.latency"].(int64); latency < 1000 {
+		t.Errorf("receive latency = %dms, want at least 1000ms", latency)
+	}
+}
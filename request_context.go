@@ -0,0 +1,86 @@
+package sentry
+
+import (
+	"context"
+	"net/http"
+)
+
+// applyRequestContext adds the values ClientOptions.RequestContextExtractor
+// extracts from the context of hint to event, keeping those already set.
+func (client *Client) applyRequestContext(event *Event, hint *EventHint) {
+	extract := client.options.RequestContextExtractor
+	if extract == nil {
+		return
+	}
+	ctx := hintContext(hint)
+	if ctx == nil {
+		return
+	}
+
+	tags, user, contexts := extract(ctx)
+	addMissingTags(event, tags)
+	if event.User.IsEmpty() {
+		event.User = user
+	}
+	if len(contexts) > 0 && event.Contexts == nil {
+		event.Contexts = make(map[string]Context, len(contexts))
+	}
+	for k, v := range contexts {
+		if _, ok := event.Contexts[k]; !ok {
+			event.Contexts[k] = v
+		}
+	}
+}
+
+// completeHint returns hint with the Request filled in from the context of
+// hint or from scope, and the Carrier from scope, if it has none. hint is
+// copied rather than modified.
+func completeHint(hint *EventHint, scope EventModifier) *EventHint {
+	request, carrier := hint.Request, hint.Carrier
+	if request == nil && hint.Context != nil {
+		request, _ = hint.Context.Value(RequestContextKey).(*http.Request)
+	}
+	if s, ok := scope.(*Scope); ok && s != nil && (request == nil || carrier == nil) {
+		s.mu.RLock()
+		if request == nil {
+			request = s.request
+		}
+		if carrier == nil {
+			carrier = s.carrier
+		}
+		s.mu.RUnlock()
+	}
+	// Carriers may be maps, which cannot be compared.
+	if request == hint.Request && (carrier == nil || hint.Carrier != nil) {
+		return hint
+	}
+	h := *hint
+	h.Request, h.Carrier = request, carrier
+	return &h
+}
+
+// hintContext returns the context an event is captured with, that of the
+// request of hint if hint has none, or nil.
+func hintContext(hint *EventHint) context.Context {
+	switch {
+	case hint == nil:
+		return nil
+	case hint.Context != nil:
+		return hint.Context
+	case hint.Request != nil:
+		return hint.Request.Context()
+	}
+	return nil
+}
+
+// addMissingTags sets the tags on event that it doesn't have yet.
+func addMissingTags(event *Event, tags map[string]string) {
+	if len(tags) > 0 && event.Tags == nil {
+		event.Tags = make(map[string]string, len(tags))
+	}
+	for k, v := range tags {
+		if _, ok := event.Tags[k]; !ok {
+			event.Tags[k] = v
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package sentry
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+)
+
+type requestIDKey struct{}
+
+func TestRequestContextExtractor(t *testing.T) {
+	transport := &TransportMock{}
+	client, err := NewClient(ClientOptions{
+		Transport: transport,
+		RequestContextExtractor: func(ctx context.Context) (map[string]string, User, map[string]Context) {
+			id, _ := ctx.Value(requestIDKey{}).(string)
+			return map[string]string{"request_id": id, "tenant": "extracted"},
+				User{ID: "user-" + id},
+				map[string]Context{"request_context": {"id": id}}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	scope := NewScope()
+	scope.SetTag("tenant", "scope")
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "42")
+
+	client.CaptureException(errors.New("failed"), &EventHint{Context: ctx}, scope)
+	event := transport.lastEvent
+	assertEqual(t, event.Tags["request_id"], "42")
+	assertEqual(t, event.Tags["tenant"], "scope")
+	assertEqual(t, event.User.ID, "user-42")
+	assertEqual(t, event.Contexts["request_context"], Context{"id": "42"})
+
+	// Without a context, nothing is extracted.
+	client.CaptureMessage("no context", nil, scope)
+	if _, ok := transport.lastEvent.Tags["request_id"]; ok {
+		t.Error("unexpected request_id tag")
+	}
+}
+
+func TestHintRequest(t *testing.T) {
+	var hints []*EventHint
+	client, err := NewClient(ClientOptions{
+		Transport: &TransportMock{},
+		BeforeSend: func(event *Event, hint *EventHint) *Event {
+			hints = append(hints, hint)
+			return event
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	scopeRequest := httptest.NewRequest("GET", "/scope", nil)
+	contextRequest := httptest.NewRequest("GET", "/context", nil)
+	scope := NewScope()
+	scope.SetRequest(scopeRequest)
+
+	hint := &EventHint{}
+	client.CaptureMessage("scope", hint, scope)
+	ctx := context.WithValue(context.Background(), RequestContextKey, contextRequest)
+	client.CaptureMessage("context", &EventHint{Context: ctx}, scope)
+	client.CaptureMessage("none", nil, NewScope())
+
+	assertEqual(t, len(hints), 3)
+	assertEqual(t, hints[0].Request, scopeRequest)
+	assertEqual(t, hints[1].Request, contextRequest)
+	if hints[2].Request != nil {
+		t.Errorf("hint.Request = %v, want nil", hints[2].Request)
+	}
+	if hint.Request != nil {
+		t.Error("the hint of the caller was modified")
+	}
+}
@@ -0,0 +1,214 @@
+package sentry
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultSchedulerWatchdogInterval is the default interval between two
+	// probes of a scheduler watchdog.
+	defaultSchedulerWatchdogInterval = 100 * time.Millisecond
+	// defaultSchedulerWatchdogSustained is the default time the scheduling
+	// delay must stay above the threshold before it is reported.
+	defaultSchedulerWatchdogSustained = 5 * time.Second
+	// schedulerSampleRetention is for how long probes are kept to compute the
+	// measurements of transactions.
+	schedulerSampleRetention = 5 * time.Minute
+)
+
+// SchedulerWatchdogOptions configure StartSchedulerWatchdog.
+type SchedulerWatchdogOptions struct {
+	// Interval between two probes. Defaults to 100ms.
+	Interval time.Duration
+	// Threshold is the scheduling delay above which an event is reported,
+	// once it lasted for Sustained. Zero disables the events, the delay is
+	// still measured for transactions.
+	Threshold time.Duration
+	// Sustained is the time the delay must stay above Threshold before it is
+	// reported. Defaults to 5s.
+	Sustained time.Duration
+	// Hub to report events to. Defaults to a clone of CurrentHub.
+	Hub *Hub
+}
+
+var (
+	schedulerWatchdogMu sync.Mutex
+	schedulerWatchdog   *schedulerProbe
+)
+
+// activeSchedulerWatchdog returns the probe of the running scheduler watchdog,
+// or nil if there is none.
+func activeSchedulerWatchdog() *schedulerProbe {
+	schedulerWatchdogMu.Lock()
+	defer schedulerWatchdogMu.Unlock()
+
+	return schedulerWatchdog
+}
+
+// StartSchedulerWatchdog starts a goroutine that measures how late it is
+// woken up after sleeping for an interval, which shows how long runnable
+// goroutines wait for a processor, and how long garbage collection stopped
+// the world in the meantime.
+//
+// While it runs, sampled transactions get the measurements scheduler_delay,
+// the longest delay during the transaction, and gc_pause, the total pause of
+// garbage collection during the transaction, both in milliseconds. If the
+// delay stays above a threshold, a warning event is captured. Only one
+// watchdog runs at a time, starting another stops the previous one.
+//
+// Call the returned function to stop the watchdog.
+func StartSchedulerWatchdog(options SchedulerWatchdogOptions) (stop func()) {
+	if options.Interval <= 0 {
+		options.Interval = defaultSchedulerWatchdogInterval
+	}
+	if options.Sustained <= 0 {
+		options.Sustained = defaultSchedulerWatchdogSustained
+	}
+	if options.Hub == nil {
+		options.Hub = CurrentHub().Clone()
+	}
+
+	p := &schedulerProbe{options: options, done: make(chan struct{})}
+	schedulerWatchdogMu.Lock()
+	if schedulerWatchdog != nil {
+		schedulerWatchdog.stop()
+	}
+	schedulerWatchdog = p
+	schedulerWatchdogMu.Unlock()
+
+	go p.run()
+	return func() {
+		schedulerWatchdogMu.Lock()
+		defer schedulerWatchdogMu.Unlock()
+
+		if schedulerWatchdog == p {
+			schedulerWatchdog = nil
+		}
+		p.stop()
+	}
+}
+
+// schedulerSample is the outcome of a single probe.
+type schedulerSample struct {
+	at      time.Time
+	delay   time.Duration
+	gcPause time.Duration
+}
+
+// schedulerProbe holds the state of the goroutine started by
+// StartSchedulerWatchdog.
+type schedulerProbe struct {
+	options  SchedulerWatchdogOptions
+	done     chan struct{}
+	stopOnce sync.Once
+
+	mu      sync.Mutex
+	samples []schedulerSample
+	// aboveSince is the time since when the delay is above the threshold,
+	// zero if it is not.
+	aboveSince time.Time
+	reported   bool
+}
+
+func (p *schedulerProbe) stop() {
+	p.stopOnce.Do(func() { close(p.done) })
+}
+
+func (p *schedulerProbe) run() {
+	var stats debug.GCStats
+	debug.ReadGCStats(&stats)
+	lastPause := stats.PauseTotal
+
+	for {
+		start := time.Now()
+		timer := time.NewTimer(p.options.Interval)
+		select {
+		case <-p.done:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		now := time.Now()
+
+		debug.ReadGCStats(&stats)
+		p.record(schedulerSample{
+			at:      now,
+			delay:   now.Sub(start) - p.options.Interval,
+			gcPause: stats.PauseTotal - lastPause,
+		})
+		lastPause = stats.PauseTotal
+	}
+}
+
+// record stores a sample and reports a sustained delay.
+func (p *schedulerProbe) record(s schedulerSample) {
+	p.mu.Lock()
+	retained := p.samples[:0]
+	for _, old := range p.samples {
+		if s.at.Sub(old.at) < schedulerSampleRetention {
+			retained = append(retained, old)
+		}
+	}
+	p.samples = append(retained, s)
+
+	var report bool
+	switch {
+	case p.options.Threshold <= 0:
+	case s.delay <= p.options.Threshold:
+		p.aboveSince = time.Time{}
+		p.reported = false
+	case p.aboveSince.IsZero():
+		p.aboveSince = s.at
+	case !p.reported && s.at.Sub(p.aboveSince) >= p.options.Sustained:
+		p.reported = true
+		report = true
+	}
+	p.mu.Unlock()
+
+	if report {
+		event := NewEvent()
+		event.Level = LevelWarning
+		event.Message = fmt.Sprintf("Scheduling delay above %s for %s", p.options.Threshold, p.options.Sustained)
+		event.Contexts["scheduler"] = Context{
+			"delay_ms":    durationMilliseconds(s.delay),
+			"gc_pause_ms": durationMilliseconds(s.gcPause),
+		}
+		event.Fingerprint = []string{"sentry-scheduler-watchdog"}
+		p.options.Hub.CaptureEvent(event)
+	}
+}
+
+// addMeasurements adds the scheduling delay and GC pause measured during the
+// transaction to its event.
+func (p *schedulerProbe) addMeasurements(event *Event) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var n int
+	var maxDelay, gcPause time.Duration
+	for _, s := range p.samples {
+		if s.at.Before(event.StartTime) || s.at.After(event.Timestamp) {
+			continue
+		}
+		n++
+		if s.delay > maxDelay {
+			maxDelay = s.delay
+		}
+		gcPause += s.gcPause
+	}
+	if n == 0 {
+		return
+	}
+	if event.Measurements == nil {
+		event.Measurements = make(map[string]Measurement)
+	}
+	event.Measurements["scheduler_delay"] = Measurement{Value: durationMilliseconds(maxDelay), Unit: "millisecond"}
+	event.Measurements["gc_pause"] = Measurement{Value: durationMilliseconds(gcPause), Unit: "millisecond"}
+}
+
+func durationMilliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,78 @@
+package sentry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSchedulerProbeReportsSustainedDelay(t *testing.T) {
+	transport := &TransportMock{}
+	client, _ := NewClient(ClientOptions{Dsn: testDsn, Transport: transport})
+	p := &schedulerProbe{options: SchedulerWatchdogOptions{
+		Threshold: 10 * time.Millisecond,
+		Sustained: time.Second,
+		Hub:       NewHub(client, NewScope()),
+	}}
+
+	start := time.Now()
+	at := func(d time.Duration, delay time.Duration) schedulerSample {
+		return schedulerSample{at: start.Add(d), delay: delay}
+	}
+	p.record(at(0, 20*time.Millisecond))
+	p.record(at(500*time.Millisecond, time.Millisecond))
+	p.record(at(time.Second, 20*time.Millisecond))
+	p.record(at(1500*time.Millisecond, 20*time.Millisecond))
+	assertEqual(t, len(transport.Events()), 0)
+
+	p.record(at(2*time.Second, 30*time.Millisecond))
+	p.record(at(3*time.Second, 30*time.Millisecond))
+	events := transport.Events()
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1 for a sustained delay", len(events))
+	}
+	assertEqual(t, events[0].Level, LevelWarning)
+	assertEqual(t, events[0].Contexts["scheduler"]["delay_ms"], 30.0)
+}
+
+func TestSchedulerProbeMeasurements(t *testing.T) {
+	p := &schedulerProbe{}
+	start := time.Now()
+	p.record(schedulerSample{at: start.Add(-time.Second), delay: time.Second})
+	p.record(schedulerSample{at: start.Add(time.Second), delay: 2 * time.Millisecond, gcPause: time.Millisecond})
+	p.record(schedulerSample{at: start.Add(2 * time.Second), delay: 4 * time.Millisecond, gcPause: time.Millisecond})
+
+	event := &Event{Type: transactionType, StartTime: start, Timestamp: start.Add(3 * time.Second)}
+	p.addMeasurements(event)
+	assertEqual(t, event.Measurements, map[string]Measurement{
+		"scheduler_delay": {Value: 4, Unit: "millisecond"},
+		"gc_pause":        {Value: 2, Unit: "millisecond"},
+	})
+
+	event = &Event{Type: transactionType, StartTime: start.Add(time.Hour), Timestamp: start.Add(2 * time.Hour)}
+	p.addMeasurements(event)
+	if event.Measurements != nil {
+		t.Errorf("got measurements %v for a transaction without samples", event.Measurements)
+	}
+}
+
+func TestStartSchedulerWatchdog(t *testing.T) {
+	stop := StartSchedulerWatchdog(SchedulerWatchdogOptions{Interval: time.Millisecond})
+	first := activeSchedulerWatchdog()
+	stopSecond := StartSchedulerWatchdog(SchedulerWatchdogOptions{Interval: time.Millisecond})
+	if activeSchedulerWatchdog() == first {
+		t.Error("starting a watchdog should replace the running one")
+	}
+	select {
+	case <-first.done:
+	default:
+		t.Error("the replaced watchdog was not stopped")
+	}
+	stop()
+	if activeSchedulerWatchdog() == nil {
+		t.Error("stopping a replaced watchdog should not stop the running one")
+	}
+	stopSecond()
+	if activeSchedulerWatchdog() != nil {
+		t.Error("the watchdog was not stopped")
+	}
+}
@@ -24,7 +24,7 @@ import (
 // scope into the event.
 type Scope struct {
 	mu          sync.RWMutex
-	breadcrumbs []*Breadcrumb
+	breadcrumbs *breadcrumbRing
 	attachments []*Attachment
 	user        User
 	tags        map[string]string
@@ -42,22 +42,50 @@ type Scope struct {
 		// size.
 		Overflow() bool
 	}
-	eventProcessors []EventProcessor
+	eventProcessors eventProcessors
 
 	propagationContext PropagationContext
 	span               *Span
+	// carrier holds the headers of the message the scope is used for, see
+	// ContinueTraceFromCarrier.
+	carrier TraceCarrier
+
+	// sampleRate and tracesSampleRate override the rates of the client if
+	// not nil, see SetSampleRate and SetTracesSampleRate.
+	sampleRate       *float64
+	tracesSampleRate *float64
+
+	// shared marks the fields whose data is shared with clones of the scope.
+	// They are copied before they are modified, see Clone.
+	shared scopeFields
 }
 
+// scopeFields is a set of Scope fields which are copied on write.
+type scopeFields uint8
+
+const (
+	sharedBreadcrumbs scopeFields = 1 << iota
+	sharedAttachments
+	sharedTags
+	sharedContexts
+	sharedExtra
+
+	sharedAll = sharedBreadcrumbs | sharedAttachments | sharedTags | sharedContexts | sharedExtra
+)
+
 // NewScope creates a new Scope.
 func NewScope() *Scope {
+	// The propagation context is not created with NewPropagationContext,
+	// which consults the current hub: that hub is itself created with a new
+	// scope.
 	return &Scope{
-		breadcrumbs:        make([]*Breadcrumb, 0),
+		breadcrumbs:        &breadcrumbRing{},
 		attachments:        make([]*Attachment, 0),
 		tags:               make(map[string]string),
 		contexts:           make(map[string]Context),
 		extra:              make(map[string]interface{}),
 		fingerprint:        make([]string, 0),
-		propagationContext: NewPropagationContext(),
+		propagationContext: newPropagationContext(randomIDGenerator{}),
 	}
 }
 
@@ -68,13 +96,19 @@ func (scope *Scope) AddBreadcrumb(breadcrumb *Breadcrumb, limit int) {
 		breadcrumb.Timestamp = time.Now()
 	}
 
-	scope.mu.Lock()
-	defer scope.mu.Unlock()
-
-	scope.breadcrumbs = append(scope.breadcrumbs, breadcrumb)
-	if len(scope.breadcrumbs) > limit {
-		scope.breadcrumbs = scope.breadcrumbs[1 : limit+1]
+	// The ring has its own lock, adding a breadcrumb only needs the scope
+	// lock for writing when the ring is shared with a clone.
+	scope.mu.RLock()
+	for scope.shared&sharedBreadcrumbs != 0 {
+		scope.mu.RUnlock()
+		scope.mu.Lock()
+		scope.ownBreadcrumbs()
+		scope.mu.Unlock()
+		scope.mu.RLock()
 	}
+	defer scope.mu.RUnlock()
+
+	scope.breadcrumbs.add(breadcrumb, limit)
 }
 
 // ClearBreadcrumbs clears all breadcrumbs from the current scope.
@@ -82,7 +116,8 @@ func (scope *Scope) ClearBreadcrumbs() {
 	scope.mu.Lock()
 	defer scope.mu.Unlock()
 
-	scope.breadcrumbs = []*Breadcrumb{}
+	scope.breadcrumbs = &breadcrumbRing{}
+	scope.shared &^= sharedBreadcrumbs
 }
 
 // AddAttachment adds new attachment to the current scope.
@@ -90,6 +125,7 @@ func (scope *Scope) AddAttachment(attachment *Attachment) {
 	scope.mu.Lock()
 	defer scope.mu.Unlock()
 
+	scope.ownAttachments()
 	scope.attachments = append(scope.attachments, attachment)
 }
 
@@ -99,6 +135,7 @@ func (scope *Scope) ClearAttachments() {
 	defer scope.mu.Unlock()
 
 	scope.attachments = []*Attachment{}
+	scope.shared &^= sharedAttachments
 }
 
 // SetUser sets the user for the current scope.
@@ -110,25 +147,35 @@ func (scope *Scope) SetUser(user User) {
 }
 
 // SetRequest sets the request for the current scope.
+//
+// Up to 10 KiB of the request body are buffered as the handler reads it, to be
+// attached to events. Use Hub.SetRequest to apply
+// ClientOptions.MaxRequestBodySize instead.
 func (scope *Scope) SetRequest(r *http.Request) {
+	scope.setRequest(r, maxRequestBodyBytes)
+}
+
+// setRequest sets the request for the current scope, buffering at most limit
+// bytes of its body.
+func (scope *Scope) setRequest(r *http.Request, limit int) {
 	scope.mu.Lock()
 	defer scope.mu.Unlock()
 
 	scope.request = r
 
-	if r == nil {
+	if r == nil || limit <= 0 {
 		return
 	}
 
 	// Don't buffer request body if we know it is oversized.
-	if r.ContentLength > maxRequestBodyBytes {
+	if r.ContentLength > int64(limit) {
 		return
 	}
 	// Don't buffer if there is no body.
 	if r.Body == nil || r.Body == http.NoBody {
 		return
 	}
-	buf := &limitedBuffer{Capacity: maxRequestBodyBytes}
+	buf := &limitedBuffer{Capacity: limit}
 	r.Body = readCloser{
 		Reader: io.TeeReader(r.Body, buf),
 		Closer: r.Body,
@@ -142,10 +189,17 @@ func (scope *Scope) SetRequest(r *http.Request) {
 // in memory. Typically, the request body is buffered lazily from the
 // Request.Body from SetRequest.
 func (scope *Scope) SetRequestBody(b []byte) {
+	scope.setRequestBody(b, maxRequestBodyBytes)
+}
+
+func (scope *Scope) setRequestBody(b []byte, capacity int) {
 	scope.mu.Lock()
 	defer scope.mu.Unlock()
 
-	capacity := maxRequestBodyBytes
+	if capacity <= 0 {
+		scope.requestBody = nil
+		return
+	}
 	overflow := false
 	if len(b) > capacity {
 		overflow = true
@@ -162,6 +216,38 @@ func (scope *Scope) SetRequestBody(b []byte) {
 // Sentry.
 const maxRequestBodyBytes = 10 * 1024
 
+// RequestBodySize controls how large request bodies attached to events may
+// be, see ClientOptions.MaxRequestBodySize. Bodies that are larger are left
+// out entirely rather than truncated.
+type RequestBodySize string
+
+const (
+	// RequestBodySizeNever leaves request bodies out of events.
+	RequestBodySizeNever RequestBodySize = "never"
+	// RequestBodySizeSmall attaches request bodies of up to 1000 bytes.
+	RequestBodySizeSmall RequestBodySize = "small"
+	// RequestBodySizeMedium attaches request bodies of up to 10 KiB. It is
+	// the default.
+	RequestBodySizeMedium RequestBodySize = "medium"
+	// RequestBodySizeAlways attaches request bodies of up to 1 MiB, the most
+	// an event can carry.
+	RequestBodySizeAlways RequestBodySize = "always"
+)
+
+// limit returns the number of bytes of a request body to buffer.
+func (size RequestBodySize) limit() int {
+	switch size {
+	case RequestBodySizeNever:
+		return 0
+	case RequestBodySizeSmall:
+		return 1000
+	case RequestBodySizeAlways:
+		return maxEventBytes
+	default:
+		return maxRequestBodyBytes
+	}
+}
+
 // A limitedBuffer is like a bytes.Buffer, but limited to store at most Capacity
 // bytes. Any writes past the capacity are silently discarded, similar to
 // io.Discard.
@@ -205,6 +291,7 @@ func (scope *Scope) SetTag(key, value string) {
 	scope.mu.Lock()
 	defer scope.mu.Unlock()
 
+	scope.ownTags()
 	scope.tags[key] = value
 }
 
@@ -213,6 +300,7 @@ func (scope *Scope) SetTags(tags map[string]string) {
 	scope.mu.Lock()
 	defer scope.mu.Unlock()
 
+	scope.ownTags()
 	for k, v := range tags {
 		scope.tags[k] = v
 	}
@@ -223,6 +311,7 @@ func (scope *Scope) RemoveTag(key string) {
 	scope.mu.Lock()
 	defer scope.mu.Unlock()
 
+	scope.ownTags()
 	delete(scope.tags, key)
 }
 
@@ -231,6 +320,7 @@ func (scope *Scope) SetContext(key string, value Context) {
 	scope.mu.Lock()
 	defer scope.mu.Unlock()
 
+	scope.ownContexts()
 	scope.contexts[key] = value
 }
 
@@ -239,6 +329,7 @@ func (scope *Scope) SetContexts(contexts map[string]Context) {
 	scope.mu.Lock()
 	defer scope.mu.Unlock()
 
+	scope.ownContexts()
 	for k, v := range contexts {
 		scope.contexts[k] = v
 	}
@@ -249,6 +340,7 @@ func (scope *Scope) RemoveContext(key string) {
 	scope.mu.Lock()
 	defer scope.mu.Unlock()
 
+	scope.ownContexts()
 	delete(scope.contexts, key)
 }
 
@@ -257,6 +349,7 @@ func (scope *Scope) SetExtra(key string, value interface{}) {
 	scope.mu.Lock()
 	defer scope.mu.Unlock()
 
+	scope.ownExtra()
 	scope.extra[key] = value
 }
 
@@ -265,6 +358,7 @@ func (scope *Scope) SetExtras(extra map[string]interface{}) {
 	scope.mu.Lock()
 	defer scope.mu.Unlock()
 
+	scope.ownExtra()
 	for k, v := range extra {
 		scope.extra[k] = v
 	}
@@ -275,6 +369,7 @@ func (scope *Scope) RemoveExtra(key string) {
 	scope.mu.Lock()
 	defer scope.mu.Unlock()
 
+	scope.ownExtra()
 	delete(scope.extra, key)
 }
 
@@ -294,6 +389,41 @@ func (scope *Scope) SetLevel(level Level) {
 	scope.level = level
 }
 
+// SetSampleRate overrides ClientOptions.SampleRate for the events captured
+// with the scope, so that a noisy component can be downsampled at runtime.
+// A negative rate removes the override.
+func (scope *Scope) SetSampleRate(rate float64) {
+	scope.mu.Lock()
+	defer scope.mu.Unlock()
+
+	scope.sampleRate = nil
+	if rate >= 0 {
+		scope.sampleRate = &rate
+	}
+}
+
+// SetTracesSampleRate overrides ClientOptions.TracesSampleRate and
+// ClientOptions.TracesSampler for the transactions started with the scope.
+// Sampling decisions passed to StartTransaction or continued from an incoming
+// trace still take precedence. A negative rate removes the override.
+func (scope *Scope) SetTracesSampleRate(rate float64) {
+	scope.mu.Lock()
+	defer scope.mu.Unlock()
+
+	scope.tracesSampleRate = nil
+	if rate >= 0 {
+		scope.tracesSampleRate = &rate
+	}
+}
+
+// sampleRates returns the overrides of SetSampleRate and SetTracesSampleRate.
+func (scope *Scope) sampleRates() (events, traces *float64) {
+	scope.mu.RLock()
+	defer scope.mu.RUnlock()
+
+	return scope.sampleRate, scope.tracesSampleRate
+}
+
 // SetPropagationContext sets the propagation context for the current scope.
 func (scope *Scope) SetPropagationContext(propagationContext PropagationContext) {
 	scope.mu.Lock()
@@ -302,6 +432,32 @@ func (scope *Scope) SetPropagationContext(propagationContext PropagationContext)
 	scope.propagationContext = propagationContext
 }
 
+// setCarrier sets the carrier of the message the scope is used for, passed
+// in EventHint.Carrier.
+func (scope *Scope) setCarrier(carrier TraceCarrier) {
+	scope.mu.Lock()
+	defer scope.mu.Unlock()
+
+	scope.carrier = carrier
+}
+
+// PropagationContext returns the propagation context of the current scope.
+//
+// Together with SetPropagationContext, it allows frameworks without built-in
+// middleware to continue and propagate traces for errors, even if performance
+// monitoring is not enabled:
+//
+//	p, err := sentry.PropagationContextFromHeaders(traceHeader, baggageHeader)
+//	if err == nil {
+//		scope.SetPropagationContext(p)
+//	}
+func (scope *Scope) PropagationContext() PropagationContext {
+	scope.mu.RLock()
+	defer scope.mu.RUnlock()
+
+	return scope.propagationContext
+}
+
 // SetSpan sets a span for the current scope.
 func (scope *Scope) SetSpan(span *Span) {
 	scope.mu.Lock()
@@ -311,34 +467,139 @@ func (scope *Scope) SetSpan(span *Span) {
 }
 
 // Clone returns a copy of the current scope with all data copied over.
+//
+// Cloning is cheap: breadcrumbs, attachments, tags, contexts and extras are
+// shared between the scope and its clone until either of them is modified,
+// only then they are copied. Context values are never copied, pass a new
+// Context to SetContext rather than modifying one that was set before.
 func (scope *Scope) Clone() *Scope {
-	scope.mu.RLock()
-	defer scope.mu.RUnlock()
+	// A write lock is required to mark the data of the scope as shared.
+	scope.mu.Lock()
+	defer scope.mu.Unlock()
 
-	clone := NewScope()
-	clone.user = scope.user
-	clone.breadcrumbs = make([]*Breadcrumb, len(scope.breadcrumbs))
-	copy(clone.breadcrumbs, scope.breadcrumbs)
-	clone.attachments = make([]*Attachment, len(scope.attachments))
-	copy(clone.attachments, scope.attachments)
-	for key, value := range scope.tags {
-		clone.tags[key] = value
+	scope.shared = sharedAll
+
+	return &Scope{
+		breadcrumbs:        scope.breadcrumbs,
+		attachments:        scope.attachments,
+		user:               scope.user,
+		tags:               scope.tags,
+		contexts:           scope.contexts,
+		extra:              scope.extra,
+		fingerprint:        append(make([]string, 0, len(scope.fingerprint)), scope.fingerprint...),
+		level:              scope.level,
+		request:            scope.request,
+		requestBody:        scope.requestBody,
+		eventProcessors:    scope.eventProcessors,
+		propagationContext: scope.propagationContext,
+		span:               scope.span,
+		carrier:            scope.carrier,
+		sampleRate:         scope.sampleRate,
+		tracesSampleRate:   scope.tracesSampleRate,
+		shared:             sharedAll,
+	}
+}
+
+// The own* methods copy the respective field if it is shared with a clone,
+// such that it can be modified. They must be called with mu held for writing.
+
+func (scope *Scope) ownBreadcrumbs() {
+	if scope.shared&sharedBreadcrumbs != 0 {
+		scope.breadcrumbs = scope.breadcrumbs.clone()
+		scope.shared &^= sharedBreadcrumbs
+	}
+}
+
+func (scope *Scope) ownAttachments() {
+	if scope.shared&sharedAttachments != 0 {
+		attachments := make([]*Attachment, len(scope.attachments), len(scope.attachments)+1)
+		copy(attachments, scope.attachments)
+		scope.attachments = attachments
+		scope.shared &^= sharedAttachments
 	}
-	for key, value := range scope.contexts {
-		clone.contexts[key] = cloneContext(value)
+}
+
+func (scope *Scope) ownTags() {
+	if scope.shared&sharedTags != 0 {
+		tags := make(map[string]string, len(scope.tags))
+		for k, v := range scope.tags {
+			tags[k] = v
+		}
+		scope.tags = tags
+		scope.shared &^= sharedTags
 	}
-	for key, value := range scope.extra {
-		clone.extra[key] = value
+}
+
+func (scope *Scope) ownContexts() {
+	if scope.shared&sharedContexts != 0 {
+		contexts := make(map[string]Context, len(scope.contexts))
+		for k, v := range scope.contexts {
+			contexts[k] = v
+		}
+		scope.contexts = contexts
+		scope.shared &^= sharedContexts
 	}
-	clone.fingerprint = make([]string, len(scope.fingerprint))
-	copy(clone.fingerprint, scope.fingerprint)
-	clone.level = scope.level
-	clone.request = scope.request
-	clone.requestBody = scope.requestBody
-	clone.eventProcessors = scope.eventProcessors
-	clone.propagationContext = scope.propagationContext
-	clone.span = scope.span
-	return clone
+}
+
+func (scope *Scope) ownExtra() {
+	if scope.shared&sharedExtra != 0 {
+		extra := make(map[string]interface{}, len(scope.extra))
+		for k, v := range scope.extra {
+			extra[k] = v
+		}
+		scope.extra = extra
+		scope.shared &^= sharedExtra
+	}
+}
+
+// ScopeSnapshot holds the data of a scope at the time Scope.Snapshot was
+// called. Restore it with Scope.Restore.
+type ScopeSnapshot struct {
+	scope *Scope
+}
+
+// Snapshot returns a snapshot of the data of the current scope.
+//
+// Together with Restore, it allows to temporarily modify the scope around a
+// block of code, even if the block panics:
+//
+//	defer scope.Restore(scope.Snapshot())
+//	scope.SetLevel(sentry.LevelWarning)
+func (scope *Scope) Snapshot() ScopeSnapshot {
+	return ScopeSnapshot{scope: scope.Clone()}
+}
+
+// Restore replaces the data of the current scope with the data of snapshot.
+// A snapshot can be restored any number of times. Restoring the zero
+// ScopeSnapshot has no effect.
+func (scope *Scope) Restore(snapshot ScopeSnapshot) {
+	if snapshot.scope == nil {
+		return
+	}
+	// Clone again, such that later modifications of the scope do not leak
+	// into the snapshot.
+	data := snapshot.scope.Clone()
+
+	scope.mu.Lock()
+	defer scope.mu.Unlock()
+
+	scope.breadcrumbs = data.breadcrumbs
+	scope.attachments = data.attachments
+	scope.user = data.user
+	scope.tags = data.tags
+	scope.contexts = data.contexts
+	scope.extra = data.extra
+	scope.fingerprint = data.fingerprint
+	scope.level = data.level
+	scope.request = data.request
+	scope.requestBody = data.requestBody
+	scope.eventProcessors = data.eventProcessors
+	scope.propagationContext = data.propagationContext
+	scope.span = data.span
+	scope.carrier = data.carrier
+	scope.sampleRate = data.sampleRate
+	scope.tracesSampleRate = data.tracesSampleRate
+	scope.shared = data.shared
 }
 
 // Clear removes the data from the current scope. Not safe for concurrent use.
@@ -346,12 +607,25 @@ func (scope *Scope) Clear() {
 	*scope = *NewScope()
 }
 
-// AddEventProcessor adds an event processor to the current scope.
-func (scope *Scope) AddEventProcessor(processor EventProcessor) {
+// AddEventProcessor adds an event processor to the current scope. See
+// EventProcessorOption for naming and ordering processors.
+func (scope *Scope) AddEventProcessor(processor EventProcessor, opts ...EventProcessorOption) {
 	scope.mu.Lock()
 	defer scope.mu.Unlock()
 
-	scope.eventProcessors = append(scope.eventProcessors, processor)
+	scope.eventProcessors = scope.eventProcessors.add(processor, opts)
+}
+
+// RemoveEventProcessor removes the event processor added to the current scope
+// with the given name, see WithEventProcessorName. It reports whether there
+// was such a processor.
+func (scope *Scope) RemoveEventProcessor(name string) bool {
+	scope.mu.Lock()
+	defer scope.mu.Unlock()
+
+	var removed bool
+	scope.eventProcessors, removed = scope.eventProcessors.remove(name)
+	return removed
 }
 
 // ApplyToEvent takes the data from the current scope and attaches it to the event.
@@ -359,12 +633,23 @@ func (scope *Scope) ApplyToEvent(event *Event, hint *EventHint, client *Client)
 	scope.mu.RLock()
 	defer scope.mu.RUnlock()
 
-	if len(scope.breadcrumbs) > 0 {
-		event.Breadcrumbs = append(event.Breadcrumbs, scope.breadcrumbs...)
+	shedding := client.loadShedding()
+	if shedding < LoadSheddingScopeData {
+		event.Breadcrumbs = scope.breadcrumbs.appendTo(event.Breadcrumbs)
 	}
 
 	if len(scope.attachments) > 0 {
-		event.Attachments = append(event.Attachments, scope.attachments...)
+		switch event.Type {
+		case checkInType, metricType:
+		case transactionType:
+			for _, attachment := range scope.attachments {
+				if attachment.AddToTransactions {
+					event.Attachments = append(event.Attachments, attachment)
+				}
+			}
+		default:
+			event.Attachments = append(event.Attachments, scope.attachments...)
+		}
 	}
 
 	if len(scope.tags) > 0 {
@@ -411,7 +696,7 @@ func (scope *Scope) ApplyToEvent(event *Event, hint *EventHint, client *Client)
 
 			transaction := scope.span.GetTransaction()
 			if transaction != nil {
-				event.sdkMetaData.dsc = DynamicSamplingContextFromTransaction(transaction)
+				event.sdkMetaData.dsc = transaction.frozenDynamicSamplingContext()
 			}
 		} else {
 			event.Contexts["trace"] = scope.propagationContext.Map()
@@ -448,6 +733,7 @@ func (scope *Scope) ApplyToEvent(event *Event, hint *EventHint, client *Client)
 
 	if event.Request == nil && scope.request != nil {
 		event.Request = NewRequest(scope.request)
+		event.sdkMetaData.clientIP = requestClientIP(scope.request)
 		// NOTE: The SDK does not attempt to send partial request body data.
 		//
 		// The reason being that Sentry's ingest pipeline and UI are optimized
@@ -457,21 +743,12 @@ func (scope *Scope) ApplyToEvent(event *Event, hint *EventHint, client *Client)
 		//
 		// Users can still send more data along their events if they want to,
 		// for example using Event.Extra.
-		if scope.requestBody != nil && !scope.requestBody.Overflow() {
+		if scope.requestBody != nil && !scope.requestBody.Overflow() && shedding < LoadSheddingRequestBodies {
 			event.Request.Data = string(scope.requestBody.Bytes())
 		}
 	}
 
-	for _, processor := range scope.eventProcessors {
-		id := event.EventID
-		event = processor(event, hint)
-		if event == nil {
-			Logger.Printf("Event dropped by one of the Scope EventProcessors: %s\n", id)
-			return nil
-		}
-	}
-
-	return event
+	return scope.eventProcessors.apply(event, hint, "Scope")
 }
 
 // cloneContext returns a new context with keys and values copied from the passed one.
@@ -486,3 +763,153 @@ func cloneContext(c Context) Context {
 	}
 	return res
 }
+
+// globalScope holds data that is applied to all events, regardless of the hub
+// and client they are captured with.
+var globalScope = NewScope()
+
+// GlobalScope returns the global scope. Data set on it is applied to every
+// event, before the data of the isolation scope and the current scope of the
+// hub the event is captured with.
+func GlobalScope() *Scope {
+	return globalScope
+}
+
+// applyTags returns a copy of tags with the tags of the scope set on it, like
+// ApplyToEvent sets them on events.
+func (scope *Scope) applyTags(tags map[string]string) map[string]string {
+	scope.mu.RLock()
+	defer scope.mu.RUnlock()
+
+	if len(scope.tags) == 0 {
+		return tags
+	}
+	merged := make(map[string]string, len(tags)+len(scope.tags))
+	for key, value := range tags {
+		merged[key] = value
+	}
+	for key, value := range scope.tags {
+		merged[key] = value
+	}
+	return merged
+}
+
+// isEmpty reports whether the scope holds no data that would be applied to an
+// event. The propagation context is not considered, every scope has one.
+func (scope *Scope) isEmpty() bool {
+	scope.mu.RLock()
+	defer scope.mu.RUnlock()
+
+	return scope.breadcrumbs.len() == 0 &&
+		len(scope.attachments) == 0 &&
+		scope.user.IsEmpty() &&
+		len(scope.tags) == 0 &&
+		len(scope.contexts) == 0 &&
+		len(scope.extra) == 0 &&
+		len(scope.fingerprint) == 0 &&
+		scope.level == "" &&
+		scope.request == nil &&
+		len(scope.eventProcessors) == 0 &&
+		scope.span == nil &&
+		scope.carrier == nil &&
+		scope.sampleRate == nil &&
+		scope.tracesSampleRate == nil
+}
+
+// mergeScopes returns a new scope with the data of all scopes. Data of later
+// scopes takes precedence over data of earlier ones, breadcrumbs, attachments
+// and event processors are concatenated in order.
+func mergeScopes(scopes ...*Scope) *Scope {
+	merged := scopes[0].Clone()
+	for _, scope := range scopes[1:] {
+		merged.merge(scope)
+	}
+	return merged
+}
+
+// merge copies the data of other into scope, overriding data that is set in
+// both. It must only be called on scopes that are not shared yet.
+func (scope *Scope) merge(other *Scope) {
+	other.mu.RLock()
+	defer other.mu.RUnlock()
+
+	scope.ownBreadcrumbs()
+	scope.ownAttachments()
+	scope.ownTags()
+	scope.ownContexts()
+	scope.ownExtra()
+	scope.breadcrumbs = newBreadcrumbRing(other.breadcrumbs.appendTo(scope.breadcrumbs.list()))
+	scope.attachments = append(scope.attachments, other.attachments...)
+	if !other.user.IsEmpty() {
+		scope.user = other.user
+	}
+	for key, value := range other.tags {
+		scope.tags[key] = value
+	}
+	for key, value := range other.contexts {
+		scope.contexts[key] = cloneContext(value)
+	}
+	for key, value := range other.extra {
+		scope.extra[key] = value
+	}
+	if len(other.fingerprint) > 0 {
+		scope.fingerprint = append([]string(nil), other.fingerprint...)
+	}
+	if other.level != "" {
+		scope.level = other.level
+	}
+	if other.request != nil {
+		scope.request = other.request
+		scope.requestBody = other.requestBody
+	}
+	if len(other.eventProcessors) > 0 {
+		scope.eventProcessors = scope.eventProcessors.concat(other.eventProcessors)
+	}
+	scope.propagationContext = other.propagationContext
+	if other.span != nil {
+		scope.span = other.span
+	}
+	if other.carrier != nil {
+		scope.carrier = other.carrier
+	}
+	if other.sampleRate != nil {
+		scope.sampleRate = other.sampleRate
+	}
+	if other.tracesSampleRate != nil {
+		scope.tracesSampleRate = other.tracesSampleRate
+	}
+}
+
+// withGlobalScope returns an EventModifier that applies the global scope
+// before scope.
+func withGlobalScope(scope EventModifier) EventModifier {
+	if globalScope.isEmpty() {
+		return scope
+	}
+	switch s := scope.(type) {
+	case nil:
+		return globalScope
+	case *Scope:
+		if s == nil {
+			return globalScope
+		}
+		if s == globalScope {
+			return s
+		}
+		return mergeScopes(globalScope, s)
+	default:
+		return eventModifiers{globalScope, scope}
+	}
+}
+
+// eventModifiers applies multiple EventModifiers in order.
+type eventModifiers []EventModifier
+
+func (m eventModifiers) ApplyToEvent(event *Event, hint *EventHint, client *Client) *Event {
+	for _, modifier := range m {
+		if event = modifier.ApplyToEvent(event, hint, client); event == nil {
+			return nil
+		}
+	}
+	return event
+}
@@ -3,6 +3,7 @@ package sentry
 import (
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
 	"time"
 )
@@ -12,7 +13,7 @@ const sharedContextsKey = "sharedContextsKey"
 var testNow = time.Now().UTC()
 
 func fillScopeWithData(scope *Scope) *Scope {
-	scope.breadcrumbs = []*Breadcrumb{{Timestamp: testNow, Message: "scopeBreadcrumbMessage"}}
+	scope.breadcrumbs = newBreadcrumbRing([]*Breadcrumb{{Timestamp: testNow, Message: "scopeBreadcrumbMessage"}})
 	scope.attachments = []*Attachment{
 		{
 			Filename: "scope-attachment.txt",
@@ -331,7 +332,7 @@ func TestScopeSetLevelOverrides(t *testing.T) {
 func TestAddBreadcrumbAddsBreadcrumb(t *testing.T) {
 	scope := NewScope()
 	scope.AddBreadcrumb(&Breadcrumb{Timestamp: testNow, Message: "test"}, maxBreadcrumbs)
-	assertEqual(t, []*Breadcrumb{{Timestamp: testNow, Message: "test"}}, scope.breadcrumbs)
+	assertEqual(t, []*Breadcrumb{{Timestamp: testNow, Message: "test"}}, scope.breadcrumbs.list())
 }
 
 func TestAddBreadcrumbAppendsBreadcrumb(t *testing.T) {
@@ -344,7 +345,7 @@ func TestAddBreadcrumbAppendsBreadcrumb(t *testing.T) {
 		{Timestamp: testNow, Message: "test1"},
 		{Timestamp: testNow, Message: "test2"},
 		{Timestamp: testNow, Message: "test3"},
-	}, scope.breadcrumbs)
+	}, scope.breadcrumbs.list())
 }
 
 func TestAddBreadcrumbDefaultLimit(t *testing.T) {
@@ -353,7 +354,7 @@ func TestAddBreadcrumbDefaultLimit(t *testing.T) {
 		scope.AddBreadcrumb(&Breadcrumb{Timestamp: testNow, Message: "test"}, maxBreadcrumbs)
 	}
 
-	if len(scope.breadcrumbs) != 100 {
+	if scope.breadcrumbs.len() != 100 {
 		t.Error("expected to have only 100 breadcrumbs")
 	}
 }
@@ -363,7 +364,7 @@ func TestAddBreadcrumbAddsTimestamp(t *testing.T) {
 	before := time.Now()
 	scope.AddBreadcrumb(&Breadcrumb{Message: "test"}, maxBreadcrumbs)
 	after := time.Now()
-	ts := scope.breadcrumbs[0].Timestamp
+	ts := scope.breadcrumbs.list()[0].Timestamp
 
 	if ts.Before(before) || ts.After(after) {
 		t.Errorf("expected default timestamp to represent current time, was '%v'", ts)
@@ -442,7 +443,7 @@ func TestScopeParentChangedInheritance(t *testing.T) {
 	assertEqual(t, map[string]interface{}{"foo": "bar"}, clone.extra)
 	assertEqual(t, LevelDebug, clone.level)
 	assertEqual(t, []string{"foo"}, clone.fingerprint)
-	assertEqual(t, []*Breadcrumb{{Timestamp: testNow, Message: "foo"}}, clone.breadcrumbs)
+	assertEqual(t, []*Breadcrumb{{Timestamp: testNow, Message: "foo"}}, clone.breadcrumbs.list())
 	assertEqual(t, []*Attachment{{Filename: "foo.txt", Payload: []byte("foo")}}, clone.attachments)
 	assertEqual(t, User{ID: "foo"}, clone.user)
 	assertEqual(t, r1, clone.request)
@@ -454,7 +455,7 @@ func TestScopeParentChangedInheritance(t *testing.T) {
 	assertEqual(t, map[string]interface{}{"foo": "baz"}, scope.extra)
 	assertEqual(t, LevelFatal, scope.level)
 	assertEqual(t, []string{"bar"}, scope.fingerprint)
-	assertEqual(t, []*Breadcrumb{{Timestamp: testNow, Message: "bar"}}, scope.breadcrumbs)
+	assertEqual(t, []*Breadcrumb{{Timestamp: testNow, Message: "bar"}}, scope.breadcrumbs.list())
 	assertEqual(t, []*Attachment{{Filename: "bar.txt", Payload: []byte("bar")}}, scope.attachments)
 	assertEqual(t, User{ID: "bar"}, scope.user)
 	assertEqual(t, r2, scope.request)
@@ -509,7 +510,7 @@ func TestScopeChildOverrideInheritance(t *testing.T) {
 	assertEqual(t, []*Breadcrumb{
 		{Timestamp: testNow, Message: "bar"},
 		{Timestamp: testNow, Message: "foo"},
-	}, clone.breadcrumbs)
+	}, clone.breadcrumbs.list())
 	assertEqual(t, []*Attachment{
 		{Filename: "bar.txt", Payload: []byte("bar")},
 		{Filename: "foo.txt", Payload: []byte("foo")},
@@ -524,7 +525,7 @@ func TestScopeChildOverrideInheritance(t *testing.T) {
 	assertEqual(t, map[string]interface{}{"foo": "baz"}, scope.extra)
 	assertEqual(t, LevelFatal, scope.level)
 	assertEqual(t, []string{"bar"}, scope.fingerprint)
-	assertEqual(t, []*Breadcrumb{{Timestamp: testNow, Message: "bar"}}, scope.breadcrumbs)
+	assertEqual(t, []*Breadcrumb{{Timestamp: testNow, Message: "bar"}}, scope.breadcrumbs.list())
 	assertEqual(t, []*Attachment{{Filename: "bar.txt", Payload: []byte("bar")}}, scope.attachments)
 	assertEqual(t, User{ID: "bar"}, scope.user)
 	assertEqual(t, r1, scope.request)
@@ -539,7 +540,7 @@ func TestClear(t *testing.T) {
 	scope := fillScopeWithData(NewScope())
 	scope.Clear()
 
-	assertEqual(t, []*Breadcrumb{}, scope.breadcrumbs)
+	assertEqual(t, []*Breadcrumb{}, scope.breadcrumbs.list())
 	assertEqual(t, []*Attachment{}, scope.attachments)
 	assertEqual(t, User{}, scope.user)
 	assertEqual(t, map[string]string{}, scope.tags)
@@ -575,7 +576,7 @@ func TestClearAndReconfigure(t *testing.T) {
 	assertEqual(t, map[string]interface{}{"foo": "bar"}, scope.extra)
 	assertEqual(t, LevelDebug, scope.level)
 	assertEqual(t, []string{"foo"}, scope.fingerprint)
-	assertEqual(t, []*Breadcrumb{{Timestamp: testNow, Message: "foo"}}, scope.breadcrumbs)
+	assertEqual(t, []*Breadcrumb{{Timestamp: testNow, Message: "foo"}}, scope.breadcrumbs.list())
 	assertEqual(t, []*Attachment{{Filename: "foo.txt", Payload: []byte("foo")}}, scope.attachments)
 	assertEqual(t, User{ID: "foo"}, scope.user)
 	assertEqual(t, r, scope.request)
@@ -587,7 +588,7 @@ func TestClearBreadcrumbs(t *testing.T) {
 	scope := fillScopeWithData(NewScope())
 	scope.ClearBreadcrumbs()
 
-	assertEqual(t, []*Breadcrumb{}, scope.breadcrumbs)
+	assertEqual(t, []*Breadcrumb{}, scope.breadcrumbs.list())
 }
 
 func TestClearAttachments(t *testing.T) {
@@ -650,10 +651,33 @@ func TestApplyToEventUsingEmptyEvent(t *testing.T) {
 	assertEqual(t, processedEvent.Request, NewRequest(scope.request), "should use scope request")
 }
 
+func TestApplyToEventAttachmentsByEventType(t *testing.T) {
+	scope := NewScope()
+	always := &Attachment{Filename: "config.txt", AddToTransactions: true}
+	errorsOnly := &Attachment{Filename: "body.txt"}
+	scope.AddAttachment(always)
+	scope.AddAttachment(errorsOnly)
+
+	tests := []struct {
+		eventType string
+		want      []*Attachment
+	}{
+		{"", []*Attachment{always, errorsOnly}},
+		{transactionType, []*Attachment{always}},
+		{checkInType, nil},
+	}
+	for _, tt := range tests {
+		event := NewEvent()
+		event.Type = tt.eventType
+		processedEvent := scope.ApplyToEvent(event, nil, nil)
+		assertEqual(t, processedEvent.Attachments, tt.want, tt.eventType)
+	}
+}
+
 func TestEventProcessorsModifiesEvent(t *testing.T) {
 	scope := NewScope()
 	event := NewEvent()
-	scope.eventProcessors = []EventProcessor{
+	for _, processor := range []EventProcessor{
 		func(event *Event, hint *EventHint) *Event {
 			event.Level = LevelFatal
 			return event
@@ -662,6 +686,8 @@ func TestEventProcessorsModifiesEvent(t *testing.T) {
 			event.Fingerprint = []string{"wat"}
 			return event
 		},
+	} {
+		scope.AddEventProcessor(processor)
 	}
 	processedEvent := scope.ApplyToEvent(event, nil, nil)
 
@@ -675,10 +701,12 @@ func TestEventProcessorsModifiesEvent(t *testing.T) {
 func TestEventProcessorsCanDropEvent(t *testing.T) {
 	scope := NewScope()
 	event := NewEvent()
-	scope.eventProcessors = []EventProcessor{
+	for _, processor := range []EventProcessor{
 		func(event *Event, hint *EventHint) *Event {
 			return nil
 		},
+	} {
+		scope.AddEventProcessor(processor)
 	}
 	processedEvent := scope.ApplyToEvent(event, nil, nil)
 
@@ -736,6 +764,22 @@ func TestScopeSetPropagationContext(t *testing.T) {
 	assertEqual(t, scope.propagationContext, p)
 }
 
+func TestScopePropagationContext(t *testing.T) {
+	scope := NewScope()
+	p, err := PropagationContextFromHeaders(
+		"bc6d53f15eb88f4320054569b8c553d4-b72fa28504b07285-1",
+		"sentry-trace_id=bc6d53f15eb88f4320054569b8c553d4",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scope.SetPropagationContext(p)
+
+	assertEqual(t, scope.PropagationContext(), p)
+	assertEqual(t, GetTraceHeader(scope), "bc6d53f15eb88f4320054569b8c553d4-"+p.SpanID.String())
+	assertEqual(t, GetBaggageHeader(scope), "sentry-trace_id=bc6d53f15eb88f4320054569b8c553d4")
+}
+
 func TestScopeSetSpan(t *testing.T) {
 	scope := NewScope()
 	s := &Span{TraceID: TraceIDFromHex("bc6d53f15eb88f4320054569b8c553d4")}
@@ -743,3 +787,125 @@ func TestScopeSetSpan(t *testing.T) {
 
 	assertEqual(t, scope.span, s)
 }
+
+func TestScopeSnapshotRestore(t *testing.T) {
+	scope := fillScopeWithData(NewScope())
+	want := scope.Clone()
+
+	func() {
+		defer func() { _ = recover() }()
+		defer scope.Restore(scope.Snapshot())
+
+		scope.SetTag("temporary", "tag")
+		scope.SetLevel(LevelFatal)
+		scope.RemoveExtra("scopeExtraKey")
+		scope.AddBreadcrumb(&Breadcrumb{Message: "temporary"}, maxBreadcrumbs)
+		panic("boom")
+	}()
+
+	assertEqual(t, scope.tags, want.tags)
+	assertEqual(t, scope.level, want.level)
+	assertEqual(t, scope.extra, want.extra)
+	assertEqual(t, scope.breadcrumbs.list(), want.breadcrumbs.list())
+}
+
+func TestScopeRestoreTwice(t *testing.T) {
+	scope := NewScope()
+	scope.SetTag("key", "value")
+	snapshot := scope.Snapshot()
+
+	scope.Restore(snapshot)
+	scope.SetTag("key", "modified")
+	scope.Restore(snapshot)
+
+	assertEqual(t, scope.tags, map[string]string{"key": "value"})
+
+	scope.Restore(ScopeSnapshot{})
+	assertEqual(t, scope.tags, map[string]string{"key": "value"})
+}
+
+func TestScopeRestoreSampleRates(t *testing.T) {
+	scope := NewScope()
+	scope.SetSampleRate(0.5)
+	snapshot := scope.Snapshot()
+
+	scope.SetSampleRate(0)
+	scope.SetTracesSampleRate(0)
+	scope.Restore(snapshot)
+
+	events, traces := scope.sampleRates()
+	assertEqual(t, *events, 0.5)
+	assertEqual(t, traces, (*float64)(nil))
+}
+
+func TestMergeScopesSampleRates(t *testing.T) {
+	global := NewScope()
+	if !global.isEmpty() {
+		t.Fatal("new scope is not empty")
+	}
+	global.SetTracesSampleRate(0.25)
+	if global.isEmpty() {
+		t.Error("scope with a sample rate is empty")
+	}
+	local := NewScope()
+	local.SetSampleRate(0.5)
+
+	events, traces := mergeScopes(global, local).sampleRates()
+	assertEqual(t, *events, 0.5)
+	assertEqual(t, *traces, 0.25)
+}
+
+func TestScopeCloneSharesUntilWrite(t *testing.T) {
+	scope := NewScope()
+	for i := 0; i < 3; i++ {
+		scope.AddBreadcrumb(&Breadcrumb{Timestamp: testNow, Message: "parent"}, maxBreadcrumbs)
+	}
+	scope.SetTag("foo", "bar")
+
+	// Appending to slices with spare capacity must not let both scopes write
+	// to the same backing array.
+	a, b := scope.Clone(), scope.Clone()
+	a.AddBreadcrumb(&Breadcrumb{Timestamp: testNow, Message: "a"}, maxBreadcrumbs)
+	b.AddBreadcrumb(&Breadcrumb{Timestamp: testNow, Message: "b"}, maxBreadcrumbs)
+	a.SetTag("foo", "a")
+
+	assertEqual(t, scope.breadcrumbs.len(), 3)
+	assertEqual(t, a.breadcrumbs.list()[3].Message, "a")
+	assertEqual(t, b.breadcrumbs.list()[3].Message, "b")
+	assertEqual(t, scope.tags["foo"], "bar")
+	assertEqual(t, b.tags["foo"], "bar")
+	assertEqual(t, a.tags["foo"], "a")
+}
+
+func BenchmarkScopeClone(b *testing.B) {
+	scope := NewScope()
+	for i := 0; i < maxBreadcrumbs; i++ {
+		scope.AddBreadcrumb(&Breadcrumb{Message: "breadcrumb"}, maxBreadcrumbs)
+	}
+	for i := 0; i < 20; i++ {
+		key := strconv.Itoa(i)
+		scope.SetTag(key, "value")
+		scope.SetExtra(key, "value")
+		scope.SetContext(key, Context{"key": "value"})
+	}
+
+	b.Run("Clone", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = scope.Clone()
+		}
+	})
+	b.Run("CloneAndSetTag", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			scope.Clone().SetTag("request", "value")
+		}
+	})
+	b.Run("HubClone", func(b *testing.B) {
+		hub := NewHub(nil, scope)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = hub.Clone()
+		}
+	})
+}
@@ -0,0 +1,121 @@
+package sentry
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// selfMonitorInterval is the minimum time between two reports of the same
+// kind of SDK failure.
+const selfMonitorInterval = time.Minute
+
+// selfMonitorNetworkErrors is the number of network errors within
+// selfMonitorInterval after which the transport is reported as failing.
+const selfMonitorNetworkErrors = 5
+
+// Kinds of SDK failures reported by a selfMonitor, set as the
+// sentry.sdk_failure tag of the report.
+const (
+	sdkFailureSerialization = "serialization"
+	sdkFailureTransport     = "transport"
+	sdkFailureSpanOverflow  = "span_overflow"
+)
+
+// selfMonitor reports internal failures of the SDK as events to a separate
+// client, see ClientOptions.SelfMonitoringDsn.
+type selfMonitor struct {
+	client *Client
+
+	mu       sync.Mutex
+	reported map[string]time.Time
+	// networkErrors holds the times of the recent network errors.
+	networkErrors []time.Time
+}
+
+func newSelfMonitor(options ClientOptions) (*selfMonitor, error) {
+	client, err := NewClient(ClientOptions{
+		Dsn:         options.SelfMonitoringDsn,
+		Release:     options.Release,
+		Environment: options.Environment,
+		ServerName:  options.ServerName,
+		Integrations: func([]Integration) []Integration {
+			return nil
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid SelfMonitoringDsn: %w", err)
+	}
+	return &selfMonitor{
+		client:   client,
+		reported: make(map[string]time.Time),
+	}, nil
+}
+
+// eventDropped is called for every event dropped by the transport, and
+// reports the drops caused by SDK failures.
+func (m *selfMonitor) eventDropped(event *Event, reason DropReason, category string) {
+	switch reason {
+	case DropReasonInternalError:
+		m.report(sdkFailureSerialization, category, "Event %s could not be serialized", event.EventID)
+	case DropReasonNetworkError:
+		if m.networkError(time.Now()) {
+			m.report(sdkFailureTransport, category,
+				"%d events failed to be sent within %s", selfMonitorNetworkErrors, selfMonitorInterval)
+		}
+	}
+}
+
+// networkError records a network error at now and reports whether there
+// were selfMonitorNetworkErrors of them within selfMonitorInterval.
+func (m *selfMonitor) networkError(now time.Time) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	recent := m.networkErrors[:0]
+	for _, t := range m.networkErrors {
+		if now.Sub(t) < selfMonitorInterval {
+			recent = append(recent, t)
+		}
+	}
+	m.networkErrors = append(recent, now)
+	return len(m.networkErrors) >= selfMonitorNetworkErrors
+}
+
+// report captures an event describing an SDK failure of the given kind, unless
+// one of the same kind was reported within selfMonitorInterval.
+func (m *selfMonitor) report(kind, category, format string, args ...interface{}) {
+	if m == nil {
+		return
+	}
+
+	now := time.Now()
+	m.mu.Lock()
+	if last, ok := m.reported[kind]; ok && now.Sub(last) < selfMonitorInterval {
+		m.mu.Unlock()
+		return
+	}
+	m.reported[kind] = now
+	m.mu.Unlock()
+
+	event := NewEvent()
+	event.Level = LevelWarning
+	event.Message = "Sentry SDK failure: " + fmt.Sprintf(format, args...)
+	event.Tags["sentry.sdk_failure"] = kind
+	if category != "" {
+		event.Tags["sentry.dropped_category"] = category
+	}
+	event.Fingerprint = []string{"sentry-sdk-failure", kind}
+	m.client.CaptureEvent(event, nil, nil)
+}
+
+// wrapOnEventDropped returns an OnEventDropped callback that passes dropped
+// events to the monitor before calling onEventDropped.
+func (m *selfMonitor) wrapOnEventDropped(onEventDropped func(*Event, DropReason, string)) func(*Event, DropReason, string) {
+	return func(event *Event, reason DropReason, category string) {
+		m.eventDropped(event, reason, category)
+		if onEventDropped != nil {
+			onEventDropped(event, reason, category)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package sentry
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestSelfMonitor(t *testing.T) (*selfMonitor, *TransportMock) {
+	t.Helper()
+	monitor, err := newSelfMonitor(ClientOptions{SelfMonitoringDsn: testDsn, Environment: "production"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport := &TransportMock{}
+	monitor.client.Transport = transport
+	return monitor, transport
+}
+
+func TestSelfMonitorReportsSerializationErrors(t *testing.T) {
+	monitor, transport := newTestSelfMonitor(t)
+
+	monitor.eventDropped(&Event{EventID: "1"}, DropReasonInternalError, "error")
+	monitor.eventDropped(&Event{EventID: "2"}, DropReasonInternalError, "error")
+	monitor.eventDropped(&Event{EventID: "3"}, DropReasonSampleRate, "error")
+
+	events := transport.Events()
+	if len(events) != 1 {
+		t.Fatalf("got %d reports, want 1", len(events))
+	}
+	assertEqual(t, events[0].Message, "Sentry SDK failure: Event 1 could not be serialized")
+	assertEqual(t, events[0].Environment, "production")
+	assertEqual(t, events[0].Tags["sentry.sdk_failure"], sdkFailureSerialization)
+	assertEqual(t, events[0].Tags["sentry.dropped_category"], "error")
+}
+
+func TestSelfMonitorReportsFailingTransport(t *testing.T) {
+	monitor, transport := newTestSelfMonitor(t)
+
+	for i := 0; i < selfMonitorNetworkErrors-1; i++ {
+		monitor.eventDropped(&Event{}, DropReasonNetworkError, "error")
+	}
+	if n := len(transport.Events()); n != 0 {
+		t.Fatalf("got %d reports before reaching the threshold", n)
+	}
+	monitor.eventDropped(&Event{}, DropReasonNetworkError, "transaction")
+	if n := len(transport.Events()); n != 1 {
+		t.Fatalf("got %d reports, want 1", n)
+	}
+	assertEqual(t, transport.lastEvent.Tags["sentry.sdk_failure"], sdkFailureTransport)
+}
+
+func TestSelfMonitorNetworkErrorsExpire(t *testing.T) {
+	monitor, _ := newTestSelfMonitor(t)
+	start := time.Now()
+
+	for i := 0; i < selfMonitorNetworkErrors-1; i++ {
+		monitor.networkError(start)
+	}
+	if monitor.networkError(start.Add(selfMonitorInterval)) {
+		t.Error("expired network errors counted")
+	}
+}
+
+func TestSelfMonitoringDsnOption(t *testing.T) {
+	client, err := NewClient(ClientOptions{Transport: &TransportMock{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.selfMonitor != nil {
+		t.Error("self-monitoring enabled without SelfMonitoringDsn")
+	}
+	client.selfMonitor.report(sdkFailureSpanOverflow, "", "not reported")
+
+	if _, err := NewClient(ClientOptions{SelfMonitoringDsn: "invalid"}); err == nil {
+		t.Error("NewClient accepted an invalid SelfMonitoringDsn")
+	}
+}
+
+// slowFlushTransport blocks for the whole timeout in Flush.
+type slowFlushTransport struct {
+	TransportMock
+}
+
+func (t *slowFlushTransport) Flush(timeout time.Duration) bool {
+	time.Sleep(timeout)
+	return false
+}
+
+func TestFlushWithSelfMonitorRespectsTimeout(t *testing.T) {
+	client, err := NewClient(ClientOptions{
+		Transport:         &slowFlushTransport{},
+		SelfMonitoringDsn: testDsn,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.selfMonitor.client.Transport = &slowFlushTransport{}
+
+	timeout := 100 * time.Millisecond
+	start := time.Now()
+	client.Flush(timeout)
+	if elapsed := time.Since(start); elapsed >= 2*timeout {
+		t.Errorf("Flush blocked for %s, want at most about %s", elapsed, timeout)
+	}
+}
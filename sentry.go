@@ -29,25 +29,60 @@ func Init(options ClientOptions) error {
 // The total number of breadcrumbs that can be recorded are limited by the
 // configuration on the client.
 func AddBreadcrumb(breadcrumb *Breadcrumb) {
-	hub := CurrentHub()
+	hub := localHub()
 	hub.AddBreadcrumb(breadcrumb, nil)
 }
 
 // CaptureMessage captures an arbitrary message.
 func CaptureMessage(message string) *EventID {
-	hub := CurrentHub()
+	hub := localHub()
 	return hub.CaptureMessage(message)
 }
 
+// CaptureMessageThrottled captures a message, collapsing its occurrences
+// within window into a single event, see Hub.CaptureMessageThrottled.
+func CaptureMessageThrottled(message string, window time.Duration) *EventID {
+	hub := localHub()
+	return hub.CaptureMessageThrottled(message, window)
+}
+
 // CaptureException captures an error.
 func CaptureException(exception error) *EventID {
-	hub := CurrentHub()
+	hub := localHub()
 	return hub.CaptureException(exception)
 }
 
+// CaptureExceptionWithHint captures an error with hint, see
+// Hub.CaptureExceptionWithHint.
+func CaptureExceptionWithHint(exception error, hint *EventHint) *EventID {
+	hub := localHub()
+	return hub.CaptureExceptionWithHint(exception, hint)
+}
+
+// CaptureExceptionWithContext captures an error and passes the relevant
+// context object. The hub stored in ctx is used, if any.
+func CaptureExceptionWithContext(ctx context.Context, exception error) *EventID {
+	hub := GetHubFromContext(ctx)
+	if hub == nil {
+		hub = localHub()
+	}
+	return hub.CaptureExceptionWithContext(ctx, exception)
+}
+
+// CaptureExceptionWithSpan captures an error and records the capture as a
+// span in the transaction of ctx, see Hub.CaptureExceptionWithSpan. The hub
+// stored in ctx is used, if any.
+func CaptureExceptionWithSpan(ctx context.Context, exception error) *EventID {
+	hub := GetHubFromContext(ctx)
+	if hub == nil {
+		hub = localHub()
+	}
+	return hub.CaptureExceptionWithSpan(ctx, exception)
+}
+
 // CaptureCheckIn captures a (cron) monitor check-in.
 func CaptureCheckIn(checkIn *CheckIn, monitorConfig *MonitorConfig) *EventID {
-	hub := CurrentHub()
+	hub := localHub()
 	return hub.CaptureCheckIn(checkIn, monitorConfig)
 }
 
@@ -57,14 +92,14 @@ func CaptureCheckIn(checkIn *CheckIn, monitorConfig *MonitorConfig) *EventID {
 // the utility methods like CaptureException. The return value is the
 // event ID. In case Sentry is disabled or event was dropped, the return value will be nil.
 func CaptureEvent(event *Event) *EventID {
-	hub := CurrentHub()
+	hub := localHub()
 	return hub.CaptureEvent(event)
 }
 
 // Recover captures a panic.
 func Recover() *EventID {
 	if err := recover(); err != nil {
-		hub := CurrentHub()
+		hub := localHub()
 		return hub.Recover(err)
 	}
 	return nil
@@ -79,7 +114,7 @@ func RecoverWithContext(ctx context.Context) *EventID {
 
 	hub := GetHubFromContext(ctx)
 	if hub == nil {
-		hub = CurrentHub()
+		hub = localHub()
 	}
 
 	return hub.RecoverWithContext(ctx, err)
@@ -87,25 +122,25 @@ func RecoverWithContext(ctx context.Context) *EventID {
 
 // WithScope is a shorthand for CurrentHub().WithScope.
 func WithScope(f func(scope *Scope)) {
-	hub := CurrentHub()
+	hub := localHub()
 	hub.WithScope(f)
 }
 
 // ConfigureScope is a shorthand for CurrentHub().ConfigureScope.
 func ConfigureScope(f func(scope *Scope)) {
-	hub := CurrentHub()
+	hub := localHub()
 	hub.ConfigureScope(f)
 }
 
 // PushScope is a shorthand for CurrentHub().PushScope.
 func PushScope() {
-	hub := CurrentHub()
+	hub := localHub()
 	hub.PushScope()
 }
 
 // PopScope is a shorthand for CurrentHub().PopScope.
 func PopScope() {
-	hub := CurrentHub()
+	hub := localHub()
 	hub.PopScope()
 }
 
@@ -127,6 +162,6 @@ func Flush(timeout time.Duration) bool {
 
 // LastEventID returns an ID of last captured event.
 func LastEventID() EventID {
-	hub := CurrentHub()
+	hub := localHub()
 	return hub.LastEventID()
 }
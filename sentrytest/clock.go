@@ -0,0 +1,53 @@
+package sentrytest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// Clock is a sentry.Clock for ClientOptions.Clock whose time only moves when
+// Advance or Set is called, so that timestamps and durations of events and
+// spans are deterministic. It is safe for concurrent use.
+//
+//	clock := sentrytest.NewClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+//	hub, transport := sentrytest.NewHub(t, sentry.ClientOptions{Clock: clock})
+//	span := sentry.StartSpan(ctx, "task")
+//	clock.Advance(time.Second)
+//	span.Finish()
+type Clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+var _ sentry.Clock = (*Clock)(nil)
+
+// NewClock returns a Clock whose time is now.
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now}
+}
+
+// Now implements sentry.Clock.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// Advance moves the time of the clock forward by d.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+// Set sets the time of the clock to now.
+func (c *Clock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = now
+}
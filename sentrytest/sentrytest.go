@@ -0,0 +1,248 @@
+// Package sentrytest provides helpers for testing code that reports to Sentry,
+// without sending anything over the network.
+//
+// A typical test binds a hub with an in-memory transport to the context of the
+// code under test, and asserts on the captured events:
+//
+//	func TestHandler(t *testing.T) {
+//		hub, transport := sentrytest.NewHub(t, sentry.ClientOptions{})
+//		ctx := sentry.SetHubOnContext(context.Background(), hub)
+//
+//		handle(ctx)
+//
+//		event := transport.RequireEvent(t, func(e *sentry.Event) bool {
+//			return e.Level == sentry.LevelError
+//		})
+//		if event.Tags["handler"] != "checkout" { ... }
+//	}
+//
+// Transport.Envelopes returns the events encoded as they would be sent to
+// Sentry, and a Clock set as ClientOptions.Clock makes timestamps
+// deterministic.
+package sentrytest
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// transactionType is the type of transaction events, see sentry.Event.Type.
+const transactionType = "transaction"
+
+// NewHub returns a hub with a new scope and a client created with options
+// that captures events with the returned Transport. The options may not set
+// a Transport of their own. The client's events are sent regardless of
+// whether options set a Dsn.
+func NewHub(tb testing.TB, options sentry.ClientOptions) (*sentry.Hub, *Transport) {
+	tb.Helper()
+
+	transport := &Transport{}
+	if options.Transport != nil {
+		tb.Fatal("sentrytest: NewHub options must not set a Transport")
+	}
+	options.Transport = transport
+	if options.Dsn == "" {
+		options.Dsn = "https://public@sentry.example.com/1"
+	}
+	client, err := sentry.NewClient(options)
+	if err != nil {
+		tb.Fatalf("sentrytest: %v", err)
+	}
+	return sentry.NewHub(client, sentry.NewScope()), transport
+}
+
+// Transport is a sentry.Transport that keeps the events it is given in
+// memory, together with the envelopes the SDK would send for them. It is safe
+// for concurrent use.
+type Transport struct {
+	mu        sync.Mutex
+	events    []*sentry.Event
+	envelopes [][]byte
+	flushes   int
+
+	// encoder encodes the envelopes with the options of the client, sending
+	// them to recordEnvelope instead of the network.
+	encoder *sentry.HTTPSyncTransport
+}
+
+// Configure implements sentry.Transport.
+func (t *Transport) Configure(options sentry.ClientOptions) {
+	options.HTTPClient = nil
+	options.HTTPTransport = envelopeRecorder{t}
+	encoder := sentry.NewHTTPSyncTransport()
+	encoder.Configure(options)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.encoder = encoder
+}
+
+// SendEvent implements sentry.Transport by storing the event and its
+// envelope.
+func (t *Transport) SendEvent(event *sentry.Event) {
+	t.mu.Lock()
+	t.events = append(t.events, event)
+	encoder := t.encoder
+	t.mu.Unlock()
+
+	if encoder != nil {
+		encoder.SendEvent(event)
+	}
+}
+
+// envelopeRecorder is an http.RoundTripper storing the bodies of requests as
+// the envelopes of a Transport.
+type envelopeRecorder struct{ t *Transport }
+
+func (r envelopeRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	r.t.mu.Lock()
+	r.t.envelopes = append(r.t.envelopes, body)
+	r.t.mu.Unlock()
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     make(http.Header),
+		Body:       io.NopCloser(bytes.NewReader(nil)),
+		Request:    req,
+	}, nil
+}
+
+// Envelopes returns the encoded envelopes of the captured events, in the
+// order they were sent, as they would be sent to Sentry. Use it to assert on
+// the effects of ClientOptions.EventEncoder and ClientOptions.EnvelopeHeader.
+// Events the client's Dsn cannot be parsed for have no envelope.
+func (t *Transport) Envelopes() [][]byte {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return append([][]byte(nil), t.envelopes...)
+}
+
+// Flush implements sentry.Transport. It returns true immediately, events are
+// available as soon as they are sent.
+func (t *Transport) Flush(time.Duration) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.flushes++
+	return true
+}
+
+// Flushes returns the number of calls to Flush.
+func (t *Transport) Flushes() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.flushes
+}
+
+// Events returns all captured events in the order they were sent, including
+// transactions and check-ins.
+func (t *Transport) Events() []*sentry.Event {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return append([]*sentry.Event(nil), t.events...)
+}
+
+// Errors returns the captured error and message events, excluding
+// transactions, check-ins and other event types.
+func (t *Transport) Errors() []*sentry.Event {
+	return t.filter(func(e *sentry.Event) bool { return e.Type == "" })
+}
+
+// Transactions returns the captured transactions.
+func (t *Transport) Transactions() []*sentry.Event {
+	return t.filter(func(e *sentry.Event) bool { return e.Type == transactionType })
+}
+
+// Breadcrumbs returns the breadcrumbs of the most recently captured error or
+// message event. As breadcrumbs accumulate on the scope, they include the
+// breadcrumbs of earlier events.
+func (t *Transport) Breadcrumbs() []*sentry.Breadcrumb {
+	errors := t.Errors()
+	if len(errors) == 0 {
+		return nil
+	}
+	return errors[len(errors)-1].Breadcrumbs
+}
+
+// LastEvent returns the most recently captured event, or nil if there is
+// none.
+func (t *Transport) LastEvent() *sentry.Event {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.events) == 0 {
+		return nil
+	}
+	return t.events[len(t.events)-1]
+}
+
+// Reset discards all captured events and envelopes.
+func (t *Transport) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.events = nil
+	t.envelopes = nil
+	t.flushes = 0
+}
+
+// FindEvent returns the first captured event for which match returns true,
+// or nil if there is none.
+func (t *Transport) FindEvent(match func(*sentry.Event) bool) *sentry.Event {
+	for _, e := range t.Events() {
+		if match(e) {
+			return e
+		}
+	}
+	return nil
+}
+
+// RequireEvent is like FindEvent, but fails the test if no event matches.
+func (t *Transport) RequireEvent(tb testing.TB, match func(*sentry.Event) bool) *sentry.Event {
+	tb.Helper()
+
+	e := t.FindEvent(match)
+	if e == nil {
+		tb.Fatalf("sentrytest: no matching event among %d captured events", len(t.Events()))
+	}
+	return e
+}
+
+// RequireEventCount fails the test unless exactly n events were captured.
+func (t *Transport) RequireEventCount(tb testing.TB, n int) {
+	tb.Helper()
+
+	if got := len(t.Events()); got != n {
+		tb.Fatalf("sentrytest: captured %d events, want %d", got, n)
+	}
+}
+
+// RequireNoEvents fails the test if any event was captured.
+func (t *Transport) RequireNoEvents(tb testing.TB) {
+	tb.Helper()
+
+	t.RequireEventCount(tb, 0)
+}
+
+func (t *Transport) filter(keep func(*sentry.Event) bool) []*sentry.Event {
+	var events []*sentry.Event
+	for _, e := range t.Events() {
+		if keep(e) {
+			events = append(events, e)
+		}
+	}
+	return events
+}
@@ -0,0 +1,104 @@
+package sentrytest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+func TestNewHub(t *testing.T) {
+	hub, transport := NewHub(t, sentry.ClientOptions{EnableTracing: true, TracesSampleRate: 1})
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+
+	hub.AddBreadcrumb(&sentry.Breadcrumb{Message: "start"}, nil)
+	hub.CaptureException(errors.New("boom"))
+	span := sentry.StartTransaction(ctx, "checkout")
+	span.Finish()
+	hub.Flush(0)
+
+	transport.RequireEventCount(t, 2)
+	event := transport.RequireEvent(t, func(e *sentry.Event) bool {
+		return len(e.Exception) > 0 && e.Exception[0].Value == "boom"
+	})
+	if event != transport.Errors()[0] {
+		t.Errorf("Errors() = %v, want the captured exception", transport.Errors())
+	}
+	if got := transport.Transactions(); len(got) != 1 || got[0].Transaction != "checkout" {
+		t.Errorf("Transactions() = %v, want the checkout transaction", got)
+	}
+	if got := transport.Breadcrumbs(); len(got) != 1 || got[0].Message != "start" {
+		t.Errorf("Breadcrumbs() = %v, want the start breadcrumb", got)
+	}
+	if transport.LastEvent().Type != "transaction" {
+		t.Errorf("LastEvent() = %v, want the transaction", transport.LastEvent())
+	}
+	if transport.Flushes() != 1 {
+		t.Errorf("Flushes() = %d, want 1", transport.Flushes())
+	}
+
+	transport.Reset()
+	transport.RequireNoEvents(t)
+	if transport.LastEvent() != nil {
+		t.Error("LastEvent() after Reset() is not nil")
+	}
+	if transport.FindEvent(func(*sentry.Event) bool { return true }) != nil {
+		t.Error("FindEvent() after Reset() is not nil")
+	}
+}
+
+func TestEnvelopes(t *testing.T) {
+	hub, transport := NewHub(t, sentry.ClientOptions{
+		EnvelopeHeader: func(header sentry.EnvelopeHeader, _ *sentry.Event) {
+			header["tenant"] = "acme"
+		},
+	})
+
+	id := hub.CaptureMessage("hello")
+	envelopes := transport.Envelopes()
+	if len(envelopes) != 1 {
+		t.Fatalf("got %d envelopes, want 1", len(envelopes))
+	}
+	header, _, _ := bytes.Cut(envelopes[0], []byte("\n"))
+	for _, want := range []string{`"event_id":"` + string(*id) + `"`, `"tenant":"acme"`} {
+		if !bytes.Contains(header, []byte(want)) {
+			t.Errorf("envelope header %s does not contain %s", header, want)
+		}
+	}
+	if !bytes.Contains(envelopes[0], []byte(`"message":"hello"`)) {
+		t.Errorf("envelope does not contain the event: %s", envelopes[0])
+	}
+
+	transport.Reset()
+	if len(transport.Envelopes()) != 0 {
+		t.Error("Envelopes() after Reset() is not empty")
+	}
+}
+
+func TestClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewClock(start)
+	hub, transport := NewHub(t, sentry.ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1,
+		Clock:            clock,
+	})
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+
+	span := sentry.StartTransaction(ctx, "task")
+	clock.Advance(time.Second)
+	span.Finish()
+
+	transaction := transport.Transactions()[0]
+	if !transaction.StartTime.Equal(start) || transaction.Timestamp.Sub(transaction.StartTime) != time.Second {
+		t.Errorf("transaction lasted from %v to %v", transaction.StartTime, transaction.Timestamp)
+	}
+
+	clock.Set(start)
+	if !clock.Now().Equal(start) {
+		t.Errorf("Now() = %v after Set(%v)", clock.Now(), start)
+	}
+}
@@ -0,0 +1,50 @@
+package sentry
+
+// SeverityKind is the kind of a native severity that is mapped to a Level,
+// see ClientOptions.SeverityMapper.
+type SeverityKind string
+
+// Kinds of native severities.
+const (
+	// SeverityError is the severity of captured errors. The value is the
+	// error.
+	SeverityError SeverityKind = "error"
+	// SeverityPanic is the severity of recovered panics. The value is the
+	// recovered value.
+	SeverityPanic SeverityKind = "panic"
+	// SeverityHTTPStatus is the severity of HTTP responses, reported by the
+	// HTTP client integration. The value is the status code as an int.
+	SeverityHTTPStatus SeverityKind = "http.status_code"
+	// SeverityGRPCCode is the severity of gRPC calls, see ReportGRPCStatus.
+	// The value is the status code as a uint32.
+	SeverityGRPCCode SeverityKind = "grpc.status_code"
+	// SeverityLogLevel is the severity of log entries, reported by the
+	// logging integrations. The value is the level of the logging library,
+	// for example a logrus.Level.
+	SeverityLogLevel SeverityKind = "log.level"
+)
+
+// Severity is a native severity of an integration, passed to
+// ClientOptions.SeverityMapper.
+type Severity struct {
+	Kind  SeverityKind
+	Value interface{}
+	// Default is the level the integration reports the severity with
+	// unless the mapper returns another one.
+	Default Level
+}
+
+// MapSeverity returns the level that events and breadcrumbs of a native
+// severity are reported with: the level returned by ClientOptions.SeverityMapper,
+// or defaultLevel if there is no mapper or it returns an empty level.
+// Integrations call it instead of hard-coding their mapping. It is safe to call
+// on a nil client.
+func (client *Client) MapSeverity(kind SeverityKind, value interface{}, defaultLevel Level) Level {
+	if client == nil || client.options.SeverityMapper == nil {
+		return defaultLevel
+	}
+	if level := client.options.SeverityMapper(Severity{Kind: kind, Value: value, Default: defaultLevel}); level != "" {
+		return level
+	}
+	return defaultLevel
+}
@@ -0,0 +1,38 @@
+package sentry
+
+import (
+	"errors"
+	"testing"
+)
+
+var errExpected = errors.New("expected")
+
+func TestSeverityMapper(t *testing.T) {
+	var kinds []SeverityKind
+	ctx := NewTestContext(ClientOptions{
+		SeverityMapper: func(s Severity) Level {
+			kinds = append(kinds, s.Kind)
+			switch {
+			case s.Kind == SeverityError && errors.Is(s.Value.(error), errExpected):
+				return LevelWarning
+			case s.Kind == SeverityGRPCCode && s.Value == grpcUnavailable:
+				return LevelInfo
+			}
+			return ""
+		},
+	})
+	hub := hubFromContext(ctx)
+	transport := hub.Client().Transport.(*TransportMock)
+
+	hub.CaptureException(errExpected)
+	assertEqual(t, transport.lastEvent.Level, LevelWarning)
+	hub.CaptureException(errors.New("unexpected"))
+	assertEqual(t, transport.lastEvent.Level, LevelError)
+	hub.Recover("panic")
+	assertEqual(t, transport.lastEvent.Level, LevelFatal)
+	ReportGRPCStatus(ctx, nil, grpcUnavailable, errors.New("unavailable"), nil)
+	assertEqual(t, transport.lastEvent.Level, LevelInfo)
+
+	assertEqual(t, kinds, []SeverityKind{SeverityError, SeverityError, SeverityPanic, SeverityGRPCCode})
+	assertEqual(t, (*Client)(nil).MapSeverity(SeverityError, nil, LevelError), LevelError)
+}
@@ -0,0 +1,140 @@
+package sentry
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// SpanMetricRule describes a metric that is summarized on the spans it
+// applies to, see ClientOptions.SpanMetrics. The summaries are sent as the
+// _metrics_summary of the spans, where Relay picks them up for span metrics.
+type SpanMetricRule struct {
+	// Op is the operation of the spans the rule applies to. An empty Op
+	// applies to all spans, including the transaction.
+	Op string
+	// Key and Unit name the metric, as in NewDistributionMetric. Unit
+	// defaults to MilliSecond() if Value is nil, and to "none" otherwise.
+	Key  string
+	Unit MetricUnit
+	// Value returns the value of the metric for span, and false if it has
+	// none. If nil, the value is the duration of the span in milliseconds.
+	Value func(span *Span) (float64, bool)
+	// Tags are the tags of the span, or else the keys of its data, that are
+	// the dimensions of the summary. Missing tags are left out.
+	Tags []string
+}
+
+// spanMetricSummary is the summary of the values of a metric with the same
+// tags.
+type spanMetricSummary struct {
+	Min   float64           `json:"min"`
+	Max   float64           `json:"max"`
+	Sum   float64           `json:"sum"`
+	Count int               `json:"count"`
+	Tags  map[string]string `json:"tags,omitempty"`
+}
+
+// spanMetricsSummary maps metric resource identifiers, such as
+// "d:custom/db.rows@none", to the summaries of the metric by tags.
+type spanMetricsSummary map[string][]*spanMetricSummary
+
+// add adds value of the metric mri to the summary with tags.
+func (m spanMetricsSummary) add(mri string, value float64, tags map[string]string) {
+	for _, summary := range m[mri] {
+		if mapsEqual(summary.Tags, tags) {
+			summary.Min = math.Min(summary.Min, value)
+			summary.Max = math.Max(summary.Max, value)
+			summary.Sum += value
+			summary.Count++
+			return
+		}
+	}
+	m[mri] = append(m[mri], &spanMetricSummary{Min: value, Max: value, Sum: value, Count: 1, Tags: tags})
+}
+
+// mri returns the metric resource identifier of the metric of the rule.
+func (r *SpanMetricRule) mri() string {
+	unit := r.Unit.toString()
+	switch {
+	case unit != "":
+	case r.Value == nil:
+		unit = MilliSecond().toString()
+	default:
+		unit = "none"
+	}
+	return fmt.Sprintf("d:custom/%s@%s", sanitizeKey(r.Key), unit)
+}
+
+// summarizeSpanMetrics returns the summary of the metrics of rules that
+// apply to span, or nil if none do. The span must be finished.
+func summarizeSpanMetrics(span *Span, rules []SpanMetricRule) spanMetricsSummary {
+	var summary spanMetricsSummary
+	for i := range rules {
+		rule := &rules[i]
+		if rule.Op != "" && rule.Op != span.Op {
+			continue
+		}
+		var value float64
+		if rule.Value == nil {
+			value = float64(span.EndTime.Sub(span.StartTime)) / float64(time.Millisecond)
+		} else {
+			var ok bool
+			if value, ok = rule.Value(span); !ok {
+				continue
+			}
+		}
+		if summary == nil {
+			summary = make(spanMetricsSummary)
+		}
+		summary.add(rule.mri(), value, span.metricTags(rule.Tags))
+	}
+	return summary
+}
+
+// metricTags returns the tags of the span named by keys, falling back to its
+// data.
+func (s *Span) metricTags(keys []string) map[string]string {
+	if len(keys) == 0 {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	tags := make(map[string]string, len(keys))
+	for _, key := range keys {
+		if value, ok := s.Tags[key]; ok {
+			tags[key] = value
+		} else if value, ok := s.Data[key]; ok {
+			tags[key] = fmt.Sprint(value)
+		}
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+	return tags
+}
+
+// applySpanMetrics attaches the metric summaries of ClientOptions.SpanMetrics
+// to the spans of event, the transaction of s.
+func (s *Span) applySpanMetrics(event *Event) {
+	rules := s.clientOptions().SpanMetrics
+	if len(rules) == 0 {
+		return
+	}
+	for _, span := range event.Spans {
+		span.metricsSummary = summarizeSpanMetrics(span, rules)
+	}
+	event.sdkMetaData.metricsSummary = summarizeSpanMetrics(s, rules)
+}
+
+func mapsEqual(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if w, ok := b[k]; !ok || v != w {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,75 @@
+package sentry
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestSpanMetrics(t *testing.T) {
+	ctx := NewTestContext(ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		SpanMetrics: []SpanMetricRule{
+			{Op: "db.query", Key: "db.duration", Tags: []string{"db.system"}},
+			{
+				Op:  "db.query",
+				Key: "db.rows",
+				Value: func(span *Span) (float64, bool) {
+					rows, ok := span.Data["db.rows"].(int)
+					return float64(rows), ok
+				},
+			},
+		},
+	})
+	transport := hubFromContext(ctx).Client().Transport.(*TransportMock)
+	start := time.Now()
+
+	transaction := StartTransaction(ctx, "GET /", WithStartTime(start))
+	for i, rows := range []int{3, 5} {
+		span := transaction.StartChild("db.query", WithStartTime(start))
+		span.SetTag("db.system", "postgresql")
+		span.SetData("db.rows", rows)
+		span.FinishWithTime(start.Add(time.Duration(i+1) * time.Millisecond))
+	}
+	other := transaction.StartChild("http.client")
+	other.Finish()
+	transaction.Finish()
+
+	event := transport.lastEvent
+	assertEqual(t, len(event.Spans), 3)
+	assertEqual(t, event.Spans[0].metricsSummary, spanMetricsSummary{
+		"d:custom/db.duration@millisecond": {{Min: 1, Max: 1, Sum: 1, Count: 1, Tags: map[string]string{"db.system": "postgresql"}}},
+		"d:custom/db.rows@none":            {{Min: 3, Max: 3, Sum: 3, Count: 1}},
+	})
+	assertEqual(t, event.Spans[1].metricsSummary["d:custom/db.rows@none"], []*spanMetricSummary{{Min: 5, Max: 5, Sum: 5, Count: 1}})
+	if event.Spans[2].metricsSummary != nil {
+		t.Errorf("span %q has metric summaries: %v", event.Spans[2].Op, event.Spans[2].metricsSummary)
+	}
+	if event.sdkMetaData.metricsSummary != nil {
+		t.Errorf("transaction has metric summaries: %v", event.sdkMetaData.metricsSummary)
+	}
+
+	b, err := json.Marshal(event.Spans[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var span struct {
+		MetricsSummary map[string][]map[string]interface{} `json:"_metrics_summary"`
+	}
+	if err := json.Unmarshal(b, &span); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, span.MetricsSummary["d:custom/db.duration@millisecond"][0]["sum"], 2.0)
+}
+
+func TestSpanMetricsSummaryAdd(t *testing.T) {
+	summary := make(spanMetricsSummary)
+	summary.add("d:custom/x@none", 2, nil)
+	summary.add("d:custom/x@none", 1, nil)
+	summary.add("d:custom/x@none", 4, map[string]string{"a": "b"})
+	assertEqual(t, summary, spanMetricsSummary{"d:custom/x@none": {
+		{Min: 1, Max: 2, Sum: 3, Count: 2},
+		{Min: 4, Max: 4, Sum: 4, Count: 1, Tags: map[string]string{"a": "b"}},
+	}})
+}
@@ -10,13 +10,17 @@ type spanRecorder struct {
 	mu           sync.Mutex
 	spans        []*Span
 	overflowOnce sync.Once
+	// initial backs spans until it outgrows it, which spares small
+	// transactions the first reallocations.
+	initial [8]*Span
 }
 
 // record stores a span. The first stored span is assumed to be the root of a
 // span tree.
 func (r *spanRecorder) record(s *Span) {
 	maxSpans := defaultMaxSpans
-	if client := CurrentHub().Client(); client != nil {
+	client := CurrentHub().Client()
+	if client != nil {
 		maxSpans = client.options.MaxSpans
 	}
 	r.mu.Lock()
@@ -26,11 +30,18 @@ func (r *spanRecorder) record(s *Span) {
 			root := r.spans[0]
 			Logger.Printf("Too many spans: dropping spans from transaction with TraceID=%s SpanID=%s limit=%d",
 				root.TraceID, root.SpanID, maxSpans)
+			if client != nil {
+				client.selfMonitor.report(sdkFailureSpanOverflow, "",
+					"Transaction %q exceeded the limit of %d spans", root.Name, maxSpans)
+			}
 		})
 		// TODO(tracing): mark the transaction event in some way to
 		// communicate that spans were dropped.
 		return
 	}
+	if r.spans == nil {
+		r.spans = r.initial[:0]
+	}
 	r.spans = append(r.spans, s)
 }
 
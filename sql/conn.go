@@ -0,0 +1,182 @@
+package sentrysql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"time"
+)
+
+// sentryConn wraps a driver.Conn, instrumenting the queries run directly on
+// the connection and on the statements it prepares.
+//
+// The optional interfaces are implemented whether or not the wrapped
+// connection implements them, returning driver.ErrSkip where that makes
+// database/sql fall back to the required ones.
+type sentryConn struct {
+	driver.Conn
+	options *Options
+}
+
+var (
+	_ driver.ConnPrepareContext = (*sentryConn)(nil)
+	_ driver.ConnBeginTx        = (*sentryConn)(nil)
+	_ driver.ExecerContext      = (*sentryConn)(nil)
+	_ driver.QueryerContext     = (*sentryConn)(nil)
+	_ driver.Pinger             = (*sentryConn)(nil)
+	_ driver.SessionResetter    = (*sentryConn)(nil)
+	_ driver.Validator          = (*sentryConn)(nil)
+	_ driver.NamedValueChecker  = (*sentryConn)(nil)
+)
+
+func (c *sentryConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *sentryConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var s driver.Stmt
+	var err error
+	if pc, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		s, err = pc.PrepareContext(ctx, query)
+	} else {
+		s, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &sentryStmt{Stmt: s, query: query, options: c.options}, nil
+}
+
+func (c *sentryConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if bc, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return bc.BeginTx(ctx, opts)
+	}
+	// The fallback of database/sql itself, which cannot honour the options.
+	if opts.Isolation != driver.IsolationLevel(sql.LevelDefault) {
+		return nil, errors.New("sql: driver does not support non-default isolation level")
+	}
+	if opts.ReadOnly {
+		return nil, errors.New("sql: driver does not support read-only transactions")
+	}
+	return c.Conn.Begin() //nolint:staticcheck // see above
+}
+
+func (c *sentryConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	ec, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	result, err := ec.ExecContext(ctx, query, args)
+	if err == driver.ErrSkip { //nolint:errorlint // drivers return it unwrapped
+		// database/sql prepares the statement instead, which records it.
+		return nil, err
+	}
+	duration := c.options.observe(ctx, opExec, query, start, err)
+	if c.options.Breadcrumbs {
+		c.options.addBreadcrumb(ctx, opExec, query, duration, rowsAffected(result, err), err)
+	}
+	return result, err
+}
+
+func (c *sentryConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	qc, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	rows, err := qc.QueryContext(ctx, query, args)
+	if err == driver.ErrSkip { //nolint:errorlint // drivers return it unwrapped
+		return nil, err
+	}
+	duration := c.options.observe(ctx, opQuery, query, start, err)
+	return c.options.breadcrumbRows(ctx, query, duration, rows, err), err
+}
+
+func (c *sentryConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *sentryConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *sentryConn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+func (c *sentryConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if nvc, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return nvc.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// sentryStmt wraps a prepared statement to instrument its executions.
+type sentryStmt struct {
+	driver.Stmt
+	query   string
+	options *Options
+}
+
+var (
+	_ driver.StmtExecContext   = (*sentryStmt)(nil)
+	_ driver.StmtQueryContext  = (*sentryStmt)(nil)
+	_ driver.NamedValueChecker = (*sentryStmt)(nil)
+)
+
+func (s *sentryStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+	var result driver.Result
+	var err error
+	if ec, ok := s.Stmt.(driver.StmtExecContext); ok {
+		result, err = ec.ExecContext(ctx, args)
+	} else {
+		result, err = s.Stmt.Exec(values(args)) //nolint:staticcheck // the fallback of database/sql itself
+	}
+	duration := s.options.observe(ctx, opExec, s.query, start, err)
+	if s.options.Breadcrumbs {
+		s.options.addBreadcrumb(ctx, opExec, s.query, duration, rowsAffected(result, err), err)
+	}
+	return result, err
+}
+
+func (s *sentryStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	var rows driver.Rows
+	var err error
+	if qc, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		rows, err = qc.QueryContext(ctx, args)
+	} else {
+		rows, err = s.Stmt.Query(values(args)) //nolint:staticcheck // the fallback of database/sql itself
+	}
+	duration := s.options.observe(ctx, opQuery, s.query, start, err)
+	return s.options.breadcrumbRows(ctx, s.query, duration, rows, err), err
+}
+
+func (s *sentryStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if nvc, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return nvc.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// values converts named arguments to the positional arguments of the
+// deprecated driver.Stmt methods.
+func values(args []driver.NamedValue) []driver.Value {
+	v := make([]driver.Value, len(args))
+	for i, arg := range args {
+		v[i] = arg.Value
+	}
+	return v
+}
@@ -0,0 +1,110 @@
+package sentrysql
+
+import (
+	"context"
+	"database/sql/driver"
+	"io"
+	"reflect"
+	"time"
+)
+
+// breadcrumbRows returns rows wrapped to record the breadcrumb of the query
+// with the number of rows once they are closed, if there are breadcrumbs.
+// Failed queries are recorded right away.
+func (o *Options) breadcrumbRows(ctx context.Context, query string, duration time.Duration, rows driver.Rows, err error) driver.Rows {
+	if !o.Breadcrumbs {
+		return rows
+	}
+	if err != nil || rows == nil {
+		o.addBreadcrumb(ctx, opQuery, query, duration, -1, err)
+		return rows
+	}
+	return &sentryRows{Rows: rows, ctx: ctx, query: query, duration: duration, options: o}
+}
+
+// sentryRows wraps driver.Rows to count the rows returned. Like sentryConn,
+// it implements the optional interfaces with the fallbacks of database/sql.
+type sentryRows struct {
+	driver.Rows
+	ctx      context.Context
+	query    string
+	duration time.Duration
+	options  *Options
+	count    int64
+	closed   bool
+}
+
+var (
+	_ driver.RowsNextResultSet              = (*sentryRows)(nil)
+	_ driver.RowsColumnTypeScanType         = (*sentryRows)(nil)
+	_ driver.RowsColumnTypeDatabaseTypeName = (*sentryRows)(nil)
+	_ driver.RowsColumnTypeLength           = (*sentryRows)(nil)
+	_ driver.RowsColumnTypeNullable         = (*sentryRows)(nil)
+	_ driver.RowsColumnTypePrecisionScale   = (*sentryRows)(nil)
+)
+
+func (r *sentryRows) Next(dest []driver.Value) error {
+	err := r.Rows.Next(dest)
+	if err == nil {
+		r.count++
+	}
+	return err
+}
+
+func (r *sentryRows) Close() error {
+	err := r.Rows.Close()
+	if !r.closed {
+		r.closed = true
+		r.options.addBreadcrumb(r.ctx, opQuery, r.query, r.duration, r.count, nil)
+	}
+	return err
+}
+
+func (r *sentryRows) HasNextResultSet() bool {
+	if n, ok := r.Rows.(driver.RowsNextResultSet); ok {
+		return n.HasNextResultSet()
+	}
+	return false
+}
+
+func (r *sentryRows) NextResultSet() error {
+	if n, ok := r.Rows.(driver.RowsNextResultSet); ok {
+		return n.NextResultSet()
+	}
+	return io.EOF
+}
+
+func (r *sentryRows) ColumnTypeScanType(index int) reflect.Type {
+	if t, ok := r.Rows.(driver.RowsColumnTypeScanType); ok {
+		return t.ColumnTypeScanType(index)
+	}
+	return reflect.TypeOf(new(interface{})).Elem()
+}
+
+func (r *sentryRows) ColumnTypeDatabaseTypeName(index int) string {
+	if t, ok := r.Rows.(driver.RowsColumnTypeDatabaseTypeName); ok {
+		return t.ColumnTypeDatabaseTypeName(index)
+	}
+	return ""
+}
+
+func (r *sentryRows) ColumnTypeLength(index int) (int64, bool) {
+	if t, ok := r.Rows.(driver.RowsColumnTypeLength); ok {
+		return t.ColumnTypeLength(index)
+	}
+	return 0, false
+}
+
+func (r *sentryRows) ColumnTypeNullable(index int) (bool, bool) {
+	if t, ok := r.Rows.(driver.RowsColumnTypeNullable); ok {
+		return t.ColumnTypeNullable(index)
+	}
+	return false, false
+}
+
+func (r *sentryRows) ColumnTypePrecisionScale(index int) (int64, int64, bool) {
+	if t, ok := r.Rows.(driver.RowsColumnTypePrecisionScale); ok {
+		return t.ColumnTypePrecisionScale(index)
+	}
+	return 0, 0, false
+}
@@ -0,0 +1,151 @@
+package sentrysql
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// maxStatementLength is the length in bytes above which sanitized statements
+// are truncated.
+const maxStatementLength = 2000
+
+// SanitizeQuery normalizes query for use as a span description or in
+// breadcrumbs and events, so that it carries no values and the number of
+// distinct statements stays bounded. It replaces the string and numeric
+// literals in query with "?", collapses the lists of IN clauses into a single
+// "?" and truncates statements longer than 2000 bytes. Placeholders such as
+// $1 and identifiers such as t1 are kept outside of IN lists.
+//
+// Integrations with other database libraries may use it for their queries.
+func SanitizeQuery(query string) string {
+	return truncateStatement(collapseLists(stripLiterals(query)))
+}
+
+// stripLiterals replaces the string and numeric literals in query with "?".
+func stripLiterals(query string) string {
+	var b strings.Builder
+	b.Grow(len(query))
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == '\'':
+			// Skip to the closing quote, '' is an escaped quote.
+			i++
+			for i < len(query) {
+				if query[i] == '\'' {
+					if i+1 < len(query) && query[i+1] == '\'' {
+						i += 2
+						continue
+					}
+					break
+				}
+				i++
+			}
+			i++
+			b.WriteByte('?')
+		case isDigit(c) && (i == 0 || !isIdentifier(query[i-1]) && !isPlaceholderPrefix(query[i-1])):
+			for i < len(query) && (isIdentifier(query[i]) || query[i] == '.') {
+				i++
+			}
+			b.WriteByte('?')
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String()
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// isIdentifier reports whether c may be part of an identifier or a
+// placeholder such as $1.
+func isIdentifier(c byte) bool {
+	return isDigit(c) || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c == '$' || c >= 0x80
+}
+
+// isPlaceholderPrefix reports whether c starts a numbered placeholder such as
+// ?1, :1 or @1.
+func isPlaceholderPrefix(c byte) bool {
+	return c == '?' || c == ':' || c == '@'
+}
+
+// collapseLists replaces the lists of values of IN clauses, such as
+// "IN (?, ?, $3)", with "IN (?)".
+func collapseLists(query string) string {
+	var b strings.Builder
+	last := 0
+	for i := 0; i+2 <= len(query); i++ {
+		if !strings.EqualFold(query[i:i+2], "in") ||
+			i > 0 && isIdentifier(query[i-1]) ||
+			i+2 < len(query) && isIdentifier(query[i+2]) {
+			continue
+		}
+		open := i + 2
+		for open < len(query) && isSpace(query[open]) {
+			open++
+		}
+		if open == len(query) || query[open] != '(' {
+			continue
+		}
+		end := strings.IndexByte(query[open:], ')')
+		if end < 0 {
+			break
+		}
+		end += open
+		if !isValueList(query[open+1 : end]) {
+			continue
+		}
+		b.WriteString(query[last : open+1])
+		b.WriteByte('?')
+		last = end
+		i = end
+	}
+	if last == 0 {
+		return query
+	}
+	b.WriteString(query[last:])
+	return b.String()
+}
+
+// isValueList reports whether list is a comma-separated list of "?" and
+// placeholders.
+func isValueList(list string) bool {
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			return false
+		}
+		if item == "?" {
+			continue
+		}
+		if !isPlaceholderPrefix(item[0]) && item[0] != '$' || len(item) == 1 {
+			return false
+		}
+		for j := 1; j < len(item); j++ {
+			if !isIdentifier(item[j]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// truncateStatement truncates statement to maxStatementLength bytes, marking
+// it with "...".
+func truncateStatement(statement string) string {
+	if len(statement) <= maxStatementLength {
+		return statement
+	}
+	n := maxStatementLength
+	for n > 0 && !utf8.RuneStart(statement[n]) {
+		n--
+	}
+	return statement[:n] + "..."
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
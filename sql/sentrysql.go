@@ -0,0 +1,240 @@
+// Package sentrysql provides Sentry integration for database/sql. It wraps
+// database drivers to record queries as spans of the transaction in the
+// query context and to report slow queries to Sentry.
+package sentrysql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// The origin of the spans recorded by the integration.
+const spanOrigin sentry.SpanOrigin = "auto.db.sql"
+
+// Options configure the instrumentation of a driver.
+type Options struct {
+	// DatabaseSystem identifies the database management system, e.g.
+	// "postgresql" or "mysql". See
+	// https://opentelemetry.io/docs/specs/semconv/attributes-registry/db/
+	// for well-known values.
+	DatabaseSystem string
+	// DatabaseName is the name of the database queries run against.
+	DatabaseName string
+	// ServerAddress is the host name of the database server.
+	ServerAddress string
+	// SlowQueryThreshold, if positive, is the duration above which a query
+	// is reported to Sentry as a slow query event. Slow queries are detected
+	// whether or not the transaction in the query context is sampled, or
+	// even if there is none.
+	SlowQueryThreshold time.Duration
+	// Breadcrumbs records a breadcrumb for each query, whether or not there
+	// is a transaction in the query context. The breadcrumbs carry the
+	// sanitized statement, its duration and the number of rows it affected
+	// or, once they are closed, returned.
+	Breadcrumbs bool
+}
+
+// Open opens a database like sql.Open, with the driver registered as
+// driverName wrapped to instrument its queries.
+func Open(driverName, dataSourceName string, options Options) (*sql.DB, error) {
+	// sql.Open only validates its arguments, it neither connects to the
+	// database nor exposes the registered drivers otherwise.
+	db, err := sql.Open(driverName, dataSourceName)
+	if err != nil {
+		return nil, err
+	}
+	d := db.Driver()
+	_ = db.Close()
+
+	if dc, ok := d.(driver.DriverContext); ok {
+		connector, err := dc.OpenConnector(dataSourceName)
+		if err != nil {
+			return nil, err
+		}
+		return sql.OpenDB(WrapConnector(connector, options)), nil
+	}
+	return sql.OpenDB(dsnConnector{
+		dsn:    dataSourceName,
+		driver: WrapDriver(d, options),
+	}), nil
+}
+
+// WrapDriver returns a driver that instruments the connections opened by d.
+// Register it with sql.Register to open instrumented databases by name.
+func WrapDriver(d driver.Driver, options Options) driver.Driver {
+	return &sentryDriver{Driver: d, options: options}
+}
+
+// WrapConnector returns a connector that instruments the connections opened
+// by c. Pass it to sql.OpenDB.
+func WrapConnector(c driver.Connector, options Options) driver.Connector {
+	return &sentryConnector{Connector: c, options: options}
+}
+
+type sentryDriver struct {
+	driver.Driver
+	options Options
+}
+
+func (d *sentryDriver) Open(name string) (driver.Conn, error) {
+	c, err := d.Driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &sentryConn{Conn: c, options: &d.options}, nil
+}
+
+type sentryConnector struct {
+	driver.Connector
+	options Options
+}
+
+func (c *sentryConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &sentryConn{Conn: conn, options: &c.options}, nil
+}
+
+func (c *sentryConnector) Driver() driver.Driver {
+	return &sentryDriver{Driver: c.Connector.Driver(), options: c.options}
+}
+
+// dsnConnector is the connector of drivers that don't implement
+// driver.DriverContext, like the one database/sql uses internally.
+type dsnConnector struct {
+	dsn    string
+	driver driver.Driver
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// Span operations, by the method that ran the query.
+const (
+	opQuery = "db.sql.query"
+	opExec  = "db.sql.exec"
+)
+
+// observe records a query that started at start and failed with err, if
+// non-nil: as a span of the span in ctx, if any, and as a slow query event if
+// it exceeded the threshold. Both carry the query sanitized with
+// SanitizeQuery. It returns the duration of the query.
+func (o *Options) observe(ctx context.Context, op, query string, start time.Time, err error) time.Duration {
+	duration := time.Since(start)
+	statement := SanitizeQuery(query)
+
+	if sentry.SpanFromContext(ctx) != nil {
+		span := sentry.StartSpan(ctx, op,
+			sentry.WithDescription(statement),
+			sentry.WithSpanOrigin(spanOrigin),
+		)
+		span.StartTime = start
+		if o.DatabaseSystem != "" {
+			span.SetData("db.system", o.DatabaseSystem)
+		}
+		if o.DatabaseName != "" {
+			span.SetData("db.name", o.DatabaseName)
+		}
+		if o.ServerAddress != "" {
+			span.SetData("server.address", o.ServerAddress)
+		}
+		if err != nil {
+			span.Status = sentry.SpanStatusInternalError
+		} else {
+			span.Status = sentry.SpanStatusOK
+		}
+		span.Finish()
+	}
+
+	if o.SlowQueryThreshold > 0 && duration > o.SlowQueryThreshold {
+		hubFromContext(ctx).CaptureEvent(o.slowQueryEvent(statement, duration))
+	}
+	return duration
+}
+
+// hubFromContext returns the hub of ctx, or else the current hub.
+func hubFromContext(ctx context.Context) *sentry.Hub {
+	if hub := sentry.GetHubFromContext(ctx); hub != nil {
+		return hub
+	}
+	return sentry.CurrentHub()
+}
+
+// addBreadcrumb records the breadcrumb of a query that took duration and
+// affected or returned rows rows, or a negative number if unknown.
+func (o *Options) addBreadcrumb(ctx context.Context, op, query string, duration time.Duration, rows int64, err error) {
+	if !o.Breadcrumbs {
+		return
+	}
+	data := map[string]interface{}{
+		"db.operation": op,
+		"duration_ms":  float64(duration) / float64(time.Millisecond),
+	}
+	if rows >= 0 {
+		data["rows"] = rows
+	}
+	if o.DatabaseSystem != "" {
+		data["db.system"] = o.DatabaseSystem
+	}
+	breadcrumb := &sentry.Breadcrumb{
+		Type:     "query",
+		Category: "query",
+		Message:  SanitizeQuery(query),
+		Data:     data,
+		Level:    sentry.LevelInfo,
+	}
+	if err != nil {
+		breadcrumb.Level = sentry.LevelError
+		data["error"] = err.Error()
+	}
+	hubFromContext(ctx).AddBreadcrumb(breadcrumb, nil)
+}
+
+// rowsAffected returns the number of rows affected by an Exec, or -1 if it is
+// unknown. Some drivers query the database for it, so it is only called for
+// breadcrumbs.
+func rowsAffected(result driver.Result, err error) int64 {
+	if err != nil || result == nil {
+		return -1
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// slowQueryEvent returns the event reported for a query that took duration.
+// The event is grouped by the sanitized statement.
+func (o *Options) slowQueryEvent(statement string, duration time.Duration) *sentry.Event {
+	event := sentry.NewEvent()
+	event.Level = sentry.LevelWarning
+	event.Message = fmt.Sprintf("Slow query: %s", statement)
+	event.Fingerprint = []string{"slow-query", statement}
+	db := sentry.Context{
+		"statement":    statement,
+		"duration_ms":  float64(duration) / float64(time.Millisecond),
+		"threshold_ms": float64(o.SlowQueryThreshold) / float64(time.Millisecond),
+	}
+	if o.DatabaseSystem != "" {
+		db["system"] = o.DatabaseSystem
+		event.Tags["db.system"] = o.DatabaseSystem
+	}
+	if o.DatabaseName != "" {
+		db["name"] = o.DatabaseName
+	}
+	event.Contexts["db"] = db
+	return event
+}
@@ -0,0 +1,307 @@
+package sentrysql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// fakeDriver is a database driver whose queries take delay and fail if they
+// contain "fail". Without direct, its connections only support prepared
+// statements.
+type fakeDriver struct {
+	delay  time.Duration
+	direct bool
+	// rowsAffected counts the calls of RowsAffected on the results.
+	rowsAffected int
+}
+
+func (d *fakeDriver) Open(string) (driver.Conn, error) {
+	if d.direct {
+		return &fakeDirectConn{fakeConn{d}}, nil
+	}
+	return &fakeConn{d}, nil
+}
+
+type fakeConn struct{ driver *fakeDriver }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{c.driver, query}, nil
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return nil, driver.ErrSkip }
+
+type fakeDirectConn struct{ fakeConn }
+
+func (c *fakeDirectConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	return fakeResult{c.driver}, c.driver.run(query)
+}
+
+func (c *fakeDirectConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	if strings.Contains(query, "rows") {
+		return &rowsLeft{n: 2}, c.driver.run(query)
+	}
+	return fakeRows{}, c.driver.run(query)
+}
+
+type fakeStmt struct {
+	driver *fakeDriver
+	query  string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+func (s *fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	return fakeResult{s.driver}, s.driver.run(s.query)
+}
+func (s *fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	return fakeRows{}, s.driver.run(s.query)
+}
+
+func (d *fakeDriver) run(query string) error {
+	time.Sleep(d.delay)
+	if strings.Contains(query, "fail") {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+type fakeResult struct{ driver *fakeDriver }
+
+func (fakeResult) LastInsertId() (int64, error) { return 0, driver.ErrSkip }
+func (r fakeResult) RowsAffected() (int64, error) {
+	r.driver.rowsAffected++
+	return 1, nil
+}
+
+type fakeRows struct{}
+
+func (fakeRows) Columns() []string         { return nil }
+func (fakeRows) Close() error              { return nil }
+func (fakeRows) Next([]driver.Value) error { return io.EOF }
+
+// rowsLeft returns n rows with a single column.
+type rowsLeft struct{ n int }
+
+func (r *rowsLeft) Columns() []string { return []string{"id"} }
+func (r *rowsLeft) Close() error      { return nil }
+func (r *rowsLeft) Next(dest []driver.Value) error {
+	if r.n == 0 {
+		return io.EOF
+	}
+	r.n--
+	dest[0] = int64(r.n)
+	return nil
+}
+
+func setupTest(t *testing.T, d *fakeDriver, options Options) (*sql.DB, context.Context, *[]*sentry.Event, *[]*sentry.Event) {
+	t.Helper()
+	var events, transactions []*sentry.Event
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		BeforeSend: func(event *sentry.Event, _ *sentry.EventHint) *sentry.Event {
+			events = append(events, event)
+			return nil
+		},
+		BeforeSendTransaction: func(event *sentry.Event, _ *sentry.EventHint) *sentry.Event {
+			transactions = append(transactions, event)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := sentry.SetHubOnContext(context.Background(), sentry.NewHub(client, sentry.NewScope()))
+	db := sql.OpenDB(dsnConnector{driver: WrapDriver(d, options)})
+	t.Cleanup(func() { db.Close() })
+	return db, ctx, &events, &transactions
+}
+
+func TestSpans(t *testing.T) {
+	for _, direct := range []bool{true, false} {
+		name := "prepared"
+		if direct {
+			name = "direct"
+		}
+		t.Run(name, func(t *testing.T) {
+			db, ctx, events, transactions := setupTest(t, &fakeDriver{direct: direct}, Options{DatabaseSystem: "postgresql"})
+
+			transaction := sentry.StartTransaction(ctx, "test")
+			ctx = transaction.Context()
+			if _, err := db.ExecContext(ctx, "UPDATE users SET name = $1 WHERE id IN (1, 2)", "x"); err != nil {
+				t.Fatal(err)
+			}
+			rows, err := db.QueryContext(ctx, "SELECT fail FROM users")
+			if err == nil {
+				rows.Close()
+				t.Fatal("expected an error")
+			}
+			transaction.Finish()
+
+			if len(*events) != 0 {
+				t.Errorf("got %d events, want none", len(*events))
+			}
+			if len(*transactions) != 1 {
+				t.Fatalf("got %d transactions, want 1", len(*transactions))
+			}
+			spans := (*transactions)[0].Spans
+			if len(spans) != 2 {
+				t.Fatalf("got %d spans, want 2", len(spans))
+			}
+			for i, want := range []struct {
+				op, description string
+				status          sentry.SpanStatus
+			}{
+				{opExec, "UPDATE users SET name = $1 WHERE id IN (?)", sentry.SpanStatusOK},
+				{opQuery, "SELECT fail FROM users", sentry.SpanStatusInternalError},
+			} {
+				span := spans[i]
+				if span.Op != want.op || span.Description != want.description || span.Status != want.status {
+					t.Errorf("span %d = %s %q %v, want %s %q %v", i, span.Op, span.Description, span.Status, want.op, want.description, want.status)
+				}
+				if span.Data["db.system"] != "postgresql" || span.Origin != spanOrigin {
+					t.Errorf("span %d: data = %v, origin = %s", i, span.Data, span.Origin)
+				}
+			}
+		})
+	}
+}
+
+func TestSlowQuery(t *testing.T) {
+	db, ctx, events, transactions := setupTest(t, &fakeDriver{direct: true, delay: 5 * time.Millisecond}, Options{
+		DatabaseSystem:     "mysql",
+		SlowQueryThreshold: time.Millisecond,
+	})
+
+	// There is no transaction in ctx, slow queries are reported anyway.
+	if _, err := db.ExecContext(ctx, "DELETE FROM sessions WHERE token = 'secret' AND age > 30"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(*transactions) != 0 {
+		t.Errorf("got %d transactions, want none", len(*transactions))
+	}
+	if len(*events) != 1 {
+		t.Fatalf("got %d events, want 1", len(*events))
+	}
+	event := (*events)[0]
+	statement := "DELETE FROM sessions WHERE token = ? AND age > ?"
+	if event.Level != sentry.LevelWarning || event.Message != "Slow query: "+statement {
+		t.Errorf("event = %s %q", event.Level, event.Message)
+	}
+	dbContext := event.Contexts["db"]
+	if dbContext["statement"] != statement || dbContext["system"] != "mysql" {
+		t.Errorf("db context = %v", dbContext)
+	}
+	if d, _ := dbContext["duration_ms"].(float64); d < 5 {
+		t.Errorf("duration_ms = %v, want at least 5", dbContext["duration_ms"])
+	}
+}
+
+func TestBeginTxOptions(t *testing.T) {
+	db, ctx, _, _ := setupTest(t, &fakeDriver{}, Options{})
+
+	// The fake connection has no BeginTx, the options cannot be honoured.
+	for _, opts := range []*sql.TxOptions{
+		{Isolation: sql.LevelSerializable},
+		{ReadOnly: true},
+	} {
+		if _, err := db.BeginTx(ctx, opts); err == nil || !strings.Contains(err.Error(), "does not support") {
+			t.Errorf("BeginTx(%+v) = %v", opts, err)
+		}
+	}
+}
+
+func TestSanitizeQuery(t *testing.T) {
+	tests := map[string]string{
+		"SELECT * FROM t1 WHERE id = $1":                     "SELECT * FROM t1 WHERE id = $1",
+		"SELECT * FROM t WHERE a = ?1 AND b = :2 AND c = @3": "SELECT * FROM t WHERE a = ?1 AND b = :2 AND c = @3",
+		"SELECT * FROM t WHERE name = 'O''Brien'":            "SELECT * FROM t WHERE name = ?",
+		"SELECT * FROM t WHERE price > 10.5 LIMIT 10":        "SELECT * FROM t WHERE price > ? LIMIT ?",
+		"INSERT INTO t (a, b) VALUES (1, 'x')":               "INSERT INTO t (a, b) VALUES (?, ?)",
+		"SELECT 'unterminated":                               "SELECT ?",
+		"SELECT * FROM t WHERE id IN (1, 2, 3)":              "SELECT * FROM t WHERE id IN (?)",
+		"SELECT * FROM t WHERE id in($1,$2) AND x IN (?)":    "SELECT * FROM t WHERE id in(?) AND x IN (?)",
+		"SELECT * FROM t WHERE id IN (:a, @b, ?3)":           "SELECT * FROM t WHERE id IN (?)",
+		"SELECT * FROM t WHERE id IN (SELECT id FROM u)":     "SELECT * FROM t WHERE id IN (SELECT id FROM u)",
+		"SELECT * FROM t WHERE id IN ()":                     "SELECT * FROM t WHERE id IN ()",
+		"SELECT pin (1, 2) FROM t":                           "SELECT pin (?, ?) FROM t",
+		"SELECT * FROM t WHERE id IN (1, 2":                  "SELECT * FROM t WHERE id IN (?, ?",
+	}
+	for query, want := range tests {
+		if got := SanitizeQuery(query); got != want {
+			t.Errorf("SanitizeQuery(%q) = %q, want %q", query, got, want)
+		}
+	}
+
+	long := "SELECT " + strings.Repeat("é", maxStatementLength)
+	got := SanitizeQuery(long)
+	if len(got) > maxStatementLength+len("...") || !strings.HasSuffix(got, "é...") {
+		t.Errorf("SanitizeQuery(long) = %d bytes ending in %q", len(got), got[len(got)-8:])
+	}
+}
+
+func TestBreadcrumbs(t *testing.T) {
+	db, ctx, events, _ := setupTest(t, &fakeDriver{direct: true}, Options{DatabaseSystem: "postgresql", Breadcrumbs: true})
+
+	// There is no transaction in ctx, breadcrumbs are recorded anyway.
+	if _, err := db.ExecContext(ctx, "UPDATE users SET name = 'x'"); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := db.QueryContext(ctx, "SELECT id FROM rows WHERE id IN (1, 2)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.Next() {
+	}
+	rows.Close()
+	if _, err := db.QueryContext(ctx, "SELECT fail"); err == nil {
+		t.Fatal("expected an error")
+	}
+	sentry.GetHubFromContext(ctx).CaptureMessage("test")
+
+	if len(*events) != 1 {
+		t.Fatalf("got %d events, want 1", len(*events))
+	}
+	breadcrumbs := (*events)[0].Breadcrumbs
+	if len(breadcrumbs) != 3 {
+		t.Fatalf("got %d breadcrumbs, want 3", len(breadcrumbs))
+	}
+	for i, want := range []struct {
+		message string
+		rows    interface{}
+		level   sentry.Level
+	}{
+		{"UPDATE users SET name = ?", int64(1), sentry.LevelInfo},
+		{"SELECT id FROM rows WHERE id IN (?)", int64(2), sentry.LevelInfo},
+		{"SELECT fail", nil, sentry.LevelError},
+	} {
+		b := breadcrumbs[i]
+		if b.Category != "query" || b.Message != want.message || b.Data["rows"] != want.rows || b.Level != want.level {
+			t.Errorf("breadcrumb %d = %s %q %v %s", i, b.Category, b.Message, b.Data, b.Level)
+		}
+		if _, ok := b.Data["duration_ms"].(float64); !ok || b.Data["db.system"] != "postgresql" {
+			t.Errorf("breadcrumb %d: data = %v", i, b.Data)
+		}
+	}
+}
+
+func TestRowsAffectedWithoutBreadcrumbs(t *testing.T) {
+	for _, direct := range []bool{true, false} {
+		d := &fakeDriver{direct: direct}
+		db, ctx, _, _ := setupTest(t, d, Options{})
+		if _, err := db.ExecContext(ctx, "UPDATE users SET name = 'x'"); err != nil {
+			t.Fatal(err)
+		}
+		if d.rowsAffected != 0 {
+			t.Errorf("direct=%t: RowsAffected called %d times without breadcrumbs", direct, d.rowsAffected)
+		}
+	}
+}
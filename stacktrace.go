@@ -5,6 +5,7 @@ import (
 	"reflect"
 	"runtime"
 	"strings"
+	"sync"
 )
 
 const unknown string = "unknown"
@@ -31,13 +32,8 @@ func NewStacktrace(opts ...EventOptions) *Stacktrace {
 		return nil
 	}
 
-	skipFrames := 0
-	if len(opts) > 0 {
-		skipFrames = opts[0].SkipFrames
-	}
-
 	runtimeFrames := extractFrames(pcs[:n])
-	frames := createFrames(runtimeFrames, skipFrames)
+	frames := createFrames(runtimeFrames, skipFrames(opts))
 
 	stacktrace := Stacktrace{
 		Frames: frames,
@@ -46,22 +42,62 @@ func NewStacktrace(opts ...EventOptions) *Stacktrace {
 	return &stacktrace
 }
 
+func skipFrames(opts []EventOptions) int {
+	if len(opts) > 0 {
+		return opts[0].SkipFrames
+	}
+	return 0
+}
+
+// callers returns the program counters of the stack of its caller, for
+// building its frames later with pendingStacktrace.
+func callers() []uintptr {
+	pcs := make([]uintptr, 100)
+	n := runtime.Callers(2, pcs)
+	return pcs[:n]
+}
+
+// pendingStacktrace is a Stacktrace of an event whose frames have not been
+// built yet. Symbolizing program counters is the most expensive part of
+// capturing an error, and it is wasted on events that are sampled out, so the
+// client only builds the frames once an event is going to be processed.
+type pendingStacktrace struct {
+	stacktrace *Stacktrace
+	pcs        []uintptr
+	skip       int
+}
+
+func (p pendingStacktrace) resolve() {
+	p.stacktrace.Frames = createFrames(extractFrames(p.pcs), p.skip)
+}
+
+// panicStacktrace creates a stacktrace of the site of the current panic, that
+// is, the frames leading to the call to panic, excluding the deferred functions
+// that are running while panicking. It returns nil if the calling goroutine is
+// not panicking.
+func panicStacktrace() *Stacktrace {
+	pcs := make([]uintptr, 100)
+	n := runtime.Callers(1, pcs)
+
+	runtimeFrames := extractFrames(pcs[:n])
+	for i := len(runtimeFrames) - 1; i >= 0; i-- {
+		if runtimeFrames[i].Function == "runtime.gopanic" {
+			return &Stacktrace{
+				Frames: createFrames(runtimeFrames[:i], 0),
+			}
+		}
+	}
+
+	return nil
+}
+
 // TODO: Make it configurable so that anyone can provide their own implementation?
 // Use of reflection allows us to not have a hard dependency on any given
 // package, so we don't have to import it.
 
 // ExtractStacktrace creates a new Stacktrace based on the given error.
 func ExtractStacktrace(err error) *Stacktrace {
-	method := extractReflectedStacktraceMethod(err)
-
-	var pcs []uintptr
-
-	if method.IsValid() {
-		pcs = extractPcs(method)
-	} else {
-		pcs = extractXErrorsPC(err)
-	}
-
+	pcs := extractStacktracePCs(err)
 	if len(pcs) == 0 {
 		return nil
 	}
@@ -76,6 +112,16 @@ func ExtractStacktrace(err error) *Stacktrace {
 	return &stacktrace
 }
 
+// extractStacktracePCs returns the program counters of the stack trace
+// recorded by err, if any.
+func extractStacktracePCs(err error) []uintptr {
+	method := extractReflectedStacktraceMethod(err)
+	if method.IsValid() {
+		return extractPcs(method)
+	}
+	return extractXErrorsPC(err)
+}
+
 func extractReflectedStacktraceMethod(err error) reflect.Value {
 	errValue := reflect.ValueOf(err)
 
@@ -296,14 +342,8 @@ func createFrames(frames []runtime.Frame, skip int) []Frame {
 	var result []Frame
 
 	for _, frame := range frames {
-		function := frame.Function
-		var pkg string
-		if function != "" {
-			pkg, function = splitQualifiedFunctionName(function)
-		}
-
-		if !shouldSkipFrame(pkg) {
-			result = append(result, newFrame(pkg, function, frame.File, frame.Line))
+		if f, skipped := frameFromRuntimeFrame(frame); !skipped {
+			result = append(result, f)
 		}
 	}
 
@@ -314,6 +354,68 @@ func createFrames(frames []runtime.Frame, skip int) []Frame {
 	return result[:len(result)-skip]
 }
 
+// maxCachedFrames bounds the number of entries in frameCache. Program
+// counters come from a finite amount of code, but the bound protects against
+// pathological programs, for example those generating code at run time.
+const maxCachedFrames = 10000
+
+// frameCacheKey identifies a frame. Frames inlined into the same call site
+// share their PC, so the function name is part of the key.
+type frameCacheKey struct {
+	pc       uintptr
+	function string
+}
+
+type cachedFrame struct {
+	frame   Frame
+	skipped bool
+}
+
+// frameCache memoizes the frames built by frameFromRuntimeFrame, so capturing
+// the same error repeatedly doesn't split function names and classify frames
+// again.
+var frameCache = struct {
+	sync.RWMutex
+	frames map[frameCacheKey]cachedFrame
+}{frames: make(map[frameCacheKey]cachedFrame)}
+
+// frameFromRuntimeFrame converts frame to a Frame and reports whether it
+// should be skipped because it is internal to the SDK or Go.
+func frameFromRuntimeFrame(frame runtime.Frame) (Frame, bool) {
+	// Frames without a PC are not produced by the runtime and aren't cached.
+	if frame.PC == 0 {
+		return buildFrame(frame)
+	}
+
+	key := frameCacheKey{pc: frame.PC, function: frame.Function}
+	frameCache.RLock()
+	cached, ok := frameCache.frames[key]
+	frameCache.RUnlock()
+	if ok {
+		return cached.frame, cached.skipped
+	}
+
+	f, skipped := buildFrame(frame)
+	frameCache.Lock()
+	if len(frameCache.frames) < maxCachedFrames {
+		frameCache.frames[key] = cachedFrame{frame: f, skipped: skipped}
+	}
+	frameCache.Unlock()
+	return f, skipped
+}
+
+func buildFrame(frame runtime.Frame) (Frame, bool) {
+	function := frame.Function
+	var pkg string
+	if function != "" {
+		pkg, function = splitQualifiedFunctionName(function)
+	}
+	if shouldSkipFrame(pkg) {
+		return Frame{}, true
+	}
+	return newFrame(pkg, function, frame.File, frame.Line), false
+}
+
 // TODO ID: why do we want to do this?
 // I'm not aware of other SDKs skipping all Sentry frames, regardless of their position in the stactrace.
 // For example, in the .NET SDK, only the first frames are skipped until the call to the SDK.
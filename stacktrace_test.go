@@ -147,6 +147,46 @@ func TestCreateFrames(t *testing.T) {
 	}
 }
 
+func TestFrameCache(t *testing.T) {
+	frame := runtime.Frame{
+		PC:       1,
+		Function: "example.com/pkg.Handler",
+		File:     "/somewhere/example.com/pkg/handler.go",
+		Line:     42,
+	}
+	want := []Frame{{
+		Function: "Handler",
+		Module:   "example.com/pkg",
+		AbsPath:  "/somewhere/example.com/pkg/handler.go",
+		Lineno:   42,
+		InApp:    true,
+	}}
+
+	frames := createFrames([]runtime.Frame{frame}, 0)
+	if diff := cmp.Diff(want, frames); diff != "" {
+		t.Fatalf("createFrames() mismatch (-want +got):\n%s", diff)
+	}
+	frameCache.RLock()
+	_, cached := frameCache.frames[frameCacheKey{pc: frame.PC, function: frame.Function}]
+	frameCache.RUnlock()
+	if !cached {
+		t.Fatal("frame was not cached")
+	}
+
+	// Changes to returned frames must not leak into the cache.
+	frames[0].PreContext = []string{"modified"}
+	if diff := cmp.Diff(want, createFrames([]runtime.Frame{frame}, 0)); diff != "" {
+		t.Errorf("cached createFrames() mismatch (-want +got):\n%s", diff)
+	}
+
+	// A different function inlined at the same PC gets its own entry.
+	inlined := frame
+	inlined.Function = "example.com/pkg.helper"
+	if got := createFrames([]runtime.Frame{inlined}, 0); got[0].Function != "helper" {
+		t.Errorf("Function of inlined frame = %q, want %q", got[0].Function, "helper")
+	}
+}
+
 func TestExtractXErrorsPC(t *testing.T) {
 	// This ensures that extractXErrorsPC does not break code that doesn't use
 	// golang.org/x/xerrors. For tests that check that it works on the
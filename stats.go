@@ -0,0 +1,196 @@
+package sentry
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go/internal/ratelimit"
+)
+
+// ClientStats is a snapshot of the statistics of a client, see Client.Stats.
+type ClientStats struct {
+	// Captured counts the events passed to the client for capturing,
+	// including the ones dropped later.
+	Captured int64
+	// QueueDepth is the number of events waiting to be sent by the
+	// transport, QueueCapacity the maximum number.
+	QueueDepth    int
+	QueueCapacity int
+	// Sent counts the events sent to Sentry by rate limit category, for
+	// example "error" or "transaction".
+	Sent map[string]int64
+	// Dropped counts the events that were discarded, by reason and rate limit
+	// category.
+	Dropped map[DropReason]map[string]int64
+	// LastTransportError is the last error that occurred sending an event,
+	// including responses with an error status code, or nil if there was
+	// none. LastTransportErrorTime is the time it occurred.
+	LastTransportError     error
+	LastTransportErrorTime time.Time
+	// RateLimits maps the rate limit categories that are currently limited to
+	// the time the limit expires. The empty category limits all events.
+	RateLimits map[string]time.Time
+	// Flushes counts the calls to Flush, FlushDuration is the total time
+	// spent in them.
+	Flushes       int64
+	FlushDuration time.Duration
+	// LoadSheddingLevel is the current level of ClientOptions.LoadShedding.
+	LoadSheddingLevel LoadSheddingLevel
+}
+
+// Stats returns the current statistics of the client, for use in health
+// checks and dashboards. The queue depth, sent events, transport errors and
+// rate limits are only available with the transports of the SDK.
+func (client *Client) Stats() ClientStats {
+	stats := ClientStats{
+		Sent:       map[string]int64{},
+		RateLimits: map[string]time.Time{},
+	}
+	client.counters.fill(&stats)
+	client.checkLoad()
+	stats.LoadSheddingLevel = client.loadShedding()
+	if t, ok := client.Transport.(statsTransport); ok {
+		t.counters().fill(&stats)
+		stats.QueueCapacity = t.capacity()
+		now := time.Now()
+		for c, deadline := range t.rateLimits() {
+			if time.Time(deadline).After(now) {
+				stats.RateLimits[string(c)] = time.Time(deadline)
+			}
+		}
+	}
+	return stats
+}
+
+// statsTransport is implemented by the transports of the SDK to provide the
+// data of Client.Stats.
+type statsTransport interface {
+	counters() *transportCounters
+	capacity() int
+	rateLimits() ratelimit.Map
+}
+
+// clientCounters collects the statistics of a client for Client.Stats.
+type clientCounters struct {
+	mu            sync.Mutex
+	captured      int64
+	dropped       map[DropReason]map[string]int64
+	flushes       int64
+	flushDuration time.Duration
+}
+
+func (c *clientCounters) recordCaptured() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.captured++
+}
+
+func (c *clientCounters) recordFlush(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.flushes++
+	c.flushDuration += d
+}
+
+// wrapOnEventDropped returns an OnEventDropped callback that counts dropped
+// events before calling onEventDropped.
+func (c *clientCounters) wrapOnEventDropped(onEventDropped func(*Event, DropReason, string)) func(*Event, DropReason, string) {
+	return func(event *Event, reason DropReason, category string) {
+		c.mu.Lock()
+		if c.dropped == nil {
+			c.dropped = make(map[DropReason]map[string]int64)
+		}
+		if c.dropped[reason] == nil {
+			c.dropped[reason] = make(map[string]int64)
+		}
+		c.dropped[reason][category]++
+		c.mu.Unlock()
+
+		if onEventDropped != nil {
+			onEventDropped(event, reason, category)
+		}
+	}
+}
+
+func (c *clientCounters) fill(stats *ClientStats) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats.Captured = c.captured
+	stats.Dropped = make(map[DropReason]map[string]int64, len(c.dropped))
+	for reason, byCategory := range c.dropped {
+		stats.Dropped[reason] = make(map[string]int64, len(byCategory))
+		for category, n := range byCategory {
+			stats.Dropped[reason][category] = n
+		}
+	}
+	stats.Flushes = c.flushes
+	stats.FlushDuration = c.flushDuration
+}
+
+// transportCounters collects the statistics of a transport for Client.Stats.
+type transportCounters struct {
+	mu sync.Mutex
+	// queued is the number of events in the queue.
+	queued      int
+	sent        map[ratelimit.Category]int64
+	lastErr     error
+	lastErrTime time.Time
+}
+
+func (c *transportCounters) enqueued() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.queued++
+}
+
+func (c *transportCounters) dequeued() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.queued--
+}
+
+func (c *transportCounters) recordSent(category ratelimit.Category) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.sent == nil {
+		c.sent = make(map[ratelimit.Category]int64)
+	}
+	c.sent[category]++
+}
+
+func (c *transportCounters) recordError(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.lastErr = err
+	c.lastErrTime = time.Now()
+}
+
+// recordResponse records the outcome of sending an event of category that
+// got a response with statusCode.
+func (c *transportCounters) recordResponse(category ratelimit.Category, statusCode int) {
+	if statusCode >= 400 {
+		c.recordError(fmt.Errorf("sentry responded with status %d", statusCode))
+		return
+	}
+	c.recordSent(category)
+}
+
+func (c *transportCounters) fill(stats *ClientStats) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats.QueueDepth = c.queued
+	for category, n := range c.sent {
+		stats.Sent[string(category)] = n
+	}
+	stats.LastTransportError = c.lastErr
+	stats.LastTransportErrorTime = c.lastErrTime
+}
@@ -0,0 +1,92 @@
+package sentry
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClientStats(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			return
+		}
+		w.Header().Set("X-Sentry-Rate-Limits", "60:error:key")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(ClientOptions{
+		Dsn:       "http://public@" + srv.Listener.Addr().String() + "/1",
+		Transport: NewHTTPSyncTransport(),
+		BeforeSend: func(event *Event, hint *EventHint) *Event {
+			if event.Message == "filtered" {
+				return nil
+			}
+			return event
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	scope := NewScope()
+
+	client.CaptureMessage("sent", nil, scope)
+	client.CaptureMessage("rejected", nil, scope)
+	client.CaptureMessage("limited", nil, scope)
+	client.CaptureMessage("filtered", nil, scope)
+
+	stats := client.Stats()
+	assertEqual(t, stats.QueueDepth, 0)
+	assertEqual(t, stats.Sent, map[string]int64{"error": 1})
+	assertEqual(t, stats.Dropped, map[DropReason]map[string]int64{
+		DropReasonRateLimit:  {"error": 2},
+		DropReasonBeforeSend: {"error": 1},
+	})
+	if stats.LastTransportError == nil || stats.LastTransportErrorTime.IsZero() {
+		t.Errorf("LastTransportError = %v at %v, want the 429 response", stats.LastTransportError, stats.LastTransportErrorTime)
+	}
+	if until, ok := stats.RateLimits["error"]; !ok || until.Before(time.Now().Add(50*time.Second)) {
+		t.Errorf("RateLimits = %v, want error limited for a minute", stats.RateLimits)
+	}
+}
+
+func TestClientStatsQueueDepth(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	client, err := NewClient(ClientOptions{Dsn: "http://public@" + srv.Listener.Addr().String() + "/1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		client.CaptureMessage("queued", nil, NewScope())
+	}
+
+	// The worker dequeues the first event while it waits for the server.
+	deadline := time.Now().Add(time.Second)
+	for client.Stats().QueueDepth != 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	assertEqual(t, client.Stats().QueueDepth, 2)
+}
+
+func TestClientStatsWithCustomTransport(t *testing.T) {
+	client, _ := NewClient(ClientOptions{Transport: &TransportMock{}, SampleRate: 0.000000000000001})
+	client.CaptureMessage("sampled", nil, NewScope())
+
+	client.Flush(time.Second)
+
+	stats := client.Stats()
+	assertEqual(t, stats.Captured, int64(1))
+	assertEqual(t, stats.Sent, map[string]int64{})
+	assertEqual(t, stats.Dropped, map[DropReason]map[string]int64{DropReasonSampleRate: {"error": 1}})
+	assertEqual(t, stats.Flushes, int64(1))
+}
@@ -0,0 +1,39 @@
+package sentry
+
+import (
+	"context"
+	"io"
+)
+
+// TemplateRender is the operation of template rendering spans.
+const TemplateRender = "ui.render"
+
+// A Template is a template that can be rendered with RenderTemplate. The
+// *Template types of html/template and text/template implement it.
+type Template interface {
+	Name() string
+	Execute(w io.Writer, data interface{}) error
+}
+
+// RenderTemplate executes t with data, writing the output to w, and records the
+// execution as a ui.render span of the span stored in ctx, if any. The span
+// description is the template name. To render a named template of a set, pass the result
+// of its Lookup method:
+//
+//	err := sentry.RenderTemplate(r.Context(), w, templates.Lookup("index.html"), page)
+func RenderTemplate(ctx context.Context, w io.Writer, t Template, data interface{}) error {
+	if SpanFromContext(ctx) == nil {
+		return t.Execute(w, data)
+	}
+	span := StartSpan(ctx, TemplateRender, WithDescription(t.Name()))
+	defer span.Finish()
+	span.SetData("template.name", t.Name())
+
+	err := t.Execute(w, data)
+	if err != nil {
+		span.Status = SpanStatusInternalError
+	} else {
+		span.Status = SpanStatusOK
+	}
+	return err
+}
@@ -0,0 +1,43 @@
+package sentry
+
+import (
+	"html/template"
+	"strings"
+	"testing"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	ctx := NewTestContext(ClientOptions{EnableTracing: true, TracesSampleRate: 1.0})
+	transaction := StartTransaction(ctx, "GET /")
+
+	templates := template.Must(template.New("page").Parse(`{{define "index.html"}}<p>{{.}}</p>{{end}}{{define "broken"}}{{.Missing}}{{end}}`))
+	var out strings.Builder
+	if err := RenderTemplate(transaction.Context(), &out, templates.Lookup("index.html"), "<hi>"); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, out.String(), "<p>&lt;hi&gt;</p>")
+	if err := RenderTemplate(transaction.Context(), &out, templates.Lookup("broken"), 1); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	spans := transaction.spanRecorder().children()
+	assertEqual(t, len(spans), 2)
+	assertEqual(t, spans[0].Op, "ui.render")
+	assertEqual(t, spans[0].Description, "index.html")
+	assertEqual(t, spans[0].Data["template.name"], "index.html")
+	assertEqual(t, spans[0].Status, SpanStatusOK)
+	assertEqual(t, spans[0].ParentSpanID, transaction.SpanID)
+	assertEqual(t, spans[1].Status, SpanStatusInternalError)
+}
+
+func TestRenderTemplateWithoutTransaction(t *testing.T) {
+	transport := &TransportMock{}
+	ctx := NewTestContext(ClientOptions{EnableTracing: true, TracesSampleRate: 1.0, Transport: transport})
+
+	var out strings.Builder
+	if err := RenderTemplate(ctx, &out, template.Must(template.New("page").Parse("{{.}}")), "hi"); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, out.String(), "hi")
+	assertEqual(t, len(transport.Events()), 0)
+}
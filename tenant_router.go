@@ -0,0 +1,92 @@
+package sentry
+
+import (
+	"sync"
+	"time"
+)
+
+// TenantRouter routes events to per-tenant Sentry projects by the value of a
+// scope tag, allowing one shared binary to report the events of each customer
+// to their own project. Bind it to a hub with
+//
+//	hub.BindClients(client, router.Route)
+//
+// Events without the tag, or of tenants without a DSN, are kept with the
+// client of the hub. The clients of the tenants are created on first use and
+// not known to the hub, flush them with TenantRouter.Flush.
+type TenantRouter struct {
+	// Tag is the name of the scope tag identifying the tenant, for example
+	// "tenant_id".
+	Tag string
+	// DSN returns the DSN of the project of tenant, or "" to keep its events
+	// with the client of the hub.
+	DSN func(tenant string) string
+	// Options are the options of the clients of the tenants. Dsn is replaced
+	// with the DSN of the tenant, and Transport is replaced with the result
+	// of NewTransport, as a transport can only be configured for one DSN.
+	Options ClientOptions
+	// NewTransport returns the transport of a new client of a tenant. If nil,
+	// the clients use the default transport.
+	NewTransport func() Transport
+
+	mu sync.Mutex
+	// clients are the clients of the tenants by DSN, shared by tenants
+	// reporting to the same project.
+	clients map[string]*Client
+}
+
+// Route is an EventRouter returning the client of the tenant of event.
+func (r *TenantRouter) Route(event *Event, _ *EventHint) *Client {
+	tenant, ok := event.Tags[r.Tag]
+	if !ok || tenant == "" || r.DSN == nil {
+		return nil
+	}
+	dsn := r.DSN(tenant)
+	if dsn == "" {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if client, ok := r.clients[dsn]; ok {
+		return client
+	}
+	options := r.Options
+	options.Dsn = dsn
+	options.Transport = nil
+	if r.NewTransport != nil {
+		options.Transport = r.NewTransport()
+	}
+	client, err := NewClient(options)
+	if err != nil {
+		Logger.Printf("Cannot create client of tenant %q: %v", tenant, err)
+		client = nil
+	}
+	if r.clients == nil {
+		r.clients = make(map[string]*Client)
+	}
+	// A failed DSN is remembered as nil, so that it is only reported once.
+	r.clients[dsn] = client
+	return client
+}
+
+// Flush waits until the underlying transports of all clients of the tenants
+// have sent their events, or the timeout is reached. It returns false if the
+// timeout is reached for any of the clients.
+func (r *TenantRouter) Flush(timeout time.Duration) bool {
+	r.mu.Lock()
+	clients := make([]*Client, 0, len(r.clients))
+	for _, client := range r.clients {
+		if client != nil {
+			clients = append(clients, client)
+		}
+	}
+	r.mu.Unlock()
+
+	deadline := time.Now().Add(timeout)
+	ok := true
+	for _, client := range clients {
+		ok = client.Flush(time.Until(deadline)) && ok
+	}
+	return ok
+}
@@ -0,0 +1,55 @@
+package sentry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTenantRouter(t *testing.T) {
+	defaultTransport := &TransportMock{}
+	defaultClient, _ := NewClient(ClientOptions{Dsn: testDsn, Transport: defaultTransport})
+	var transports []*TransportMock
+	router := &TenantRouter{
+		Tag: "tenant_id",
+		DSN: func(tenant string) string {
+			switch tenant {
+			case "acme", "acme-eu":
+				return "http://acme@example.com/1"
+			case "globex":
+				return "http://globex@example.com/2"
+			case "broken":
+				return "not a dsn"
+			}
+			return ""
+		},
+		NewTransport: func() Transport {
+			transport := &TransportMock{}
+			transports = append(transports, transport)
+			return transport
+		},
+	}
+	hub := NewHub(nil, NewScope())
+	hub.BindClients(defaultClient, router.Route)
+
+	for _, tenant := range []string{"acme", "globex", "acme-eu", "unknown", "broken", ""} {
+		hub.WithScope(func(scope *Scope) {
+			scope.SetTag("tenant_id", tenant)
+			hub.CaptureMessage(tenant)
+		})
+	}
+	hub.CaptureMessage("untagged")
+
+	messages := func(transport *TransportMock) []string {
+		var m []string
+		for _, event := range transport.Events() {
+			m = append(m, event.Message)
+		}
+		return m
+	}
+	// The transport of "broken" is created before its DSN is parsed.
+	assertEqual(t, len(transports), 3)
+	assertEqual(t, messages(transports[0]), []string{"acme", "acme-eu"})
+	assertEqual(t, messages(transports[1]), []string{"globex"})
+	assertEqual(t, messages(defaultTransport), []string{"unknown", "broken", "", "untagged"})
+	assertEqual(t, router.Flush(time.Second), true)
+}
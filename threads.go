@@ -0,0 +1,88 @@
+package sentry
+
+import (
+	"runtime"
+	"strconv"
+
+	"github.com/getsentry/sentry-go/internal/traceparser"
+)
+
+// goroutineThreads returns the threads of event with the stacks of up to max
+// goroutines, the first of which is the calling goroutine. A stack trace
+// already attached to the calling goroutine, such as the one of a panic, is
+// kept as it is more precise than the one of the goroutine dump.
+func goroutineThreads(event *Event, max int) []Thread {
+	traces := traceparser.Parse(goroutineStacks())
+	n := traces.Length()
+	if n > max {
+		n = max
+	}
+
+	threads := make([]Thread, 0, n)
+	for i := 0; i < n; i++ {
+		trace := traces.Item(i)
+		thread := Thread{
+			ID:         strconv.FormatUint(trace.GoID(), 10),
+			Name:       goroutineName(trace),
+			Stacktrace: goroutineStacktrace(trace),
+		}
+		if i == 0 {
+			// runtime.Stack dumps the calling goroutine first.
+			thread.Current = true
+			thread.Crashed = len(event.Exception) > 0
+			if len(event.Threads) > 0 && event.Threads[0].Stacktrace != nil {
+				thread.Stacktrace = event.Threads[0].Stacktrace
+				thread.Crashed = thread.Crashed || event.Threads[0].Crashed
+			}
+		}
+		threads = append(threads, thread)
+	}
+	return threads
+}
+
+// goroutineStacks returns the stacks of all goroutines as formatted by
+// runtime.Stack, truncated to stackBufferLimit.
+func goroutineStacks() []byte {
+	buf := make([]byte, 64*1024)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) || len(buf) >= stackBufferLimit {
+			return buf[:n]
+		}
+		size := 2 * len(buf)
+		if size > stackBufferLimit {
+			size = stackBufferLimit
+		}
+		buf = make([]byte, size)
+	}
+}
+
+// goroutineName returns the header of a goroutine dump without the trailing
+// colon, for example "goroutine 7 [chan receive]".
+func goroutineName(trace traceparser.Trace) string {
+	header := trace.Header()
+	if len(header) > 0 && header[len(header)-1] == ':' {
+		header = header[:len(header)-1]
+	}
+	return string(header)
+}
+
+// goroutineStacktrace converts the frames of a goroutine dump to a
+// Stacktrace, skipping the same frames as NewStacktrace.
+func goroutineStacktrace(trace traceparser.Trace) *Stacktrace {
+	iter := trace.FramesReversed()
+	frames := make([]Frame, 0, iter.LengthUpperBound())
+	for iter.HasNext() {
+		f := iter.Next()
+		module, function := splitQualifiedFunctionName(string(f.Func()))
+		if shouldSkipFrame(module) {
+			continue
+		}
+		file, line := f.File()
+		frames = append(frames, newFrame(module, function, string(file), line))
+	}
+	if len(frames) == 0 {
+		return nil
+	}
+	return &Stacktrace{Frames: frames}
+}
@@ -0,0 +1,154 @@
+package sentry
+
+import (
+	"context"
+	"strings"
+)
+
+// TraceCarrier is implemented by the metadata of a request or message that
+// carries the "sentry-trace" and "baggage" headers of a trace to another
+// service. http.Header implements it, so do the adapters below for gRPC
+// metadata, Kafka message headers and plain maps.
+type TraceCarrier interface {
+	// Get returns the value stored for key, or an empty string.
+	Get(key string) string
+	// Set stores value for key, replacing any existing value.
+	Set(key, value string)
+}
+
+// InjectTraceHeaders writes the "sentry-trace" and "baggage" headers of the
+// current span of ctx into carrier. Without a span, the headers of the
+// propagation context of the scope of the hub of ctx are written. Injecting
+// the headers freezes the dynamic sampling context of the transaction.
+func InjectTraceHeaders(ctx context.Context, carrier TraceCarrier) {
+	var trace, baggage string
+	if span := SpanFromContext(ctx); span != nil {
+		trace, baggage = span.ToSentryTrace(), span.ToBaggage()
+	} else if scope := hubFromContext(ctx).Scope(); scope != nil {
+		scope.mu.RLock()
+		trace, baggage = GetTraceHeader(scope), GetBaggageHeader(scope)
+		scope.mu.RUnlock()
+	}
+
+	if trace != "" {
+		carrier.Set(SentryTraceHeader, trace)
+	}
+	if baggage != "" {
+		carrier.Set(SentryBaggageHeader, baggage)
+	}
+}
+
+// ContinueFromCarrier returns a span option that updates the span to continue
+// the trace whose headers carrier holds. It is the counterpart of
+// InjectTraceHeaders, see ContinueFromHeaders.
+func ContinueFromCarrier(carrier TraceCarrier) SpanOption {
+	return ContinueFromHeaders(carrier.Get(SentryTraceHeader), carrier.Get(SentryBaggageHeader))
+}
+
+// ContinueTraceFromCarrier continues the trace whose headers carrier holds, for
+// consumers of messages carrying the headers in their metadata. It sets the
+// propagation context of the scope of the hub of ctx to the trace, so that
+// errors captured while processing the message are linked to it, and returns
+// the options continuing the trace with the span of the consumer:
+//
+//	options := sentry.ContinueTraceFromCarrier(ctx, sentry.MapCarrier(msg.Metadata))
+//	span := sentry.StartSpan(ctx, "queue.process", options...)
+//
+// The scope also keeps carrier, which is passed in EventHint.Carrier of the
+// events captured with it, and in EventHint.Metadata by ReportGRPCStatus if
+// carrier is a MetadataCarrier. Use a hub dedicated to the message, as both
+// apply to everything captured with the scope afterwards. If carrier holds no
+// valid trace, the propagation context is left unchanged and the span starts
+// a new trace.
+func ContinueTraceFromCarrier(ctx context.Context, carrier TraceCarrier) []SpanOption {
+	hub := hubFromContext(ctx)
+	scope := hub.Scope()
+	if scope != nil {
+		scope.setCarrier(carrier)
+	}
+	trace, baggage := carrier.Get(SentryTraceHeader), carrier.Get(SentryBaggageHeader)
+	if trace != "" && scope != nil {
+		client := hub.Client()
+		strict := client == nil || !client.options.LenientBaggageParsing
+		if p, err := propagationContextFromHeaders(trace, baggage, strict, clientIDGenerator(client)); err == nil {
+			scope.SetPropagationContext(p)
+		}
+	}
+	return []SpanOption{ContinueFromHeaders(trace, baggage)}
+}
+
+// MapCarrier is a TraceCarrier backed by a map, for transports whose metadata
+// is a map of strings.
+type MapCarrier map[string]string
+
+func (c MapCarrier) Get(key string) string {
+	return c[key]
+}
+
+func (c MapCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+// MetadataCarrier is a TraceCarrier backed by gRPC metadata. Convert a
+// google.golang.org/grpc/metadata.MD to use it:
+//
+//	md := metadata.MD{}
+//	sentry.InjectTraceHeaders(ctx, sentry.MetadataCarrier(md))
+//	ctx = metadata.NewOutgoingContext(ctx, md)
+//
+// Keys are lowercased, as gRPC requires.
+type MetadataCarrier map[string][]string
+
+func (c MetadataCarrier) Get(key string) string {
+	if values := c[strings.ToLower(key)]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (c MetadataCarrier) Set(key, value string) {
+	c[strings.ToLower(key)] = []string{value}
+}
+
+// KafkaHeader is satisfied by the message header types of Kafka clients
+// such as github.com/segmentio/kafka-go and
+// github.com/confluentinc/confluent-kafka-go.
+type KafkaHeader interface {
+	~struct {
+		Key   string
+		Value []byte
+	}
+}
+
+// KafkaHeadersCarrier is a TraceCarrier backed by the headers of a Kafka
+// message:
+//
+//	sentry.InjectTraceHeaders(ctx, sentry.KafkaHeadersCarrier[kafka.Header]{Headers: &msg.Headers})
+type KafkaHeadersCarrier[H KafkaHeader] struct {
+	Headers *[]H
+}
+
+type kafkaHeader = struct {
+	Key   string
+	Value []byte
+}
+
+func (c KafkaHeadersCarrier[H]) Get(key string) string {
+	for _, h := range *c.Headers {
+		if h := kafkaHeader(h); h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func (c KafkaHeadersCarrier[H]) Set(key, value string) {
+	header := H(kafkaHeader{Key: key, Value: []byte(value)})
+	for i, h := range *c.Headers {
+		if kafkaHeader(h).Key == key {
+			(*c.Headers)[i] = header
+			return
+		}
+	}
+	*c.Headers = append(*c.Headers, header)
+}
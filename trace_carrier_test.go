@@ -0,0 +1,98 @@
+package sentry
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/getsentry/sentry-go/internal/testutils"
+)
+
+type testKafkaHeader struct {
+	Key   string
+	Value []byte
+}
+
+func TestTraceCarriers(t *testing.T) {
+	ctx := NewTestContext(ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		Dsn:              "http://public@example.com/sentry/1",
+	})
+	transaction := StartTransaction(ctx, "name")
+	wantTrace, wantBaggage := transaction.ToSentryTrace(), transaction.ToBaggage()
+
+	var headers []testKafkaHeader
+	carriers := map[string]TraceCarrier{
+		"map":      MapCarrier{},
+		"metadata": MetadataCarrier{},
+		"kafka":    KafkaHeadersCarrier[testKafkaHeader]{Headers: &headers},
+		"http":     http.Header{},
+	}
+	for name, carrier := range carriers {
+		t.Run(name, func(t *testing.T) {
+			InjectTraceHeaders(transaction.Context(), carrier)
+			// Injecting twice replaces the values.
+			InjectTraceHeaders(transaction.Context(), carrier)
+
+			assertEqual(t, carrier.Get(SentryTraceHeader), wantTrace)
+			testutils.AssertBaggageStringsEqual(t, carrier.Get(SentryBaggageHeader), wantBaggage)
+
+			continued := StartTransaction(ctx, "continued", ContinueFromCarrier(carrier))
+			assertEqual(t, continued.TraceID, transaction.TraceID)
+			assertEqual(t, continued.ParentSpanID, transaction.SpanID)
+			testutils.AssertBaggageStringsEqual(t, continued.ToBaggage(), wantBaggage)
+		})
+	}
+	assertEqual(t, len(headers), 2)
+}
+
+func TestInjectTraceHeadersFromScope(t *testing.T) {
+	ctx := NewTestContext(ClientOptions{})
+	scope := hubFromContext(ctx).Scope()
+	p, err := PropagationContextFromHeaders(
+		"bc6d53f15eb88f4320054569b8c553d4-b72fa28504b07285-1",
+		"sentry-trace_id=bc6d53f15eb88f4320054569b8c553d4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	scope.SetPropagationContext(p)
+
+	carrier := MetadataCarrier{}
+	InjectTraceHeaders(ctx, carrier)
+	assertEqual(t, carrier["sentry-trace"], []string{"bc6d53f15eb88f4320054569b8c553d4-" + p.SpanID.String()})
+	assertEqual(t, carrier["baggage"], []string{"sentry-trace_id=bc6d53f15eb88f4320054569b8c553d4"})
+}
+
+func TestContinueTraceFromCarrier(t *testing.T) {
+	ctx := NewTestContext(ClientOptions{EnableTracing: true, TracesSampleRate: 1.0})
+	hub := hubFromContext(ctx)
+	carrier := MapCarrier{
+		SentryTraceHeader:   "bc6d53f15eb88f4320054569b8c553d4-b72fa28504b07285-1",
+		SentryBaggageHeader: "sentry-trace_id=bc6d53f15eb88f4320054569b8c553d4,sentry-public_key=public",
+	}
+
+	options := ContinueTraceFromCarrier(ctx, carrier)
+	p := hub.Scope().PropagationContext()
+	assertEqual(t, p.TraceID.String(), "bc6d53f15eb88f4320054569b8c553d4")
+	assertEqual(t, p.ParentSpanID.String(), "b72fa28504b07285")
+	assertEqual(t, p.DynamicSamplingContext.Entries["public_key"], "public")
+
+	span := StartSpan(ctx, "queue.process", options...)
+	assertEqual(t, span.TraceID, p.TraceID)
+	assertEqual(t, span.ParentSpanID, p.ParentSpanID)
+	assertEqual(t, span.Sampled, SampledTrue)
+	assertEqual(t, span.dynamicSamplingContext.Entries["public_key"], "public")
+
+	// The carrier is passed to BeforeSend with the events of the scope.
+	var hint *EventHint
+	hub.Client().options.BeforeSend = func(event *Event, h *EventHint) *Event {
+		hint = h
+		return event
+	}
+	hub.CaptureMessage("processing failed")
+	assertEqual(t, hint.Carrier, TraceCarrier(carrier))
+
+	// Without a trace, the propagation context is left unchanged.
+	ContinueTraceFromCarrier(ctx, MapCarrier{})
+	assertEqual(t, hub.Scope().PropagationContext().TraceID, p.TraceID)
+}
@@ -0,0 +1,88 @@
+// Package sentrytracelog stamps application logs with the Sentry trace and
+// span IDs of a context, so that log lines can be found from the traces and
+// errors in Sentry and the other way around. It works with any logging
+// library, for applications that don't use one of the logging integrations.
+package sentrytracelog
+
+import (
+	"context"
+	"strings"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// The keys of the IDs in the output of KeyVals and Format. Change them
+// before logging, e.g. to match the field names of another tracing system.
+var (
+	TraceIDKey = "trace_id"
+	SpanIDKey  = "span_id"
+)
+
+// TraceIDFromContext returns the ID of the trace of the span in ctx, or else
+// of the propagation context of the scope of the hub in ctx, which errors
+// captured outside of transactions belong to. It reports false if ctx has
+// neither.
+func TraceIDFromContext(ctx context.Context) (string, bool) {
+	if span := sentry.SpanFromContext(ctx); span != nil {
+		return span.TraceID.String(), true
+	}
+	if hub := sentry.GetHubFromContext(ctx); hub != nil {
+		if id := hub.Scope().PropagationContext().TraceID; id != (sentry.TraceID{}) {
+			return id.String(), true
+		}
+	}
+	return "", false
+}
+
+// SpanIDFromContext returns the ID of the span in ctx. It reports false if
+// there is none.
+func SpanIDFromContext(ctx context.Context) (string, bool) {
+	if span := sentry.SpanFromContext(ctx); span != nil {
+		return span.SpanID.String(), true
+	}
+	return "", false
+}
+
+// KeyVals returns the IDs in ctx as alternating keys and values, for loggers
+// that take structured fields, e.g.
+//
+//	logger.Info("charged", sentrytracelog.KeyVals(ctx)...)
+//
+// It returns nil if ctx has no trace.
+func KeyVals(ctx context.Context) []interface{} {
+	traceID, ok := TraceIDFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	keyvals := []interface{}{TraceIDKey, traceID}
+	if spanID, ok := SpanIDFromContext(ctx); ok {
+		keyvals = append(keyvals, SpanIDKey, spanID)
+	}
+	return keyvals
+}
+
+// Format prefixes msg with the IDs in ctx, such as
+// "[trace_id=... span_id=...] msg", for loggers that take plain text. It
+// returns msg as is if ctx has no trace. Use it as the formatter hook of a
+// logger, or directly:
+//
+//	log.Print(sentrytracelog.Format(ctx, "charged"))
+func Format(ctx context.Context, msg string) string {
+	keyvals := KeyVals(ctx)
+	if keyvals == nil {
+		return msg
+	}
+	var b strings.Builder
+	b.WriteByte('[')
+	for i := 0; i < len(keyvals); i += 2 {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(keyvals[i].(string))
+		b.WriteByte('=')
+		b.WriteString(keyvals[i+1].(string))
+	}
+	b.WriteString("] ")
+	b.WriteString(msg)
+	return b.String()
+}
@@ -0,0 +1,46 @@
+package sentrytracelog
+
+import (
+	"context"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestIDsFromContext(t *testing.T) {
+	client, err := sentry.NewClient(sentry.ClientOptions{EnableTracing: true, TracesSampleRate: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+
+	if got := Format(context.Background(), "msg"); got != "msg" {
+		t.Errorf("Format without trace = %q", got)
+	}
+	if got := KeyVals(context.Background()); got != nil {
+		t.Errorf("KeyVals without trace = %v", got)
+	}
+
+	// Outside of a transaction, the trace of the scope applies.
+	traceID, ok := TraceIDFromContext(ctx)
+	if !ok || traceID != hub.Scope().PropagationContext().TraceID.String() {
+		t.Errorf("TraceIDFromContext = %q, %v", traceID, ok)
+	}
+	if _, ok := SpanIDFromContext(ctx); ok {
+		t.Error("SpanIDFromContext without span reported true")
+	}
+
+	span := sentry.StartTransaction(ctx, "test")
+	defer span.Finish()
+	ctx = span.Context()
+	want := []interface{}{"trace_id", span.TraceID.String(), "span_id", span.SpanID.String()}
+	if diff := cmp.Diff(want, KeyVals(ctx)); diff != "" {
+		t.Errorf("KeyVals mismatch (-want +got):\n%s", diff)
+	}
+	wantFormat := "[trace_id=" + span.TraceID.String() + " span_id=" + span.SpanID.String() + "] msg"
+	if got := Format(ctx, "msg"); got != wantFormat {
+		t.Errorf("Format = %q, want %q", got, wantFormat)
+	}
+}
@@ -1,5 +1,7 @@
 package sentry
 
+import "fmt"
+
 // A SamplingContext is passed to a TracesSampler to determine a sampling
 // decision.
 //
@@ -17,3 +19,64 @@ type TracesSampler func(ctx SamplingContext) float64
 func (f TracesSampler) Sample(ctx SamplingContext) float64 {
 	return f(ctx)
 }
+
+// SamplingReason tells which rule made the sampling decision of a span, see
+// Span.SamplingDecision. The rules are tried in the order of the constants.
+type SamplingReason string
+
+const (
+	// SamplingReasonTracingDisabled means ClientOptions.EnableTracing is not
+	// set, so that no span is sampled.
+	SamplingReasonTracingDisabled SamplingReason = "tracing_disabled"
+	// SamplingReasonExplicit means the decision was passed with
+	// WithSpanSampled.
+	SamplingReasonExplicit SamplingReason = "explicit"
+	// SamplingReasonParent means the decision was inherited from the parent
+	// span, or from the sentry-trace header of an incoming request.
+	SamplingReasonParent SamplingReason = "parent"
+	// SamplingReasonScopeRate means the rate set with
+	// Scope.SetTracesSampleRate was applied.
+	SamplingReasonScopeRate SamplingReason = "scope_rate"
+	// SamplingReasonSampler means the rate returned by
+	// ClientOptions.TracesSampler was applied.
+	SamplingReasonSampler SamplingReason = "sampler"
+	// SamplingReasonSampleRate means ClientOptions.TracesSampleRate was
+	// applied.
+	SamplingReasonSampleRate SamplingReason = "sample_rate"
+)
+
+// SamplingDecision explains the sampling decision of a span.
+type SamplingDecision struct {
+	Sampled Sampled
+	Reason  SamplingReason
+	// Rate is the sample rate of the decision. It is 1 or 0 for decisions
+	// that were passed or inherited.
+	Rate float64
+	// Rand is the random number of the trace in [0, 1). Rates above it
+	// sample the span.
+	Rand float64
+}
+
+func (d SamplingDecision) String() string {
+	var sampled bool
+	switch d.Sampled {
+	case SampledTrue:
+		sampled = true
+	case SampledUndefined:
+		return fmt.Sprintf("undecided (%s)", d.Reason)
+	}
+	switch d.Reason {
+	case SamplingReasonScopeRate, SamplingReasonSampler, SamplingReasonSampleRate:
+		if d.Rate < 0 || d.Rate > 1 {
+			return fmt.Sprintf("sampled=%t by %s: rate %v out of range [0, 1]", sampled, d.Reason, d.Rate)
+		}
+		return fmt.Sprintf("sampled=%t by %s: rate %v, random number %v", sampled, d.Reason, d.Rate, d.Rand)
+	}
+	return fmt.Sprintf("sampled=%t by %s", sampled, d.Reason)
+}
+
+// SamplingDecision explains why the span was sampled or not, e.g. to debug
+// missing transactions. Child spans of a local parent inherit its decision.
+func (s *Span) SamplingDecision() SamplingDecision {
+	return SamplingDecision{Sampled: s.Sampled, Reason: s.samplingReason, Rate: s.sampleRate, Rand: s.sampleRand}
+}
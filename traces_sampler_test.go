@@ -1,6 +1,7 @@
 package sentry
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"testing"
@@ -58,3 +59,72 @@ func repeatedSample(sampler TracesSampler, ctx SamplingContext, count int) (obse
 	}
 	return n / float64(count)
 }
+
+func TestSamplingDecision(t *testing.T) {
+	tests := []struct {
+		name    string
+		options ClientOptions
+		start   func(ctx context.Context) *Span
+		want    SamplingDecision
+	}{
+		{
+			name:    "tracing disabled",
+			options: ClientOptions{},
+			start:   func(ctx context.Context) *Span { return StartTransaction(ctx, "t") },
+			want:    SamplingDecision{Sampled: SampledFalse, Reason: SamplingReasonTracingDisabled},
+		},
+		{
+			name:    "explicit",
+			options: ClientOptions{EnableTracing: true},
+			start: func(ctx context.Context) *Span {
+				return StartTransaction(ctx, "t", WithSpanSampled(SampledTrue))
+			},
+			want: SamplingDecision{Sampled: SampledTrue, Reason: SamplingReasonExplicit, Rate: 1},
+		},
+		{
+			name:    "incoming trace",
+			options: ClientOptions{EnableTracing: true, TracesSampleRate: 1},
+			start: func(ctx context.Context) *Span {
+				return StartTransaction(ctx, "t", ContinueFromHeaders("d49d9bf66f13450b81f65bc51cf49c03-1fc2e4b4d2c3cd8c-0", ""))
+			},
+			want: SamplingDecision{Sampled: SampledFalse, Reason: SamplingReasonParent},
+		},
+		{
+			name: "sampler",
+			options: ClientOptions{EnableTracing: true, TracesSampler: func(SamplingContext) float64 {
+				return 2
+			}},
+			start: func(ctx context.Context) *Span { return StartTransaction(ctx, "t") },
+			want:  SamplingDecision{Sampled: SampledFalse, Reason: SamplingReasonSampler, Rate: 2},
+		},
+		{
+			name:    "child span",
+			options: ClientOptions{EnableTracing: true, TracesSampleRate: 1},
+			start:   func(ctx context.Context) *Span { return StartTransaction(ctx, "t").StartChild("child") },
+			want:    SamplingDecision{Sampled: SampledTrue, Reason: SamplingReasonParent, Rate: 1},
+		},
+		{
+			name:    "child span of an unsampled transaction",
+			options: ClientOptions{EnableTracing: true, TracesSampleRate: 0},
+			start:   func(ctx context.Context) *Span { return StartTransaction(ctx, "t").StartChild("child") },
+			want:    SamplingDecision{Sampled: SampledFalse, Reason: SamplingReasonParent, Rate: 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			span := tt.start(NewTestContext(tt.options))
+			got := span.SamplingDecision()
+			got.Rand = 0
+			assertEqual(t, got, tt.want)
+		})
+	}
+
+	span := StartTransaction(NewTestContext(ClientOptions{EnableTracing: true, TracesSampleRate: 0.5}), "t")
+	decision := span.SamplingDecision()
+	assertEqual(t, decision.Reason, SamplingReasonSampleRate)
+	assertEqual(t, decision.Rate, 0.5)
+	assertEqual(t, decision.Sampled.Bool(), decision.Rand < 0.5)
+	assertEqual(t, decision.String(), fmt.Sprintf("sampled=%t by sample_rate: rate 0.5, random number %v", decision.Rand < 0.5, decision.Rand))
+	assertEqual(t, SamplingDecision{Sampled: SampledFalse, Reason: SamplingReasonSampler, Rate: 2}.String(),
+		"sampled=false by sampler: rate 2 out of range [0, 1]")
+}
@@ -2,15 +2,18 @@ package sentry
 
 import (
 	"context"
-	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"math"
 	"net/http"
 	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/getsentry/sentry-go/internal/intern"
 )
 
 const (
@@ -57,10 +60,29 @@ type Span struct { //nolint: maligned // prefer readability over optimal memory
 	mu sync.RWMutex
 	// sample rate the span was sampled with.
 	sampleRate float64
+	// sampleRateSource tells where sampleRate came from.
+	sampleRateSource sampleRateSource
+	// samplingReason tells which rule made the sampling decision.
+	samplingReason SamplingReason
+	// sampleRand is the random number in [0, 1) the sampling decision of the
+	// trace is based on, propagated as sample_rand in the DSC so that all
+	// SDKs in the trace make consistent decisions. It is truncated to the
+	// six decimals it is propagated with, see truncateSampleRand.
+	sampleRand float64
 	// ctx is the context where the span was started. Always non-nil.
 	ctx context.Context
+	// spanCtx is the context created for the span, see Span.Context.
+	spanCtx spanContext
 	// Dynamic Sampling context
 	dynamicSamplingContext DynamicSamplingContext
+	// incomingSamplingEntries are the entries of the dynamic sampling
+	// context of the baggage header a transaction continues. They stay
+	// available to DynamicSamplingContextFromTransaction after the frozen
+	// context of the transaction replaced them.
+	incomingSamplingEntries map[string]string
+	// baggage caches the serialized dynamicSamplingContext of a transaction
+	// for ToBaggage. It is protected by mu.
+	baggage string
 	// parent refers to the immediate local parent span. A remote parent span is
 	// only referenced by setting ParentSpanID.
 	parent *Span
@@ -72,6 +94,24 @@ type Span struct { //nolint: maligned // prefer readability over optimal memory
 	collectProfile transactionProfiler
 	// a Once instance to make sure that Finish() is only called once.
 	finishOnce sync.Once
+	// discardData is set for unsampled spans with
+	// ClientOptions.DiscardUnsampledSpanData, their data is never sent.
+	discardData bool
+	// request is the request set by ContinueFromRequest, passed to
+	// ClientOptions.TransactionNamer when the transaction is started.
+	request *http.Request
+	// scopeTags are the tags of the scope a transaction was started with
+	// that are listed in ClientOptions.DynamicSamplingContextTags.
+	scopeTags map[string]string
+	// clock is the clock of the span tree, see ClientOptions.Clock. It is
+	// only nil for spans not started with StartSpan.
+	clock Clock
+	// metricsSummary holds the summaries of ClientOptions.SpanMetrics,
+	// computed when the transaction is finished.
+	metricsSummary spanMetricsSummary
+	// hintData is passed as EventHint.Data with the transaction event, see
+	// SetHintData. It is protected by mu.
+	hintData interface{}
 }
 
 // TraceParentContext describes the context of a (remote) parent span.
@@ -116,22 +156,33 @@ type TraceParentContext struct {
 // root span sends the span and all of its children, recursively, as a
 // transaction to Sentry.
 func StartSpan(ctx context.Context, operation string, options ...SpanOption) *Span {
+	return startSpan(ctx, operation, options, nil)
+}
+
+// startSpan implements StartSpan. If transactionName is not nil, it is set as
+// the name of the span after the options are applied, which is cheaper than
+// passing WithTransactionName.
+func startSpan(ctx context.Context, operation string, options []SpanOption, transactionName *string) *Span {
 	parent, hasParent := ctx.Value(spanContextKey{}).(*Span)
 	var span Span
 	span = Span{
 		// defaults
-		Op:        operation,
-		StartTime: time.Now(),
-		Sampled:   SampledUndefined,
+		Op:      intern.String(operation),
+		Sampled: SampledUndefined,
 
-		ctx:    context.WithValue(ctx, spanContextKey{}, &span),
 		parent: parent,
 	}
-
-	_, err := rand.Read(span.SpanID[:])
-	if err != nil {
-		panic(err)
+	span.spanCtx = spanContext{Context: ctx, span: &span}
+	span.ctx = &span.spanCtx
+	if hasParent {
+		span.clock = parent.clock
+	} else {
+		span.clock = clockOrDefault(span.clientOptions().Clock)
 	}
+	span.StartTime = span.clock.Now()
+
+	ids := idGeneratorOrDefault(span.clientOptions().IDGenerator)
+	span.SpanID = ids.SpanID()
 
 	if hasParent {
 		span.TraceID = parent.TraceID
@@ -142,61 +193,47 @@ func StartSpan(ctx context.Context, operation string, options ...SpanOption) *Sp
 		span.Source = SourceCustom
 		span.Origin = SpanOriginManual
 
-		// Implementation note:
-		//
-		// While math/rand is ~2x faster than crypto/rand (exact
-		// difference depends on hardware / OS), crypto/rand is probably
-		// fast enough and a safer choice.
-		//
-		// For reference, OpenTelemetry [1] uses crypto/rand to seed
-		// math/rand. AFAICT this approach does not preserve the
-		// properties from crypto/rand that make it suitable for
-		// cryptography. While it might be debatable whether those
-		// properties are important for us here, again, we're taking the
-		// safer path.
-		//
-		// See [2a] & [2b] for a discussion of some of the properties we
-		// obtain by using crypto/rand and [3a] & [3b] for why we avoid
-		// math/rand.
-		//
-		// Because the math/rand seed has only 64 bits (int64), if the
-		// first thing we do after seeding an RNG is to read in a random
-		// TraceID, there are only 2^64 possible values. Compared to
-		// UUID v4 that have 122 random bits, there is a much greater
-		// chance of collision [4a] & [4b].
-		//
-		// [1]:  https://github.com/open-telemetry/opentelemetry-go/blob/958041ddf619a128/sdk/trace/trace.go#L25-L31
-		// [2a]: https://security.stackexchange.com/q/120352/246345
-		// [2b]: https://security.stackexchange.com/a/120365/246345
-		// [3a]: https://github.com/golang/go/issues/11871#issuecomment-126333686
-		// [3b]: https://github.com/golang/go/issues/11871#issuecomment-126357889
-		// [4a]: https://en.wikipedia.org/wiki/Universally_unique_identifier#Collisions
-		// [4b]: https://www.wolframalpha.com/input/?i=sqrt%282*2%5E64*ln%281%2F%281-0.5%29%29%29
-		_, err := rand.Read(span.TraceID[:])
-		if err != nil {
-			panic(err)
-		}
+		span.TraceID = ids.TraceID()
 	}
 
 	// Apply options to override defaults.
 	for _, option := range options {
 		option(&span)
 	}
+	if transactionName != nil {
+		span.Name = *transactionName
+	}
+	if !hasParent {
+		span.applyTransactionNamer()
+	}
 
+	if hasParent {
+		span.sampleRand = parent.sampleRand
+	} else {
+		span.initSampleRand()
+		if tags := span.clientOptions().DynamicSamplingContextTags; len(tags) > 0 {
+			if scope := hubFromContext(ctx).Scope(); scope != nil {
+				span.scopeTags = scope.applyTags(nil)
+			}
+		}
+	}
 	span.Sampled = span.sample()
+	span.discardData = !span.Sampled.Bool() && span.clientOptions().DiscardUnsampledSpanData
+	if hasParent && hubFromContext(ctx).Client().loadShedding() >= LoadSheddingScopeData {
+		span.discardData = true
+	}
 
-	span.recorder = &spanRecorder{}
 	if hasParent {
 		span.recorder = parent.spanRecorder()
+	} else {
+		span.recorder = &spanRecorder{}
 	}
 
-	span.recorder.record(&span)
-
-	hub := hubFromContext(ctx)
-
-	// Update scope so that all events include a trace context, allowing
-	// Sentry to correlate errors to transactions/spans.
-	hub.Scope().SetContext("trace", span.traceContext().Map())
+	// The root span is always recorded, it is found through the recorder by
+	// GetTransaction and TransactionFromContext.
+	if !span.discardData || !hasParent {
+		span.recorder.record(&span)
+	}
 
 	// Start profiling only if it's a sampled root transaction.
 	if span.IsTransaction() && span.Sampled.Bool() {
@@ -215,6 +252,21 @@ func (s *Span) Finish() {
 	s.finishOnce.Do(s.doFinish)
 }
 
+// FinishWithTime is like Finish, but sets the end time of the span to end
+// instead of the current time. Together with WithStartTime, it allows
+// instrumenting work measured externally, for example spans reconstructed from
+// logs or the records of batch jobs. An end before the start of the span is
+// replaced with the start, a zero end with the current time.
+func (s *Span) FinishWithTime(end time.Time) {
+	s.finishOnce.Do(func() {
+		if !end.IsZero() && end.Before(s.StartTime) {
+			end = s.StartTime
+		}
+		s.EndTime = end
+		s.doFinish()
+	})
+}
+
 // Context returns the context containing the span.
 func (s *Span) Context() context.Context { return s.ctx }
 
@@ -230,20 +282,24 @@ func (s *Span) StartChild(operation string, options ...SpanOption) *Span {
 // accessing the tags map directly as SetTag takes care of initializing the map
 // when necessary.
 func (s *Span) SetTag(name, value string) {
+	if s.discardData {
+		return
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	if s.Tags == nil {
 		s.Tags = make(map[string]string)
 	}
-	s.Tags[name] = value
+	s.Tags[intern.String(name)] = intern.String(value)
 }
 
 // SetData sets a data on the span. It is recommended to use SetData instead of
 // accessing the data map directly as SetData takes care of initializing the map
 // when necessary.
 func (s *Span) SetData(name string, value interface{}) {
-	if value == nil {
+	if value == nil || s.discardData {
 		return
 	}
 
@@ -256,10 +312,35 @@ func (s *Span) SetData(name string, value interface{}) {
 	s.Data[name] = value
 }
 
+// SetHintData sets the data passed as EventHint.Data to event processors and
+// BeforeSendTransaction along with the event of the transaction, for example
+// the span of another tracing library the transaction was created from. It
+// only has an effect on transactions.
+func (s *Span) SetHintData(data interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hintData = data
+}
+
+// eventHint returns the hint to capture the event of the transaction with,
+// or nil.
+func (s *Span) eventHint() *EventHint {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.hintData == nil {
+		return nil
+	}
+	return &EventHint{Data: s.hintData}
+}
+
 // SetContext sets a context on the span. It is recommended to use SetContext instead of
 // accessing the contexts map directly as SetContext takes care of initializing the map
 // when necessary.
 func (s *Span) SetContext(key string, value Context) {
+	if s.discardData {
+		return
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -324,50 +405,117 @@ func (s *Span) ToSentryTrace() string {
 // Use this function to propagate the DynamicSamplingContext to a downstream SDK,
 // either as the value of the "baggage" HTTP header, or as an html "baggage" meta tag.
 func (s *Span) ToBaggage() string {
-	if containingTransaction := s.GetTransaction(); containingTransaction != nil {
-		// In case there is currently no frozen DynamicSamplingContext attached to the transaction,
-		// create one from the properties of the transaction.
-		if !s.dynamicSamplingContext.IsFrozen() {
-			// This will return a frozen DynamicSamplingContext.
-			s.dynamicSamplingContext = DynamicSamplingContextFromTransaction(containingTransaction)
-		}
+	t := s.GetTransaction()
+	if t == nil {
+		return ""
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
 
-		return containingTransaction.dynamicSamplingContext.String()
+	t.freezeDynamicSamplingContextLocked()
+	if t.baggage == "" {
+		t.baggage = t.dynamicSamplingContext.String()
 	}
-	return ""
+	return t.baggage
 }
 
 // SetDynamicSamplingContext sets the given dynamic sampling context on the
 // current transaction.
 func (s *Span) SetDynamicSamplingContext(dsc DynamicSamplingContext) {
 	if s.IsTransaction() {
+		dsc.Entries = normalizeDSCEntries(dsc.Entries)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
 		s.dynamicSamplingContext = dsc
+		s.baggage = ""
 	}
 }
 
+// frozenDynamicSamplingContext returns the dynamic sampling context of the
+// transaction s. Unless it continues a trace or one was set, it is created
+// from the transaction on first use and reused afterwards.
+func (s *Span) frozenDynamicSamplingContext() DynamicSamplingContext {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.freezeDynamicSamplingContextLocked()
+}
+
+// freezeDynamicSamplingContextLocked is frozenDynamicSamplingContext for
+// callers that hold s.mu.
+func (s *Span) freezeDynamicSamplingContextLocked() DynamicSamplingContext {
+	if !s.dynamicSamplingContext.IsFrozen() {
+		s.dynamicSamplingContext = DynamicSamplingContextFromTransaction(s)
+		s.baggage = ""
+	}
+	return s.dynamicSamplingContext
+}
+
 // doFinish runs the actual Span.Finish() logic.
 func (s *Span) doFinish() {
 	if s.EndTime.IsZero() {
-		s.EndTime = monotonicTimeSince(s.StartTime)
+		s.EndTime = monotonicTimeSince(clockOrDefault(s.clock), s.StartTime)
 	}
 
 	if !s.Sampled.Bool() {
+		if s.IsTransaction() {
+			s.reportDropped(DropReasonSampleRate)
+		}
 		return
 	}
+
+	hub := hubFromContext(s.ctx)
+	if client := hub.Client(); client != nil && s.IsTransaction() && client.ignoresTransaction(s) {
+		s.reportDropped(DropReasonEventProcessor)
+		return
+	}
+
 	event := s.toEvent()
 	if event == nil {
 		return
 	}
+	s.applySpanMetrics(event)
+	if client := hubFromContext(s.ctx).Client(); client != nil {
+		client.filterSpanQueries(event.Spans)
+	}
 
 	if s.collectProfile != nil {
 		event.sdkMetaData.transactionProfile = s.collectProfile(s)
 	}
 
+	if w := activeSchedulerWatchdog(); w != nil {
+		w.addMeasurements(event)
+	}
+
 	// TODO(tracing): add breadcrumbs
 	// (see https://github.com/getsentry/sentry-python/blob/f6f3525f8812f609/sentry_sdk/tracing.py#L372)
 
-	hub := hubFromContext(s.ctx)
-	hub.CaptureEvent(event)
+	if threshold := s.clientOptions().ExecutionTraceThreshold; threshold > 0 && s.IsTransaction() &&
+		s.EndTime.Sub(s.StartTime) > threshold {
+		if snapshot := executionTraceSnapshot(time.Now()); snapshot != nil {
+			event.Attachments = append(event.Attachments, snapshot)
+		}
+	}
+
+	if threshold := s.clientOptions().ContentionProfileThreshold; threshold > 0 && s.IsTransaction() &&
+		s.EndTime.Sub(s.StartTime) > threshold {
+		captureWithContentionProfiles(hub.Clone(), event, s.eventHint(), s.clientOptions().ContentionProfileWindow)
+		return
+	}
+
+	hub.CaptureEventWithHint(event, s.eventHint())
+}
+
+// reportDropped reports a transaction that is discarded before it is
+// converted into an event to the OnEventDropped callback.
+func (s *Span) reportDropped(reason DropReason) {
+	client := hubFromContext(s.ctx).Client()
+	if client != nil && client.onEventDropped != nil {
+		client.onEventDropped(nil, reason, string(categoryFor(transactionType)))
+	}
 }
 
 // sentryTracePattern matches either
@@ -400,36 +548,70 @@ func (s *Span) updateFromSentryTrace(header []byte) (updated bool) {
 		case '1':
 			s.Sampled = SampledTrue
 		}
+		s.sampleRateSource = sampleRateFromParent
 	}
 	return true
 }
 
 func (s *Span) updateFromBaggage(header []byte) {
 	if s.IsTransaction() {
-		dsc, err := DynamicSamplingContextFromHeader(header)
+		// Spans built by hand to apply options to have no context.
+		strict := s.ctx == nil || !s.clientOptions().LenientBaggageParsing
+		dsc, err := parseDynamicSamplingContext(header, strict)
 		if err != nil {
+			Logger.Printf("Ignoring malformed baggage header: %v", err)
 			return
 		}
 
 		s.dynamicSamplingContext = dsc
+		if dsc.HasEntries() {
+			s.incomingSamplingEntries = dsc.Entries
+		}
 	}
 }
 
 func (s *Span) MarshalJSON() ([]byte, error) {
-	// span aliases Span to allow calling json.Marshal without an infinite loop.
-	// It preserves all fields while none of the attached methods.
-	type span Span
+	return json.Marshal(s.jsonValue())
+}
+
+// spanFields aliases Span to allow calling json.Marshal without an infinite
+// loop. It preserves all fields while none of the attached methods.
+type spanFields Span
+
+// spanJSON is the JSON encoding of a span. Transactions encode their spans as
+// spanJSON, which saves calling MarshalJSON for every span.
+type spanJSON struct {
+	*spanFields
+	ParentSpanID   string             `json:"parent_span_id,omitempty"`
+	MetricsSummary spanMetricsSummary `json:"_metrics_summary,omitempty"`
+}
+
+// spansJSON returns the JSON encodings of spans, nil spans are kept as nil.
+func spansJSON(spans []*Span) []*spanJSON {
+	if len(spans) == 0 {
+		return nil
+	}
+	values := make([]spanJSON, len(spans))
+	result := make([]*spanJSON, len(spans))
+	for i, s := range spans {
+		if s != nil {
+			values[i] = s.jsonValue()
+			result[i] = &values[i]
+		}
+	}
+	return result
+}
+
+func (s *Span) jsonValue() spanJSON {
 	var parentSpanID string
 	if s.ParentSpanID != zeroSpanID {
 		parentSpanID = s.ParentSpanID.String()
 	}
-	return json.Marshal(struct {
-		*span
-		ParentSpanID string `json:"parent_span_id,omitempty"`
-	}{
-		span:         (*span)(s),
-		ParentSpanID: parentSpanID,
-	})
+	return spanJSON{
+		spanFields:     (*spanFields)(s),
+		ParentSpanID:   parentSpanID,
+		MetricsSummary: s.metricsSummary,
+	}
 }
 
 func (s *Span) clientOptions() *ClientOptions {
@@ -440,13 +622,25 @@ func (s *Span) clientOptions() *ClientOptions {
 	return &ClientOptions{}
 }
 
+// sample makes the sampling decision of the span, see Span.SamplingDecision.
 func (s *Span) sample() Sampled {
+	sampled := s.decideSampling()
+	if s.IsTransaction() {
+		decision := s.SamplingDecision()
+		decision.Sampled = sampled
+		debugLog(LevelDebug, "Sampling decision", "transaction", s.Name, "decision", decision.String())
+	}
+	return sampled
+}
+
+func (s *Span) decideSampling() Sampled {
 	clientOptions := s.clientOptions()
 	// https://develop.sentry.dev/sdk/performance/#sampling
 	// #1 tracing is not enabled.
 	if !clientOptions.EnableTracing {
 		Logger.Printf("Dropping transaction: EnableTracing is set to %t", clientOptions.EnableTracing)
 		s.sampleRate = 0.0
+		s.samplingReason = SamplingReasonTracingDisabled
 		return SampledFalse
 	}
 
@@ -459,6 +653,12 @@ func (s *Span) sample() Sampled {
 		case SampledFalse:
 			s.sampleRate = 0.0
 		}
+		if s.sampleRateSource != sampleRateFromParent {
+			s.sampleRateSource = sampleRateFromDecision
+			s.samplingReason = SamplingReasonExplicit
+		} else {
+			s.samplingReason = SamplingReasonParent
+		}
 		return s.Sampled
 	}
 
@@ -467,10 +667,30 @@ func (s *Span) sample() Sampled {
 	// conditions anyway -- the first for semantic meaning, the second to
 	// avoid a nil pointer dereference.
 	if !s.IsTransaction() && s.parent != nil {
+		s.samplingReason = SamplingReasonParent
+		if s.parent.Sampled.Bool() {
+			s.sampleRate = 1.0
+		} else {
+			s.sampleRate = 0.0
+		}
 		return s.parent.Sampled
 	}
 
-	// #3 use TracesSampler from ClientOptions.
+	// #3 use the sample rate set on the scope with SetTracesSampleRate.
+	if scope := hubFromContext(s.ctx).Scope(); scope != nil {
+		if _, rate := scope.sampleRates(); rate != nil {
+			s.sampleRate = *rate
+			s.sampleRateSource = sampleRateFromDecision
+			s.samplingReason = SamplingReasonScopeRate
+			if s.sampleRand < *rate {
+				return SampledTrue
+			}
+			Logger.Printf("Dropping transaction: scope sample rate is: %f", *rate)
+			return SampledFalse
+		}
+	}
+
+	// #4 use TracesSampler from ClientOptions.
 	sampler := clientOptions.TracesSampler
 	samplingContext := SamplingContext{
 		Span:   s,
@@ -480,6 +700,8 @@ func (s *Span) sample() Sampled {
 	if sampler != nil {
 		tracesSamplerSampleRate := sampler.Sample(samplingContext)
 		s.sampleRate = tracesSamplerSampleRate
+		s.sampleRateSource = sampleRateFromDecision
+		s.samplingReason = SamplingReasonSampler
 		if tracesSamplerSampleRate < 0.0 || tracesSamplerSampleRate > 1.0 {
 			Logger.Printf("Dropping transaction: Returned TracesSampler rate is out of range [0.0, 1.0]: %f", tracesSamplerSampleRate)
 			return SampledFalse
@@ -489,15 +711,16 @@ func (s *Span) sample() Sampled {
 			return SampledFalse
 		}
 
-		if rng.Float64() < tracesSamplerSampleRate {
+		if s.sampleRand < tracesSamplerSampleRate {
 			return SampledTrue
 		}
 		Logger.Printf("Dropping transaction: TracesSampler returned rate: %f", tracesSamplerSampleRate)
 		return SampledFalse
 	}
-	// #4 inherit parent decision.
+	// #5 inherit parent decision.
 	if s.parent != nil {
 		Logger.Printf("Using sampling decision from parent: %v", s.parent.Sampled)
+		s.samplingReason = SamplingReasonParent
 		switch s.parent.Sampled {
 		case SampledTrue:
 			s.sampleRate = 1.0
@@ -507,9 +730,14 @@ func (s *Span) sample() Sampled {
 		return s.parent.Sampled
 	}
 
-	// #5 use TracesSampleRate from ClientOptions.
-	sampleRate := clientOptions.TracesSampleRate
+	// #6 use TracesSampleRate from ClientOptions.
+	var sampleRate float64
+	if client := hubFromContext(s.ctx).Client(); client != nil {
+		sampleRate = client.runtimeOptions().TracesSampleRate
+	}
 	s.sampleRate = sampleRate
+	s.sampleRateSource = sampleRateFromClient
+	s.samplingReason = SamplingReasonSampleRate
 	if sampleRate < 0.0 || sampleRate > 1.0 {
 		Logger.Printf("Dropping transaction: TracesSamplerRate out of range [0.0, 1.0]: %f", sampleRate)
 		return SampledFalse
@@ -519,13 +747,76 @@ func (s *Span) sample() Sampled {
 		return SampledFalse
 	}
 
-	if rng.Float64() < sampleRate {
+	if s.sampleRand < sampleRate {
 		return SampledTrue
 	}
 
 	return SampledFalse
 }
 
+// sampleRateSource tells where the sample rate of a transaction came from.
+type sampleRateSource uint8
+
+const (
+	// The sampling decision was inherited from a local parent, or tracing is
+	// disabled.
+	sampleRateUnset sampleRateSource = iota
+	// The sampling decision was read from an incoming sentry-trace header.
+	sampleRateFromParent
+	// The rate was returned by TracesSampler or is implied by a decision
+	// passed to StartSpan.
+	sampleRateFromDecision
+	// The rate is ClientOptions.TracesSampleRate.
+	sampleRateFromClient
+)
+
+// initSampleRand sets the sampleRand of a transaction. It is taken from the
+// incoming DSC if there is one, and generated otherwise. A generated value is
+// kept consistent with the decision of an upstream SDK that propagated a
+// sample rate but no sample_rand, and added to a frozen DSC so that it is
+// propagated further.
+func (s *Span) initSampleRand() {
+	entries := s.dynamicSamplingContext.Entries
+	if r, err := strconv.ParseFloat(entries["sample_rand"], 64); err == nil && r >= 0 && r < 1 {
+		s.sampleRand = truncateSampleRand(r)
+		return
+	}
+
+	r := rng.Float64()
+	if rate, err := strconv.ParseFloat(entries["sample_rate"], 64); err == nil && rate > 0 && rate <= 1 {
+		switch {
+		case s.Sampled == SampledTrue:
+			r *= rate
+		case s.Sampled == SampledFalse && rate < 1:
+			r = rate + r*(1-rate)
+		}
+	}
+	r = truncateSampleRand(r)
+	s.sampleRand = r
+
+	if s.dynamicSamplingContext.IsFrozen() {
+		frozen := make(map[string]string, len(entries)+1)
+		for k, v := range entries {
+			frozen[k] = v
+		}
+		frozen["sample_rand"] = formatSampleRand(r)
+		s.dynamicSamplingContext.Entries = frozen
+	}
+}
+
+// truncateSampleRand truncates a sample_rand value to six decimals, such that
+// the sampling decisions of the SDK are based on the value it propagates. The
+// value is truncated rather than rounded, which could turn it into 1.
+func truncateSampleRand(r float64) float64 {
+	return math.Floor(r*1e6) / 1e6
+}
+
+// formatSampleRand formats a sample_rand value truncated with
+// truncateSampleRand.
+func formatSampleRand(r float64) string {
+	return strconv.FormatFloat(r, 'f', 6, 64)
+}
+
 func (s *Span) toEvent() *Event {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -546,9 +837,7 @@ func (s *Span) toEvent() *Event {
 
 	// Create and attach a DynamicSamplingContext to the transaction.
 	// If the DynamicSamplingContext is not frozen at this point, we can assume being head of trace.
-	if !s.dynamicSamplingContext.IsFrozen() {
-		s.dynamicSamplingContext = DynamicSamplingContextFromTransaction(s)
-	}
+	s.freezeDynamicSamplingContextLocked()
 
 	contexts := map[string]Context{}
 	for k, v := range s.contexts {
@@ -883,6 +1172,17 @@ func WithTransactionSource(source TransactionSource) SpanOption {
 func WithSpanSampled(sampled Sampled) SpanOption {
 	return func(s *Span) {
 		s.Sampled = sampled
+		s.sampleRateSource = sampleRateUnset
+	}
+}
+
+// WithStartTime sets the start time of the span, instead of the time the span
+// is started. See Span.FinishWithTime.
+func WithStartTime(start time.Time) SpanOption {
+	return func(s *Span) {
+		if !start.IsZero() {
+			s.StartTime = start
+		}
 	}
 }
 
@@ -913,11 +1213,17 @@ func GetBaggageHeader(s *Scope) string {
 // an existing trace. If it cannot detect an existing trace in the request, the
 // span will be left unchanged.
 //
-// ContinueFromRequest is an alias for:
+// ContinueFromRequest is like:
 //
 // ContinueFromHeaders(r.Header.Get(SentryTraceHeader), r.Header.Get(SentryBaggageHeader)).
+//
+// Additionally, r is passed to ClientOptions.TransactionNamer.
 func ContinueFromRequest(r *http.Request) SpanOption {
-	return ContinueFromHeaders(r.Header.Get(SentryTraceHeader), r.Header.Get(SentryBaggageHeader))
+	continueFromHeaders := ContinueFromHeaders(r.Header.Get(SentryTraceHeader), r.Header.Get(SentryBaggageHeader))
+	return func(s *Span) {
+		s.request = r
+		continueFromHeaders(s)
+	}
 }
 
 // ContinueFromHeaders returns a span option that updates the span to continue
@@ -955,6 +1261,21 @@ func ContinueFromTrace(trace string) SpanOption {
 // spanContextKey is used to store span values in contexts.
 type spanContextKey struct{}
 
+// spanContext is the context of a span, it holds the span under
+// spanContextKey. It is allocated as part of the Span instead of separately
+// by context.WithValue.
+type spanContext struct {
+	context.Context
+	span *Span
+}
+
+func (c *spanContext) Value(key interface{}) interface{} {
+	if key == (spanContextKey{}) {
+		return c.span
+	}
+	return c.Context.Value(key)
+}
+
 // TransactionFromContext returns the root span of the current transaction. It
 // returns nil if no transaction is tracked in the context.
 func TransactionFromContext(ctx context.Context) *Span {
@@ -982,12 +1303,7 @@ func StartTransaction(ctx context.Context, name string, options ...SpanOption) *
 		return currentTransaction
 	}
 
-	options = append(options, WithTransactionName(name))
-	return StartSpan(
-		ctx,
-		"",
-		options...,
-	)
+	return startSpan(ctx, "", options, &name)
 }
 
 // HTTPtoSpanStatus converts an HTTP status code to a SpanStatus.
@@ -366,6 +366,48 @@ func TestSetData(t *testing.T) {
 	}
 }
 
+func TestDiscardUnsampledSpanData(t *testing.T) {
+	for _, discard := range []bool{false, true} {
+		ctx := NewTestContext(ClientOptions{
+			EnableTracing:            true,
+			DiscardUnsampledSpanData: discard,
+		})
+		transaction := StartTransaction(ctx, "name", WithSpanSampled(SampledFalse))
+		span := transaction.StartChild("op")
+		span.SetTag("key", "value")
+		span.SetData("key", "value")
+		transaction.SetContext("key", Context{"key": "value"})
+
+		wantChildren := 1
+		if discard {
+			wantChildren = 0
+		}
+		if got := len(transaction.spanRecorder().children()); got != wantChildren {
+			t.Errorf("discard=%t: recorded %d child spans, want %d", discard, got, wantChildren)
+		}
+		if got := len(span.Tags) + len(span.Data) + len(transaction.contexts); (got == 0) != discard {
+			t.Errorf("discard=%t: span has %d tags, data and contexts", discard, got)
+		}
+		if got := TransactionFromContext(span.Context()); got != transaction {
+			t.Errorf("discard=%t: TransactionFromContext() = %v, want the transaction", discard, got)
+		}
+		if event := GetHubFromContext(ctx).Scope().ApplyToEvent(NewEvent(), nil, nil); event.Contexts["trace"] == nil {
+			t.Errorf("discard=%t: error event has no trace context", discard)
+		}
+	}
+
+	// Sampled spans keep their data.
+	ctx := NewTestContext(ClientOptions{
+		EnableTracing:            true,
+		DiscardUnsampledSpanData: true,
+	})
+	span := StartSpan(ctx, "op", WithSpanSampled(SampledTrue))
+	span.SetTag("key", "value")
+	if span.Tags["key"] != "value" {
+		t.Errorf("Tags of sampled span = %v", span.Tags)
+	}
+}
+
 func TestWithDescription(t *testing.T) {
 	ctx := NewTestContext(ClientOptions{
 		EnableTracing: true,
@@ -609,8 +651,9 @@ func TestContinueTransactionFromHeaders(t *testing.T) {
 			wantSpan: &Span{
 				Sampled: 0,
 				dynamicSamplingContext: DynamicSamplingContext{
-					Frozen:  false,
-					Entries: map[string]string{},
+					Frozen:     false,
+					Entries:    map[string]string{},
+					thirdParty: baggageMembers([]byte("other-vendor-key1=value1;value2, other-vendor-key2=value3")),
 				},
 			},
 		},
@@ -620,9 +663,10 @@ func TestContinueTransactionFromHeaders(t *testing.T) {
 			traceStr:   "bc6d53f15eb88f4320054569b8c553d4-b72fa28504b07285-1",
 			baggageStr: "",
 			wantSpan: &Span{
-				TraceID:      TraceIDFromHex("bc6d53f15eb88f4320054569b8c553d4"),
-				ParentSpanID: SpanIDFromHex("b72fa28504b07285"),
-				Sampled:      1,
+				TraceID:          TraceIDFromHex("bc6d53f15eb88f4320054569b8c553d4"),
+				ParentSpanID:     SpanIDFromHex("b72fa28504b07285"),
+				Sampled:          1,
+				sampleRateSource: sampleRateFromParent,
 				dynamicSamplingContext: DynamicSamplingContext{
 					Frozen: true,
 				},
@@ -633,9 +677,10 @@ func TestContinueTransactionFromHeaders(t *testing.T) {
 			traceStr:   "bc6d53f15eb88f4320054569b8c553d4-b72fa28504b07285-1",
 			baggageStr: "sentry-trace_id=d49d9bf66f13450b81f65bc51cf49c03,sentry-public_key=public,sentry-sample_rate=1",
 			wantSpan: &Span{
-				TraceID:      TraceIDFromHex("bc6d53f15eb88f4320054569b8c553d4"),
-				ParentSpanID: SpanIDFromHex("b72fa28504b07285"),
-				Sampled:      1,
+				TraceID:          TraceIDFromHex("bc6d53f15eb88f4320054569b8c553d4"),
+				ParentSpanID:     SpanIDFromHex("b72fa28504b07285"),
+				Sampled:          1,
+				sampleRateSource: sampleRateFromParent,
 				dynamicSamplingContext: DynamicSamplingContext{
 					Frozen: true,
 					Entries: map[string]string{
@@ -644,6 +689,11 @@ func TestContinueTransactionFromHeaders(t *testing.T) {
 						"trace_id":    "d49d9bf66f13450b81f65bc51cf49c03",
 					},
 				},
+				incomingSamplingEntries: map[string]string{
+					"public_key":  "public",
+					"sample_rate": "1",
+					"trace_id":    "d49d9bf66f13450b81f65bc51cf49c03",
+				},
 			},
 		},
 	}
@@ -737,6 +787,76 @@ func TestDoubleSampling(t *testing.T) {
 	}
 }
 
+func TestSampleRand(t *testing.T) {
+	ctx := NewTestContext(ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 0.5,
+	})
+
+	// Head of trace: the decision follows the generated value, which is
+	// propagated.
+	for i := 0; i < 20; i++ {
+		transaction := StartTransaction(ctx, "name")
+		if got, want := transaction.Sampled.Bool(), transaction.sampleRand < 0.5; got != want {
+			t.Fatalf("Sampled = %t with sample_rand %v and sample rate 0.5", got, transaction.sampleRand)
+		}
+		if got := transaction.StartChild("op").sampleRand; got != transaction.sampleRand {
+			t.Errorf("child span sample_rand = %v, want %v", got, transaction.sampleRand)
+		}
+		dsc := DynamicSamplingContextFromTransaction(transaction)
+		if got := dsc.Entries["sample_rand"]; got != formatSampleRand(transaction.sampleRand) {
+			t.Errorf("sample_rand = %q, want %q", got, formatSampleRand(transaction.sampleRand))
+		}
+	}
+
+	// An incoming sample_rand decides.
+	for baggage, want := range map[string]Sampled{
+		"sentry-sample_rand=0.1": SampledTrue,
+		"sentry-sample_rand=0.9": SampledFalse,
+	} {
+		transaction := StartTransaction(ctx, "name", ContinueFromHeaders("", baggage))
+		if transaction.Sampled != want {
+			t.Errorf("baggage %q: Sampled = %s, want %s", baggage, transaction.Sampled, want)
+		}
+		if got := transaction.ToBaggage(); got != baggage {
+			t.Errorf("baggage %q: propagated %q", baggage, got)
+		}
+	}
+
+	// Without one, the value is consistent with the upstream decision and
+	// propagated further.
+	transaction := StartTransaction(ctx, "name", ContinueFromHeaders(
+		"d49d9bf66f13450b81f65bc51cf49c03-a9f442f9330b4e09-1", "sentry-sample_rate=0.25"))
+	if transaction.sampleRand >= 0.25 {
+		t.Errorf("sample_rand = %v, want less than the upstream sample rate 0.25", transaction.sampleRand)
+	}
+	if got := transaction.dynamicSamplingContext.Entries["sample_rand"]; got != formatSampleRand(transaction.sampleRand) {
+		t.Errorf("propagated sample_rand = %q", got)
+	}
+
+	for _, tt := range []struct {
+		r    float64
+		want string
+	}{
+		{0.9999999, "0.999999"},
+		{0.1234567, "0.123456"},
+		{0.5, "0.500000"},
+	} {
+		if got := formatSampleRand(truncateSampleRand(tt.r)); got != tt.want {
+			t.Errorf("sample_rand %v is propagated as %q, want %q", tt.r, got, tt.want)
+		}
+	}
+
+	// The decision is based on the propagated value: with the rate between
+	// the incoming value and its truncation, the trace is sampled.
+	transaction = StartTransaction(NewTestContext(ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 0.1234565,
+	}), "name", ContinueFromHeaders("", "sentry-sample_rand=0.1234567"))
+	assertEqual(t, transaction.Sampled, SampledTrue)
+	assertEqual(t, transaction.sampleRand, 0.123456)
+}
+
 func TestSample(t *testing.T) {
 	var ctx context.Context
 	var span *Span
@@ -803,6 +923,44 @@ func TestDoesNotCrashWithEmptyContext(_ *testing.T) {
 	tx.Finish()
 }
 
+func TestToBaggageIsCachedOnTransaction(t *testing.T) {
+	ctx := NewTestContext(ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		Release:          "test-release",
+	})
+	transaction := StartTransaction(ctx, "transaction-name")
+	child := transaction.StartChild("op-name")
+
+	// The DSC is created on the transaction even if a child asks first.
+	baggage := child.ToBaggage()
+	if !transaction.dynamicSamplingContext.IsFrozen() {
+		t.Fatal("the DSC of the transaction was not frozen")
+	}
+	assertEqual(t, transaction.ToBaggage(), baggage)
+	assertEqual(t, child.dynamicSamplingContext, DynamicSamplingContext{})
+
+	// Later changes to the transaction don't affect the frozen DSC.
+	transaction.Name = "renamed"
+	assertEqual(t, transaction.ToBaggage(), baggage)
+	if n := testing.AllocsPerRun(10, func() { child.ToBaggage() }); n != 0 {
+		t.Errorf("ToBaggage allocated %v times with a cached DSC", n)
+	}
+
+	// Setting a DSC replaces the cached one.
+	transaction.SetDynamicSamplingContext(DynamicSamplingContext{
+		Entries: map[string]string{"release": "other"},
+		Frozen:  true,
+	})
+	assertEqual(t, child.ToBaggage(), "sentry-release=other")
+
+	// Error events captured during the transaction carry the same DSC.
+	scope := NewScope()
+	scope.SetSpan(child)
+	event := scope.ApplyToEvent(NewEvent(), nil, nil)
+	assertEqual(t, event.sdkMetaData.dsc.Entries, map[string]string{"release": "other"})
+}
+
 func TestSetDynamicSamplingContextWorksOnTransaction(t *testing.T) {
 	s := Span{
 		dynamicSamplingContext: DynamicSamplingContext{Frozen: false},
@@ -815,7 +973,7 @@ func TestSetDynamicSamplingContextWorksOnTransaction(t *testing.T) {
 
 	s.SetDynamicSamplingContext(newDsc)
 
-	if diff := cmp.Diff(newDsc, s.dynamicSamplingContext); diff != "" {
+	if diff := cmp.Diff(newDsc, s.dynamicSamplingContext, cmp.AllowUnexported(DynamicSamplingContext{})); diff != "" {
 		t.Errorf("DynamicSamplingContext mismatch (-want +got):\n%s", diff)
 	}
 }
@@ -833,7 +991,7 @@ func TestSetDynamicSamplingContextDoesNothingOnSpan(t *testing.T) {
 
 	s.SetDynamicSamplingContext(newDsc)
 
-	if diff := cmp.Diff(DynamicSamplingContext{}, s.dynamicSamplingContext); diff != "" {
+	if diff := cmp.Diff(DynamicSamplingContext{}, s.dynamicSamplingContext, cmp.AllowUnexported(DynamicSamplingContext{})); diff != "" {
 		t.Errorf("DynamicSamplingContext mismatch (-want +got):\n%s", diff)
 	}
 }
@@ -929,11 +1087,12 @@ func TestToBaggage(t *testing.T) {
 	})
 	transaction := StartTransaction(ctx, "transaction-name")
 	transaction.TraceID = TraceIDFromHex("f1a4c5c9071eca1cdf04e4132527ed16")
+	transaction.sampleRand = 0.5
 
 	assertBaggageStringsEqual(
 		t,
 		transaction.ToBaggage(),
-		"sentry-trace_id=f1a4c5c9071eca1cdf04e4132527ed16,sentry-release=test-release,sentry-transaction=transaction-name,sentry-sample_rate=1,sentry-sampled=true",
+		"sentry-trace_id=f1a4c5c9071eca1cdf04e4132527ed16,sentry-release=test-release,sentry-transaction=transaction-name,sentry-sample_rate=1,sentry-sampled=true,sentry-sample_rand=0.500000",
 	)
 
 	// Calling ToBaggage() on a child span should return the same result
@@ -941,7 +1100,7 @@ func TestToBaggage(t *testing.T) {
 	assertBaggageStringsEqual(
 		t,
 		child.ToBaggage(),
-		"sentry-trace_id=f1a4c5c9071eca1cdf04e4132527ed16,sentry-release=test-release,sentry-transaction=transaction-name,sentry-sample_rate=1,sentry-sampled=true",
+		"sentry-trace_id=f1a4c5c9071eca1cdf04e4132527ed16,sentry-release=test-release,sentry-transaction=transaction-name,sentry-sample_rate=1,sentry-sampled=true,sentry-sample_rand=0.500000",
 	)
 }
 
@@ -1091,3 +1250,116 @@ func TestSpanFinishConcurrentlyWithoutRaces(_ *testing.T) {
 
 	time.Sleep(50 * time.Millisecond)
 }
+
+func BenchmarkStartTransaction(b *testing.B) {
+	ctx := NewTestContext(ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		Transport:        &TransportMock{},
+	})
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		StartTransaction(ctx, "transaction")
+	}
+}
+
+func BenchmarkStartSpan(b *testing.B) {
+	ctx := NewTestContext(ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		Transport:        &TransportMock{},
+	})
+	var transaction *Span
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// Start a new transaction every few hundred spans, like a request
+		// would.
+		if i%500 == 0 {
+			b.StopTimer()
+			transaction = StartTransaction(ctx, "transaction")
+			b.StartTimer()
+		}
+		StartSpan(transaction.Context(), "op", WithDescription("description")).Finish()
+	}
+}
+
+type testClock struct{ now time.Time }
+
+func (c *testClock) Now() time.Time { return c.now }
+
+func TestSpanClock(t *testing.T) {
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	clock := &testClock{now: start}
+	ctx := NewTestContext(ClientOptions{EnableTracing: true, TracesSampleRate: 1.0, Clock: clock})
+
+	transaction := StartTransaction(ctx, "transaction")
+	child := transaction.StartChild("child")
+	assertEqual(t, transaction.StartTime, start)
+	assertEqual(t, child.StartTime, start)
+
+	clock.now = start.Add(time.Second)
+	child.Finish()
+	assertEqual(t, child.EndTime, start.Add(time.Second))
+
+	// A clock turned back doesn't produce a negative duration.
+	clock.now = start.Add(-time.Minute)
+	transaction.Finish()
+	assertEqual(t, transaction.EndTime, start)
+}
+
+func TestSpanExplicitTimes(t *testing.T) {
+	ctx := NewTestContext(ClientOptions{EnableTracing: true, TracesSampleRate: 1.0})
+	transport := hubFromContext(ctx).Client().Transport.(*TransportMock)
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	transaction := StartTransaction(ctx, "batch job", WithStartTime(start))
+	child := transaction.StartChild("step", WithStartTime(start.Add(time.Second)))
+	child.FinishWithTime(start.Add(3 * time.Second))
+	child.FinishWithTime(start.Add(time.Hour))
+	transaction.FinishWithTime(start.Add(-time.Second))
+
+	assertEqual(t, child.StartTime, start.Add(time.Second))
+	assertEqual(t, child.EndTime, start.Add(3*time.Second))
+	assertEqual(t, transaction.EndTime, start)
+	event := transport.lastEvent
+	assertEqual(t, event.StartTime, start)
+	assertEqual(t, len(event.Spans), 1)
+}
+
+type sequentialIDGenerator struct {
+	mu   sync.Mutex
+	next byte
+}
+
+func (g *sequentialIDGenerator) TraceID() (id TraceID) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.next++
+	id[len(id)-1] = g.next
+	return id
+}
+
+func (g *sequentialIDGenerator) SpanID() (id SpanID) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.next++
+	id[len(id)-1] = g.next
+	return id
+}
+
+func TestSpanIDGenerator(t *testing.T) {
+	ctx := NewTestContext(ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		IDGenerator:      &sequentialIDGenerator{},
+	})
+	transaction := StartTransaction(ctx, "transaction")
+	child := transaction.StartChild("child")
+
+	assertEqual(t, transaction.SpanID.String(), "0000000000000001")
+	assertEqual(t, transaction.TraceID.String(), "00000000000000000000000000000002")
+	assertEqual(t, child.SpanID.String(), "0000000000000003")
+	assertEqual(t, child.TraceID, transaction.TraceID)
+}
@@ -0,0 +1,169 @@
+package sentry
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// defaultTransactionAggregationInterval is the interval of
+// ClientOptions.AggregateTransactionsShorterThan unless
+// TransactionAggregationInterval is set.
+const defaultTransactionAggregationInterval = 10 * time.Second
+
+// aggregationBuckets are the upper bounds of the buckets of the duration
+// histogram of aggregated transactions. Durations above the last bound are
+// counted in an additional bucket.
+var aggregationBuckets = [...]time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+}
+
+// transactionAggregator merges short transactions sharing a name, see
+// ClientOptions.AggregateTransactionsShorterThan.
+type transactionAggregator struct {
+	threshold time.Duration
+	interval  time.Duration
+
+	mu sync.Mutex
+	// start is the beginning of the current interval.
+	start      time.Time
+	aggregates map[string]*transactionAggregate
+}
+
+// transactionAggregate is the summary of the transactions of one name merged
+// within an interval.
+type transactionAggregate struct {
+	// template is the first merged transaction, the summary is sent as a
+	// copy of it.
+	template        *Event
+	count           int
+	total, min, max time.Duration
+	// buckets counts the durations of the transactions by
+	// aggregationBuckets.
+	buckets    [len(aggregationBuckets) + 1]int
+	start, end time.Time
+}
+
+func newTransactionAggregator(threshold, interval time.Duration) *transactionAggregator {
+	if interval <= 0 {
+		interval = defaultTransactionAggregationInterval
+	}
+	return &transactionAggregator{
+		threshold:  threshold,
+		interval:   interval,
+		aggregates: make(map[string]*transactionAggregate),
+	}
+}
+
+// add merges the transaction event into the summary of its name, unless it
+// is too long or carries a profile, and reports whether it did. When now
+// starts a new interval, it also returns the summaries of the previous one.
+func (a *transactionAggregator) add(event *Event, now time.Time) (bool, []*Event) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var summaries []*Event
+	if now.Sub(a.start) >= a.interval {
+		summaries = a.drainLocked()
+		a.start = now
+	}
+
+	duration := event.Timestamp.Sub(event.StartTime)
+	if duration < 0 || duration >= a.threshold || event.sdkMetaData.transactionProfile != nil {
+		return false, summaries
+	}
+
+	g, ok := a.aggregates[event.Transaction]
+	if !ok {
+		g = &transactionAggregate{template: event, min: duration, start: event.StartTime, end: event.Timestamp}
+		a.aggregates[event.Transaction] = g
+	}
+	g.count++
+	g.total += duration
+	if duration < g.min {
+		g.min = duration
+	}
+	if duration > g.max {
+		g.max = duration
+	}
+	g.buckets[sort.Search(len(aggregationBuckets), func(i int) bool { return duration <= aggregationBuckets[i] })]++
+	if event.StartTime.Before(g.start) {
+		g.start = event.StartTime
+	}
+	if event.Timestamp.After(g.end) {
+		g.end = event.Timestamp
+	}
+	return true, summaries
+}
+
+// drain returns the summaries of the transactions merged in the current
+// interval so far, and resets them.
+func (a *transactionAggregator) drain() []*Event {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.drainLocked()
+}
+
+func (a *transactionAggregator) drainLocked() []*Event {
+	if len(a.aggregates) == 0 {
+		return nil
+	}
+	summaries := make([]*Event, 0, len(a.aggregates))
+	for _, g := range a.aggregates {
+		summaries = append(summaries, g.event())
+	}
+	a.aggregates = make(map[string]*transactionAggregate)
+	return summaries
+}
+
+// event returns the summary transaction. It lasts the average duration of
+// the merged transactions, from the start of the first one, and reports the
+// count and durations as measurements.
+func (g *transactionAggregate) event() *Event {
+	summary := *g.template
+	summary.EventID = EventID(uuid())
+	summary.Spans = nil
+	summary.Attachments = nil
+	summary.StartTime = g.start
+	summary.Timestamp = g.start.Add(g.total / time.Duration(g.count))
+	summary.sdkMetaData = SDKMetaData{dsc: g.template.sdkMetaData.dsc}
+
+	ms := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+	summary.Measurements = make(map[string]Measurement, len(g.template.Measurements)+5+len(g.buckets))
+	summary.Measurements["aggregate.count"] = Measurement{Value: float64(g.count), Unit: "none"}
+	summary.Measurements["aggregate.duration.min"] = Measurement{Value: ms(g.min), Unit: "millisecond"}
+	summary.Measurements["aggregate.duration.max"] = Measurement{Value: ms(g.max), Unit: "millisecond"}
+	summary.Measurements["aggregate.duration.avg"] = Measurement{Value: ms(g.total / time.Duration(g.count)), Unit: "millisecond"}
+	for i, n := range g.buckets {
+		name := "aggregate.duration.le_inf"
+		if i < len(aggregationBuckets) {
+			name = fmt.Sprintf("aggregate.duration.le_%dms", aggregationBuckets[i].Milliseconds())
+		}
+		summary.Measurements[name] = Measurement{Value: float64(n), Unit: "none"}
+	}
+
+	summary.Tags = make(map[string]string, len(g.template.Tags)+1)
+	for k, v := range g.template.Tags {
+		summary.Tags[k] = v
+	}
+	summary.Tags["aggregated"] = "true"
+	summary.Contexts = make(map[string]Context, len(g.template.Contexts)+1)
+	for k, v := range g.template.Contexts {
+		summary.Contexts[k] = v
+	}
+	summary.Contexts["aggregate"] = Context{
+		"count":        g.count,
+		"window_start": g.start,
+		"window_end":   g.end,
+	}
+	return &summary
+}
@@ -0,0 +1,54 @@
+package sentry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTransactionAggregation(t *testing.T) {
+	transport := &TransportMock{}
+	client, err := NewClient(ClientOptions{
+		EnableTracing:                    true,
+		TracesSampleRate:                 1.0,
+		Transport:                        transport,
+		AggregateTransactionsShorterThan: 100 * time.Millisecond,
+		TransactionAggregationInterval:   time.Hour,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	transaction := func(name string, duration time.Duration) *Event {
+		event := NewEvent()
+		event.Type = transactionType
+		event.Transaction = name
+		event.StartTime = start
+		event.Timestamp = start.Add(duration)
+		return event
+	}
+	scope := NewScope()
+	for _, d := range []time.Duration{time.Millisecond, 3 * time.Millisecond, 8 * time.Millisecond} {
+		client.CaptureEvent(transaction("consume", d), nil, scope)
+	}
+	client.CaptureEvent(transaction("consume", time.Second), nil, scope)
+	assertEqual(t, len(transport.events), 1)
+	assertEqual(t, transport.lastEvent.Timestamp, start.Add(time.Second))
+
+	client.Flush(time.Second)
+	assertEqual(t, len(transport.events), 2)
+	summary := transport.lastEvent
+	assertEqual(t, summary.Transaction, "consume")
+	assertEqual(t, summary.Tags["aggregated"], "true")
+	assertEqual(t, summary.StartTime, start)
+	assertEqual(t, summary.Timestamp, start.Add(4*time.Millisecond))
+	assertEqual(t, summary.Measurements["aggregate.count"].Value, 3.0)
+	assertEqual(t, summary.Measurements["aggregate.duration.min"].Value, 1.0)
+	assertEqual(t, summary.Measurements["aggregate.duration.max"].Value, 8.0)
+	assertEqual(t, summary.Measurements["aggregate.duration.le_1ms"].Value, 1.0)
+	assertEqual(t, summary.Measurements["aggregate.duration.le_5ms"].Value, 1.0)
+	assertEqual(t, summary.Measurements["aggregate.duration.le_10ms"].Value, 1.0)
+	assertEqual(t, summary.Measurements["aggregate.duration.le_inf"].Value, 0.0)
+
+	client.Flush(time.Second)
+	assertEqual(t, len(transport.events), 2)
+}
@@ -0,0 +1,35 @@
+package sentry
+
+import "net/http"
+
+// TransactionNameInfo describes a transaction that is being named, see
+// ClientOptions.TransactionNamer.
+type TransactionNameInfo struct {
+	// Name and Source are the name of the transaction and its source, as
+	// chosen by the integration or the caller of StartTransaction.
+	Name   string
+	Source TransactionSource
+	// Op is the operation of the transaction, such as "http.server".
+	Op string
+	// Request is the request the transaction continues, set by the server
+	// integrations through ContinueFromRequest. May be nil.
+	Request *http.Request
+}
+
+// applyTransactionNamer renames the transaction s with
+// ClientOptions.TransactionNamer, if set.
+func (s *Span) applyTransactionNamer() {
+	request := s.request
+	s.request = nil
+	namer := s.clientOptions().TransactionNamer
+	if namer == nil {
+		return
+	}
+	name, source := namer(TransactionNameInfo{Name: s.Name, Source: s.Source, Op: s.Op, Request: request})
+	if name != "" {
+		s.Name = name
+	}
+	if source != "" {
+		s.Source = source
+	}
+}
@@ -0,0 +1,40 @@
+package sentry
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTransactionNamer(t *testing.T) {
+	var infos []TransactionNameInfo
+	ctx := NewTestContext(ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		TransactionNamer: func(info TransactionNameInfo) (string, TransactionSource) {
+			infos = append(infos, info)
+			if info.Request == nil {
+				return "", ""
+			}
+			return strings.Replace(info.Name, "/v2/", "/", 1), SourceRoute
+		},
+	})
+	r := httptest.NewRequest("GET", "/v2/users", nil)
+
+	transaction := StartTransaction(ctx, "GET /v2/users",
+		WithOpName("http.server"), ContinueFromRequest(r), WithTransactionSource(SourceURL))
+	assertEqual(t, transaction.Name, "GET /users")
+	assertEqual(t, transaction.Source, SourceRoute)
+	if transaction.request != nil {
+		t.Error("the request must not be kept by the transaction")
+	}
+	assertEqual(t, infos[0].Op, "http.server")
+	assertEqual(t, infos[0].Source, SourceURL)
+
+	child := transaction.StartChild("child")
+	job := StartTransaction(ctx, "job")
+	assertEqual(t, job.Name, "job")
+	assertEqual(t, job.Source, SourceCustom)
+	child.Finish()
+	assertEqual(t, len(infos), 2)
+}
@@ -8,8 +8,11 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"net/url"
+	"os"
+	"strconv"
 	"sync"
 	"time"
 
@@ -65,12 +68,83 @@ func getTLSConfig(options ClientOptions) *tls.Config {
 	return nil
 }
 
+// maxEventBytes is the maximum size of a serialized event accepted by Sentry.
+// Larger events are truncated before sending, as they would otherwise be
+// rejected as a whole.
+//
+// See https://develop.sentry.dev/sdk/envelopes/#size-limits.
+const maxEventBytes = 1 << 20
+
+// bufferPool holds the buffers events are encoded into before they are
+// copied into an envelope.
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+// EventEncoder encodes an event as JSON to w, see ClientOptions.EventEncoder.
+//
+// value encodes to the JSON of the event with encoding/json, without calling
+// the MarshalJSON methods of the event, its breadcrumbs and its spans on the
+// way. A reflection based encoder, such as github.com/goccy/go-json or
+// github.com/bytedance/sonic, can encode it instead of encoding/json:
+//
+//	EventEncoder: func(w io.Writer, _ *sentry.Event, value interface{}) error {
+//		return gojson.NewEncoder(w).Encode(value)
+//	}
+//
+// A hand-rolled encoder writes the fields of event instead, following the
+// JSON of Event.MarshalJSON.
+type EventEncoder func(w io.Writer, event *Event, value interface{}) error
+
+// encodeEvent encodes event into b with encoder, or encoding/json if encoder
+// is nil, without trailing newlines.
+func encodeEvent(b *bytes.Buffer, event *Event, encoder EventEncoder) error {
+	v, err := event.jsonValue(true)
+	if err != nil {
+		return err
+	}
+	if encoder != nil {
+		err = encoder(b, event, v)
+	} else {
+		err = json.NewEncoder(b).Encode(v)
+	}
+	if err != nil {
+		return err
+	}
+	for b.Len() > 0 && b.Bytes()[b.Len()-1] == '\n' {
+		b.Truncate(b.Len() - 1)
+	}
+	return nil
+}
+
+// getRequestBodyFromEvent returns the JSON of event, fitted into the size
+// limit of events.
 func getRequestBodyFromEvent(event *Event) []byte {
-	body, err := json.Marshal(event)
+	var b bytes.Buffer
+	return getRequestBodyFromEventBuffer(event, &b, nil)
+}
+
+// getRequestBodyFromEventBuffer is like getRequestBodyFromEvent, using b and
+// encoder to encode the event. The result may be backed by b.
+func getRequestBodyFromEventBuffer(event *Event, b *bytes.Buffer, encoder EventEncoder) []byte {
+	err := encodeEvent(b, event, encoder)
 	if err == nil {
-		return body
+		return fitEventBody(event, b.Bytes(), maxEventBytes)
+	}
+	if encoder != nil {
+		Logger.Printf("EventEncoder failed, encoding event %s with encoding/json: %v", event.EventID, err)
+		b.Reset()
+		if err := encodeEvent(b, event, nil); err == nil {
+			return fitEventBody(event, b.Bytes(), maxEventBytes)
+		}
 	}
 
+	// Marshal the event once more for an error message naming the type that
+	// failed.
+	_, err = json.Marshal(event)
+
 	msg := fmt.Sprintf("Could not encode original event as JSON. "+
 		"Succeeded by removing Breadcrumbs, Contexts and Extra. "+
 		"Please verify the data you attach to the scope. "+
@@ -81,7 +155,7 @@ func getRequestBodyFromEvent(event *Event) []byte {
 	event.Extra = map[string]interface{}{
 		"info": msg,
 	}
-	body, err = json.Marshal(event)
+	body, err := json.Marshal(event)
 	if err == nil {
 		Logger.Println(msg)
 		return body
@@ -95,6 +169,136 @@ func getRequestBodyFromEvent(event *Event) []byte {
 	return nil
 }
 
+// truncatedExtraKey is the extra under which fitEventBody records what it
+// removed from an event.
+const truncatedExtraKey = "sentry.truncated"
+
+// fitEventBody progressively removes data from the event until its serialized
+// form fits into limit bytes. On each step, it drops either the largest extra
+// or the oldest half of the breadcrumbs, whichever is bigger, and finally the
+// data of spans. What was removed is recorded in the "sentry.truncated" extra.
+// If the event cannot be made small enough, the smallest body that could be
+// produced is returned. event itself is left unchanged.
+func fitEventBody(event *Event, body []byte, limit int) []byte {
+	if len(body) <= limit {
+		return body
+	}
+
+	var truncated []string
+	// Work on copies, the event is still used by the caller, and its maps,
+	// slices and spans might be shared with a scope or held by the user.
+	trimmed := *event
+	event = &trimmed
+	extra := make(map[string]interface{}, len(event.Extra))
+	for k, v := range event.Extra {
+		extra[k] = v
+	}
+	event.Extra = extra
+	event.Breadcrumbs = append([]*Breadcrumb(nil), event.Breadcrumbs...)
+
+	for len(body) > limit {
+		key, extraSize := largestExtra(extra)
+		// Drop the oldest half of the breadcrumbs at once to bound the number
+		// of times the event is serialized.
+		n := (len(event.Breadcrumbs) + 1) / 2
+		crumbsSize := 0
+		if n > 0 {
+			crumbsSize = jsonSize(event.Breadcrumbs[:n])
+		}
+
+		switch {
+		case key != "" && extraSize >= crumbsSize:
+			delete(extra, key)
+			truncated = append(truncated, "extra."+key)
+		case n > 0:
+			event.Breadcrumbs = event.Breadcrumbs[n:]
+			truncated = append(truncated, fmt.Sprintf("breadcrumbs:%d", n))
+		case spansHaveData(event.Spans):
+			spans := make([]*Span, len(event.Spans))
+			for i, span := range event.Spans {
+				spans[i] = spanWithoutData(span)
+			}
+			event.Spans = spans
+			truncated = append(truncated, "spans.data")
+		default:
+			Logger.Printf("Event %s is %d bytes, above the size limit of %d bytes, even after truncation.",
+				event.EventID, len(body), limit)
+			return body
+		}
+
+		extra[truncatedExtraKey] = truncated
+		b, err := json.Marshal(event)
+		if err != nil {
+			return body
+		}
+		body = b
+	}
+
+	Logger.Printf("Event %s exceeded the size limit of %d bytes and was truncated: %v", event.EventID, limit, truncated)
+	return body
+}
+
+// largestExtra returns the key and serialized size of the largest extra,
+// ignoring the truncation marker. The key is empty if there are no candidates.
+func largestExtra(extra map[string]interface{}) (string, int) {
+	var key string
+	size := -1
+	for k, v := range extra {
+		if k == truncatedExtraKey {
+			continue
+		}
+		n := jsonSize(v)
+		if n > size || (n == size && k < key) {
+			key, size = k, n
+		}
+	}
+	return key, size
+}
+
+// jsonSize returns the length of the JSON encoding of v, or math.MaxInt if v
+// cannot be encoded.
+func jsonSize(v interface{}) int {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return math.MaxInt
+	}
+	return len(b)
+}
+
+func spansHaveData(spans []*Span) bool {
+	for _, span := range spans {
+		span.mu.RLock()
+		n := len(span.Data)
+		span.mu.RUnlock()
+		if n > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// spanWithoutData returns a copy of the serialized fields of span, without
+// its data.
+func spanWithoutData(span *Span) *Span {
+	span.mu.RLock()
+	defer span.mu.RUnlock()
+	return &Span{
+		TraceID:      span.TraceID,
+		SpanID:       span.SpanID,
+		ParentSpanID: span.ParentSpanID,
+		Name:         span.Name,
+		Op:           span.Op,
+		Description:  span.Description,
+		Status:       span.Status,
+		Tags:         span.Tags,
+		StartTime:    span.StartTime,
+		EndTime:      span.EndTime,
+		Sampled:      span.Sampled,
+		Source:       span.Source,
+		Origin:       span.Origin,
+	}
+}
+
 func marshalMetrics(metrics []Metric) []byte {
 	var b bytes.Buffer
 	for i, metric := range metrics {
@@ -115,175 +319,267 @@ func marshalMetrics(metrics []Metric) []byte {
 	return b.Bytes()
 }
 
-func encodeMetric(enc *json.Encoder, b io.Writer, metrics []Metric) error {
-	body := marshalMetrics(metrics)
-	// Item header
-	err := enc.Encode(struct {
-		Type   string `json:"type"`
-		Length int    `json:"length"`
-	}{
-		Type:   metricType,
-		Length: len(body),
-	})
-	if err != nil {
-		return err
-	}
-
-	// metric payload
-	if _, err = b.Write(body); err != nil {
-		return err
-	}
-
-	// "Envelopes should be terminated with a trailing newline."
-	//
-	// [1]: https://develop.sentry.dev/sdk/envelopes/#envelopes
-	if _, err := b.Write([]byte("\n")); err != nil {
-		return err
-	}
-
-	return err
+func encodeMetric(b *bytes.Buffer, metrics []Metric) {
+	encodeEnvelopeItem(b, metricType, marshalMetrics(metrics))
 }
 
-func encodeAttachment(enc *json.Encoder, b io.Writer, attachment *Attachment) error {
-	// Attachment header
-	err := enc.Encode(struct {
+// defaultMaxAttachmentSize is the default of Attachment.MaxSize.
+const defaultMaxAttachmentSize = 20 << 20
+
+func encodeAttachmentHeader(enc *json.Encoder, attachment *Attachment, length int64) error {
+	return enc.Encode(struct {
 		Type        string `json:"type"`
-		Length      int    `json:"length"`
+		Length      int64  `json:"length"`
 		Filename    string `json:"filename"`
 		ContentType string `json:"content_type,omitempty"`
 	}{
 		Type:        "attachment",
-		Length:      len(attachment.Payload),
+		Length:      length,
 		Filename:    attachment.Filename,
 		ContentType: attachment.ContentType,
 	})
-	if err != nil {
-		return err
-	}
+}
 
-	// Attachment payload
-	if _, err = b.Write(attachment.Payload); err != nil {
-		return err
+type sizer interface {
+	Size() int64
+}
+
+type statter interface {
+	Stat() (os.FileInfo, error)
+}
+
+// attachmentPayload returns the payload of attachment, either as data or, for
+// a Reader that can be streamed, as a reader of the given length.
+func attachmentPayload(attachment *Attachment) (data []byte, stream io.Reader, length int64, err error) {
+	if attachment.Reader == nil {
+		return attachment.Payload, nil, int64(len(attachment.Payload)), nil
+	}
+	maxSize := attachment.MaxSize
+	if maxSize <= 0 {
+		maxSize = defaultMaxAttachmentSize
 	}
 
-	// "Envelopes should be terminated with a trailing newline."
-	//
-	// [1]: https://develop.sentry.dev/sdk/envelopes/#envelopes
-	if _, err := b.Write([]byte("\n")); err != nil {
-		return err
+	if r, ok := attachment.Reader.(io.ReaderAt); ok {
+		if size, ok := readerSize(attachment.Reader); ok {
+			var offset int64
+			if size > maxSize {
+				offset, size = size-maxSize, maxSize
+			}
+			return nil, &paddedReader{r: io.NewSectionReader(r, offset, size), n: size}, size, nil
+		}
 	}
 
-	return nil
+	data, err = io.ReadAll(io.LimitReader(attachment.Reader, maxSize))
+	return data, nil, int64(len(data)), err
 }
 
-func encodeEnvelopeItem(enc *json.Encoder, itemType string, body json.RawMessage) error {
-	// Item header
-	err := enc.Encode(struct {
-		Type   string `json:"type"`
-		Length int    `json:"length"`
-	}{
-		Type:   itemType,
-		Length: len(body),
-	})
-	if err == nil {
-		// payload
-		err = enc.Encode(body)
+// readerSize reports the size of the content of r, if it is known.
+func readerSize(r io.Reader) (int64, bool) {
+	switch r := r.(type) {
+	case sizer:
+		return r.Size(), true
+	case statter:
+		info, err := r.Stat()
+		if err != nil || !info.Mode().IsRegular() {
+			return 0, false
+		}
+		return info.Size(), true
 	}
-	return err
+	return 0, false
 }
 
-func envelopeFromBody(event *Event, dsn *Dsn, sentAt time.Time, body json.RawMessage) (*bytes.Buffer, error) {
-	var b bytes.Buffer
-	enc := json.NewEncoder(&b)
+// paddedReader reads exactly n bytes from r, padding its content with zeros
+// if it ends early, for example because a log file was truncated after its
+// size was announced in the envelope.
+type paddedReader struct {
+	r io.Reader
+	n int64
+}
 
-	// Construct the trace envelope header
-	var trace = map[string]string{}
-	if dsc := event.sdkMetaData.dsc; dsc.HasEntries() {
-		for k, v := range dsc.Entries {
-			trace[k] = v
+func (p *paddedReader) Read(b []byte) (int, error) {
+	if p.n <= 0 {
+		return 0, io.EOF
+	}
+	if int64(len(b)) > p.n {
+		b = b[:p.n]
+	}
+	n, err := p.r.Read(b)
+	if err != nil && n == 0 {
+		// Pad instead of failing the whole request.
+		for i := range b {
+			b[i] = 0
 		}
+		n = len(b)
 	}
+	p.n -= int64(n)
+	return n, nil
+}
 
-	// Envelope header
-	err := enc.Encode(struct {
-		EventID EventID           `json:"event_id"`
-		SentAt  time.Time         `json:"sent_at"`
-		Dsn     string            `json:"dsn"`
-		Sdk     map[string]string `json:"sdk"`
-		Trace   map[string]string `json:"trace,omitempty"`
-	}{
-		EventID: event.EventID,
-		SentAt:  sentAt,
-		Trace:   trace,
-		Dsn:     dsn.String(),
-		Sdk: map[string]string{
-			"name":    event.Sdk.Name,
-			"version": event.Sdk.Version,
-		},
-	})
+// encodeEnvelopeItem writes an item with the given payload to b. The item
+// types are constants that need no escaping, and writing the header directly
+// avoids encoding it with reflection.
+func encodeEnvelopeItem(b *bytes.Buffer, itemType string, body []byte) {
+	// Item header
+	b.WriteString(`{"type":"`)
+	b.WriteString(itemType)
+	b.WriteString(`","length":`)
+	b.WriteString(strconv.Itoa(len(body)))
+	b.WriteString("}\n")
+
+	// Payload. "Envelopes should be terminated with a trailing newline."
+	//
+	// [1]: https://develop.sentry.dev/sdk/envelopes/#envelopes
+	b.Write(body)
+	b.WriteByte('\n')
+}
+
+func envelopeFromBody(event *Event, dsn *Dsn, sentAt time.Time, body json.RawMessage) (*bytes.Buffer, error) {
+	r, _, err := envelopeReader(event, dsn, sentAt, body, nil)
 	if err != nil {
 		return nil, err
 	}
+	if b, ok := r.(*bytes.Buffer); ok {
+		return b, nil
+	}
+	var b bytes.Buffer
+	_, err = b.ReadFrom(r)
+	return &b, err
+}
+
+// envelopeReader returns the envelope of event and its length. The payloads
+// of attachments with streamed Readers are read while reading the envelope,
+// everything else is buffered. If header is not nil, it is called with the
+// envelope header before it is encoded.
+func envelopeReader(event *Event, dsn *Dsn, sentAt time.Time, body json.RawMessage, header func(EnvelopeHeader, *Event)) (io.Reader, int64, error) {
+	// Most of the envelope is the body, reserve room for the headers too.
+	b := bytes.NewBuffer(make([]byte, 0, len(body)+512))
+	enc := json.NewEncoder(b)
+
+	if err := encodeEnvelopeHeader(enc, event, dsn, sentAt, header); err != nil {
+		return nil, 0, err
+	}
 
 	switch event.Type {
 	case transactionType, checkInType:
-		err = encodeEnvelopeItem(enc, event.Type, body)
+		encodeEnvelopeItem(b, event.Type, body)
 	case metricType:
-		err = encodeMetric(enc, &b, event.Metrics)
+		encodeMetric(b, event.Metrics)
 	default:
-		err = encodeEnvelopeItem(enc, eventType, body)
-	}
-
-	if err != nil {
-		return nil, err
+		encodeEnvelopeItem(b, eventType, body)
 	}
 
-	// Attachments
+	// Attachments. Streamed payloads split the envelope into parts.
+	var parts []io.Reader
+	var length int64
 	for _, attachment := range event.Attachments {
-		if err := encodeAttachment(enc, &b, attachment); err != nil {
-			return nil, err
+		data, stream, n, err := attachmentPayload(attachment)
+		if err != nil {
+			Logger.Printf("Dropping attachment %q: %v", attachment.Filename, err)
+			continue
+		}
+		if err := encodeAttachmentHeader(enc, attachment, n); err != nil {
+			return nil, 0, err
 		}
+		if stream == nil {
+			b.Write(data)
+		} else {
+			length += int64(b.Len()) + n
+			parts = append(parts, b, stream)
+			b = new(bytes.Buffer)
+			enc = json.NewEncoder(b)
+		}
+		// "Envelopes should be terminated with a trailing newline."
+		//
+		// [1]: https://develop.sentry.dev/sdk/envelopes/#envelopes
+		b.WriteByte('\n')
 	}
 
 	// Profile data
 	if event.sdkMetaData.transactionProfile != nil {
-		body, err = json.Marshal(event.sdkMetaData.transactionProfile)
+		body, err := json.Marshal(event.sdkMetaData.transactionProfile)
 		if err != nil {
-			return nil, err
-		}
-		err = encodeEnvelopeItem(enc, profileType, body)
-		if err != nil {
-			return nil, err
+			return nil, 0, err
 		}
+		encodeEnvelopeItem(b, profileType, body)
 	}
 
-	return &b, nil
+	if len(parts) == 0 {
+		return b, int64(b.Len()), nil
+	}
+	length += int64(b.Len())
+	return io.MultiReader(append(parts, b)...), length, nil
 }
 
-func getRequestFromEvent(ctx context.Context, event *Event, dsn *Dsn) (r *http.Request, err error) {
-	defer func() {
-		if r != nil {
-			r.Header.Set("User-Agent", fmt.Sprintf("%s/%s", event.Sdk.Name, event.Sdk.Version))
-			r.Header.Set("Content-Type", "application/x-sentry-envelope")
+// EnvelopeHeader is the header of an envelope, keyed by field name, such as
+// "event_id", "sent_at", "dsn", "sdk" and "trace". The values are encoded
+// with encoding/json. See ClientOptions.EnvelopeHeader.
+type EnvelopeHeader map[string]interface{}
+
+// encodeEnvelopeHeader writes the header of the envelope of event to enc.
+// The trace header holds the entries of the dynamic sampling context, they
+// are only read while encoding.
+func encodeEnvelopeHeader(enc *json.Encoder, event *Event, dsn *Dsn, sentAt time.Time, header func(EnvelopeHeader, *Event)) error {
+	sdk := envelopeSdk{
+		Name:    event.Sdk.Name,
+		Version: event.Sdk.Version,
+	}
+	if header != nil {
+		h := EnvelopeHeader{
+			"event_id": event.EventID,
+			"sent_at":  sentAt,
+			"dsn":      dsn.String(),
+			"sdk":      sdk,
+		}
+		if entries := event.sdkMetaData.dsc.Entries; len(entries) > 0 {
+			// The entries are shared by the events of the trace, the hook
+			// gets a copy it may change.
+			trace := make(map[string]string, len(entries))
+			for k, v := range entries {
+				trace[k] = v
+			}
+			h["trace"] = trace
+		}
+		header(h, event)
+		return enc.Encode(h)
+	}
 
-			auth := fmt.Sprintf("Sentry sentry_version=%s, "+
-				"sentry_client=%s/%s, sentry_key=%s", apiVersion, event.Sdk.Name, event.Sdk.Version, dsn.publicKey)
+	return enc.Encode(struct {
+		EventID EventID           `json:"event_id"`
+		SentAt  time.Time         `json:"sent_at"`
+		Dsn     string            `json:"dsn"`
+		Sdk     envelopeSdk       `json:"sdk"`
+		Trace   map[string]string `json:"trace,omitempty"`
+	}{
+		EventID: event.EventID,
+		SentAt:  sentAt,
+		Trace:   event.sdkMetaData.dsc.Entries,
+		Dsn:     dsn.String(),
+		Sdk:     sdk,
+	})
+}
 
-			// The key sentry_secret is effectively deprecated and no longer needs to be set.
-			// However, since it was required in older self-hosted versions,
-			// it should still passed through to Sentry if set.
-			if dsn.secretKey != "" {
-				auth = fmt.Sprintf("%s, sentry_secret=%s", auth, dsn.secretKey)
-			}
+// envelopeSdk is the sdk header of an envelope.
+type envelopeSdk struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
 
-			r.Header.Set("X-Sentry-Auth", auth)
+func getRequestFromEvent(ctx context.Context, event *Event, dsn *Dsn, encoder EventEncoder, header func(EnvelopeHeader, *Event), sentAt time.Time) (r *http.Request, err error) {
+	defer func() {
+		if r != nil {
+			setEnvelopeRequestHeaders(r, dsn, event.Sdk.Name, event.Sdk.Version)
 		}
 	}()
-	body := getRequestBodyFromEvent(event)
+	// The body is copied into the envelope, its buffer can be reused right
+	// after.
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer bufferPool.Put(buf)
+	body := getRequestBodyFromEventBuffer(event, buf, encoder)
 	if body == nil {
 		return nil, errors.New("event could not be marshaled")
 	}
-	envelope, err := envelopeFromBody(event, dsn, time.Now(), body)
+	envelope, length, err := envelopeReader(event, dsn, sentAt, body, header)
 	if err != nil {
 		return nil, err
 	}
@@ -292,12 +588,38 @@ func getRequestFromEvent(ctx context.Context, event *Event, dsn *Dsn) (r *http.R
 		ctx = context.Background()
 	}
 
-	return http.NewRequestWithContext(
+	r, err = http.NewRequestWithContext(
 		ctx,
 		http.MethodPost,
 		dsn.GetAPIURL().String(),
 		envelope,
 	)
+	if err != nil {
+		return nil, err
+	}
+	// The length of streamed envelopes is known up front, avoid chunked
+	// encoding for them.
+	r.ContentLength = length
+	return r, nil
+}
+
+// setEnvelopeRequestHeaders sets the headers of a request that sends an
+// envelope of the SDK with the given name and version to dsn.
+func setEnvelopeRequestHeaders(r *http.Request, dsn *Dsn, sdkName, sdkVersion string) {
+	r.Header.Set("User-Agent", fmt.Sprintf("%s/%s", sdkName, sdkVersion))
+	r.Header.Set("Content-Type", "application/x-sentry-envelope")
+
+	auth := fmt.Sprintf("Sentry sentry_version=%s, "+
+		"sentry_client=%s/%s, sentry_key=%s", apiVersion, sdkName, sdkVersion, dsn.publicKey)
+
+	// The key sentry_secret is effectively deprecated and no longer needs to be set.
+	// However, since it was required in older self-hosted versions,
+	// it should still passed through to Sentry if set.
+	if dsn.secretKey != "" {
+		auth = fmt.Sprintf("%s, sentry_secret=%s", auth, dsn.secretKey)
+	}
+
+	r.Header.Set("X-Sentry-Auth", auth)
 }
 
 func categoryFor(eventType string) ratelimit.Category {
@@ -323,8 +645,11 @@ type batch struct {
 }
 
 type batchItem struct {
+	ctx context.Context
+	// request is nil if the event is yet to be encoded.
 	request  *http.Request
 	category ratelimit.Category
+	event    *Event
 }
 
 // HTTPTransport is the default, non-blocking, implementation of Transport.
@@ -350,6 +675,14 @@ type HTTPTransport struct {
 
 	mu     sync.RWMutex
 	limits ratelimit.Map
+
+	onEventDropped func(*Event, DropReason, string)
+	eventEncoder   EventEncoder
+	envelopeHeader func(EnvelopeHeader, *Event)
+	geoResolver    func(ip string) *Geo
+	stats          transportCounters
+	// clock is ClientOptions.Clock, rate limits expire by it.
+	clock Clock
 }
 
 // NewHTTPTransport returns a new pre-configured instance of HTTPTransport.
@@ -361,8 +694,19 @@ func NewHTTPTransport() *HTTPTransport {
 	return &transport
 }
 
+// now returns the current time of the clock of the transport.
+func (t *HTTPTransport) now() time.Time {
+	return clockOrDefault(t.clock).Now()
+}
+
 // Configure is called by the Client itself, providing it it's own ClientOptions.
 func (t *HTTPTransport) Configure(options ClientOptions) {
+	t.onEventDropped = options.OnEventDropped
+	t.eventEncoder = options.EventEncoder
+	t.envelopeHeader = options.EnvelopeHeader
+	t.geoResolver = options.GeoResolver
+	t.clock = options.Clock
+
 	dsn, err := NewDsn(options.Dsn)
 	if err != nil {
 		Logger.Printf("%v\n", err)
@@ -411,18 +755,27 @@ func (t *HTTPTransport) SendEvent(event *Event) {
 // SendEventWithContext assembles a new packet out of Event and sends it to the remote server.
 func (t *HTTPTransport) SendEventWithContext(ctx context.Context, event *Event) {
 	if t.dsn == nil {
+		dropEvent(t.onEventDropped, event, DropReasonInternalError)
 		return
 	}
 
 	category := categoryFor(event.Type)
 
 	if t.disabled(category) {
+		dropEvent(t.onEventDropped, event, DropReasonRateLimit)
 		return
 	}
 
-	request, err := getRequestFromEvent(ctx, event, t.dsn)
-	if err != nil {
-		return
+	// Events whose location is to be resolved are encoded by the worker,
+	// after resolving it.
+	var request *http.Request
+	if t.geoResolver == nil || geoIP(event) == "" {
+		var err error
+		request, err = getRequestFromEvent(ctx, event, t.dsn, t.eventEncoder, t.envelopeHeader, time.Now())
+		if err != nil {
+			dropEvent(t.onEventDropped, event, DropReasonInternalError)
+			return
+		}
 	}
 
 	// <-t.buffer is equivalent to acquiring a lock to access the current batch.
@@ -440,9 +793,12 @@ func (t *HTTPTransport) SendEventWithContext(ctx context.Context, event *Event)
 
 	select {
 	case b.items <- batchItem{
+		ctx:      ctx,
 		request:  request,
 		category: category,
+		event:    event,
 	}:
+		t.stats.enqueued()
 		var eventType string
 		if event.Type == transactionType {
 			eventType = "transaction"
@@ -457,7 +813,7 @@ func (t *HTTPTransport) SendEventWithContext(ctx context.Context, event *Event)
 			t.dsn.projectID,
 		)
 	default:
-		Logger.Println("Event dropped due to transport buffer being full.")
+		dropEvent(t.onEventDropped, event, DropReasonQueueOverflow)
 	}
 
 	t.buffer <- b
@@ -533,28 +889,47 @@ func (t *HTTPTransport) worker() {
 
 		// Process all batch items.
 		for item := range b.items {
+			t.stats.dequeued()
 			if t.disabled(item.category) {
+				dropEvent(t.onEventDropped, item.event, DropReasonRateLimit)
 				continue
 			}
+			if item.request == nil {
+				request, err := getRequestFromEvent(item.ctx, resolveGeo(t.geoResolver, item.event), t.dsn, t.eventEncoder, t.envelopeHeader, time.Now())
+				if err != nil {
+					dropEvent(t.onEventDropped, item.event, DropReasonInternalError)
+					continue
+				}
+				item.request = request
+			}
 
 			response, err := t.client.Do(item.request)
 			if err != nil {
-				Logger.Printf("There was an issue with sending an event: %v", err)
+				debugLog(LevelError, "Sending event failed", "event_id", item.event.EventID, "error", err)
+				t.stats.recordError(err)
+				dropEvent(t.onEventDropped, item.event, DropReasonNetworkError)
 				continue
 			}
+			t.stats.recordResponse(item.category, response.StatusCode)
+			if reason, dropped := dropReasonFromResponse(response.StatusCode); dropped {
+				dropEvent(t.onEventDropped, item.event, reason)
+			} else {
+				item.event.sdkMetaData.delivery.resolve(DeliverySent)
+			}
 			if response.StatusCode >= 400 && response.StatusCode <= 599 {
 				b, err := io.ReadAll(response.Body)
 				if err != nil {
 					Logger.Printf("Error while reading response code: %v", err)
 				}
-				Logger.Printf("Sending %s failed with the following error: %s", eventType, string(b))
+				debugLog(LevelError, "Event rejected by Sentry",
+					"event_id", item.event.EventID, "status", response.StatusCode, "response", string(b))
 			}
 
 			t.mu.Lock()
 			if t.limits == nil {
 				t.limits = make(ratelimit.Map)
 			}
-			t.limits.Merge(ratelimit.FromResponse(response))
+			t.limits.Merge(ratelimit.FromResponseAt(response, t.now()))
 			t.mu.Unlock()
 
 			// Drain body up to a limit and close it, allowing the
@@ -568,12 +943,29 @@ func (t *HTTPTransport) worker() {
 	}
 }
 
+func (t *HTTPTransport) counters() *transportCounters {
+	return &t.stats
+}
+
+func (t *HTTPTransport) capacity() int {
+	return t.BufferSize
+}
+
+func (t *HTTPTransport) rateLimits() ratelimit.Map {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	limits := make(ratelimit.Map, len(t.limits))
+	limits.Merge(t.limits)
+	return limits
+}
+
 func (t *HTTPTransport) disabled(c ratelimit.Category) bool {
 	t.mu.RLock()
 	defer t.mu.RUnlock()
-	disabled := t.limits.IsRateLimited(c)
+	disabled := t.limits.IsRateLimitedAt(c, t.now())
 	if disabled {
-		Logger.Printf("Too many requests for %q, backing off till: %v", c, t.limits.Deadline(c))
+		debugLog(LevelWarning, "Rate limited, backing off", "category", c, "until", t.limits.Deadline(c))
 	}
 	return disabled
 }
@@ -601,6 +993,14 @@ type HTTPSyncTransport struct {
 	mu     sync.Mutex
 	limits ratelimit.Map
 
+	onEventDropped func(*Event, DropReason, string)
+	eventEncoder   EventEncoder
+	envelopeHeader func(EnvelopeHeader, *Event)
+	geoResolver    func(ip string) *Geo
+	stats          transportCounters
+	// clock is ClientOptions.Clock, rate limits expire by it.
+	clock Clock
+
 	// HTTP Client request timeout. Defaults to 30 seconds.
 	Timeout time.Duration
 }
@@ -615,8 +1015,19 @@ func NewHTTPSyncTransport() *HTTPSyncTransport {
 	return &transport
 }
 
+// now returns the current time of the clock of the transport.
+func (t *HTTPSyncTransport) now() time.Time {
+	return clockOrDefault(t.clock).Now()
+}
+
 // Configure is called by the Client itself, providing it it's own ClientOptions.
 func (t *HTTPSyncTransport) Configure(options ClientOptions) {
+	t.onEventDropped = options.OnEventDropped
+	t.eventEncoder = options.EventEncoder
+	t.envelopeHeader = options.EnvelopeHeader
+	t.geoResolver = options.GeoResolver
+	t.clock = options.Clock
+
 	dsn, err := NewDsn(options.Dsn)
 	if err != nil {
 		Logger.Printf("%v\n", err)
@@ -651,15 +1062,18 @@ func (t *HTTPSyncTransport) SendEvent(event *Event) {
 // SendEventWithContext assembles a new packet out of Event and sends it to the remote server.
 func (t *HTTPSyncTransport) SendEventWithContext(ctx context.Context, event *Event) {
 	if t.dsn == nil {
+		dropEvent(t.onEventDropped, event, DropReasonInternalError)
 		return
 	}
 
 	if t.disabled(categoryFor(event.Type)) {
+		dropEvent(t.onEventDropped, event, DropReasonRateLimit)
 		return
 	}
 
-	request, err := getRequestFromEvent(ctx, event, t.dsn)
+	request, err := getRequestFromEvent(ctx, resolveGeo(t.geoResolver, event), t.dsn, t.eventEncoder, t.envelopeHeader, time.Now())
 	if err != nil {
+		dropEvent(t.onEventDropped, event, DropReasonInternalError)
 		return
 	}
 
@@ -682,15 +1096,24 @@ func (t *HTTPSyncTransport) SendEventWithContext(ctx context.Context, event *Eve
 
 	response, err := t.client.Do(request)
 	if err != nil {
-		Logger.Printf("There was an issue with sending an event: %v", err)
+		debugLog(LevelError, "Sending event failed", "event_id", event.EventID, "error", err)
+		t.stats.recordError(err)
+		dropEvent(t.onEventDropped, event, DropReasonNetworkError)
 		return
 	}
+	t.stats.recordResponse(categoryFor(event.Type), response.StatusCode)
+	if reason, dropped := dropReasonFromResponse(response.StatusCode); dropped {
+		dropEvent(t.onEventDropped, event, reason)
+	} else {
+		event.sdkMetaData.delivery.resolve(DeliverySent)
+	}
 	if response.StatusCode >= 400 && response.StatusCode <= 599 {
 		b, err := io.ReadAll(response.Body)
 		if err != nil {
 			Logger.Printf("Error while reading response code: %v", err)
 		}
-		Logger.Printf("Sending %s failed with the following error: %s", eventType, string(b))
+		debugLog(LevelError, "Event rejected by Sentry",
+			"event_id", event.EventID, "status", response.StatusCode, "response", string(b))
 	}
 
 	t.mu.Lock()
@@ -698,7 +1121,7 @@ func (t *HTTPSyncTransport) SendEventWithContext(ctx context.Context, event *Eve
 		t.limits = make(ratelimit.Map)
 	}
 
-	t.limits.Merge(ratelimit.FromResponse(response))
+	t.limits.Merge(ratelimit.FromResponseAt(response, t.now()))
 	t.mu.Unlock()
 
 	// Drain body up to a limit and close it, allowing the
@@ -712,12 +1135,30 @@ func (t *HTTPSyncTransport) Flush(_ time.Duration) bool {
 	return true
 }
 
+func (t *HTTPSyncTransport) counters() *transportCounters {
+	return &t.stats
+}
+
+// capacity is 0, events are sent without queueing them.
+func (t *HTTPSyncTransport) capacity() int {
+	return 0
+}
+
+func (t *HTTPSyncTransport) rateLimits() ratelimit.Map {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	limits := make(ratelimit.Map, len(t.limits))
+	limits.Merge(t.limits)
+	return limits
+}
+
 func (t *HTTPSyncTransport) disabled(c ratelimit.Category) bool {
 	t.mu.Lock()
 	defer t.mu.Unlock()
-	disabled := t.limits.IsRateLimited(c)
+	disabled := t.limits.IsRateLimitedAt(c, t.now())
 	if disabled {
-		Logger.Printf("Too many requests for %q, backing off till: %v", c, t.limits.Deadline(c))
+		debugLog(LevelWarning, "Rate limited, backing off", "category", c, "until", t.limits.Deadline(c))
 	}
 	return disabled
 }
@@ -736,8 +1177,9 @@ func (noopTransport) Configure(ClientOptions) {
 	Logger.Println("Sentry client initialized with an empty DSN. Using noopTransport. No events will be delivered.")
 }
 
-func (noopTransport) SendEvent(*Event) {
+func (noopTransport) SendEvent(event *Event) {
 	Logger.Println("Event dropped due to noopTransport usage.")
+	event.sdkMetaData.delivery.resolve(DeliveryDropped)
 }
 
 func (noopTransport) Flush(time.Duration) bool {
@@ -4,15 +4,18 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/http/httptrace"
+	"os"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
+	"testing/iotest"
 	"time"
 
 	"github.com/getsentry/sentry-go/internal/testutils"
@@ -134,6 +137,98 @@ func TestGetRequestBodyFromEventCompletelyInvalid(t *testing.T) {
 	}
 }
 
+func TestGetRequestBodyFromEventTruncatesLargeEvents(t *testing.T) {
+	large := strings.Repeat("x", maxEventBytes/4)
+	breadcrumbs := make([]*Breadcrumb, 8)
+	for i := range breadcrumbs {
+		breadcrumbs[i] = &Breadcrumb{Message: fmt.Sprintf("%d-%s", i, large[:maxEventBytes/6])}
+	}
+	extra := map[string]interface{}{
+		"small":  "value",
+		"large1": large,
+		"large2": large,
+		"large3": large,
+		"large4": large,
+	}
+	event := &Event{
+		Message:     "mkey",
+		Extra:       extra,
+		Breadcrumbs: breadcrumbs,
+	}
+
+	body := getRequestBodyFromEvent(event)
+	if len(body) > maxEventBytes {
+		t.Fatalf("body is %d bytes, want at most %d", len(body), maxEventBytes)
+	}
+
+	var got struct {
+		Extra       map[string]interface{} `json:"extra"`
+		Breadcrumbs []*Breadcrumb          `json:"breadcrumbs"`
+	}
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatal(err)
+	}
+	// Breadcrumbs and large extras are dropped, whichever is bigger first,
+	// before the small extra would be considered.
+	if got.Extra["small"] != "value" {
+		t.Errorf("small extra was dropped: %v", got.Extra)
+	}
+	if _, ok := got.Extra["large1"]; ok {
+		t.Errorf("large extra was kept: %v", got.Extra["sentry.truncated"])
+	}
+	if _, ok := got.Extra["sentry.truncated"]; !ok {
+		t.Errorf("truncation was not recorded: %v", got.Extra)
+	}
+	if len(got.Breadcrumbs) == 0 || len(got.Breadcrumbs) == len(breadcrumbs) {
+		t.Errorf("got %d breadcrumbs, want some of the oldest dropped", len(got.Breadcrumbs))
+	}
+	if last := got.Breadcrumbs[len(got.Breadcrumbs)-1].Message; !strings.HasPrefix(last, "7-") {
+		t.Errorf("newest breadcrumb was dropped, last is %.10q", last)
+	}
+	if len(extra) != 5 {
+		t.Errorf("original extra was modified: %d keys", len(extra))
+	}
+	if len(event.Breadcrumbs) != len(breadcrumbs) || len(event.Extra) != 5 {
+		t.Errorf("original event was modified: %d breadcrumbs, %d extras", len(event.Breadcrumbs), len(event.Extra))
+	}
+}
+
+func TestFitEventBodySpanData(t *testing.T) {
+	span := &Span{Data: map[string]interface{}{"payload": strings.Repeat("x", 1000)}}
+	event := &Event{Type: transactionType, Spans: []*Span{span}}
+	body, err := json.Marshal(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body = fitEventBody(event, body, 500)
+	if len(body) > 500 {
+		t.Fatalf("body is %d bytes, want at most 500", len(body))
+	}
+	if !strings.Contains(string(body), `"sentry.truncated":["spans.data"]`) {
+		t.Errorf("truncation was not recorded: %s", body)
+	}
+	if len(span.Data) == 0 {
+		t.Error("data of the original span was cleared")
+	}
+	if event.Spans[0] != span || event.Extra != nil {
+		t.Errorf("the original event was modified: %+v", event)
+	}
+}
+
+func TestFitEventBodyGivesUp(t *testing.T) {
+	event := &Event{Message: strings.Repeat("x", 1000), Extra: map[string]interface{}{"n": 1}}
+	body, err := json.Marshal(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body = fitEventBody(event, body, 500)
+	if !strings.Contains(string(body), `"extra":{"sentry.truncated":["extra.n"]}`) {
+		t.Errorf("expected only the extra to be dropped: %s", body)
+	}
+}
+
 func newTestEvent(eventType string) *Event {
 	event := NewEvent()
 	event.Type = eventType
@@ -193,6 +288,37 @@ func TestEnvelopeFromTransactionBody(t *testing.T) {
 	}
 }
 
+func TestEnvelopeHeader(t *testing.T) {
+	event := newTestEvent(transactionType)
+	event.sdkMetaData.dsc = DynamicSamplingContext{Entries: map[string]string{"trace_id": "abc"}, Frozen: true}
+	sentAt := time.Unix(0, 0).UTC()
+
+	body := json.RawMessage(`{"type":"transaction","fields":"omitted"}`)
+
+	r, _, err := envelopeReader(event, newTestDSN(t), sentAt, body, func(header EnvelopeHeader, e *Event) {
+		if e != event {
+			t.Errorf("header hook called with event %v", e)
+		}
+		header["sent_at"] = time.Unix(1, 0).UTC()
+		header["x_route"] = "eu"
+		header["trace"].(map[string]string)["x_tenant"] = "acme"
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _, _ := strings.Cut(string(b), "\n")
+	want := `{"dsn":"http://public@example.com/sentry/1","event_id":"b81c5be4d31e48959103a1f878a1efcb","sdk":{"name":"sentry.go","version":"0.0.1"},"sent_at":"1970-01-01T00:00:01Z","trace":{"trace_id":"abc","x_tenant":"acme"},"x_route":"eu"}`
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Envelope header mismatch (-want +got):\n%s", diff)
+	}
+	// The hook changes a copy of the dynamic sampling context.
+	assertEqual(t, event.sdkMetaData.dsc.Entries, map[string]string{"trace_id": "abc"})
+}
+
 func TestEnvelopeFromEventWithAttachments(t *testing.T) {
 	event := newTestEvent(eventType)
 	event.Attachments = []*Attachment{
@@ -229,6 +355,72 @@ func TestEnvelopeFromEventWithAttachments(t *testing.T) {
 	}
 }
 
+func TestEnvelopeFromEventWithStreamedAttachments(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "app.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString("first line\nlast line\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	event := newTestEvent(eventType)
+	event.Attachments = []*Attachment{
+		// Streamed, only the end is sent.
+		{Filename: "app.log", Reader: f, MaxSize: 10},
+		// Buffered, because the reader can't be read at an offset.
+		{Filename: "stdin.txt", Reader: io.MultiReader(strings.NewReader("buffered input")), MaxSize: 8},
+		// Streamed, with the default size cap.
+		{Filename: "small.txt", Reader: strings.NewReader("small")},
+		{Filename: "broken.txt", Reader: iotest.ErrReader(errors.New("broken"))},
+	}
+	sentAt := time.Unix(0, 0).UTC()
+
+	body := json.RawMessage(`{"type":"event","fields":"omitted"}`)
+
+	want := `{"event_id":"b81c5be4d31e48959103a1f878a1efcb","sent_at":"1970-01-01T00:00:00Z","dsn":"http://public@example.com/sentry/1","sdk":{"name":"sentry.go","version":"0.0.1"}}
+{"type":"event","length":35}
+{"type":"event","fields":"omitted"}
+{"type":"attachment","length":10,"filename":"app.log"}
+last line
+
+{"type":"attachment","length":8,"filename":"stdin.txt"}
+buffered
+{"type":"attachment","length":5,"filename":"small.txt"}
+small
+`
+	// Streamed readers can be sent any number of times, the buffered one is
+	// replaced for each envelope.
+	for i := 0; i < 2; i++ {
+		event.Attachments[1].Reader = io.MultiReader(strings.NewReader("buffered input"))
+		r, length, err := envelopeReader(event, newTestDSN(t), sentAt, body, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(want, string(got)); diff != "" {
+			t.Errorf("Envelope mismatch (-want +got):\n%s", diff)
+		}
+		if length != int64(len(got)) {
+			t.Errorf("length = %d, want %d", length, len(got))
+		}
+	}
+}
+
+func TestPaddedReader(t *testing.T) {
+	got, err := io.ReadAll(&paddedReader{r: strings.NewReader("ab"), n: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "ab\x00\x00"; string(got) != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
 func TestEnvelopeFromTransactionWithProfile(t *testing.T) {
 	event := newTestEvent(transactionType)
 	event.sdkMetaData.transactionProfile = &profileInfo{
@@ -340,7 +532,7 @@ func TestGetRequestFromEvent(t *testing.T) {
 		}
 
 		t.Run(test.testName, func(t *testing.T) {
-			req, err := getRequestFromEvent(context.TODO(), test.event, dsn)
+			req, err := getRequestFromEvent(context.TODO(), test.event, dsn, nil, nil, time.Now())
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -713,3 +905,121 @@ no_tags@second:1|c|T1597790835
 		t.Errorf("Envelope mismatch (-want +got):\n%s", diff)
 	}
 }
+
+func benchmarkEvents() map[string]*Event {
+	errorEvent := NewEvent()
+	errorEvent.EventID = "9ec79c33ec9942ab8353589fcb2e04dc"
+	errorEvent.Message = "message"
+	errorEvent.Level = LevelError
+	errorEvent.Timestamp = time.Unix(1700000000, 0)
+	errorEvent.Tags["environment"] = "production"
+	errorEvent.Contexts["os"] = Context{"name": "linux"}
+	errorEvent.Exception = []Exception{{
+		Type:       "*errors.errorString",
+		Value:      "boom",
+		Stacktrace: NewStacktrace(),
+	}}
+	for i := 0; i < 20; i++ {
+		errorEvent.Breadcrumbs = append(errorEvent.Breadcrumbs, &Breadcrumb{
+			Category:  "http",
+			Message:   "GET /api/items",
+			Data:      map[string]interface{}{"status_code": 200},
+			Timestamp: time.Unix(1700000000, 0),
+		})
+	}
+
+	transaction := NewEvent()
+	transaction.EventID = "9ec79c33ec9942ab8353589fcb2e04dd"
+	transaction.Type = transactionType
+	transaction.Transaction = "GET /api/items"
+	transaction.StartTime = time.Unix(1700000000, 0)
+	transaction.Timestamp = transaction.StartTime.Add(time.Second)
+	transaction.Contexts["trace"] = TraceContext{Op: "http.server"}.Map()
+	transaction.sdkMetaData.dsc = DynamicSamplingContext{
+		Entries: map[string]string{"trace_id": "d49d9bf66f13450b81f65bc51cf49c03", "public_key": "public"},
+		Frozen:  true,
+	}
+	for i := 0; i < 20; i++ {
+		transaction.Spans = append(transaction.Spans, &Span{
+			Op:          "db.sql.query",
+			Description: "SELECT * FROM items",
+			StartTime:   transaction.StartTime,
+			EndTime:     transaction.Timestamp,
+			Data:        map[string]interface{}{"db.system": "postgresql"},
+		})
+	}
+
+	return map[string]*Event{"Error": errorEvent, "Transaction": transaction}
+}
+
+func BenchmarkGetRequestFromEvent(b *testing.B) {
+	dsn, _ := NewDsn("https://public@example.com/1")
+	for name, event := range benchmarkEvents() {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := getRequestFromEvent(context.Background(), event, dsn, nil, nil, time.Now()); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestEncodeEventMatchesMarshalJSON(t *testing.T) {
+	for name, event := range benchmarkEvents() {
+		t.Run(name, func(t *testing.T) {
+			event.Breadcrumbs = append(event.Breadcrumbs, &Breadcrumb{Message: "without timestamp"})
+			want, err := json.Marshal(event)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var b bytes.Buffer
+			if err := encodeEvent(&b, event, nil); err != nil {
+				t.Fatal(err)
+			}
+
+			var gotValue, wantValue interface{}
+			if err := json.Unmarshal(b.Bytes(), &gotValue); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal(want, &wantValue); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(wantValue, gotValue); diff != "" {
+				t.Errorf("encodeEvent() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestEventEncoder(t *testing.T) {
+	event := benchmarkEvents()["Error"]
+
+	var got interface{}
+	var b bytes.Buffer
+	err := encodeEvent(&b, event, func(w io.Writer, e *Event, value interface{}) error {
+		if e != event {
+			t.Error("EventEncoder got a different event")
+		}
+		got = value
+		return json.NewEncoder(w).Encode(value)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == nil {
+		t.Fatal("EventEncoder was not called")
+	}
+	want, _ := json.Marshal(event)
+	if b.String() != string(want) {
+		t.Errorf("EventEncoder output %q, want the encoding of the event", b.Bytes())
+	}
+
+	failing := func(io.Writer, *Event, interface{}) error { return errors.New("boom") }
+	b.Reset()
+	body := getRequestBodyFromEventBuffer(event, &b, failing)
+	if string(body) != string(want) {
+		t.Errorf("body after a failing EventEncoder = %s, want the encoding/json encoding", body)
+	}
+}
@@ -0,0 +1,101 @@
+package sentry
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// UserExtractor returns the user that made an incoming request, and false if
+// the request is not authenticated. The metadata are the headers of HTTP
+// requests, or the metadata of gRPC calls, whose keys are lower case.
+// Integrations call it through Hub.ExtractUser; gRPC interceptors identify the
+// users of calls by passing their metadata, such as metadata.MD.
+type UserExtractor func(ctx context.Context, metadata map[string][]string) (User, bool)
+
+// ExtractUser sets the user of the scope of the hub to the one returned by
+// extractor, if any. The username, name and email address are only kept if
+// the PII options of the client allow user details; a user left without data
+// is not set.
+func (hub *Hub) ExtractUser(ctx context.Context, metadata map[string][]string, extractor UserExtractor) {
+	if extractor == nil {
+		return
+	}
+	user, ok := extractor(ctx, metadata)
+	if !ok {
+		return
+	}
+	if !hub.Client().PII().UserDetails {
+		user.Username, user.Name, user.Email = "", "", ""
+	}
+	if user.IsEmpty() {
+		return
+	}
+	hub.Scope().SetUser(user)
+}
+
+// metadataValue returns the first value of key in metadata, ignoring the case
+// of key.
+func metadataValue(metadata map[string][]string, key string) string {
+	if values := metadata[key]; len(values) > 0 {
+		return values[0]
+	}
+	for k, values := range metadata {
+		if strings.EqualFold(k, key) && len(values) > 0 {
+			return values[0]
+		}
+	}
+	return ""
+}
+
+// BasicAuthUser extracts the username of the basic authentication of
+// requests. The password is ignored.
+func BasicAuthUser(_ context.Context, metadata map[string][]string) (User, bool) {
+	r := http.Request{Header: http.Header{"Authorization": {metadataValue(metadata, "Authorization")}}}
+	username, _, ok := r.BasicAuth()
+	if !ok || username == "" {
+		return User{}, false
+	}
+	return User{Username: username}, true
+}
+
+// HeaderUser returns a UserExtractor that takes the ID of users from the
+// header, or gRPC metadata, key, as set by an authenticating proxy.
+func HeaderUser(key string) UserExtractor {
+	return func(_ context.Context, metadata map[string][]string) (User, bool) {
+		id := metadataValue(metadata, key)
+		return User{ID: id}, id != ""
+	}
+}
+
+// JWTUser extracts the user from the claims of the bearer token of requests:
+// the ID from "sub", the email from "email", the username from
+// "preferred_username" and the name from "name". The signature of the token
+// is not verified, so use it only behind a middleware that does.
+func JWTUser(_ context.Context, metadata map[string][]string) (User, bool) {
+	scheme, token, ok := strings.Cut(metadataValue(metadata, "Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return User{}, false
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return User{}, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return User{}, false
+	}
+	var claims struct {
+		Subject  string `json:"sub"`
+		Email    string `json:"email"`
+		Username string `json:"preferred_username"`
+		Name     string `json:"name"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return User{}, false
+	}
+	user := User{ID: claims.Subject, Email: claims.Email, Username: claims.Username, Name: claims.Name}
+	return user, !user.IsEmpty()
+}
@@ -0,0 +1,65 @@
+package sentry
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+)
+
+func TestBasicAuthUser(t *testing.T) {
+	auth := "Basic " + base64.StdEncoding.EncodeToString([]byte("alice:secret"))
+	user, ok := BasicAuthUser(context.Background(), map[string][]string{"authorization": {auth}})
+	assertEqual(t, ok, true)
+	assertEqual(t, user, User{Username: "alice"})
+
+	_, ok = BasicAuthUser(context.Background(), map[string][]string{"Authorization": {"Bearer x"}})
+	assertEqual(t, ok, false)
+}
+
+func TestHeaderUser(t *testing.T) {
+	extractor := HeaderUser("X-User-Id")
+	user, ok := extractor(context.Background(), map[string][]string{"X-User-Id": {"42"}})
+	assertEqual(t, ok, true)
+	assertEqual(t, user, User{ID: "42"})
+
+	_, ok = extractor(context.Background(), nil)
+	assertEqual(t, ok, false)
+}
+
+func TestJWTUser(t *testing.T) {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"42","email":"a@example.com","preferred_username":"alice","exp":1}`))
+	user, ok := JWTUser(context.Background(), map[string][]string{"Authorization": {"Bearer e30." + payload + ".sig"}})
+	assertEqual(t, ok, true)
+	assertEqual(t, user, User{ID: "42", Email: "a@example.com", Username: "alice"})
+
+	for _, auth := range []string{"", "Bearer x", "Bearer a.!!.c", "Basic a.e30.c"} {
+		if _, ok := JWTUser(context.Background(), map[string][]string{"Authorization": {auth}}); ok {
+			t.Errorf("JWTUser(%q) = true", auth)
+		}
+	}
+}
+
+func TestHubExtractUser(t *testing.T) {
+	hub, client, scope := setupHubTest()
+	extractor := func(context.Context, map[string][]string) (User, bool) {
+		return User{ID: "42", Username: "alice", Name: "Alice", Email: "a@example.com"}, true
+	}
+
+	// Without an ID, nothing is left to set.
+	hub.ExtractUser(context.Background(), nil, func(context.Context, map[string][]string) (User, bool) {
+		return User{Email: "a@example.com"}, true
+	})
+	assertEqual(t, scope.user, User{})
+
+	hub.ExtractUser(context.Background(), nil, extractor)
+	assertEqual(t, scope.user, User{ID: "42"})
+
+	client.options.SendDefaultPII = true
+	hub.ExtractUser(context.Background(), nil, extractor)
+	assertEqual(t, scope.user, User{ID: "42", Username: "alice", Name: "Alice", Email: "a@example.com"})
+
+	hub.ExtractUser(context.Background(), nil, func(context.Context, map[string][]string) (User, bool) {
+		return User{}, false
+	})
+	assertEqual(t, scope.user, User{ID: "42", Username: "alice", Name: "Alice", Email: "a@example.com"})
+}
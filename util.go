@@ -8,6 +8,7 @@ import (
 	"os"
 	"runtime/debug"
 	"strings"
+	"sync"
 	"time"
 
 	exec "golang.org/x/sys/execabs"
@@ -29,11 +30,17 @@ func fileExists(fileName string) bool {
 	return err == nil
 }
 
-// monotonicTimeSince replaces uses of time.Now() to take into account the
+// monotonicTimeSince replaces uses of clock.Now() to take into account the
 // monotonic clock reading stored in start, such that duration = end - start is
-// unaffected by changes in the system wall clock.
-func monotonicTimeSince(start time.Time) (end time.Time) {
-	return start.Add(time.Since(start))
+// unaffected by changes in the system wall clock. The duration is never
+// negative, even if start has no monotonic reading and the wall clock was
+// turned back.
+func monotonicTimeSince(clock Clock, start time.Time) (end time.Time) {
+	d := clock.Now().Sub(start)
+	if d < 0 {
+		d = 0
+	}
+	return start.Add(d)
 }
 
 // nolint: deadcode, unused
@@ -42,9 +49,9 @@ func prettyPrint(data interface{}) {
 	fmt.Println(string(dbg))
 }
 
-// defaultRelease attempts to guess a default release for the currently running
-// program.
-func defaultRelease() (release string) {
+// defaultRelease returns the release from well-known environment variables,
+// falling back to the build information and Git if fromBuild is true.
+func defaultRelease(fromBuild bool) (release string) {
 	// Return first non-empty environment variable known to hold release info, if any.
 	envs := []string{
 		"SENTRY_RELEASE",
@@ -67,6 +74,10 @@ func defaultRelease() (release string) {
 		}
 	}
 
+	if !fromBuild {
+		return ""
+	}
+
 	if info, ok := debug.ReadBuildInfo(); ok {
 		buildInfoVcsRevision := revisionFromBuildInfo(info)
 		if len(buildInfoVcsRevision) > 0 {
@@ -102,17 +113,82 @@ func defaultRelease() (release string) {
 	return ""
 }
 
+// revisionFromBuildInfo returns a release of the form module/path@revision
+// from the VCS information in info, with a "-dirty" suffix if the build had
+// uncommitted changes.
 func revisionFromBuildInfo(info *debug.BuildInfo) string {
+	var revision string
+	var modified bool
 	for _, setting := range info.Settings {
-		if setting.Key == "vcs.revision" && setting.Value != "" {
-			Logger.Printf("Using release from debug info: %s", setting.Value)
-			return setting.Value
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			modified = setting.Value == "true"
 		}
 	}
+	if revision == "" {
+		return ""
+	}
 
-	return ""
+	release := revision
+	if info.Main.Path != "" {
+		release = info.Main.Path + "@" + revision
+	}
+	if modified {
+		release += "-dirty"
+	}
+	Logger.Printf("Using release from debug info: %s", release)
+	return release
 }
 
 func Pointer[T any](v T) *T {
 	return &v
 }
+
+// backgroundTasks counts the goroutines of a client that Flush waits for.
+type backgroundTasks struct {
+	mu sync.Mutex
+	n  int
+	// idle is closed when n drops to zero.
+	idle chan struct{}
+}
+
+func (t *backgroundTasks) add() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.n == 0 {
+		t.idle = make(chan struct{})
+	}
+	t.n++
+}
+
+func (t *backgroundTasks) done() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.n--
+	if t.n == 0 {
+		close(t.idle)
+	}
+}
+
+// wait waits for the tasks for at most timeout. It reports whether all of
+// them are done.
+func (t *backgroundTasks) wait(timeout time.Duration) bool {
+	t.mu.Lock()
+	if t.n == 0 {
+		t.mu.Unlock()
+		return true
+	}
+	idle := t.idle
+	t.mu.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-idle:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
@@ -33,7 +33,7 @@ func TestDefaultReleaseSentryReleaseEnvvar(t *testing.T) {
 	releaseVersion := "1.2.3"
 	t.Setenv("SENTRY_RELEASE", releaseVersion)
 
-	assertEqual(t, defaultRelease(), releaseVersion)
+	assertEqual(t, defaultRelease(true), releaseVersion)
 }
 
 func TestDefaultReleaseSentryReleaseEnvvarPrecedence(t *testing.T) {
@@ -41,7 +41,21 @@ func TestDefaultReleaseSentryReleaseEnvvarPrecedence(t *testing.T) {
 	t.Setenv("SOURCE_VERSION", "3.2.1")
 	t.Setenv("SENTRY_RELEASE", releaseVersion)
 
-	assertEqual(t, defaultRelease(), releaseVersion)
+	assertEqual(t, defaultRelease(true), releaseVersion)
+}
+
+func TestDefaultReleaseFromBuildDisabled(t *testing.T) {
+	for _, key := range []string{
+		"SENTRY_RELEASE", "HEROKU_SLUG_COMMIT", "SOURCE_VERSION", "CODEBUILD_RESOLVED_SOURCE_VERSION",
+		"CIRCLE_SHA1", "GAE_DEPLOYMENT_ID", "GITHUB_SHA", "COMMIT_REF", "VERCEL_GIT_COMMIT_SHA",
+		"ZEIT_GITHUB_COMMIT_SHA", "ZEIT_GITLAB_COMMIT_SHA", "ZEIT_BITBUCKET_COMMIT_SHA",
+	} {
+		t.Setenv(key, "")
+	}
+	assertEqual(t, defaultRelease(false), "")
+
+	t.Setenv("SENTRY_RELEASE", "1.2.3")
+	assertEqual(t, defaultRelease(false), "1.2.3")
 }
 
 func TestRevisionFromBuildInfo(t *testing.T) {
@@ -69,7 +83,13 @@ func TestRevisionFromBuildInfo(t *testing.T) {
 		},
 	}
 
-	assertEqual(t, revisionFromBuildInfo(info), releaseVersion)
+	assertEqual(t, revisionFromBuildInfo(info), "my/module@"+releaseVersion)
+
+	info.Settings = append(info.Settings, debug.BuildSetting{Key: "vcs.modified", Value: "true"})
+	assertEqual(t, revisionFromBuildInfo(info), "my/module@"+releaseVersion+"-dirty")
+
+	info.Main.Path = ""
+	assertEqual(t, revisionFromBuildInfo(info), releaseVersion+"-dirty")
 }
 
 func TestRevisionFromBuildInfoNoVcsInformation(t *testing.T) {
@@ -0,0 +1,150 @@
+package sentry
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// OptionsError is returned by ClientOptions.Validate and lists every
+// misconfiguration found in the options.
+type OptionsError struct {
+	Problems []string
+}
+
+func (e *OptionsError) Error() string {
+	if len(e.Problems) == 1 {
+		return "[Sentry] invalid client options: " + e.Problems[0]
+	}
+	return fmt.Sprintf("[Sentry] %d problems with the client options:\n\t%s",
+		len(e.Problems), strings.Join(e.Problems, "\n\t"))
+}
+
+// Validate checks the options for values that NewClient would reject, ignore
+// or silently correct, and returns an *OptionsError describing all of them,
+// or nil if there are none. Empty options are valid: an empty Dsn disables
+// the client and zero values select the defaults.
+//
+// NewClient only rejects an invalid Dsn, unless StrictOptions is set.
+func (options ClientOptions) Validate() error {
+	var problems []string
+	problemf := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if options.Dsn != "" {
+		if _, err := NewDsn(options.Dsn); err != nil {
+			problemf("Dsn %q: %s", options.Dsn, dsnProblem(err))
+		}
+	}
+	if options.SelfMonitoringDsn != "" {
+		if _, err := NewDsn(options.SelfMonitoringDsn); err != nil {
+			problemf("SelfMonitoringDsn %q: %s", options.SelfMonitoringDsn, dsnProblem(err))
+		}
+	}
+
+	for _, rate := range []struct {
+		name  string
+		value float64
+	}{
+		{"SampleRate", options.SampleRate},
+		{"TracesSampleRate", options.TracesSampleRate},
+		{"ProfilesSampleRate", options.ProfilesSampleRate},
+	} {
+		if rate.value < 0 || rate.value > 1 {
+			problemf("%s is %v, it must be between 0.0 and 1.0", rate.name, rate.value)
+		}
+	}
+
+	if !options.EnableTracing {
+		if options.TracesSampleRate != 0 {
+			problemf("TracesSampleRate is set, but EnableTracing is false: no transactions are sent")
+		}
+		if options.TracesSampler != nil {
+			problemf("TracesSampler is set, but EnableTracing is false: no transactions are sent")
+		}
+	} else if options.TracesSampler != nil && options.TracesSampleRate != 0 {
+		problemf("both TracesSampler and TracesSampleRate are set: TracesSampleRate is ignored, " +
+			"return it from TracesSampler instead")
+	}
+	if options.ProfilesSampleRate != 0 && !options.EnableTracing {
+		problemf("ProfilesSampleRate is set, but EnableTracing is false: only transactions are profiled")
+	}
+
+	if options.MaxBreadcrumbs > maxBreadcrumbs {
+		problemf("MaxBreadcrumbs is %d, events hold at most %d breadcrumbs", options.MaxBreadcrumbs, maxBreadcrumbs)
+	}
+	if options.MaxSpans < 0 {
+		problemf("MaxSpans is %d, it must not be negative", options.MaxSpans)
+	}
+	if options.ContentionProfileThreshold < 0 || options.ContentionProfileWindow < 0 {
+		problemf("ContentionProfileThreshold and ContentionProfileWindow must not be negative")
+	}
+	if options.ContentionProfileThreshold > 0 && !options.EnableTracing {
+		problemf("ContentionProfileThreshold is set, but EnableTracing is false: only transactions are profiled")
+	}
+	if options.ExecutionTraceThreshold > 0 && !options.EnableTracing {
+		problemf("ExecutionTraceThreshold is set, but EnableTracing is false: traces are only attached to transactions")
+	}
+	if options.MaxGoroutines < 0 {
+		problemf("MaxGoroutines is %d, it must not be negative", options.MaxGoroutines)
+	}
+	if options.MaxErrorDepth < 0 {
+		problemf("MaxErrorDepth is %d, it must not be negative", options.MaxErrorDepth)
+	}
+
+	for _, option := range []struct {
+		name     string
+		patterns []string
+	}{
+		{"IgnoreErrors", options.IgnoreErrors},
+		{"IgnoreTransactions", options.IgnoreTransactions},
+	} {
+		for _, pattern := range option.patterns {
+			if _, err := regexp.Compile(pattern); err != nil {
+				problemf("%s pattern %q is not a valid regular expression and is ignored: %v", option.name, pattern, err)
+			}
+		}
+	}
+
+	if options.Transport != nil {
+		if options.HTTPClient != nil || options.HTTPTransport != nil {
+			problemf("HTTPClient and HTTPTransport are only used by the transports of the SDK, " +
+				"configure the custom Transport instead")
+		}
+	}
+	if options.HTTPClient != nil {
+		if options.HTTPTransport != nil {
+			problemf("HTTPTransport is ignored because HTTPClient is set, set the Transport of the HTTPClient instead")
+		}
+		if options.HTTPProxy != "" || options.HTTPSProxy != "" || options.CaCerts != nil {
+			problemf("HTTPProxy, HTTPSProxy and CaCerts are ignored because HTTPClient is set")
+		}
+	} else if options.HTTPTransport != nil && (options.HTTPProxy != "" || options.HTTPSProxy != "" || options.CaCerts != nil) {
+		problemf("HTTPProxy, HTTPSProxy and CaCerts are ignored because HTTPTransport is set")
+	}
+
+	if len(options.CrashSignals) > 0 && options.CrashDir == "" {
+		problemf("CrashSignals is set, but CrashDir is empty: signals are only handled with crash persistence")
+	}
+	if options.CaptureFatalCrashes && options.CrashDir == "" {
+		problemf("CaptureFatalCrashes is set, but CrashDir is empty: there is nowhere to record the crash output")
+	}
+
+	if !options.Debug && (options.DebugWriter != nil || options.DebugLogger != nil) {
+		problemf("DebugWriter or DebugLogger is set, but Debug is false: nothing is logged")
+	}
+
+	if len(problems) > 0 {
+		return &OptionsError{Problems: problems}
+	}
+	return nil
+}
+
+// dsnProblem returns the message of a DSN parse error without its prefix.
+func dsnProblem(err error) string {
+	if err, ok := err.(*DsnParseError); ok {
+		return err.Message
+	}
+	return err.Error()
+}
@@ -0,0 +1,94 @@
+package sentry
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestClientOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		options ClientOptions
+		want    []string
+	}{
+		{
+			name:    "Empty",
+			options: ClientOptions{},
+		},
+		{
+			name: "Valid",
+			options: ClientOptions{
+				Dsn:              "https://public@example.com/1",
+				SampleRate:       0.5,
+				EnableTracing:    true,
+				TracesSampleRate: 0.1,
+				MaxBreadcrumbs:   -1,
+				IgnoreErrors:     []string{"^context canceled$"},
+			},
+		},
+		{
+			name: "Invalid",
+			options: ClientOptions{
+				Dsn:              "https://example.com/1",
+				SampleRate:       2,
+				TracesSampleRate: 0.5,
+				MaxBreadcrumbs:   500,
+				IgnoreErrors:     []string{"("},
+				HTTPClient:       http.DefaultClient,
+				HTTPProxy:        "http://proxy",
+				DebugWriter:      &strings.Builder{},
+			},
+			want: []string{
+				`Dsn "https://example.com/1": empty username`,
+				"SampleRate is 2, it must be between 0.0 and 1.0",
+				"TracesSampleRate is set, but EnableTracing is false: no transactions are sent",
+				"MaxBreadcrumbs is 500, events hold at most 100 breadcrumbs",
+				"IgnoreErrors pattern \"(\" is not a valid regular expression and is ignored: " +
+					"error parsing regexp: missing closing ): `(`",
+				"HTTPProxy, HTTPSProxy and CaCerts are ignored because HTTPClient is set",
+				"DebugWriter or DebugLogger is set, but Debug is false: nothing is logged",
+			},
+		},
+		{
+			name: "SamplerAndRate",
+			options: ClientOptions{
+				EnableTracing:    true,
+				TracesSampleRate: 0.5,
+				TracesSampler:    func(SamplingContext) float64 { return 1 },
+			},
+			want: []string{
+				"both TracesSampler and TracesSampleRate are set: TracesSampleRate is ignored, " +
+					"return it from TracesSampler instead",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.options.Validate()
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			var optionsErr *OptionsError
+			if !errors.As(err, &optionsErr) {
+				t.Fatalf("Validate() = %v, want an *OptionsError", err)
+			}
+			assertEqual(t, optionsErr.Problems, tt.want)
+		})
+	}
+}
+
+func TestNewClientStrictOptions(t *testing.T) {
+	options := ClientOptions{SampleRate: 1.5, Transport: &TransportMock{}}
+	if _, err := NewClient(options); err != nil {
+		t.Fatalf("NewClient() without StrictOptions = %v", err)
+	}
+
+	options.StrictOptions = true
+	_, err := NewClient(options)
+	assertEqual(t, err.Error(), "[Sentry] invalid client options: SampleRate is 1.5, it must be between 0.0 and 1.0")
+}